@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// nonWord matches the runs of characters a migration name can't keep
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+var migrationTemplate = template.Must(template.New("migration").Parse(`package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&{{.Type}}{})
+}
+
+// {{.Type}} {{.Description}}
+type {{.Type}} struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *{{.Type}}) Version() string {
+	return "{{.Version}}"
+}
+
+// Description returns the migration description
+func (m *{{.Type}}) Description() string {
+	return "{{.Description}}"
+}
+
+// Up applies the migration
+func (m *{{.Type}}) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	// Statements that differ between PostgreSQL and MySQL go through
+	// execDialect
+	return adapter.Exec(ctx, ` + "`" + `
+	` + "`" + `)
+}
+
+// Down reverts the migration
+func (m *{{.Type}}) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	return adapter.Exec(ctx, ` + "`" + `
+	` + "`" + `)
+}
+`))
+
+// create writes a migration template named name to dir, versioned by the
+// current time, and returns its path
+func create(dir, name string) (string, error) {
+	words := strings.Trim(nonWord.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if words == "" {
+		return "", fmt.Errorf("%q has no letters or digits to name the migration after", name)
+	}
+
+	version := time.Now().UTC().Format("20060102150405")
+	typeName := "Migration_" + version + "_"
+	for _, word := range strings.Split(words, "_") {
+		typeName += strings.ToUpper(word[:1]) + word[1:]
+	}
+
+	path := filepath.Join(dir, version+"_"+words+".go")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	err = migrationTemplate.Execute(file, map[string]string{
+		"Type":        typeName,
+		"Version":     version,
+		"Description": strings.ReplaceAll(words, "_", " "),
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, file.Close()
+}
@@ -1,17 +1,49 @@
+// Command migrate applies the database migrations and checks the schema
+// they produce.
+//
+// Usage:
+//
+//	migrate [up] [--to VERSION] [--dry-run]
+//	migrate down [--steps N] [--dry-run]
+//	migrate rollback [--dry-run]
+//	migrate status
+//	migrate reset|fresh [--yes] [--dry-run]
+//	migrate create NAME
+//	migrate schema check
+//
+// up applies the pending migrations, or only those up to and including
+// VERSION. down rolls back the last N migrations, one by default, and
+// rollback the last batch. reset rolls back every migration and fresh
+// migrates again afterwards; both ask for the database name first in
+// production, unless --yes is given. With --dry-run the SQL is printed
+// instead of run.
+//
+// Every run holds a database lock, so app instances migrating as they start
+// up wait for each other instead of racing.
+//
+// create writes a migration template to migrations/, versioned by the
+// current time.
+//
+// schema check compares the live database against the columns the
+// repositories use and exits non-zero with a diff of what's missing.
 package main
 
 import (
+	"bufio"
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
-	"github.com/toutaio/toutago-sil-migrator/pkg/sil/adapters"
-
-	// Import migrations to register them
-	_ "github.com/toutaio/toutago-starter-kit-basic/internal/migrations"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/schema"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
 )
 
 func main() {
@@ -22,98 +54,100 @@ func main() {
 
 	// Get command from args
 	command := "migrate"
-	if len(os.Args) > 1 {
-		command = os.Args[1]
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
 
-	// Build DATABASE_URL from environment variables
-	databaseURL := os.Getenv("DATABASE_URL")
-	if databaseURL == "" {
-		// Build from individual variables
-		dbDriver := os.Getenv("DB_DRIVER")
-		if dbDriver == "" {
-			dbDriver = "postgres"
-		}
-		dbHost := os.Getenv("DB_HOST")
-		dbPort := os.Getenv("DB_PORT")
-		dbName := os.Getenv("DB_NAME")
-		dbUser := os.Getenv("DB_USER")
-		dbPassword := os.Getenv("DB_PASSWORD")
-
-		if dbHost == "" || dbName == "" || dbUser == "" {
-			log.Fatal("Database configuration is incomplete. Set DATABASE_URL or DB_HOST, DB_NAME, DB_USER variables")
+	if command == "create" {
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "usage: migrate create NAME")
+			os.Exit(2)
 		}
-
-		switch dbDriver {
-		case "postgres":
-			if dbPort == "" {
-				dbPort = "5432"
-			}
-			databaseURL = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", dbUser, dbPassword, dbHost, dbPort, dbName)
-		case "mysql":
-			if dbPort == "" {
-				dbPort = "3306"
-			}
-			databaseURL = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", dbUser, dbPassword, dbHost, dbPort, dbName)
-		default:
-			log.Fatalf("Unsupported database driver: %s", dbDriver)
+		path, err := create("migrations", args[0])
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
 		}
+		fmt.Printf("✅ Created %s\n", path)
+		return
 	}
 
-	// Configure database connection
-	config := sil.DefaultConfig()
-	config.DatabaseURL = databaseURL
-	config.MigrationsDir = "./internal/migrations"
-	config.Verbose = true
-
-	// Determine database type
-	var adapter sil.DatabaseAdapter
-	var err error
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	to := flags.String("to", "", "up: only migrate up to and including this version")
+	steps := flags.Int("steps", 1, "down: number of migrations to roll back")
+	dryRun := flags.Bool("dry-run", false, "print the SQL instead of running it")
+	yes := flags.Bool("yes", false, "reset, fresh: don't ask for confirmation in production")
+	flags.Parse(args)
 
-	dbType := os.Getenv("DB_DRIVER")
-	if dbType == "" {
-		dbType = os.Getenv("DB_TYPE")
-	}
-	if dbType == "" {
-		dbType = "postgres" // default
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	switch dbType {
-	case "postgres":
-		adapter, err = adapters.NewPostgresAdapter(config)
-	case "mysql":
-		adapter, err = adapters.NewMySQLAdapter(config)
-	default:
-		log.Fatalf("Unsupported database type: %s", dbType)
-	}
+	ctx := context.Background()
 
-	if err != nil {
-		log.Fatalf("Failed to create adapter: %v", err)
+	if command == "schema" {
+		if flags.Arg(0) != "check" {
+			fmt.Fprintln(os.Stderr, "usage: migrate schema check")
+			os.Exit(2)
+		}
+		checkSchema(ctx, cfg.Database)
+		return
 	}
 
-	// Connect to database
-	ctx := context.Background()
-	if err := adapter.Connect(ctx, config); err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	if (command == "reset" || command == "fresh") && !*dryRun && !*yes {
+		confirm(cfg, command)
 	}
-	defer adapter.Close()
 
-	// Create migrator
-	migrator, err := sil.NewMigrator(config, adapter)
+	var opts database.MigratorOptions
+	if *dryRun {
+		opts.DryRun = os.Stdout
+	}
+	migrator, release, err := database.NewMigrator(ctx, cfg.Database, opts)
 	if err != nil {
-		log.Fatalf("Failed to create migrator: %v", err)
+		log.Fatalf("Failed to set up migrations: %v", err)
+	}
+	defer release()
+
+	if *dryRun {
+		migrator.SetBeforeMigrate(func(m sil.Migration, direction string) error {
+			fmt.Printf("\n-- %s %s: %s\n", direction, m.Version(), m.Description())
+			return nil
+		})
 	}
 
 	// Execute command
 	switch command {
 	case "migrate", "up":
-		fmt.Println("Running migrations...")
-		if err := migrator.Migrate(ctx); err != nil {
-			log.Fatalf("Migration failed: %v", err)
+		if *to != "" {
+			n, err := pendingUpTo(ctx, migrator, *to)
+			if err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
+			if n == 0 {
+				fmt.Printf("Nothing to migrate: %s is already applied\n", *to)
+				return
+			}
+			fmt.Printf("Running migrations up to %s...\n", *to)
+			if err := migrator.MigrateUp(ctx, n); err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
+		} else {
+			fmt.Println("Running migrations...")
+			if err := migrator.Migrate(ctx); err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
 		}
 		fmt.Println("✅ Migrations complete!")
 
-	case "rollback", "down":
+	case "down":
+		fmt.Printf("Rolling back %d migrations...\n", *steps)
+		if err := migrator.MigrateDown(ctx, *steps); err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		fmt.Println("✅ Rollback complete!")
+
+	case "rollback":
 		fmt.Println("Rolling back last batch...")
 		if err := migrator.Rollback(ctx); err != nil {
 			log.Fatalf("Rollback failed: %v", err)
@@ -153,6 +187,71 @@ func main() {
 		fmt.Println("✅ Fresh migration complete!")
 
 	default:
-		log.Fatalf("Unknown command: %s. Available: migrate, rollback, status, reset, fresh", command)
+		log.Fatalf("Unknown command: %s. Available: up, down, rollback, status, reset, fresh, create, schema check", command)
+	}
+}
+
+// pendingUpTo returns the number of pending migrations up to and including
+// version
+func pendingUpTo(ctx context.Context, migrator sil.Migrator, version string) (int, error) {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	known := false
+	n := 0
+	for _, status := range statuses {
+		if status.Version == version {
+			known = true
+		}
+		if !status.Applied && status.Version <= version {
+			n++
+		}
+	}
+	if !known {
+		return 0, fmt.Errorf("no migration has version %s", version)
+	}
+	return n, nil
+}
+
+// confirm exits unless the user types the name of the production database
+// command is about to empty
+func confirm(cfg *config.Config, command string) {
+	if !cfg.IsProduction() {
+		return
+	}
+
+	fmt.Printf("%s rolls back every migration of the production database %s, deleting its data.\n", command, cfg.Database.Name)
+	fmt.Print("Type the database name to continue: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != cfg.Database.Name {
+		log.Fatal("Aborted: the name didn't match")
+	}
+}
+
+// checkSchema exits non-zero if the database lacks columns the repositories
+// use
+func checkSchema(ctx context.Context, cfg config.DatabaseConfig) {
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close(db)
+
+	d, err := dialect.For(cfg.Driver)
+	if err != nil {
+		log.Fatalf("Failed to select SQL dialect: %v", err)
+	}
+
+	mismatches, err := schema.Check(ctx, db, d, repository.Columns)
+	if err != nil {
+		log.Fatalf("Schema check failed: %v", err)
+	}
+	if len(mismatches) > 0 {
+		fmt.Print(schema.Diff(mismatches))
+		database.Close(db)
+		log.Fatalf("%d tables lack columns the repositories use; run the migrations", len(mismatches))
 	}
+	fmt.Println("✅ The database schema matches the repositories")
 }
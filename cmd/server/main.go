@@ -2,6 +2,8 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"log"
 	"net/http"
 	"os"
@@ -9,9 +11,21 @@ import (
 	router "github.com/toutaio/toutago-cosan-router"
 	"github.com/toutaio/toutago-fith-renderer"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/content"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/database"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/replica"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/feed"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/handlers"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repositories"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/seo"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/services"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
 )
 
 func main() {
@@ -36,14 +50,42 @@ func main() {
 	}
 
 	// Initialize database connection
-	log.Printf("Connecting to %s database at %s:%s", cfg.Database.Driver, cfg.Database.Host, cfg.Database.Port)
-	sqlDB, err := database.Connect(cfg.Database)
+	if cfg.Database.Driver == "sqlite" {
+		log.Printf("Opening sqlite database %s", cfg.Database.Name)
+	} else {
+		log.Printf("Connecting to %s database at %s:%s", cfg.Database.Driver, cfg.Database.Host, cfg.Database.Port)
+	}
+	sqlDB, err := database.Open(cfg.Database)
 	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close(sqlDB)
+	// The monitor retries until the database answers, then keeps checking
+	// it for the health endpoints; the pool reconnects after an outage
+	monitor := database.NewMonitor(sqlDB, cfg.Database)
+	if err := monitor.Connect(context.Background()); err != nil {
+		if cfg.Database.Required {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
 		log.Printf("Warning: Failed to connect to database: %v", err)
-		log.Println("Continuing without database connection...")
+		log.Println("Serving as not ready until the database can be reached...")
 	} else {
 		log.Println("Database connected successfully")
-		defer database.Close(sqlDB)
+	}
+	go monitor.Run(context.Background())
+
+	var replicas []*sql.DB
+	if len(cfg.Database.Replicas) > 0 {
+		replicas, err = database.ConnectReplicas(cfg.Database)
+		if err != nil {
+			log.Printf("Warning: Failed to connect to read replicas: %v", err)
+			log.Println("Continuing with reads on the primary...")
+		} else {
+			log.Printf("Connected to %d read replicas", len(replicas))
+			for _, db := range replicas {
+				defer database.Close(db)
+			}
+		}
 	}
 
 	// Initialize template renderer
@@ -64,27 +106,181 @@ func main() {
 	r.Use(router.MiddlewareFunc(middleware.Logger))
 	r.Use(router.MiddlewareFunc(middleware.Recovery))
 	r.Use(router.MiddlewareFunc(middleware.SecurityHeaders))
+	r.Use(router.MiddlewareFunc(middleware.RequireReady(monitor.Ready, "/health", "/static/")))
+
+	// Users are kept in memory until a database-backed user repository exists
+	userRepo := repositories.NewMemoryUserRepository()
+	authService := services.NewAuthService(userRepo, services.NewSessionStore())
+	authMiddleware := middleware.NewAuthMiddleware(authService)
+	requireEditor := authMiddleware.RequireRole(models.RoleEditor)
 
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler(sqlDB)
-	homeHandler := handlers.NewHomeHandler(renderer)
+	healthHandler.SetMonitor(monitor)
 
 	// Register routes
-	r.GET("/", homeHandler.Index)
 	r.GET("/health", healthHandler.Check)
+	r.GET("/health/live", healthHandler.Live)
+	r.GET("/health/ready", healthHandler.Check)
+
+	// Register public content routes
+	sqlDialect, err := dialect.For(cfg.Database.Driver)
+	if err != nil {
+		log.Fatalf("Failed to select SQL dialect: %v", err)
+	}
+	// Services make several repository calls atomic by running them in
+	// a transaction the repositories join through the context
+	txManager := txn.NewTxManager(sqlDB)
+	// Repositories read from replicas outside transactions
+	db := replica.New(sqlDB, replicas...)
+	slugHistoryRepo := repository.NewSlugHistoryRepository(db, sqlDialect)
+	postService := service.NewPostService(repository.NewPostRepository(db, sqlDialect), slugHistoryRepo)
+	postService.SetTransactor(txManager)
+	pageService := service.NewPageService(repository.NewPageRepository(db, sqlDialect), slugHistoryRepo)
+	pageService.SetTransactor(txManager)
+	taxonomyService := service.NewTaxonomyService(repository.NewTaxonomyRepository(db, sqlDialect))
+	menuService := service.NewMenuService(repository.NewMenuRepository(db, sqlDialect), pageService)
+	seoBuilder := seo.NewBuilder(cfg.Site)
+	store, err := storage.New(cfg.Storage, cfg.Storage.Driver)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	mediaService := service.NewMediaService(repository.NewMediaRepository(db, sqlDialect), store)
+	// Post and page bodies render through the content pipeline, which
+	// expands these shortcodes
+	pipeline := content.New(content.Options{UploadsURL: store.URL("uploads")})
+	pipeline.Register("youtube", content.YouTube)
+	pipeline.Register("post-link", content.PostLink(postService))
+	renderService := service.NewRenderService(repository.NewRenderRepository(db, sqlDialect), pipeline)
+	// Bring cached renders up to date after a pipeline change without
+	// holding up startup, once the database can be reached
+	go func() {
+		<-monitor.Connected()
+		n, err := renderService.RerenderStale(context.Background())
+		if err != nil {
+			log.Printf("Warning: Failed to re-render content: %v", err)
+		}
+		if n > 0 {
+			log.Printf("Re-rendered %d posts and pages", n)
+		}
+	}()
+	homeHandler := handlers.NewHomeHandler(renderer, postService, mediaService, taxonomyService, renderService, cfg.Home)
+	reviewService := service.NewReviewService(postService, repository.NewReviewRepository(db, sqlDialect), repository.NewNotificationRepository(db, sqlDialect))
+	reviewService.SetTransactor(txManager)
+	commentService := service.NewCommentService(repository.NewCommentRepository(db, sqlDialect), postService, cfg.Comments)
+	autosaveService := service.NewAutosaveService(repository.NewAutosaveRepository(db, sqlDialect))
+	postHandler := handlers.NewPostHandler(postService, reviewService, commentService, taxonomyService, mediaService, renderService, autosaveService, userRepo, seoBuilder, renderer)
+	pageHandler := handlers.NewPageHandler(pageService, mediaService, renderService, seoBuilder, renderer)
+	feedHandler := handlers.NewFeedHandler(postService, taxonomyService, renderService, userRepo, cfg.Site)
+	sitemapHandler := handlers.NewSitemapHandler(postService, pageService, cfg.Site, cfg.Robots)
+	pageTreeHandler := handlers.NewPageTreeHandler(pageService, renderer)
+	menuHandler := handlers.NewMenuHandler(menuService, pageService, renderer)
+	reviewHandler := handlers.NewReviewHandler(reviewService, postService, userRepo, renderer)
+	commentHandler := handlers.NewCommentHandler(commentService, postService, renderer)
+	mediaHandler := handlers.NewMediaHandler(mediaService, renderer)
+
+	// Templates render navigation with {{range menu "header"}}
+	renderer.RegisterFunction("menu", menuService.TemplateFunc())
+
+	r.GET("/posts", postHandler.Index)
+	r.GET("/posts/:slug", authMiddleware.OptionalAuth(postHandler.Show))
+	r.GET("/pages", pageHandler.Index)
+	r.GET("/pages/:slug", pageHandler.Show)
+	// Pages live at their nested URLs (/about/team). The router always
+	// prefers static and parameter routes, so this only catches the rest.
+	r.GET("/*path", pageHandler.ShowPath)
+	r.GET("/sitemap.xml", sitemapHandler.Index)
+	r.GET("/sitemaps/:file", sitemapHandler.Part)
+	r.GET("/robots.txt", sitemapHandler.Robots)
+
+	// Page tree and menu builder
+	r.GET("/admin/pages", requireEditor(pageTreeHandler.Show))
+	r.POST("/admin/pages/tree", requireEditor(pageTreeHandler.Save))
+	r.GET("/admin/menus", requireEditor(menuHandler.Edit))
+	r.POST("/admin/menus/:location", requireEditor(menuHandler.Save))
 
-	// Serve static files (using GET for now since Static might not be available)
-	r.GET("/static/*", func(ctx router.Context) error {
-		http.FileServer(http.Dir("static")).ServeHTTP(ctx.Response(), ctx.Request())
+	// Writing and editorial review. Authors submit posts for review;
+	// editors approve, request changes and publish.
+	r.GET("/posts/new", authMiddleware.RequireAuth(postHandler.New))
+	r.POST("/posts", authMiddleware.RequireAuth(postHandler.Create))
+	r.POST("/posts/preview", authMiddleware.RequireAuth(postHandler.Preview))
+	r.POST("/posts/autosave", authMiddleware.RequireAuth(postHandler.Autosave))
+	r.POST("/posts/autosave/discard", authMiddleware.RequireAuth(postHandler.DiscardAutosave))
+	r.GET("/posts/:id/edit", authMiddleware.RequireAuth(postHandler.Edit))
+	r.POST("/posts/:id", authMiddleware.RequireAuth(postHandler.Update))
+	r.POST("/posts/:id/delete", authMiddleware.RequireAuth(postHandler.Delete))
+	r.POST("/posts/:id/autosave", authMiddleware.RequireAuth(postHandler.Autosave))
+	r.POST("/posts/:id/autosave/discard", authMiddleware.RequireAuth(postHandler.DiscardAutosave))
+	r.POST("/posts/:id/publish", requireEditor(postHandler.Publish))
+	r.POST("/posts/:id/unpublish", requireEditor(postHandler.Unpublish))
+	r.GET("/posts/:id/review", authMiddleware.RequireAuth(reviewHandler.Show))
+	r.POST("/posts/:id/status", authMiddleware.RequireAuth(reviewHandler.UpdateStatus))
+	r.POST("/posts/:id/comments", authMiddleware.RequireAuth(reviewHandler.Comment))
+	r.POST("/posts/:id/reviewer", requireEditor(reviewHandler.AssignReviewer))
+	r.GET("/admin/reviews", requireEditor(reviewHandler.Queue))
+	r.GET("/notifications", authMiddleware.RequireAuth(reviewHandler.Notifications))
+
+	// Reader comments. Whether visitors must sign in is up to the
+	// comment service, so the form only loads the session if present.
+	r.POST("/comments", authMiddleware.OptionalAuth(commentHandler.Create))
+	r.GET("/admin/comments", requireEditor(commentHandler.Queue))
+	r.POST("/admin/comments/:id/status", requireEditor(commentHandler.Moderate))
+	r.POST("/admin/comments/:id/delete", requireEditor(commentHandler.Delete))
+
+	// Media library. Everyone who writes can upload; editors see and
+	// manage all uploads, others only their own.
+	r.GET("/admin/media", authMiddleware.RequireAuth(mediaHandler.Library))
+	r.GET("/admin/media/picker", authMiddleware.RequireAuth(mediaHandler.Picker))
+	r.POST("/admin/media", authMiddleware.RequireAuth(mediaHandler.Upload))
+	r.POST("/admin/media/:id", authMiddleware.RequireAuth(mediaHandler.Update))
+	r.GET("/admin/media/:id/download", authMiddleware.RequireAuth(mediaHandler.Download))
+	r.POST("/admin/media/:id/delete", authMiddleware.RequireAuth(mediaHandler.Delete))
+
+	// Feeds: site-wide plus per-author, per-category and per-tag
+	feeds := map[string]feed.Format{
+		"feed.xml":  feed.FormatRSS,
+		"atom.xml":  feed.FormatAtom,
+		"feed.json": feed.FormatJSON,
+	}
+	for file, format := range feeds {
+		r.GET("/"+file, feedHandler.Site(format))
+		r.GET("/authors/:username/"+file, feedHandler.Author(format))
+		r.GET("/categories/:slug/"+file, feedHandler.Category(format))
+		r.GET("/tags/:slug/"+file, feedHandler.Tag(format))
+	}
+
+	// Registered after the content routes so the home page gets their services
+	r.GET("/", homeHandler.Index)
+
+	// Serve static files (using GET for now since Static might not be available).
+	// Uploads get download and CSP headers so they can't act as site pages.
+	static := http.StripPrefix("/static", http.FileServer(http.Dir("static")))
+	r.GET("/static/*", middleware.UploadHeaders("/static/uploads/")(func(ctx router.Context) error {
+		static.ServeHTTP(ctx.Response(), ctx.Request())
 		return nil
-	})
+	}))
+
+	// Local upload storage kept outside the static directory gets its own route
+	if cfg.Storage.Driver == storage.DriverLocal && cfg.Storage.LocalURL != "/static" {
+		uploads := http.StripPrefix(cfg.Storage.LocalURL, http.FileServer(http.Dir(cfg.Storage.LocalRoot)))
+		r.GET(cfg.Storage.LocalURL+"/*", middleware.UploadHeaders(cfg.Storage.LocalURL+"/")(func(ctx router.Context) error {
+			uploads.ServeHTTP(ctx.Response(), ctx.Request())
+			return nil
+		}))
+	}
 
 	// Start HTTP server
 	addr := ":" + cfg.Server.Port
 	log.Printf("Server listening on %s", addr)
 	log.Printf("Visit http://localhost%s", addr)
 
-	if err := http.ListenAndServe(addr, r); err != nil {
+	var handler http.Handler = r
+	if len(replicas) > 0 {
+		handler = replica.ReadYourWrites(cfg.Database.ReadYourWrites)(handler)
+	}
+	// Count each request's queries; development also shows them on the page
+	handler = middleware.QueryStats(cfg.Database.QueryRepeatLimit, cfg.IsDevelopment())(handler)
+	if err := http.ListenAndServe(addr, handler); err != nil {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
@@ -0,0 +1,93 @@
+// Command storage manages uploaded files across storage backends.
+//
+// Usage:
+//
+//	storage migrate --from local --to s3 [--prefix uploads] [--delete]
+//
+// migrate copies every file under prefix from one backend to the other,
+// skipping files the destination already has, so it can be re-run after an
+// interruption. With --delete the source copies are removed once everything
+// has been copied. Switch STORAGE_DRIVER afterwards to serve from the new
+// backend.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/joho/godotenv"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
+)
+
+func main() {
+	// Load .env file
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	if len(os.Args) < 2 || os.Args[1] != "migrate" {
+		fmt.Fprintln(os.Stderr, "usage: storage migrate --from local|s3 --to local|s3 [--prefix uploads] [--delete]")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	from := flags.String("from", storage.DriverLocal, "backend to copy files from")
+	to := flags.String("to", storage.DriverS3, "backend to copy files to")
+	prefix := flags.String("prefix", "uploads", "only copy keys starting with this prefix")
+	deleteSource := flags.Bool("delete", false, "delete files from the source once copied")
+	flags.Parse(os.Args[2:])
+
+	if *from == *to {
+		log.Fatal("--from and --to must be different backends")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	src, err := storage.New(cfg.Storage, *from)
+	if err != nil {
+		log.Fatalf("Failed to open %s storage: %v", *from, err)
+	}
+	dst, err := storage.New(cfg.Storage, *to)
+	if err != nil {
+		log.Fatalf("Failed to open %s storage: %v", *to, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("Copying %s/ from %s to %s storage...", *prefix, *from, *to)
+	copied, err := storage.Copy(ctx, src, dst, *prefix, func(key string) {
+		log.Printf("  copied %s", key)
+	})
+	if err != nil {
+		log.Fatalf("Migration stopped after %d files: %v", copied, err)
+	}
+	log.Printf("Copied %d files", copied)
+
+	if !*deleteSource {
+		return
+	}
+
+	var keys []string
+	err = src.Walk(ctx, *prefix, func(obj storage.Object) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to list source files: %v", err)
+	}
+	for _, key := range keys {
+		if err := src.Delete(ctx, key); err != nil {
+			log.Fatalf("Failed to delete %s: %v", key, err)
+		}
+	}
+	log.Printf("Deleted %d files from %s storage", len(keys), *from)
+}
@@ -2,8 +2,15 @@
 package config
 
 import (
+	"cmp"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all application configuration.
@@ -12,6 +19,11 @@ type Config struct {
 	Database DatabaseConfig
 	Session  SessionConfig
 	Email    EmailConfig
+	Site     SiteConfig
+	Robots   RobotsConfig
+	Comments CommentsConfig
+	Storage  StorageConfig
+	Home     HomeConfig
 }
 
 // ServerConfig holds server-related configuration.
@@ -23,14 +35,51 @@ type ServerConfig struct {
 
 // DatabaseConfig holds database connection configuration.
 type DatabaseConfig struct {
-	Driver   string
+	Driver   string // "postgres", "mysql" or "sqlite"
 	Host     string
 	Port     string
-	Name     string
+	Name     string // path of the database file for SQLite
 	User     string
 	Password string
+
+	// SSLMode is disable, require, verify-ca or verify-full, as in
+	// PostgreSQL; MySQL connections are mapped to the same checks
+	SSLMode     string
+	SSLRootCert string // CA certificate file servers are verified against
+
+	ConnectTimeout  time.Duration // 0 waits as long as the driver does
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Reaching the database at startup is retried ConnectRetries times,
+	// waiting RetryBackoff and doubling the wait up to RetryMaxBackoff
+	ConnectRetries  int
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	// Required refuses to start the server while the database can't be
+	// reached; otherwise it starts and reports not ready until it can
+	Required       bool
+	HealthInterval time.Duration // how often the connection is checked
+
+	// Queries slower than SlowQuery are logged, and a request that runs the
+	// same statement more than QueryRepeatLimit times is warned about as a
+	// likely N+1 query; 0 turns the warning off
+	SlowQuery        time.Duration
+	QueryRepeatLimit int
+
+	// Replicas are the host[:port] addresses of read replicas, reached with
+	// the primary's credentials and settings
+	Replicas []string
+	// ReadYourWrites keeps a visitor's reads on the primary for this long
+	// after they write, so they see their changes before replicas catch up
+	ReadYourWrites time.Duration
 }
 
+// SSLModes lists the values DB_SSL_MODE can take.
+var SSLModes = []string{"disable", "require", "verify-ca", "verify-full"}
+
 // SessionConfig holds session management configuration.
 type SessionConfig struct {
 	Secret string
@@ -45,6 +94,61 @@ type EmailConfig struct {
 	FromAddress  string
 }
 
+// SiteConfig holds public site metadata used in feeds and absolute links.
+type SiteConfig struct {
+	Name        string
+	Description string
+	URL         string
+	Image       string // default social sharing image, absolute or site-relative
+}
+
+// RobotsConfig controls the generated robots.txt.
+type RobotsConfig struct {
+	AllowIndexing bool
+	Disallow      []string
+}
+
+// CommentsConfig controls who can comment on posts and how comments are
+// moderated and rate limited.
+type CommentsConfig struct {
+	AllowAnonymous  bool          // let visitors comment without an account
+	RequireApproval bool          // hold comments from non-editors for moderation
+	MaxDepth        int           // levels of replies, 1 disables threading
+	RateLimit       int           // comments allowed per visitor within RateWindow
+	RateWindow      time.Duration // sliding window for RateLimit
+}
+
+// HomeConfig controls what the home page shows.
+type HomeConfig struct {
+	FeaturedCount int      // featured posts in the carousel, 0 hides it
+	LatestCount   int      // latest posts listed below it
+	Widgets       []string // sidebar widgets in display order, see HomeWidgets
+}
+
+// HomeWidgets lists the widgets HOME_WIDGETS can name.
+var HomeWidgets = []string{"categories", "tags", "subscribe"}
+
+// StorageConfig selects where uploaded files are kept and configures each
+// backend, so files can be copied from one to the other.
+type StorageConfig struct {
+	Driver    string // "local" or "s3"
+	LocalRoot string // directory local files are saved under
+	LocalURL  string // URL LocalRoot is served at
+	S3        S3Config
+}
+
+// S3Config holds the settings for an S3-compatible bucket (AWS S3, MinIO).
+type S3Config struct {
+	Endpoint  string // host[:port], without scheme
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	PathStyle bool   // address the bucket as endpoint/bucket, needed by MinIO
+	PublicURL string // base URL objects are served from, e.g. a CDN
+}
+
 // Load reads configuration from environment variables.
 func Load() (*Config, error) {
 	cfg := &Config{
@@ -60,6 +164,27 @@ func Load() (*Config, error) {
 			Name:     getEnv("DB_NAME", ""),
 			User:     getEnv("DB_USER", ""),
 			Password: getEnv("DB_PASSWORD", ""),
+
+			SSLMode:     getEnv("DB_SSL_MODE", "disable"),
+			SSLRootCert: getEnv("DB_SSL_ROOT_CERT", ""),
+
+			ConnectTimeout:  getEnvDuration("DB_CONNECT_TIMEOUT", 10*time.Second),
+			MaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 5),
+			ConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+			ConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", 2*time.Minute),
+
+			ConnectRetries:  getEnvInt("DB_CONNECT_RETRIES", 5),
+			RetryBackoff:    getEnvDuration("DB_RETRY_BACKOFF", 500*time.Millisecond),
+			RetryMaxBackoff: getEnvDuration("DB_RETRY_MAX_BACKOFF", 30*time.Second),
+			Required:        getEnv("DB_REQUIRED", "false") == "true",
+			HealthInterval:  getEnvDuration("DB_HEALTH_INTERVAL", 15*time.Second),
+
+			SlowQuery:        getEnvDuration("DB_SLOW_QUERY", 200*time.Millisecond),
+			QueryRepeatLimit: getEnvInt("DB_QUERY_REPEAT_LIMIT", 10),
+
+			Replicas:       getEnvList("DB_REPLICAS", ""),
+			ReadYourWrites: getEnvDuration("DB_READ_YOUR_WRITES", 5*time.Second),
 		},
 		Session: SessionConfig{
 			Secret: getEnv("SESSION_SECRET", ""),
@@ -71,6 +196,50 @@ func Load() (*Config, error) {
 			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
 			FromAddress:  getEnv("SMTP_FROM", "noreply@example.com"),
 		},
+		Site: SiteConfig{
+			Name:        getEnv("SITE_NAME", "Starter Kit Basic"),
+			Description: getEnv("SITE_DESCRIPTION", ""),
+			URL:         strings.TrimRight(getEnv("APP_URL", "http://localhost:8080"), "/"),
+			Image:       getEnv("SITE_IMAGE", ""),
+		},
+		Robots: RobotsConfig{
+			AllowIndexing: getEnv("ROBOTS_ALLOW_INDEXING", "true") == "true",
+			Disallow:      getEnvList("ROBOTS_DISALLOW", "/dashboard,/profile,/settings"),
+		},
+		Comments: CommentsConfig{
+			AllowAnonymous:  getEnv("COMMENTS_ALLOW_ANONYMOUS", "true") == "true",
+			RequireApproval: getEnv("COMMENTS_REQUIRE_APPROVAL", "true") == "true",
+			MaxDepth:        getEnvInt("COMMENTS_MAX_DEPTH", 3),
+			RateLimit:       getEnvInt("COMMENTS_RATE_LIMIT", 5),
+			RateWindow:      getEnvDuration("COMMENTS_RATE_WINDOW", 10*time.Minute),
+		},
+		Home: HomeConfig{
+			FeaturedCount: getEnvInt("HOME_FEATURED_COUNT", 5),
+			LatestCount:   getEnvInt("HOME_LATEST_COUNT", 10),
+			Widgets:       getEnvList("HOME_WIDGETS", strings.Join(HomeWidgets, ",")),
+		},
+		Storage: StorageConfig{
+			Driver:    getEnv("STORAGE_DRIVER", "local"),
+			LocalRoot: getEnv("STORAGE_LOCAL_ROOT", "static"),
+			LocalURL:  strings.TrimRight(getEnv("STORAGE_LOCAL_URL", "/static"), "/"),
+			S3: S3Config{
+				Endpoint:  getEnv("S3_ENDPOINT", ""),
+				Region:    getEnv("S3_REGION", "us-east-1"),
+				Bucket:    getEnv("S3_BUCKET", ""),
+				AccessKey: getEnv("S3_ACCESS_KEY", ""),
+				SecretKey: getEnv("S3_SECRET_KEY", ""),
+				UseSSL:    getEnv("S3_USE_SSL", "true") == "true",
+				PathStyle: getEnv("S3_PATH_STYLE", "false") == "true",
+				PublicURL: strings.TrimRight(getEnv("S3_PUBLIC_URL", ""), "/"),
+			},
+		},
+	}
+
+	// A connection URL, as hosting platforms provide, overrides DB_*
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		if err := cfg.Database.applyURL(dsn); err != nil {
+			return nil, fmt.Errorf("DATABASE_URL: %w", err)
+		}
 	}
 
 	if err := cfg.validate(); err != nil {
@@ -82,11 +251,53 @@ func Load() (*Config, error) {
 
 // validate checks that required configuration is present.
 func (c *Config) validate() error {
-	if c.Database.User == "" {
-		return fmt.Errorf("DB_USER is required")
+	// SQLite databases are plain files without credentials
+	if c.Database.Driver == "sqlite" {
+		if c.Database.Name == "" {
+			return fmt.Errorf("DB_NAME is required: the path of the SQLite database file")
+		}
+	} else {
+		if c.Database.User == "" {
+			return fmt.Errorf("DB_USER is required")
+		}
+		if c.Database.Password == "" {
+			return fmt.Errorf("DB_PASSWORD is required")
+		}
+		if !slices.Contains(SSLModes, c.Database.SSLMode) {
+			return fmt.Errorf("DB_SSL_MODE must be one of %s", strings.Join(SSLModes, ", "))
+		}
+		if c.Database.SSLRootCert != "" && !strings.HasPrefix(c.Database.SSLMode, "verify-") {
+			return fmt.Errorf("DB_SSL_ROOT_CERT needs DB_SSL_MODE verify-ca or verify-full")
+		}
+	}
+	if c.Database.MaxOpenConns < 1 || c.Database.MaxIdleConns < 0 {
+		return fmt.Errorf("DB_MAX_OPEN_CONNS must be at least 1 and DB_MAX_IDLE_CONNS cannot be negative")
+	}
+	if c.Database.ConnectRetries < 0 || c.Database.QueryRepeatLimit < 0 {
+		return fmt.Errorf("DB_CONNECT_RETRIES and DB_QUERY_REPEAT_LIMIT cannot be negative")
 	}
-	if c.Database.Password == "" {
-		return fmt.Errorf("DB_PASSWORD is required")
+	if len(c.Database.Replicas) > 0 && c.Database.Driver == "sqlite" {
+		return fmt.Errorf("DB_REPLICAS is not supported with SQLite")
+	}
+	if c.Comments.MaxDepth < 1 {
+		return fmt.Errorf("COMMENTS_MAX_DEPTH must be at least 1")
+	}
+	if c.Home.FeaturedCount < 0 || c.Home.LatestCount < 0 {
+		return fmt.Errorf("HOME_FEATURED_COUNT and HOME_LATEST_COUNT cannot be negative")
+	}
+	for _, widget := range c.Home.Widgets {
+		if !slices.Contains(HomeWidgets, widget) {
+			return fmt.Errorf("HOME_WIDGETS: unknown widget %q, expected one of %s", widget, strings.Join(HomeWidgets, ", "))
+		}
+	}
+	switch c.Storage.Driver {
+	case "local":
+	case "s3":
+		if c.Storage.S3.Endpoint == "" || c.Storage.S3.Bucket == "" {
+			return fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required when STORAGE_DRIVER is s3")
+		}
+	default:
+		return fmt.Errorf("STORAGE_DRIVER must be local or s3")
 	}
 	return nil
 }
@@ -105,16 +316,47 @@ func (c *Config) IsProduction() bool {
 func (d *DatabaseConfig) ConnectionString() string {
 	switch d.Driver {
 	case "postgres":
-		return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-			d.User, d.Password, d.Host, d.Port, d.Name)
+		params := url.Values{}
+		params.Set("sslmode", cmp.Or(d.SSLMode, "disable"))
+		if d.SSLRootCert != "" {
+			params.Set("sslrootcert", d.SSLRootCert)
+		}
+		if d.ConnectTimeout > 0 {
+			params.Set("connect_timeout", strconv.Itoa(int(d.ConnectTimeout.Round(time.Second)/time.Second)))
+		}
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(d.User, d.Password),
+			Host:     net.JoinHostPort(d.Host, d.Port),
+			Path:     "/" + d.Name,
+			RawQuery: params.Encode(),
+		}
+		return u.String()
 	case "mysql":
-		return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-			d.User, d.Password, d.Host, d.Port, d.Name)
+		dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true",
+			d.User, d.Password, net.JoinHostPort(d.Host, d.Port), d.Name)
+		if tls := d.mysqlTLS(); tls != "" {
+			dsn += "&tls=" + tls
+		}
+		if d.ConnectTimeout > 0 {
+			dsn += "&timeout=" + d.ConnectTimeout.String()
+		}
+		return dsn
+	case "sqlite":
+		return fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL", d.Name)
 	default:
 		return ""
 	}
 }
 
+// DriverName returns the database/sql driver registered for Driver.
+func (d *DatabaseConfig) DriverName() string {
+	if d.Driver == "sqlite" {
+		return "sqlite3"
+	}
+	return d.Driver
+}
+
 // getEnv retrieves an environment variable or returns a default value.
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -122,3 +364,33 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList retrieves a comma-separated environment variable as a list,
+// skipping empty entries.
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvInt retrieves an integer environment variable, falling back to the
+// default when it is unset or not a number.
+func getEnvInt(key string, defaultValue int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return n
+	}
+	return defaultValue
+}
+
+// getEnvDuration retrieves a duration such as "10m" from an environment
+// variable, falling back to the default when it is unset or invalid.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return defaultValue
+}
@@ -0,0 +1,61 @@
+package content
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// admonitionMarker matches the GitHub-style marker opening a blockquote:
+//
+//	> [!WARNING]
+//	> Back up your database first.
+var admonitionMarker = regexp.MustCompile(`^\[!(NOTE|TIP|IMPORTANT|WARNING|CAUTION)\][ \t]*(?:\n|$)`)
+
+// findAdmonitions returns the blockquotes that open with an admonition
+// marker, keyed to their kind, and removes the marker from their text.
+func findAdmonitions(doc ast.Node) map[*ast.BlockQuote]string {
+	found := make(map[*ast.BlockQuote]string)
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		quote, ok := node.(*ast.BlockQuote)
+		if !ok || !entering {
+			return ast.GoToNext
+		}
+
+		children := quote.GetChildren()
+		if len(children) == 0 {
+			return ast.GoToNext
+		}
+		para, ok := children[0].(*ast.Paragraph)
+		if !ok || len(para.Children) == 0 {
+			return ast.GoToNext
+		}
+		text, ok := para.Children[0].(*ast.Text)
+		if !ok {
+			return ast.GoToNext
+		}
+
+		m := admonitionMarker.FindSubmatchIndex(text.Literal)
+		if m == nil {
+			return ast.GoToNext
+		}
+		found[quote] = strings.ToLower(string(text.Literal[m[2]:m[3]]))
+		text.Literal = text.Literal[m[1]:]
+		return ast.GoToNext
+	})
+	return found
+}
+
+// renderAdmonition writes the box an admonition is rendered in in place of
+// its blockquote tags.
+func renderAdmonition(w io.Writer, kind string, entering bool) {
+	if !entering {
+		_, _ = io.WriteString(w, "</div>\n")
+		return
+	}
+	title := strings.ToUpper(kind[:1]) + kind[1:]
+	_, _ = fmt.Fprintf(w, "<div class=\"admonition admonition-%s\">\n<p class=\"admonition-title\">%s</p>\n", kind, title)
+}
@@ -0,0 +1,125 @@
+// Package content renders post and page Markdown to HTML. On top of plain
+// Markdown it highlights fenced code on the server, builds a table of
+// contents from the headings, and supports footnotes, GitHub-style
+// admonitions, responsive images for uploaded media and shortcodes
+// registered from Go. All output is sanitized before it is returned.
+package content
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+)
+
+// Version identifies the output of the pipeline. Bump it whenever a change
+// alters the HTML rendered for existing content, so cached renders are
+// rebuilt.
+const Version = 1
+
+// Reading time assumes this many words a minute, and excerpts are cut to
+// ExcerptLength characters.
+const (
+	WordsPerMinute = 200
+	ExcerptLength  = 160
+)
+
+const parserExtensions = parser.CommonExtensions | parser.AutoHeadingIDs | parser.Footnotes | parser.NoEmptyLineBeforeBlock
+
+// Options configures a Pipeline.
+type Options struct {
+	// UploadsURL is the public URL uploads are served under, such as
+	// "/static/uploads". Images below it get responsive srcsets.
+	UploadsURL string
+}
+
+// Document is a rendered piece of content.
+type Document struct {
+	// HTML is the sanitized body
+	HTML string
+	// TOC lists the document's headings as a tree
+	TOC []*TOCEntry
+	// Text is the body as whitespace-collapsed plain text
+	Text string
+	// WordCount and ReadingTime, in minutes, are derived from Text
+	WordCount   int
+	ReadingTime int
+}
+
+// Pipeline turns Markdown into sanitized HTML. It is safe for concurrent
+// use once its shortcodes are registered.
+type Pipeline struct {
+	opts       Options
+	policy     *bluemonday.Policy
+	mu         sync.RWMutex
+	shortcodes map[string]Shortcode
+}
+
+// New creates a pipeline without any shortcodes.
+func New(opts Options) *Pipeline {
+	return &Pipeline{
+		opts:       opts,
+		policy:     newPolicy(),
+		shortcodes: make(map[string]Shortcode),
+	}
+}
+
+// Render converts Markdown to HTML. Shortcodes that fail are left in the
+// output as text, so rendering itself never fails.
+func (p *Pipeline) Render(ctx context.Context, md string) *Document {
+	source, calls := p.extractShortcodes(md)
+
+	root := parser.NewWithExtensions(parserExtensions).Parse([]byte(source))
+	admonitions := findAdmonitions(root)
+	rewriteImages(root, p.opts.UploadsURL)
+
+	renderer := mdhtml.NewRenderer(mdhtml.RendererOptions{
+		Flags: mdhtml.CommonFlags | mdhtml.HrefTargetBlank | mdhtml.FootnoteReturnLinks | mdhtml.LazyLoadImages,
+		RenderNodeHook: func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+			switch n := node.(type) {
+			case *ast.CodeBlock:
+				return ast.GoToNext, renderCodeBlock(w, n)
+			case *ast.BlockQuote:
+				if kind, ok := admonitions[n]; ok {
+					renderAdmonition(w, kind, entering)
+					return ast.GoToNext, true
+				}
+			}
+			return ast.GoToNext, false
+		},
+	})
+
+	html := string(markdown.Render(root, renderer))
+	html = p.expandShortcodes(ctx, html, calls)
+
+	doc := &Document{
+		HTML: p.policy.Sanitize(html),
+		TOC:  buildTOC(root),
+	}
+	doc.Text = helpers.HTMLText(doc.HTML)
+	doc.WordCount = len(strings.Fields(doc.Text))
+	doc.ReadingTime = readingTime(doc.WordCount)
+	return doc
+}
+
+// Version reports the pipeline version documents are rendered with.
+func (p *Pipeline) Version() int {
+	return Version
+}
+
+// Excerpt is the start of the document's text, cut at a word boundary.
+func (d *Document) Excerpt() string {
+	return helpers.Truncate(d.Text, ExcerptLength)
+}
+
+// readingTime rounds up to whole minutes, so any text takes at least one
+func readingTime(words int) int {
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
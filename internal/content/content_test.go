@@ -0,0 +1,170 @@
+package content
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type stubPosts map[string]*domain.Post
+
+func (s stubPosts) GetPostBySlug(_ context.Context, slug string) (*domain.Post, error) {
+	if post, ok := s[slug]; ok {
+		return post, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func newTestPipeline() *Pipeline {
+	p := New(Options{UploadsURL: "/static/uploads"})
+	p.Register("youtube", YouTube)
+	p.Register("post-link", PostLink(stubPosts{
+		"hello": {Slug: "hello", Title: "Hello & Welcome", Status: domain.PostStatusPublished},
+		"draft": {Slug: "draft", Title: "Draft", Status: domain.PostStatusDraft},
+	}))
+	return p
+}
+
+func render(t *testing.T, md string) *Document {
+	t.Helper()
+	return newTestPipeline().Render(context.Background(), md)
+}
+
+func assertContains(t *testing.T, html string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, html)
+		}
+	}
+}
+
+func assertNotContains(t *testing.T, html string, unwanted ...string) {
+	t.Helper()
+	for _, s := range unwanted {
+		if strings.Contains(html, s) {
+			t.Errorf("expected output not to contain %q, got:\n%s", s, html)
+		}
+	}
+}
+
+func TestRender_HighlightsFencedCode(t *testing.T) {
+	doc := render(t, "```go\nfunc main() {}\n```\n")
+
+	assertContains(t, doc.HTML, `<pre class="chroma">`, `<span class="kd">func</span>`, `<span class="nf">main</span>`)
+}
+
+func TestRender_UnknownLanguageIsPlain(t *testing.T) {
+	doc := render(t, "```nosuchlang\n<b>x</b>\n```\n")
+
+	assertContains(t, doc.HTML, `<code class="language-nosuchlang">&lt;b&gt;x&lt;/b&gt;`)
+	assertNotContains(t, doc.HTML, "chroma")
+}
+
+func TestRender_TableOfContents(t *testing.T) {
+	doc := render(t, "# Title\n\n## Install\n\n### From *source*\n\n## Usage\n\ntext\n")
+
+	if len(doc.TOC) != 2 {
+		t.Fatalf("expected 2 top-level entries, got %d", len(doc.TOC))
+	}
+	if doc.TOC[0].ID != "install" || len(doc.TOC[0].Children) != 1 {
+		t.Errorf("unexpected first entry: %+v", doc.TOC[0])
+	}
+	if got := doc.TOC[0].Children[0].Text; got != "From source" {
+		t.Errorf("expected heading text without markup, got %q", got)
+	}
+
+	assertContains(t, doc.HTML, `<h2 id="install">Install</h2>`)
+	assertContains(t, doc.TOCHTML(),
+		`<nav class="toc" aria-label="Table of contents"><ul><li><a href="#install">Install</a><ul><li><a href="#from-source">From source</a></li></ul></li>`)
+}
+
+func TestRender_ShortDocumentHasNoTOC(t *testing.T) {
+	doc := render(t, "## One\n\n## Two\n")
+
+	if got := doc.TOCHTML(); got != "" {
+		t.Errorf("expected no table of contents, got %q", got)
+	}
+}
+
+func TestRender_Footnotes(t *testing.T) {
+	doc := render(t, "Claim.[^1]\n\n[^1]: Source.\n")
+
+	assertContains(t, doc.HTML, `<sup class="footnote-ref" id="fnref:1"><a href="#fn:1" rel="nofollow">1</a></sup>`, `<div class="footnotes">`, `<li id="fn:1">`)
+}
+
+func TestRender_Admonition(t *testing.T) {
+	doc := render(t, "> [!WARNING]\n> Back up first.\n\nThen:\n\n> Just a quote.\n")
+
+	assertContains(t, doc.HTML,
+		`<div class="admonition admonition-warning">`,
+		`<p class="admonition-title">Warning</p>`,
+		"<p>Back up first.</p>",
+		"<blockquote>\n<p>Just a quote.</p>\n</blockquote>")
+	assertNotContains(t, doc.HTML, "[!WARNING]")
+}
+
+func TestRender_ResponsiveUploadImages(t *testing.T) {
+	doc := render(t, "![Cat](/static/uploads/2026/01/cat-large.jpg)\n\n![Remote](https://example.com/dog-large.jpg)\n")
+
+	assertContains(t, doc.HTML,
+		`loading="lazy"`,
+		`sizes="(max-width: 800px) 100vw, 800px"`,
+		`srcset="/static/uploads/2026/01/cat-thumb.jpg 300w, /static/uploads/2026/01/cat-medium.jpg 800w, /static/uploads/2026/01/cat-large.jpg 1600w"`)
+	if strings.Count(doc.HTML, "srcset") != 1 {
+		t.Errorf("expected only the uploaded image to get a srcset, got:\n%s", doc.HTML)
+	}
+}
+
+func TestRender_Shortcodes(t *testing.T) {
+	doc := render(t, "{{< youtube dQw4w9WgXcQ >}}\n\nSee {{< post-link hello >}} and {{< post-link hello \"the intro\" >}}.\n")
+
+	assertContains(t, doc.HTML,
+		`<div class="embed embed-video"><iframe src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ" title="YouTube video" loading="lazy" allowfullscreen`,
+		`See <a href="/posts/hello" rel="nofollow">Hello &amp; Welcome</a> and <a href="/posts/hello" rel="nofollow">the intro</a>.`)
+	assertNotContains(t, doc.HTML, "<p><div")
+}
+
+func TestRender_ShortcodesInCodeAreLiteral(t *testing.T) {
+	doc := render(t, "Write `{{< youtube dQw4w9WgXcQ >}}` to embed.\n\n```\n{{< youtube dQw4w9WgXcQ >}}\n```\n")
+
+	assertNotContains(t, doc.HTML, "iframe")
+	if strings.Count(doc.HTML, "{{&lt; youtube dQw4w9WgXcQ &gt;}}") != 2 {
+		t.Errorf("expected both shortcodes left as text, got:\n%s", doc.HTML)
+	}
+}
+
+func TestRender_FailedAndUnknownShortcodes(t *testing.T) {
+	doc := render(t, "{{< youtube not-an-id >}} {{< post-link draft >}} {{< post-link missing >}} {{< gallery x >}}\n")
+
+	assertContains(t, doc.HTML,
+		"{{&lt; youtube not-an-id &gt;}}",
+		"{{&lt; post-link draft &gt;}}",
+		"{{&lt; post-link missing &gt;}}",
+		"{{&lt; gallery x &gt;}}")
+	assertNotContains(t, doc.HTML, "iframe", "<a ")
+}
+
+func TestRender_StaysSafe(t *testing.T) {
+	doc := render(t, strings.Join([]string{
+		`<script>alert(1)</script>`,
+		`[click](javascript:alert(1))`,
+		`<iframe src="https://evil.example.com/"></iframe>`,
+		`<div class="btn-primary" onclick="x()">styled</div>`,
+		`{{< youtube dQw4w9WgXcQ "<img src=x onerror=alert(1)>" >}}`,
+		`![x](/static/uploads/a"onerror="alert(1)-large.jpg)`,
+	}, "\n\n"))
+
+	assertNotContains(t, doc.HTML, "<script", "javascript:", "evil.example.com", "btn-primary", "onclick", "<img src=x", `" onerror`)
+}
+
+func TestWriteHighlightCSS(t *testing.T) {
+	var b strings.Builder
+	if err := WriteHighlightCSS(&b); err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, b.String(), ".chroma .kd")
+}
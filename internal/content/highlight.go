@@ -0,0 +1,58 @@
+package content
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// HighlightStyle is the chroma style static/css/highlight.css is built from
+const HighlightStyle = "github"
+
+// codeFormatter emits CSS classes rather than inline styles so the
+// sanitizer can keep the markup and the colours live in a stylesheet.
+var codeFormatter = chromahtml.New(chromahtml.WithClasses(true))
+
+// renderCodeBlock highlights a fenced code block whose language chroma
+// knows. It reports false for everything else so the default renderer
+// writes a plain block.
+func renderCodeBlock(w io.Writer, block *ast.CodeBlock) bool {
+	if !block.IsFenced {
+		return false
+	}
+	fields := strings.Fields(string(block.Info))
+	if len(fields) == 0 {
+		return false
+	}
+	lexer := lexers.Get(fields[0])
+	if lexer == nil {
+		return false
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, string(block.Literal))
+	if err != nil {
+		log.Printf("Error highlighting %s code: %v", fields[0], err)
+		return false
+	}
+
+	// Buffer so a failed format doesn't leave half a block behind
+	var buf bytes.Buffer
+	if err := codeFormatter.Format(&buf, styles.Get(HighlightStyle), iterator); err != nil {
+		log.Printf("Error highlighting %s code: %v", fields[0], err)
+		return false
+	}
+	_, _ = w.Write(buf.Bytes())
+	return true
+}
+
+// WriteHighlightCSS writes the stylesheet for highlighted code blocks.
+func WriteHighlightCSS(w io.Writer) error {
+	return codeFormatter.WriteCSS(w, styles.Get(HighlightStyle))
+}
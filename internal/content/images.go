@@ -0,0 +1,60 @@
+package content
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+// imageSizes tells browsers how wide content images are laid out
+const imageSizes = "(max-width: 800px) 100vw, 800px"
+
+// variantURL splits an uploaded image variant URL into its base and
+// extension. It only accepts URL-safe characters since the srcset it feeds
+// is written out unescaped.
+var variantURL = regexp.MustCompile(`^([\w:/.~%-]+)-(` + variantNames() + `)(\.[a-z]+)$`)
+
+func variantNames() string {
+	names := make([]string, len(domain.MediaVariants))
+	for i, v := range domain.MediaVariants {
+		names[i] = regexp.QuoteMeta(v.Name)
+	}
+	return strings.Join(names, "|")
+}
+
+// rewriteImages gives images that point at a variant of an upload a srcset
+// listing all variants, so browsers fetch the size they need.
+func rewriteImages(doc ast.Node, uploadsURL string) {
+	if uploadsURL == "" {
+		return
+	}
+	prefix := strings.TrimSuffix(uploadsURL, "/") + "/"
+
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		img, ok := node.(*ast.Image)
+		if !ok || !entering {
+			return ast.GoToNext
+		}
+		src := string(img.Destination)
+		if !strings.HasPrefix(src, prefix) {
+			return ast.GoToNext
+		}
+		m := variantURL.FindStringSubmatch(src)
+		if m == nil {
+			return ast.GoToNext
+		}
+
+		srcset := make([]string, len(domain.MediaVariants))
+		for i, v := range domain.MediaVariants {
+			srcset[i] = fmt.Sprintf("%s-%s%s %dw", m[1], v.Name, m[3], v.MaxWidth)
+		}
+		img.Attribute = &ast.Attribute{Attrs: map[string][]byte{
+			"srcset": []byte(strings.Join(srcset, ", ")),
+			"sizes":  []byte(imageSizes),
+		}}
+		return ast.GoToNext
+	})
+}
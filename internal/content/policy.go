@@ -0,0 +1,38 @@
+package content
+
+import (
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// contentClass matches the class names the pipeline emits: chroma's short
+// token classes, code languages, footnotes, admonitions and embeds.
+// Anything else would let authors borrow the site's own styles.
+var contentClass = regexp.MustCompile(`^(?:` + classToken + `)(?:\s+(?:` + classToken + `))*$`)
+
+const classToken = `[a-z][a-z0-9]{0,2}|chroma|line|language-[\w+#-]+|footnotes|footnote-ref|footnote-return|admonition(?:-[a-z]+)?|embed(?:-[a-z]+)?`
+
+// youtubeEmbed matches the only iframe source content may use
+var youtubeEmbed = regexp.MustCompile(`^https://www\.youtube-nocookie\.com/embed/[A-Za-z0-9_-]{11}$`)
+
+// newPolicy extends the user-generated content policy with what the
+// pipeline's features need.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	p.AllowAttrs("class").Matching(contentClass).OnElements("div", "p", "pre", "code", "span", "sup", "a")
+
+	// Responsive images and lazy loading
+	p.AllowAttrs("srcset").Matching(regexp.MustCompile(`^[\w:/.~%, -]+$`)).OnElements("img")
+	p.AllowAttrs("sizes").Matching(regexp.MustCompile(`^[\w(): ,.-]+$`)).OnElements("img")
+	p.AllowAttrs("loading").Matching(regexp.MustCompile(`^lazy$`)).OnElements("img", "iframe")
+
+	// Video embeds from the youtube shortcode
+	p.AllowElements("iframe")
+	p.AllowAttrs("src").Matching(youtubeEmbed).OnElements("iframe")
+	p.AllowAttrs("title").OnElements("iframe")
+	p.AllowAttrs("allowfullscreen").Matching(regexp.MustCompile(`^(?:|allowfullscreen)$`)).OnElements("iframe")
+
+	return p
+}
@@ -0,0 +1,231 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+// Shortcode renders a {{< name args >}} tag to HTML. Its output goes
+// through the same sanitizer as the rest of the content, so it can only
+// use markup the policy allows.
+type Shortcode func(ctx context.Context, args []string) (string, error)
+
+// shortcodeCall is a shortcode found in the Markdown source
+type shortcodeCall struct {
+	name string
+	args []string
+	raw  string
+}
+
+var (
+	shortcodeTag = regexp.MustCompile(`\{\{<\s*([a-z][a-z0-9-]*)((?:\s+(?:"[^"]*"|[^\s">]+))*)\s*>\}\}`)
+	shortcodeArg = regexp.MustCompile(`"([^"]*)"|([^\s"]+)`)
+	codeFence    = regexp.MustCompile("^ {0,3}(```|~~~)")
+)
+
+// shortcodePlaceholder stands in for the i-th shortcode while the Markdown
+// is rendered
+func shortcodePlaceholder(i int) string {
+	return "<!--shortcode:" + strconv.Itoa(i) + "-->"
+}
+
+// Register adds a shortcode, replacing any registered under the same name.
+func (p *Pipeline) Register(name string, sc Shortcode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.shortcodes[name] = sc
+}
+
+func (p *Pipeline) shortcode(name string) (Shortcode, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	sc, ok := p.shortcodes[name]
+	return sc, ok
+}
+
+// extractShortcodes swaps registered shortcodes for HTML comment
+// placeholders that the Markdown renderer passes through untouched.
+// Shortcodes inside fenced code blocks and code spans are left alone, as
+// are unknown names.
+func (p *Pipeline) extractShortcodes(md string) (string, []shortcodeCall) {
+	if !strings.Contains(md, "{{<") {
+		return md, nil
+	}
+
+	var calls []shortcodeCall
+	var fence string
+	lines := strings.SplitAfter(md, "\n")
+	for i, line := range lines {
+		if m := codeFence.FindStringSubmatch(line); m != nil {
+			switch fence {
+			case "":
+				fence = m[1]
+			case m[1]:
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		lines[i] = replaceOutsideCode(line, func(text string) string {
+			return shortcodeTag.ReplaceAllStringFunc(text, func(tag string) string {
+				m := shortcodeTag.FindStringSubmatch(tag)
+				if _, ok := p.shortcode(m[1]); !ok {
+					return tag
+				}
+				calls = append(calls, shortcodeCall{name: m[1], args: parseArgs(m[2]), raw: tag})
+				return shortcodePlaceholder(len(calls) - 1)
+			})
+		})
+	}
+	return strings.Join(lines, ""), calls
+}
+
+// replaceOutsideCode applies fn to the parts of a line outside code spans
+func replaceOutsideCode(line string, fn func(string) string) string {
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		run := backticks(line[i:])
+		end := closingBackticks(line[i+run:], run)
+		if end < 0 {
+			i += run
+			continue
+		}
+		b.WriteString(fn(line[start:i]))
+		b.WriteString(line[i : i+run+end+run])
+		i += run + end + run
+		start = i
+	}
+	b.WriteString(fn(line[start:]))
+	return b.String()
+}
+
+func backticks(s string) int {
+	n := 0
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	return n
+}
+
+// closingBackticks finds a run of exactly n backticks, returning its offset
+func closingBackticks(s string, n int) int {
+	for i := 0; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := backticks(s[i:])
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+func parseArgs(s string) []string {
+	var args []string
+	for _, m := range shortcodeArg.FindAllStringSubmatch(s, -1) {
+		if m[2] != "" {
+			args = append(args, m[2])
+		} else {
+			args = append(args, m[1])
+		}
+	}
+	return args
+}
+
+// expandShortcodes runs the shortcodes and puts their output in place of
+// the placeholders. A shortcode on a line of its own replaces the whole
+// paragraph. One that fails is shown as its original text.
+func (p *Pipeline) expandShortcodes(ctx context.Context, out string, calls []shortcodeCall) string {
+	if len(calls) == 0 {
+		return out
+	}
+
+	pairs := make([]string, 0, len(calls)*4)
+	for i, call := range calls {
+		rendered := html.EscapeString(call.raw)
+		if sc, ok := p.shortcode(call.name); ok {
+			if s, err := sc(ctx, call.args); err != nil {
+				log.Printf("Error rendering shortcode %q: %v", call.raw, err)
+			} else {
+				rendered = s
+			}
+		}
+		placeholder := shortcodePlaceholder(i)
+		pairs = append(pairs, "<p>"+placeholder+"</p>", rendered, placeholder, rendered)
+	}
+	return strings.NewReplacer(pairs...).Replace(out)
+}
+
+// youtubeID matches a YouTube video ID
+var youtubeID = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// YouTube embeds a video using YouTube's privacy-enhanced domain:
+//
+//	{{< youtube dQw4w9WgXcQ >}}
+//	{{< youtube dQw4w9WgXcQ "Video title" >}}
+func YouTube(ctx context.Context, args []string) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", errors.New("youtube expects a video ID and an optional title")
+	}
+	if !youtubeID.MatchString(args[0]) {
+		return "", fmt.Errorf("invalid YouTube video ID %q", args[0])
+	}
+
+	title := "YouTube video"
+	if len(args) == 2 {
+		title = args[1]
+	}
+	return fmt.Sprintf(`<div class="embed embed-video"><iframe src="https://www.youtube-nocookie.com/embed/%s" title="%s" loading="lazy" allowfullscreen></iframe></div>`,
+		args[0], html.EscapeString(title)), nil
+}
+
+// PostLookup finds posts by slug. PostService satisfies it.
+type PostLookup interface {
+	GetPostBySlug(ctx context.Context, slug string) (*domain.Post, error)
+}
+
+// PostLink returns a shortcode linking to another published post, using
+// its title as the link text unless one is given:
+//
+//	{{< post-link hello-world >}}
+//	{{< post-link hello-world "our first post" >}}
+func PostLink(posts PostLookup) Shortcode {
+	return func(ctx context.Context, args []string) (string, error) {
+		if len(args) == 0 || len(args) > 2 {
+			return "", errors.New("post-link expects a slug and optional link text")
+		}
+
+		post, err := posts.GetPostBySlug(ctx, args[0])
+		if err != nil {
+			return "", fmt.Errorf("loading post %q: %w", args[0], err)
+		}
+		if post.Status != domain.PostStatusPublished {
+			return "", fmt.Errorf("post %q is not published", args[0])
+		}
+
+		text := post.Title
+		if len(args) == 2 {
+			text = args[1]
+		}
+		return fmt.Sprintf(`<a href="/posts/%s">%s</a>`, html.EscapeString(post.Slug), html.EscapeString(text)), nil
+	}
+}
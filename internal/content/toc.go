@@ -0,0 +1,109 @@
+package content
+
+import (
+	"html"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// Headings outside this range are left out of the table of contents. The
+// page title is the only h1, and anything below h4 is too fine-grained.
+const (
+	tocMinLevel = 2
+	tocMaxLevel = 4
+)
+
+// MinTOCEntries is how many headings a document needs before its table of
+// contents is worth showing
+const MinTOCEntries = 3
+
+// TOCEntry is a heading in the table of contents.
+type TOCEntry struct {
+	Level    int
+	ID       string
+	Text     string
+	Children []*TOCEntry
+}
+
+// buildTOC collects the document's headings, nesting each under the
+// closest preceding heading of a higher level.
+func buildTOC(doc ast.Node) []*TOCEntry {
+	var roots, stack []*TOCEntry
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, ok := node.(*ast.Heading)
+		if !ok || !entering {
+			return ast.GoToNext
+		}
+		if heading.Level < tocMinLevel || heading.Level > tocMaxLevel || heading.HeadingID == "" {
+			return ast.SkipChildren
+		}
+
+		entry := &TOCEntry{Level: heading.Level, ID: heading.HeadingID, Text: headingText(heading)}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= entry.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, entry)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, entry)
+		}
+		stack = append(stack, entry)
+		return ast.SkipChildren
+	})
+	return roots
+}
+
+// headingText is the plain text of a heading with its markup removed
+func headingText(heading *ast.Heading) string {
+	var b strings.Builder
+	ast.WalkFunc(heading, func(node ast.Node, entering bool) ast.WalkStatus {
+		if leaf := node.AsLeaf(); leaf != nil && entering {
+			switch node.(type) {
+			case *ast.Text, *ast.Code:
+				b.Write(leaf.Literal)
+			}
+		}
+		return ast.GoToNext
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// TOCHTML renders the table of contents as a nested list, or returns an
+// empty string when the document has fewer than MinTOCEntries headings.
+func (d *Document) TOCHTML() string {
+	if countEntries(d.TOC) < MinTOCEntries {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(`<nav class="toc" aria-label="Table of contents">`)
+	writeTOCList(&b, d.TOC)
+	b.WriteString("</nav>")
+	return b.String()
+}
+
+func writeTOCList(b *strings.Builder, entries []*TOCEntry) {
+	b.WriteString("<ul>")
+	for _, entry := range entries {
+		b.WriteString(`<li><a href="#`)
+		b.WriteString(html.EscapeString(entry.ID))
+		b.WriteString(`">`)
+		b.WriteString(html.EscapeString(entry.Text))
+		b.WriteString("</a>")
+		if len(entry.Children) > 0 {
+			writeTOCList(b, entry.Children)
+		}
+		b.WriteString("</li>")
+	}
+	b.WriteString("</ul>")
+}
+
+func countEntries(entries []*TOCEntry) int {
+	n := len(entries)
+	for _, entry := range entries {
+		n += countEntries(entry.Children)
+	}
+	return n
+}
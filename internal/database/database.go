@@ -5,47 +5,91 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"time"
+	"io"
 
 	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
 	"github.com/toutaio/toutago-sil-migrator/pkg/sil/adapters"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/instrument"
+	"github.com/toutaio/toutago-starter-kit-basic/migrations"
 
 	// Import database drivers
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
-
-	// Import migrations to register them
-	_ "github.com/toutaio/toutago-starter-kit-basic/migrations"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 // Connect establishes a database connection based on configuration.
 func Connect(cfg config.DatabaseConfig) (*sql.DB, error) {
+	db, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
+
+// Open sets up the connection pool cfg describes without connecting, so
+// the database needn't be reachable yet. Connections are made, and remade
+// after the database went away, as queries need them.
+func Open(cfg config.DatabaseConfig) (*sql.DB, error) {
 	connStr := cfg.ConnectionString()
 	if connStr == "" {
 		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
 	}
 
-	db, err := sql.Open(cfg.Driver, connStr)
+	if err := registerTLS(cfg); err != nil {
+		return nil, err
+	}
+
+	// Queries are timed, so slow ones are logged and requests can count
+	// theirs
+	db, err := instrument.Open(cfg.DriverName(), connStr, instrument.Options{SlowQuery: cfg.SlowQuery})
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Set connection pool settings
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
-	db.SetConnMaxIdleTime(2 * time.Minute)
-
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	// Set connection pool settings; those left at zero keep database/sql's
+	// defaults
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 	}
 
 	return db, nil
 }
 
+// ConnectReplicas connects to each read replica of cfg. If one can't be
+// reached, those already connected are closed again.
+func ConnectReplicas(cfg config.DatabaseConfig) ([]*sql.DB, error) {
+	var replicas []*sql.DB
+	for _, addr := range cfg.Replicas {
+		db, err := Connect(cfg.Replica(addr))
+		if err != nil {
+			for _, replica := range replicas {
+				replica.Close()
+			}
+			return nil, fmt.Errorf("replica %s: %w", addr, err)
+		}
+		replicas = append(replicas, db)
+	}
+	return replicas, nil
+}
+
 // Ping checks if the database connection is alive.
 func Ping(db *sql.DB) error {
 	if db == nil {
@@ -74,6 +118,41 @@ func Stats(db *sql.DB) sql.DBStats {
 func RunMigrations(cfg config.DatabaseConfig) error {
 	ctx := context.Background()
 
+	migrator, closeMigrator, err := NewMigrator(ctx, cfg, MigratorOptions{})
+	if err != nil {
+		return err
+	}
+	defer closeMigrator()
+
+	// Run migrations
+	if err := migrator.Migrate(ctx); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	return nil
+}
+
+// MigratorOptions changes how a migrator from NewMigrator runs migrations.
+type MigratorOptions struct {
+	// DryRun receives the statements migrations would run instead of the
+	// database, when set.
+	DryRun io.Writer
+}
+
+// NewMigrator connects a Sil migrator for the migrations package to the
+// database, once no other process is migrating it. Call release when done
+// with the migrator to let others migrate again.
+func NewMigrator(ctx context.Context, cfg config.DatabaseConfig, opts MigratorOptions) (migrator sil.Migrator, release func() error, err error) {
+	db, err := Connect(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	unlock, err := LockMigrations(ctx, db, cfg.Driver)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
 	// Create Sil config
 	silConfig := sil.DefaultConfig()
 	silConfig.DatabaseURL = buildDatabaseURL(cfg)
@@ -82,54 +161,61 @@ func RunMigrations(cfg config.DatabaseConfig) error {
 
 	// Create appropriate adapter
 	var adapter sil.DatabaseAdapter
-	var err error
 
 	switch cfg.Driver {
 	case "postgres":
 		adapter, err = adapters.NewPostgresAdapter(silConfig)
 	case "mysql":
 		adapter, err = adapters.NewMySQLAdapter(silConfig)
+	case "sqlite":
+		adapter, err = migrations.NewSQLiteAdapter(silConfig)
 	default:
-		return fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+		err = fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+	if err == nil {
+		// Connect to database
+		err = adapter.Connect(ctx, silConfig)
 	}
-
 	if err != nil {
-		return fmt.Errorf("failed to create database adapter: %w", err)
+		unlock()
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to connect the migrator to the database: %w", err)
 	}
 
-	// Connect to database
-	if err := adapter.Connect(ctx, silConfig); err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+	if opts.DryRun != nil {
+		adapter = migrations.NewDryRunAdapter(adapter, opts.DryRun)
 	}
-	defer adapter.Close()
 
 	// Create migrator
-	migrator, err := sil.NewMigrator(silConfig, adapter)
+	migrator, err = sil.NewMigrator(silConfig, adapter)
 	if err != nil {
-		return fmt.Errorf("failed to create migrator: %w", err)
+		adapter.Close()
+		unlock()
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to create migrator: %w", err)
 	}
 
-	// Run migrations
-	if err := migrator.Migrate(ctx); err != nil {
-		return fmt.Errorf("migration failed: %w", err)
+	if opts.DryRun != nil {
+		// Sil logs to stdout, where the statements usually go
+		migrator.SetLogger(sil.NewDefaultLogger(false))
 	}
 
-	return nil
+	return migrator, func() error {
+		adapter.Close()
+		err := unlock()
+		db.Close()
+		return err
+	}, nil
 }
 
 // buildDatabaseURL builds the database URL for Sil migrator
 func buildDatabaseURL(cfg config.DatabaseConfig) string {
 	switch cfg.Driver {
-	case "postgres":
-		return fmt.Sprintf(
-			"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-			cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Name,
-		)
-	case "mysql":
-		return fmt.Sprintf(
-			"mysql://%s:%s@tcp(%s:%s)/%s?parseTime=true",
-			cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Name,
-		)
+	case "postgres", "mysql":
+		// Sil hands the URL to the database/sql driver as it is
+		return cfg.ConnectionString()
+	case "sqlite":
+		return "sqlite://" + cfg.Name
 	default:
 		return ""
 	}
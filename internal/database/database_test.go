@@ -1,10 +1,17 @@
 package database_test
 
 import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/database"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/schema"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
 )
 
 func TestConnect(t *testing.T) {
@@ -37,6 +44,20 @@ func TestConnect(t *testing.T) {
 			},
 			wantErr: true, // Will fail without real DB, but tests structure
 		},
+		{
+			name: "mysql with a missing CA certificate",
+			cfg: config.DatabaseConfig{
+				Driver:      "mysql",
+				Host:        "localhost",
+				Port:        "3306",
+				Name:        "test_db",
+				User:        "test_user",
+				Password:    "test_pass",
+				SSLMode:     "verify-full",
+				SSLRootCert: "testdata/missing-ca.pem",
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid driver",
 			cfg: config.DatabaseConfig{
@@ -67,6 +88,103 @@ func TestConnect(t *testing.T) {
 	}
 }
 
+// SQLite needs no server, so the migrations are run against a real database
+func TestRunMigrations_SQLite(t *testing.T) {
+	cfg := config.DatabaseConfig{
+		Driver: "sqlite",
+		Name:   filepath.Join(t.TempDir(), "app.db"),
+	}
+
+	if err := database.RunMigrations(cfg); err != nil {
+		t.Fatalf("RunMigrations() error = %v", err)
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer db.Close()
+
+	for _, table := range []string{"users", "posts", "pages", "menu_items", "comments", "media", "rendered_content", "post_autosaves"} {
+		var name string
+		if err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&name); err != nil {
+			t.Errorf("expected table %s: %v", table, err)
+		}
+	}
+
+	// The migrations create everything the repositories use
+	mismatches, err := schema.Check(context.Background(), db, dialect.SQLite, repository.Columns)
+	if err != nil {
+		t.Fatalf("schema.Check() error = %v", err)
+	}
+	if len(mismatches) > 0 {
+		t.Errorf("migrated schema differs from the repositories:\n%s", schema.Diff(mismatches))
+	}
+
+	// IDs are generated and references are enforced
+	result, err := db.Exec(`INSERT INTO users (email, password_hash, name) VALUES ('a@example.com', 'x', 'A')`)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	if id, _ := result.LastInsertId(); id != 1 {
+		t.Errorf("expected user ID 1, got %d", id)
+	}
+	if _, err := db.Exec(`INSERT INTO posts (title, slug, content, author_id) VALUES ('Hi', 'hi', 'Body', 2)`); err == nil {
+		t.Error("expected a post by a missing author to be rejected")
+	}
+}
+
+// A dry run prints the statements of the pending migrations and leaves the
+// database as it was
+func TestNewMigrator_DryRun(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DatabaseConfig{
+		Driver: "sqlite",
+		Name:   filepath.Join(t.TempDir(), "app.db"),
+	}
+
+	var out bytes.Buffer
+	migrator, release, err := database.NewMigrator(ctx, cfg, database.MigratorOptions{DryRun: &out})
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	defer release()
+
+	if err := migrator.MigrateUp(ctx, 2); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "CREATE TABLE IF NOT EXISTS users (") ||
+		!strings.Contains(out.String(), "CREATE TABLE IF NOT EXISTS posts (") {
+		t.Errorf("expected the statements of the first two migrations, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "CREATE TABLE IF NOT EXISTS pages (") {
+		t.Error("expected only two migrations to be printed")
+	}
+
+	// The next step picks up where the dry run left off
+	out.Reset()
+	if err := migrator.MigrateUp(ctx, 1); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "CREATE TABLE IF NOT EXISTS pages (") {
+		t.Errorf("expected the statements of the third migration, got:\n%s", out.String())
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer db.Close()
+
+	var tables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'`).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Errorf("expected no tables after a dry run, got %d", tables)
+	}
+}
+
 func TestConnectionString(t *testing.T) {
 	// This test verifies we're using the config properly
 	cfg := config.DatabaseConfig{
@@ -0,0 +1,207 @@
+// Package dialect hides the SQL differences between the supported databases
+// so repositories can be written once.
+//
+// Queries are written with ? placeholders and rebound for the database they
+// run on, so a query must not contain a literal question mark.
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Driver names accepted by For, matching config.DatabaseConfig.Driver.
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+)
+
+// Conn is the part of *sql.DB and *sql.Tx a dialect runs statements on.
+type Conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Dialect is the SQL a database understands differently from the others.
+type Dialect interface {
+	// Name returns the database driver name.
+	Name() string
+	// Rebind rewrites the ? placeholders of query into the database's own.
+	Rebind(query string) string
+	// InsertID runs an INSERT statement and returns the ID of the new row.
+	InsertID(ctx context.Context, conn Conn, query string, args ...interface{}) (int64, error)
+	// Upsert returns the clause that follows an INSERT so that a row
+	// clashing on the conflict columns gets the update columns overwritten
+	// with the inserted values instead.
+	Upsert(conflict, update []string) string
+	// Bool returns v the way the database stores booleans.
+	Bool(v bool) interface{}
+	// LimitOffset returns the paging clause for a SELECT and its arguments.
+	// A limit of zero or less returns every row after offset.
+	LimitOffset(limit, offset int) (string, []interface{})
+	// ColumnsQuery returns a query listing the column names of the table
+	// bound to its placeholder, which returns no rows for a missing table.
+	ColumnsQuery() string
+}
+
+var (
+	// Postgres is the dialect of PostgreSQL.
+	Postgres Dialect = postgres{}
+	// MySQL is the dialect of MySQL and MariaDB.
+	MySQL Dialect = mysql{}
+	// SQLite is the dialect of SQLite.
+	SQLite Dialect = sqlite{}
+)
+
+// For returns the dialect of a database driver.
+func For(driver string) (Dialect, error) {
+	switch driver {
+	case DriverPostgres:
+		return Postgres, nil
+	case DriverMySQL:
+		return MySQL, nil
+	case DriverSQLite:
+		return SQLite, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+type postgres struct{}
+
+func (postgres) Name() string { return DriverPostgres }
+
+// Rebind numbers the placeholders $1, $2, ... in order
+func (postgres) Rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+func (d postgres) InsertID(ctx context.Context, conn Conn, query string, args ...interface{}) (int64, error) {
+	var id int64
+	err := conn.QueryRowContext(ctx, d.Rebind(strings.TrimSpace(query))+" RETURNING id", args...).Scan(&id)
+	return id, err
+}
+
+func (postgres) Upsert(conflict, update []string) string {
+	set := make([]string, len(update))
+	for i, col := range update {
+		set[i] = col + " = EXCLUDED." + col
+	}
+	return "ON CONFLICT (" + strings.Join(conflict, ", ") + ") DO UPDATE SET " + strings.Join(set, ", ")
+}
+
+func (postgres) Bool(v bool) interface{} { return v }
+
+func (postgres) LimitOffset(limit, offset int) (string, []interface{}) {
+	if limit <= 0 {
+		return "OFFSET ?", []interface{}{offset}
+	}
+	return "LIMIT ? OFFSET ?", []interface{}{limit, offset}
+}
+
+func (postgres) ColumnsQuery() string {
+	return `SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position`
+}
+
+type mysql struct{}
+
+// mysqlNoLimit is the row count MySQL documents for an OFFSET without a
+// LIMIT, which its grammar doesn't allow
+const mysqlNoLimit = "18446744073709551615"
+
+func (mysql) Name() string { return DriverMySQL }
+
+func (mysql) Rebind(query string) string { return query }
+
+func (mysql) InsertID(ctx context.Context, conn Conn, query string, args ...interface{}) (int64, error) {
+	result, err := conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// Upsert relies on the unique key behind the conflict columns, which MySQL
+// doesn't let a statement name
+func (mysql) Upsert(conflict, update []string) string {
+	set := make([]string, len(update))
+	for i, col := range update {
+		set[i] = col + " = VALUES(" + col + ")"
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+}
+
+// Bool returns 1 or 0 for the TINYINT(1) columns MySQL uses as booleans
+func (mysql) Bool(v bool) interface{} {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func (mysql) LimitOffset(limit, offset int) (string, []interface{}) {
+	if limit <= 0 {
+		return "LIMIT " + mysqlNoLimit + " OFFSET ?", []interface{}{offset}
+	}
+	return "LIMIT ? OFFSET ?", []interface{}{limit, offset}
+}
+
+func (mysql) ColumnsQuery() string {
+	return `SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position`
+}
+
+type sqlite struct{}
+
+func (sqlite) Name() string { return DriverSQLite }
+
+func (sqlite) Rebind(query string) string { return query }
+
+func (sqlite) InsertID(ctx context.Context, conn Conn, query string, args ...interface{}) (int64, error) {
+	result, err := conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (sqlite) Upsert(conflict, update []string) string {
+	return postgres{}.Upsert(conflict, update)
+}
+
+// Bool returns 1 or 0, as SQLite has no boolean type
+func (sqlite) Bool(v bool) interface{} {
+	return mysql{}.Bool(v)
+}
+
+// LimitOffset uses a negative limit for no limit, as SQLite only takes an
+// OFFSET after a LIMIT
+func (sqlite) LimitOffset(limit, offset int) (string, []interface{}) {
+	if limit <= 0 {
+		return "LIMIT -1 OFFSET ?", []interface{}{offset}
+	}
+	return "LIMIT ? OFFSET ?", []interface{}{limit, offset}
+}
+
+func (sqlite) ColumnsQuery() string {
+	return `SELECT name FROM pragma_table_info(?) ORDER BY cid`
+}
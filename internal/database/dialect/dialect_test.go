@@ -0,0 +1,193 @@
+package dialect_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+)
+
+func TestFor(t *testing.T) {
+	d, err := dialect.For("postgres")
+	require.NoError(t, err)
+	assert.Equal(t, dialect.Postgres, d)
+
+	d, err = dialect.For("mysql")
+	require.NoError(t, err)
+	assert.Equal(t, dialect.MySQL, d)
+
+	d, err = dialect.For("sqlite")
+	require.NoError(t, err)
+	assert.Equal(t, dialect.SQLite, d)
+
+	_, err = dialect.For("oracle")
+	assert.EqualError(t, err, "unsupported database driver: oracle")
+}
+
+func TestRebind(t *testing.T) {
+	query := "SELECT id FROM posts WHERE status = ? AND author_id = ? LIMIT ?"
+
+	assert.Equal(t, "SELECT id FROM posts WHERE status = $1 AND author_id = $2 LIMIT $3", dialect.Postgres.Rebind(query))
+	assert.Equal(t, query, dialect.MySQL.Rebind(query))
+	assert.Equal(t, query, dialect.SQLite.Rebind(query))
+	assert.Equal(t, "SELECT 1", dialect.Postgres.Rebind("SELECT 1"))
+}
+
+func TestInsertID(t *testing.T) {
+	ctx := context.Background()
+	query := `
+		INSERT INTO tags (name, slug)
+		VALUES (?, ?)
+	`
+
+	t.Run("postgres", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`INSERT INTO tags \(name, slug\) VALUES \(\$1, \$2\) RETURNING id`).
+			WithArgs("Go", "go").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+		id, err := dialect.Postgres.InsertID(ctx, db, query, "Go", "go")
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("mysql", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT INTO tags \(name, slug\) VALUES \(\?, \?\)$`).
+			WithArgs("Go", "go").
+			WillReturnResult(sqlmock.NewResult(7, 1))
+
+		id, err := dialect.MySQL.InsertID(ctx, db, query, "Go", "go")
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUpsert(t *testing.T) {
+	conflict := []string{"user_id", "post_id"}
+	update := []string{"title", "content"}
+
+	assert.Equal(t, "ON CONFLICT (user_id, post_id) DO UPDATE SET title = EXCLUDED.title, content = EXCLUDED.content",
+		dialect.Postgres.Upsert(conflict, update))
+	assert.Equal(t, "ON DUPLICATE KEY UPDATE title = VALUES(title), content = VALUES(content)",
+		dialect.MySQL.Upsert(conflict, update))
+	assert.Equal(t, "ON CONFLICT (user_id, post_id) DO UPDATE SET title = EXCLUDED.title, content = EXCLUDED.content",
+		dialect.SQLite.Upsert(conflict, update))
+}
+
+func TestBool(t *testing.T) {
+	assert.Equal(t, true, dialect.Postgres.Bool(true))
+	assert.Equal(t, 1, dialect.MySQL.Bool(true))
+	assert.Equal(t, 0, dialect.MySQL.Bool(false))
+	assert.Equal(t, 1, dialect.SQLite.Bool(true))
+}
+
+func TestLimitOffset(t *testing.T) {
+	tests := []struct {
+		name          string
+		d             dialect.Dialect
+		limit, offset int
+		clause        string
+		args          []interface{}
+	}{
+		{"postgres page", dialect.Postgres, 10, 20, "LIMIT ? OFFSET ?", []interface{}{10, 20}},
+		{"postgres no limit", dialect.Postgres, 0, 20, "OFFSET ?", []interface{}{20}},
+		{"mysql page", dialect.MySQL, 10, 20, "LIMIT ? OFFSET ?", []interface{}{10, 20}},
+		{"mysql no limit", dialect.MySQL, 0, 20, "LIMIT 18446744073709551615 OFFSET ?", []interface{}{20}},
+		{"sqlite page", dialect.SQLite, 10, 20, "LIMIT ? OFFSET ?", []interface{}{10, 20}},
+		{"sqlite no limit", dialect.SQLite, 0, 20, "LIMIT -1 OFFSET ?", []interface{}{20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, args := tt.d.LimitOffset(tt.limit, tt.offset)
+			assert.Equal(t, tt.clause, clause)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestColumnsQuery(t *testing.T) {
+	assert.Contains(t, dialect.Postgres.ColumnsQuery(), "table_schema = current_schema() AND table_name = $1")
+	assert.Contains(t, dialect.MySQL.ColumnsQuery(), "table_schema = DATABASE() AND table_name = ?")
+}
+
+// SQLite needs no server, so its dialect is checked against a real database
+func TestSQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", "file::memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	// Every connection to :memory: opens a new database
+	db.SetMaxOpenConns(1)
+
+	d := dialect.SQLite
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name VARCHAR(100) NOT NULL,
+			slug VARCHAR(100) UNIQUE NOT NULL,
+			hidden BOOLEAN NOT NULL DEFAULT FALSE
+		)
+	`)
+	require.NoError(t, err)
+
+	insert := `INSERT INTO tags (name, slug, hidden) VALUES (?, ?, ?)`
+	for i, tag := range []string{"go", "sql", "web"} {
+		id, err := d.InsertID(ctx, db, d.Rebind(insert), tag, tag, d.Bool(tag == "sql"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(i+1), id)
+	}
+
+	upsert := insert + " " + d.Upsert([]string{"slug"}, []string{"name"})
+	_, err = db.ExecContext(ctx, d.Rebind(upsert), "Go", "go", d.Bool(false))
+	require.NoError(t, err)
+
+	paging, args := d.LimitOffset(0, 1)
+	rows, err := db.QueryContext(ctx, d.Rebind("SELECT name, hidden FROM tags ORDER BY id "+paging), args...)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var names []string
+	var hidden []bool
+	for rows.Next() {
+		var name string
+		var h bool
+		require.NoError(t, rows.Scan(&name, &h))
+		names = append(names, name)
+		hidden = append(hidden, h)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"sql", "web"}, names)
+	assert.Equal(t, []bool{true, false}, hidden)
+
+	var name string
+	require.NoError(t, db.QueryRowContext(ctx, d.Rebind("SELECT name FROM tags WHERE slug = ?"), "go").Scan(&name))
+	assert.Equal(t, "Go", name)
+
+	columns, err := db.QueryContext(ctx, d.ColumnsQuery(), "tags")
+	require.NoError(t, err)
+	defer columns.Close()
+
+	names = nil
+	for columns.Next() {
+		var column string
+		require.NoError(t, columns.Scan(&column))
+		names = append(names, column)
+	}
+	require.NoError(t, columns.Err())
+	assert.Equal(t, []string{"id", "name", "slug", "hidden"}, names)
+}
@@ -0,0 +1,149 @@
+// Package fixtures loads rows declared in YAML into a database, so tests can
+// set up the data they need on any supported database.
+//
+// A fixture file maps table names to lists of rows, in the order they are
+// inserted, so that rows come after those they refer to:
+//
+//	users:
+//	  - id: 1
+//	    email: author@example.com
+//	    password_hash: "!"
+//	    name: Author
+//	posts:
+//	  - id: 1
+//	    title: Hello
+//	    slug: hello
+//	    content: "# Hello"
+//	    author_id: 1
+//	    is_featured: true
+//
+// Booleans are converted for the database, and unquoted timestamps become
+// times.
+package fixtures
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"gopkg.in/yaml.v3"
+)
+
+// Table is the rows of a fixture for one table.
+type Table struct {
+	Name string
+	Rows []map[string]interface{}
+}
+
+// Parse reads the tables of a fixture in the order they are declared.
+func Parse(data []byte) ([]Table, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: a fixture maps table names to rows", root.Line)
+	}
+
+	tables := make([]Table, 0, len(root.Content)/2)
+	for i := 0; i < len(root.Content); i += 2 {
+		table := Table{Name: root.Content[i].Value}
+		if err := root.Content[i+1].Decode(&table.Rows); err != nil {
+			return nil, fmt.Errorf("table %s: %w", table.Name, err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// Load inserts the rows of the fixture files at paths, in one transaction.
+func Load(ctx context.Context, db *sql.DB, d dialect.Dialect, paths ...string) error {
+	return LoadFS(ctx, db, d, os.DirFS("."), paths...)
+}
+
+// LoadFS is Load for fixture files read from fsys.
+func LoadFS(ctx context.Context, db *sql.DB, d dialect.Dialect, fsys fs.FS, paths ...string) error {
+	var tables []Table
+	for _, path := range paths {
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		parsed, err := Parse(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		tables = append(tables, parsed...)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := Insert(ctx, tx, d, tables...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Insert inserts the rows of tables on conn.
+func Insert(ctx context.Context, conn dialect.Conn, d dialect.Dialect, tables ...Table) error {
+	for _, table := range tables {
+		withIDs := false
+		for i, row := range table.Rows {
+			columns := make([]string, 0, len(row))
+			for column := range row {
+				columns = append(columns, column)
+			}
+			slices.Sort(columns)
+
+			values := make([]interface{}, len(columns))
+			for j, column := range columns {
+				values[j] = value(d, row[column])
+			}
+			_, withID := row["id"]
+			withIDs = withIDs || withID
+
+			query := `INSERT INTO ` + table.Name + ` (` + strings.Join(columns, ", ") + `) VALUES (` +
+				strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + `)`
+			if _, err := conn.ExecContext(ctx, d.Rebind(query), values...); err != nil {
+				return fmt.Errorf("%s row %d: %w", table.Name, i+1, err)
+			}
+		}
+
+		// PostgreSQL hands out IDs from a sequence that rows inserted with
+		// their own ID don't advance, so it's moved past them
+		if withIDs && d.Name() == dialect.DriverPostgres {
+			query := `SELECT setval(pg_get_serial_sequence('` + table.Name + `', 'id'), (SELECT MAX(id) FROM ` + table.Name + `))`
+			if _, err := conn.ExecContext(ctx, query); err != nil {
+				return fmt.Errorf("%s: %w", table.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// value converts a YAML value for the database
+func value(d dialect.Dialect, v interface{}) interface{} {
+	switch v := v.(type) {
+	case bool:
+		return d.Bool(v)
+	case time.Time:
+		return v.UTC()
+	default:
+		return v
+	}
+}
@@ -0,0 +1,76 @@
+package fixtures_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/fixtures"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+)
+
+func TestParse(t *testing.T) {
+	tables, err := fixtures.Parse([]byte(`
+posts:
+  - id: 1
+    is_featured: true
+    published_at: 2026-01-02T10:00:00Z
+users:
+  - email: a@example.com
+`))
+	require.NoError(t, err)
+	require.Len(t, tables, 2)
+
+	// Tables keep the order they are declared in
+	assert.Equal(t, "posts", tables[0].Name)
+	assert.Equal(t, "users", tables[1].Name)
+	assert.Equal(t, map[string]interface{}{
+		"id":           1,
+		"is_featured":  true,
+		"published_at": time.Date(2026, time.January, 2, 10, 0, 0, 0, time.UTC),
+	}, tables[0].Rows[0])
+
+	_, err = fixtures.Parse([]byte(`- posts`))
+	assert.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DatabaseConfig{
+		Driver: "sqlite",
+		Name:   filepath.Join(t.TempDir(), "app.db"),
+	}
+	require.NoError(t, database.RunMigrations(cfg))
+
+	db, err := database.Connect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, fixtures.Load(ctx, db, dialect.SQLite, "testdata/blog.yml"))
+
+	post, err := repository.NewPostRepository(db, dialect.SQLite).GetBySlug(ctx, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), post.ID)
+	assert.Equal(t, domain.PostStatusPublished, post.Status)
+	assert.True(t, post.IsFeatured)
+	require.NotNil(t, post.PublishedAt)
+	assert.True(t, post.PublishedAt.Equal(time.Date(2026, time.January, 2, 10, 0, 0, 0, time.UTC)))
+
+	var tags int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM post_tags WHERE post_id = 1`).Scan(&tags))
+	assert.Equal(t, 1, tags)
+
+	// A failing row leaves nothing of the fixture behind
+	err = fixtures.Load(ctx, db, dialect.SQLite, "testdata/blog.yml")
+	assert.Error(t, err)
+	var users int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&users))
+	assert.Equal(t, 1, users)
+}
@@ -0,0 +1,111 @@
+// Package instrument times the queries of a database.
+//
+// Open wraps a database/sql driver so that every statement, whether run by
+// a repository, in a transaction or by a migration, is measured from the
+// moment it is sent until its rows are closed. Queries slower than a
+// threshold are logged, and those run with a context from WithRecorder are
+// collected, so a request can report what it ran:
+//
+//	ctx, rec := instrument.WithRecorder(r.Context(), requestID)
+//	next.ServeHTTP(w, r.WithContext(ctx))
+//	count, total := rec.Stats()
+package instrument
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// Options configures an instrumented database.
+type Options struct {
+	SlowQuery time.Duration // queries taking this long are logged, 0 logs none
+}
+
+// Open opens a database like sql.Open whose queries are timed and recorded.
+func Open(driverName, dsn string, opts Options) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	// sql.Open doesn't connect; it was only needed to find the driver
+	d := db.Driver()
+	db.Close()
+
+	var c driver.Connector = dsnConnector{dsn: dsn, driver: d}
+	if dc, ok := d.(driver.DriverContext); ok {
+		if c, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	return sql.OpenDB(&connector{Connector: c, opts: opts}), nil
+}
+
+// dsnConnector connects through a driver that has no connectors of its own
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// connector hands out instrumented connections
+type connector struct {
+	driver.Connector
+	opts Options
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: conn, opts: c.opts}, nil
+}
+
+// record notes a query that finished for the recorder of ctx, and logs it
+// if it was slow
+func record(ctx context.Context, opts Options, query string, start time.Time, rows int64, err error) {
+	q := Query{SQL: strings.Join(strings.Fields(query), " "), Duration: time.Since(start), Rows: rows, Err: err}
+	rec := recorderFrom(ctx)
+	if rec != nil {
+		rec.add(q)
+	}
+
+	if opts.SlowQuery > 0 && q.Duration >= opts.SlowQuery {
+		id := ""
+		if rec != nil && rec.RequestID != "" {
+			id = " [request " + rec.RequestID + "]"
+		}
+		log.Printf("Slow query (%v, %d rows)%s: %s", q.Duration.Round(time.Microsecond), q.Rows, id, q.SQL)
+	}
+}
+
+// affected returns the rows a statement changed, or 0 if the driver can't
+// tell
+func affected(res driver.Result) int64 {
+	if res == nil {
+		return 0
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// skipped reports whether the driver declined to run a statement directly,
+// so database/sql prepares it instead and it is timed there
+func skipped(err error) bool {
+	return errors.Is(err, driver.ErrSkip)
+}
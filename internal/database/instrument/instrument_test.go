@@ -0,0 +1,128 @@
+package instrument_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/instrument"
+)
+
+// open returns an instrumented database with a notes table of three rows
+func open(t *testing.T, opts instrument.Options) *sql.DB {
+	t.Helper()
+	db, err := instrument.Open("sqlite3", filepath.Join(t.TempDir(), "app.db"), opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE notes (body TEXT NOT NULL)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO notes (body) VALUES ('a'), ('b'), ('c')`)
+	require.NoError(t, err)
+	return db
+}
+
+func TestOpen_Records(t *testing.T) {
+	db := open(t, instrument.Options{})
+	ctx, rec := instrument.WithRecorder(context.Background(), "req-1")
+
+	_, err := db.ExecContext(ctx, `UPDATE notes SET body = upper(body) WHERE body <> ?`, "c")
+	require.NoError(t, err)
+
+	rows, err := db.QueryContext(ctx, `SELECT body
+		FROM notes`)
+	require.NoError(t, err)
+	for rows.Next() {
+		var body string
+		require.NoError(t, rows.Scan(&body))
+	}
+	require.NoError(t, rows.Close())
+
+	_, err = db.QueryContext(ctx, `SELECT missing FROM notes`)
+	require.Error(t, err)
+
+	stmt, err := db.PrepareContext(ctx, `SELECT body FROM notes WHERE body = ?`)
+	require.NoError(t, err)
+	defer stmt.Close()
+	var body string
+	require.NoError(t, stmt.QueryRowContext(ctx, "A").Scan(&body))
+
+	// Queries without a recorder aren't collected
+	_, err = db.Exec(`DELETE FROM notes WHERE body = 'c'`)
+	require.NoError(t, err)
+
+	queries := rec.Queries()
+	require.Len(t, queries, 4)
+	assert.Equal(t, "UPDATE notes SET body = upper(body) WHERE body <> ?", queries[0].SQL)
+	assert.Equal(t, int64(2), queries[0].Rows)
+	assert.Equal(t, "SELECT body FROM notes", queries[1].SQL, "whitespace is collapsed")
+	assert.Equal(t, int64(3), queries[1].Rows)
+	assert.Error(t, queries[2].Err)
+	assert.Equal(t, int64(1), queries[3].Rows)
+
+	count, total := rec.Stats()
+	assert.Equal(t, 4, count)
+	assert.Positive(t, total)
+}
+
+func TestOpen_Transactions(t *testing.T) {
+	db := open(t, instrument.Options{})
+	ctx, rec := instrument.WithRecorder(context.Background(), "req-1")
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, `INSERT INTO notes (body) VALUES (?)`, "d")
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM notes`).Scan(&n))
+	require.NoError(t, tx.Commit())
+
+	assert.Equal(t, 4, n)
+	count, _ := rec.Stats()
+	assert.Equal(t, 2, count)
+}
+
+func TestOpen_SlowQueries(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	db := open(t, instrument.Options{SlowQuery: 1})
+	ctx, _ := instrument.WithRecorder(context.Background(), "req-7")
+
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notes`).Scan(&n))
+
+	assert.Contains(t, logs.String(), "[request req-7]: SELECT COUNT(*) FROM notes")
+	assert.Contains(t, logs.String(), "1 rows")
+}
+
+func TestRecorder_Repeated(t *testing.T) {
+	db := open(t, instrument.Options{})
+	ctx, rec := instrument.WithRecorder(context.Background(), "req-1")
+
+	var body string
+	for _, id := range []int{1, 2, 3} {
+		require.NoError(t, db.QueryRowContext(ctx, `SELECT body FROM notes WHERE rowid = ?`, id).Scan(&body))
+	}
+	for range 2 {
+		_, err := db.ExecContext(ctx, `UPDATE notes SET body = body`)
+		require.NoError(t, err)
+	}
+
+	repeats := rec.Repeated(1)
+	require.Len(t, repeats, 2)
+	assert.Equal(t, instrument.Repeat{SQL: "SELECT body FROM notes WHERE rowid = ?", Count: 3}, repeats[0])
+	assert.Equal(t, 2, repeats[1].Count)
+	assert.Len(t, rec.Repeated(2), 1)
+	assert.Empty(t, rec.Repeated(3))
+	assert.True(t, strings.HasPrefix(repeats[1].SQL, "UPDATE"))
+}
@@ -0,0 +1,93 @@
+package instrument
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Query is a statement a database ran.
+type Query struct {
+	SQL      string // with its whitespace collapsed
+	Duration time.Duration
+	Rows     int64 // rows read or affected
+	Err      error
+}
+
+// Repeat is a statement one request ran many times.
+type Repeat struct {
+	SQL   string
+	Count int
+}
+
+// Recorder collects the queries run with a context, such as those of one
+// request.
+type Recorder struct {
+	RequestID string
+
+	mu      sync.Mutex
+	queries []Query
+}
+
+// recorderKey is the context key of the current Recorder
+type recorderKey struct{}
+
+// WithRecorder returns a context whose queries are recorded, with the
+// recorder that collects them under requestID.
+func WithRecorder(ctx context.Context, requestID string) (context.Context, *Recorder) {
+	r := &Recorder{RequestID: requestID}
+	return context.WithValue(ctx, recorderKey{}, r), r
+}
+
+// Queries returns the queries recorded so far, in the order they ran.
+func (r *Recorder) Queries() []Query {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.queries)
+}
+
+// Stats returns the number of queries recorded and the time they took.
+func (r *Recorder) Stats() (count int, total time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, q := range r.queries {
+		total += q.Duration
+	}
+	return len(r.queries), total
+}
+
+// Repeated returns the statements that ran more than limit times, the most
+// repeated first. The same statement run over and over with different
+// arguments usually means a query in a loop that one query could replace.
+func (r *Recorder) Repeated(limit int) []Repeat {
+	counts := map[string]int{}
+	for _, q := range r.Queries() {
+		counts[q.SQL]++
+	}
+
+	var repeats []Repeat
+	for sql, n := range counts {
+		if n > limit {
+			repeats = append(repeats, Repeat{SQL: sql, Count: n})
+		}
+	}
+	slices.SortFunc(repeats, func(a, b Repeat) int {
+		return cmp.Or(b.Count-a.Count, strings.Compare(a.SQL, b.SQL))
+	})
+	return repeats
+}
+
+func (r *Recorder) add(q Query) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = append(r.queries, q)
+}
+
+// recorderFrom returns the recorder of ctx, if any
+func recorderFrom(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// The lock migration runs take, by name on MySQL and by number on
+// PostgreSQL
+const (
+	migrationLockName       = "starter_kit_migrations"
+	migrationLockKey  int64 = 0x7374617274657273 // "starters" in ASCII
+)
+
+// LockMigrations waits until no other process is migrating the database and
+// keeps it that way until unlock is called, so app instances that migrate
+// as they start up run one after the other. PostgreSQL and MySQL hold the
+// lock on a connection of its own, and release it if the process dies;
+// SQLite is a local file, for which the migrator's own lock file suffices.
+func LockMigrations(ctx context.Context, db *sql.DB, driver string) (unlock func() error, err error) {
+	if driver == "sqlite" {
+		return func() error { return nil }, nil
+	}
+
+	// Session locks are released by the connection that took them, so the
+	// same one must be used throughout
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a connection for the migration lock: %w", err)
+	}
+
+	switch driver {
+	case "postgres":
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to take the migration lock: %w", err)
+		}
+		return func() error {
+			defer conn.Close()
+			_, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+			return err
+		}, nil
+
+	case "mysql":
+		// A negative timeout waits for as long as it takes
+		var locked sql.NullInt64
+		if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, -1)`, migrationLockName).Scan(&locked); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to take the migration lock: %w", err)
+		}
+		if locked.Int64 != 1 {
+			conn.Close()
+			return nil, fmt.Errorf("failed to take the migration lock %s", migrationLockName)
+		}
+		return func() error {
+			defer conn.Close()
+			_, err := conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, migrationLockName)
+			return err
+		}, nil
+
+	default:
+		conn.Close()
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
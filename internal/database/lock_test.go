@@ -0,0 +1,92 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database"
+)
+
+func TestLockMigrations(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("postgres", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		unlock, err := database.LockMigrations(ctx, db, "postgres")
+		if err != nil {
+			t.Fatalf("LockMigrations() error = %v", err)
+		}
+		if err := unlock(); err != nil {
+			t.Errorf("unlock() error = %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("mysql", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT GET_LOCK\(\?, -1\)`).WithArgs("starter_kit_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+		mock.ExpectExec(`SELECT RELEASE_LOCK\(\?\)`).WithArgs("starter_kit_migrations").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		unlock, err := database.LockMigrations(ctx, db, "mysql")
+		if err != nil {
+			t.Fatalf("LockMigrations() error = %v", err)
+		}
+		if err := unlock(); err != nil {
+			t.Errorf("unlock() error = %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("mysql lock not granted", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT GET_LOCK`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(0))
+
+		if _, err := database.LockMigrations(ctx, db, "mysql"); err == nil {
+			t.Error("expected an error when the lock isn't granted")
+		}
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		unlock, err := database.LockMigrations(ctx, db, "sqlite")
+		if err != nil {
+			t.Fatalf("LockMigrations() error = %v", err)
+		}
+		if err := unlock(); err != nil {
+			t.Errorf("unlock() error = %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+)
+
+// Backoff spaces out attempts to reach a database. The wait doubles from
+// Initial up to Max, and a random part of up to half of it is taken off,
+// so instances that restart together don't retry in step.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// Delay returns how long to wait after the failed attempt numbered from 0.
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := b.Initial
+	for i := 0; i < attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	d = min(d, b.Max)
+	if d <= 0 {
+		return 0
+	}
+	return d - rand.N(d/2+1)
+}
+
+// Statuses of the database connection a Monitor watches.
+const (
+	StatusConnecting   = "connecting"   // not reached yet
+	StatusConnected    = "connected"    // answered the last check
+	StatusDisconnected = "disconnected" // reached before, but not any more
+)
+
+// Monitor checks that a database can be reached and keeps its status for
+// health checks.
+type Monitor struct {
+	db       *sql.DB
+	retries  int
+	backoff  Backoff
+	interval time.Duration
+	timeout  time.Duration
+
+	mu        sync.RWMutex
+	status    string
+	connected chan struct{}
+}
+
+// NewMonitor creates a monitor of db with the retry settings of cfg.
+func NewMonitor(db *sql.DB, cfg config.DatabaseConfig) *Monitor {
+	return &Monitor{
+		db:        db,
+		retries:   cfg.ConnectRetries,
+		backoff:   Backoff{Initial: cfg.RetryBackoff, Max: cfg.RetryMaxBackoff},
+		interval:  cfg.HealthInterval,
+		timeout:   cfg.ConnectTimeout,
+		status:    StatusConnecting,
+		connected: make(chan struct{}),
+	}
+}
+
+// Connect waits until the database answers, retrying with backoff as many
+// times as configured. It returns the last error if the database never
+// answered.
+func (m *Monitor) Connect(ctx context.Context) error {
+	for attempt := 0; ; attempt++ {
+		err := m.check(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= m.retries {
+			return err
+		}
+
+		delay := m.backoff.Delay(attempt)
+		log.Printf("Database unreachable (attempt %d of %d), retrying in %v: %v", attempt+1, m.retries+1, delay.Round(time.Millisecond), err)
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Run checks the database until ctx is done: every interval while it
+// answers, and with backoff while it doesn't. The pool reconnects by itself
+// once the database is back.
+func (m *Monitor) Run(ctx context.Context) {
+	attempt := 0
+	for {
+		wait := m.interval
+		if err := m.check(ctx); err != nil {
+			wait = m.backoff.Delay(attempt)
+			attempt++
+		} else {
+			attempt = 0
+		}
+		if sleep(ctx, wait) != nil {
+			return
+		}
+	}
+}
+
+// Status returns StatusConnecting, StatusConnected or StatusDisconnected.
+func (m *Monitor) Status() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Ready reports whether the database answered the last check.
+func (m *Monitor) Ready() bool {
+	return m.Status() == StatusConnected
+}
+
+// Connected is closed once the database has answered a check.
+func (m *Monitor) Connected() <-chan struct{} {
+	return m.connected
+}
+
+// check pings the database and records the outcome, logging when the
+// connection is lost or regained
+func (m *Monitor) check(ctx context.Context) error {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+	err := m.db.PingContext(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case err == nil && m.status == StatusConnecting:
+		close(m.connected)
+		m.status = StatusConnected
+	case err == nil && m.status == StatusDisconnected:
+		log.Println("Database connection restored")
+		m.status = StatusConnected
+	case err != nil && m.status == StatusConnected:
+		log.Printf("Database connection lost: %v", err)
+		m.status = StatusDisconnected
+	}
+	return err
+}
+
+// sleep waits for d or until ctx is done, whichever comes first
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,117 @@
+package database_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	b := database.Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		{20, time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 50 {
+			if d := b.Delay(tt.attempt); d < tt.max/2 || d > tt.max {
+				t.Fatalf("attempt %d: expected a delay between %v and %v, got %v", tt.attempt, tt.max/2, tt.max, d)
+			}
+		}
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	// SQLite only creates the file once its directory exists, so the
+	// database can be made reachable while the monitor waits for it
+	dir := filepath.Join(t.TempDir(), "data")
+	cfg := config.DatabaseConfig{
+		Driver:          "sqlite",
+		Name:            filepath.Join(dir, "app.db"),
+		ConnectRetries:  2,
+		RetryBackoff:    time.Millisecond,
+		RetryMaxBackoff: 5 * time.Millisecond,
+		HealthInterval:  time.Millisecond,
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+	monitor := database.NewMonitor(db, cfg)
+
+	if err := monitor.Connect(context.Background()); err == nil {
+		t.Fatal("expected Connect() to fail without the database directory")
+	}
+	if monitor.Status() != database.StatusConnecting || monitor.Ready() {
+		t.Errorf("expected the monitor to be connecting, got %s", monitor.Status())
+	}
+
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := monitor.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if !monitor.Ready() {
+		t.Errorf("expected the monitor to be ready, got %s", monitor.Status())
+	}
+	select {
+	case <-monitor.Connected():
+	default:
+		t.Error("expected Connected() to be closed")
+	}
+
+	// A closed pool stands in for a database that went away
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		monitor.Run(ctx)
+		close(done)
+	}()
+	db.Close()
+	deadline := time.Now().Add(time.Second)
+	for monitor.Status() != database.StatusDisconnected && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if monitor.Status() != database.StatusDisconnected {
+		t.Errorf("expected the monitor to notice the lost connection, got %s", monitor.Status())
+	}
+}
+
+func TestMonitor_ConnectCanceled(t *testing.T) {
+	cfg := config.DatabaseConfig{
+		Driver:          "sqlite",
+		Name:            filepath.Join(t.TempDir(), "missing", "app.db"),
+		ConnectRetries:  100,
+		RetryBackoff:    time.Hour,
+		RetryMaxBackoff: time.Hour,
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := database.NewMonitor(db, cfg).Connect(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected Connect() to stop when the context is done, got %v", err)
+	}
+}
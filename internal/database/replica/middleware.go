@@ -0,0 +1,65 @@
+package replica
+
+import (
+	"net/http"
+	"time"
+)
+
+// cookieName is the cookie that keeps a visitor's reads on the primary
+const cookieName = "db_primary"
+
+// ReadYourWrites keeps the reads of a visitor who wrote to the database on
+// the primary for window, by setting a cookie on the response to their
+// write. It has to wrap the router, so handlers get the request it gives a
+// Session.
+func ReadYourWrites(window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, err := req.Cookie(cookieName)
+			ctx, session := WithSession(req.Context(), err == nil)
+			sw := &stickyWriter{ResponseWriter: w, session: session, window: window}
+			next.ServeHTTP(sw, req.WithContext(ctx))
+		})
+	}
+}
+
+// stickyWriter sets the cookie before the response headers go out if the
+// request wrote
+type stickyWriter struct {
+	http.ResponseWriter
+	session *Session
+	window  time.Duration
+	started bool
+}
+
+func (w *stickyWriter) WriteHeader(code int) {
+	w.start()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *stickyWriter) Write(b []byte) (int, error) {
+	w.start()
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *stickyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *stickyWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+	if w.session.Wrote() {
+		http.SetCookie(w.ResponseWriter, &http.Cookie{
+			Name:     cookieName,
+			Value:    "1",
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			MaxAge:   int((w.window + time.Second - 1) / time.Second),
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Package replica sends reads to read replicas of the primary database.
+//
+// A Router is a txn.Querier repositories are built on like a database. It
+// runs writes, and reads in transactions, on the primary, and spreads other
+// SELECTs over the replicas in turn. Replicas lag a little behind the
+// primary, so reads of a visitor who has just written stay on the primary
+// for a while; see ReadYourWrites.
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync/atomic"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+)
+
+// Router routes queries between a primary database and its replicas.
+type Router struct {
+	primary  *sql.DB
+	writes   txn.Querier
+	replicas []*sql.DB
+	next     atomic.Uint64
+}
+
+// New creates a router that writes to primary and reads from replicas.
+// Without replicas everything runs on the primary.
+func New(primary *sql.DB, replicas ...*sql.DB) *Router {
+	return &Router{primary: primary, writes: txn.Join(primary), replicas: replicas}
+}
+
+// ExecContext runs a statement on the primary.
+func (r *Router) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	markWrite(ctx)
+	return r.writes.ExecContext(ctx, query, args...)
+}
+
+// QueryContext runs a query on the database route picks.
+func (r *Router) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.route(ctx, query).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs a query on the database route picks.
+func (r *Router) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.route(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+// Unwrap returns the primary, which transactions on the router begin on.
+func (r *Router) Unwrap() *sql.DB {
+	return r.primary
+}
+
+// route returns the next replica for a read that may see slightly stale
+// data, and the primary for anything else
+func (r *Router) route(ctx context.Context, query string) txn.Querier {
+	if !isRead(query) {
+		// INSERT ... RETURNING and the like
+		markWrite(ctx)
+		return r.writes
+	}
+	if len(r.replicas) == 0 || txn.InTransaction(ctx, r.primary) || sticky(ctx) {
+		return r.writes
+	}
+	return r.replicas[(r.next.Add(1)-1)%uint64(len(r.replicas))]
+}
+
+// isRead reports whether query only reads, without locking rows
+func isRead(query string) bool {
+	q := strings.ToUpper(strings.TrimSpace(query))
+	if !strings.HasPrefix(q, "SELECT") {
+		return false
+	}
+	for _, lock := range []string{"FOR UPDATE", "FOR SHARE", "LOCK IN SHARE MODE"} {
+		if strings.Contains(q, lock) {
+			return false
+		}
+	}
+	return true
+}
+
+// Session tracks whether a visitor's reads have to see their writes.
+type Session struct {
+	sticky bool
+	wrote  atomic.Bool
+}
+
+// sessionKey is the context key of the current Session
+type sessionKey struct{}
+
+// WithSession returns a context whose reads go to the primary once a write
+// is made with it, or from the start if sticky is set.
+func WithSession(ctx context.Context, sticky bool) (context.Context, *Session) {
+	s := &Session{sticky: sticky}
+	return context.WithValue(ctx, sessionKey{}, s), s
+}
+
+// Wrote reports whether a write was made with the session's context.
+func (s *Session) Wrote() bool {
+	return s.wrote.Load()
+}
+
+func markWrite(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*Session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+func sticky(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*Session)
+	return ok && (s.sticky || s.Wrote())
+}
@@ -0,0 +1,162 @@
+package replica_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/replica"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+)
+
+// open returns a database whose notes table holds its name, so reads show
+// which database served them
+func open(t *testing.T, name string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), name+".db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE notes (body TEXT NOT NULL)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO notes (body) VALUES (?)`, name)
+	require.NoError(t, err)
+	return db
+}
+
+// served returns the name of the database that answered a read
+func served(t *testing.T, ctx context.Context, q txn.Querier) string {
+	t.Helper()
+	var name string
+	require.NoError(t, q.QueryRowContext(ctx, `SELECT body FROM notes ORDER BY rowid LIMIT 1`).Scan(&name))
+	return name
+}
+
+func TestRouter_Reads(t *testing.T) {
+	ctx := context.Background()
+	router := replica.New(open(t, "primary"), open(t, "replica-1"), open(t, "replica-2"))
+
+	assert.Equal(t, "replica-1", served(t, ctx, router))
+	assert.Equal(t, "replica-2", served(t, ctx, router))
+	assert.Equal(t, "replica-1", served(t, ctx, router))
+
+	rows, err := router.QueryContext(ctx, `SELECT body FROM notes`)
+	require.NoError(t, err)
+	defer rows.Close()
+	require.True(t, rows.Next())
+	var name string
+	require.NoError(t, rows.Scan(&name))
+	assert.Equal(t, "replica-2", name)
+}
+
+func TestRouter_WithoutReplicas(t *testing.T) {
+	router := replica.New(open(t, "primary"))
+
+	assert.Equal(t, "primary", served(t, context.Background(), router))
+}
+
+func TestRouter_Writes(t *testing.T) {
+	ctx := context.Background()
+	primary := open(t, "primary")
+	router := replica.New(primary, open(t, "replica"))
+
+	_, err := router.ExecContext(ctx, `INSERT INTO notes (body) VALUES ('exec')`)
+	require.NoError(t, err)
+	var body string
+	err = router.QueryRowContext(ctx, `INSERT INTO notes (body) VALUES ('returning') RETURNING body`).Scan(&body)
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, primary.QueryRow(`SELECT COUNT(*) FROM notes`).Scan(&count))
+	assert.Equal(t, 3, count)
+}
+
+func TestRouter_LockingReads(t *testing.T) {
+	router := replica.New(open(t, "primary"), open(t, "replica"))
+	ctx, session := replica.WithSession(context.Background(), false)
+
+	// SQLite has no row locks and rejects the clause, but the query is
+	// routed like a write before it runs
+	_, err := router.QueryContext(ctx, `SELECT body FROM notes FOR UPDATE`)
+	assert.Error(t, err)
+	assert.True(t, session.Wrote())
+}
+
+func TestRouter_Transactions(t *testing.T) {
+	primary := open(t, "primary")
+	router := replica.New(primary, open(t, "replica"))
+
+	err := txn.NewTxManager(primary).Do(context.Background(), func(ctx context.Context) error {
+		assert.Equal(t, "primary", served(t, ctx, router))
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Repositories start units of work on the router they are built on
+	err = txn.Do(context.Background(), router, func(ctx context.Context) error {
+		assert.True(t, txn.InTransaction(ctx, primary))
+		assert.Equal(t, "primary", served(t, ctx, router))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRouter_Session(t *testing.T) {
+	router := replica.New(open(t, "primary"), open(t, "replica"))
+
+	ctx, session := replica.WithSession(context.Background(), false)
+	assert.Equal(t, "replica", served(t, ctx, router))
+	assert.False(t, session.Wrote())
+
+	_, err := router.ExecContext(ctx, `INSERT INTO notes (body) VALUES ('new')`)
+	require.NoError(t, err)
+	assert.True(t, session.Wrote())
+	assert.Equal(t, "primary", served(t, ctx, router))
+
+	ctx, session = replica.WithSession(context.Background(), true)
+	assert.Equal(t, "primary", served(t, ctx, router))
+	assert.False(t, session.Wrote())
+}
+
+func TestReadYourWrites(t *testing.T) {
+	router := replica.New(open(t, "primary"), open(t, "replica"))
+	handler := replica.ReadYourWrites(5 * time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			_, err := router.ExecContext(r.Context(), `INSERT INTO notes (body) VALUES ('new')`)
+			require.NoError(t, err)
+		}
+		w.Write([]byte(served(t, r.Context(), router)))
+	}))
+
+	t.Run("reads go to a replica", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, "replica", w.Body.String())
+		assert.Empty(t, w.Result().Cookies())
+	})
+
+	t.Run("a write keeps the visitor on the primary", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+		assert.Equal(t, "primary", w.Body.String())
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, 5, cookies[0].MaxAge)
+
+		w = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookies[0])
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, "primary", w.Body.String())
+	})
+}
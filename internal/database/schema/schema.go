@@ -0,0 +1,101 @@
+// Package schema checks the live database against the tables and columns
+// the application expects, so drift between the migrations and the queries
+// shows up before a request fails.
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+)
+
+// Mismatch is a table the database lacks, or lacks columns of.
+type Mismatch struct {
+	Table string
+	// MissingTable is set when the table doesn't exist at all.
+	MissingTable bool
+	// Missing lists the expected columns the database doesn't have, in the
+	// order they were expected.
+	Missing []string
+}
+
+// Check compares the database against want, the expected columns by table,
+// and returns the tables that differ ordered by name. Columns the database
+// has but want doesn't list are not a mismatch.
+func Check(ctx context.Context, db *sql.DB, d dialect.Dialect, want map[string][]string) ([]Mismatch, error) {
+	tables := make([]string, 0, len(want))
+	for table := range want {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var mismatches []Mismatch
+	for _, table := range tables {
+		have, err := columns(ctx, db, d, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the columns of %s: %w", table, err)
+		}
+		if len(have) == 0 {
+			mismatches = append(mismatches, Mismatch{Table: table, MissingTable: true, Missing: want[table]})
+			continue
+		}
+
+		var missing []string
+		for _, column := range want[table] {
+			if !have[strings.ToLower(column)] {
+				missing = append(missing, column)
+			}
+		}
+		if len(missing) > 0 {
+			mismatches = append(mismatches, Mismatch{Table: table, Missing: missing})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// columns returns the lower case column names of table
+func columns(ctx context.Context, db *sql.DB, d dialect.Dialect, table string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, d.ColumnsQuery(), table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	have := make(map[string]bool)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		have[strings.ToLower(column)] = true
+	}
+	return have, rows.Err()
+}
+
+// Diff formats mismatches as a diff from the expected schema to the
+// database's, with a hunk for each table and a removed line for each
+// missing column.
+func Diff(mismatches []Mismatch) string {
+	if len(mismatches) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("--- expected\n+++ database\n")
+	for _, m := range mismatches {
+		if m.MissingTable {
+			fmt.Fprintf(&b, "@@ %s (missing table) @@\n", m.Table)
+		} else {
+			fmt.Fprintf(&b, "@@ %s @@\n", m.Table)
+		}
+		for _, column := range m.Missing {
+			fmt.Fprintf(&b, "-%s\n", column)
+		}
+	}
+	return b.String()
+}
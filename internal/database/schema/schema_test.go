@@ -0,0 +1,51 @@
+package schema_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/schema"
+)
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", "file::memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT, Slug TEXT, extra TEXT)`)
+	require.NoError(t, err)
+
+	want := map[string][]string{
+		"posts": {"id", "title", "slug", "meta_title", "is_featured"},
+		"menus": {"id", "name"},
+	}
+
+	mismatches, err := schema.Check(ctx, db, dialect.SQLite, want)
+	require.NoError(t, err)
+	assert.Equal(t, []schema.Mismatch{
+		{Table: "menus", MissingTable: true, Missing: []string{"id", "name"}},
+		{Table: "posts", Missing: []string{"meta_title", "is_featured"}},
+	}, mismatches)
+
+	assert.Equal(t, `--- expected
++++ database
+@@ menus (missing table) @@
+-id
+-name
+@@ posts @@
+-meta_title
+-is_featured
+`, schema.Diff(mismatches))
+
+	mismatches, err = schema.Check(ctx, db, dialect.SQLite, map[string][]string{"posts": {"id", "slug"}})
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+	assert.Empty(t, schema.Diff(mismatches))
+}
@@ -0,0 +1,96 @@
+package seed
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The words the made-up text is built from
+var (
+	firstNames = []string{
+		"Ada", "Alan", "Barbara", "Claude", "Dennis", "Edsger", "Frances", "Grace", "Hedy", "Ivan",
+		"Joan", "Ken", "Linus", "Margaret", "Niklaus", "Radia", "Rob", "Sophie", "Tim", "Whitfield",
+	}
+	lastNames = []string{
+		"Allen", "Backus", "Cerf", "Dijkstra", "Engelbart", "Floyd", "Goldberg", "Hamilton", "Hopper", "Kay",
+		"Knuth", "Lamport", "Liskov", "Lovelace", "McCarthy", "Perlman", "Pike", "Ritchie", "Thompson", "Wirth",
+	}
+	topics = []string{
+		"Go", "Databases", "Testing", "Performance", "Security", "Design", "Markdown", "Deployment",
+		"Caching", "Accessibility", "Observability", "Concurrency", "Migrations", "Templates", "APIs", "Search",
+	}
+	nouns = []string{
+		"server", "query", "template", "handler", "index", "release", "cache", "request", "schema", "editor",
+		"workflow", "module", "feed", "sitemap", "router", "pipeline", "benchmark", "draft", "review", "backup",
+	}
+	adjectives = []string{
+		"fast", "simple", "reliable", "small", "careful", "modern", "practical", "quiet", "robust", "tidy",
+		"portable", "readable", "lazy", "eager", "secure", "gentle", "honest", "curious", "steady", "clear",
+	}
+	verbs = []string{
+		"builds", "checks", "renders", "stores", "reads", "writes", "ships", "tests", "measures", "caches",
+		"serves", "migrates", "logs", "retries", "indexes", "compiles", "reviews", "publishes", "trims", "tunes",
+	}
+)
+
+// pick returns a random element of words
+func (s *Seeding) pick(words []string) string {
+	return words[s.Rand.IntN(len(words))]
+}
+
+// name returns a person's name
+func (s *Seeding) name() string {
+	return s.pick(firstNames) + " " + s.pick(lastNames)
+}
+
+// title returns a headline of a few words
+func (s *Seeding) title() string {
+	if s.Rand.IntN(3) == 0 {
+		return fmt.Sprintf("How a %s %s %s everything", s.pick(adjectives), s.pick(nouns), s.pick(verbs))
+	}
+	return fmt.Sprintf("The %s %s %s %ss", s.pick(adjectives), s.pick(nouns), s.pick(verbs), s.pick(nouns))
+}
+
+// sentence returns a sentence of made-up words
+func (s *Seeding) sentence() string {
+	words := []string{"The", s.pick(adjectives), s.pick(nouns), s.pick(verbs), "the"}
+	for range 2 + s.Rand.IntN(6) {
+		words = append(words, s.pick(adjectives), s.pick(nouns))
+	}
+	return strings.Join(words, " ") + "."
+}
+
+// paragraph returns a few sentences
+func (s *Seeding) paragraph() string {
+	sentences := make([]string, 2+s.Rand.IntN(4))
+	for i := range sentences {
+		sentences[i] = s.sentence()
+	}
+	return strings.Join(sentences, " ")
+}
+
+// markdown returns a post body using the Markdown the content pipeline
+// renders: headings, emphasis, lists, links and code blocks
+func (s *Seeding) markdown() string {
+	var b strings.Builder
+	b.WriteString(s.paragraph() + "\n\n")
+
+	for section := range 2 + s.Rand.IntN(3) {
+		adjective := s.pick(adjectives)
+		fmt.Fprintf(&b, "## %s %ss\n\n", strings.ToUpper(adjective[:1])+adjective[1:], s.pick(nouns))
+		fmt.Fprintf(&b, "%s **%s** %s\n\n", s.sentence(), s.pick(nouns), s.paragraph())
+
+		switch section % 3 {
+		case 0:
+			for range 3 + s.Rand.IntN(3) {
+				fmt.Fprintf(&b, "- The %s %s\n", s.pick(nouns), s.pick(verbs))
+			}
+			b.WriteString("\n")
+		case 1:
+			fmt.Fprintf(&b, "```go\nfunc %s() error {\n\treturn nil\n}\n```\n\n", s.pick(verbs))
+		case 2:
+			fmt.Fprintf(&b, "> %s\n\nRead more about [%s](https://example.com/%s).\n\n", s.sentence(), s.pick(nouns), s.pick(nouns))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
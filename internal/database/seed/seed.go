@@ -0,0 +1,234 @@
+// Package seed fills a database with demo data.
+//
+// Each seeder is named and idempotent: rows are found by a natural key, such
+// as an email or a slug, and only inserted when missing, so seeding again
+// adds nothing. The data is made up from a seed value, and the same seed and
+// scale always make the same rows.
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+)
+
+// Options changes what the seeders make.
+type Options struct {
+	// Seed picks the made-up data
+	Seed uint64
+	// Scale multiplies the number of authors, tags and posts, for
+	// load-testing datasets. Zero or less means one.
+	Scale int
+	// AdminPassword is the password of the admin user
+	AdminPassword string
+}
+
+// Seeder makes one kind of demo data.
+type Seeder struct {
+	// Name selects the seeder on the command line
+	Name string
+	// Needs names the seeders whose rows this one refers to, which are run
+	// first
+	Needs []string
+	// Run inserts the rows that are missing
+	Run func(ctx context.Context, s *Seeding) error
+}
+
+// Seeding is what a seeder writes with. Every seeder runs in a transaction
+// of its own.
+type Seeding struct {
+	Tx      *sql.Tx
+	Dialect dialect.Dialect
+	// Rand depends only on the seed and the seeder's name, so a seeder run
+	// on its own makes the same rows as in a full run
+	Rand    *rand.Rand
+	Options Options
+	// Epoch is the time the made-up timestamps count back from, fixed so
+	// that they don't change between runs
+	Epoch time.Time
+}
+
+// Seeders returns every seeder, each after the ones it needs.
+func Seeders() []Seeder {
+	return []Seeder{
+		{Name: "admin", Run: seedAdmin},
+		{Name: "authors", Run: seedAuthors},
+		{Name: "tags", Run: seedTags},
+		{Name: "posts", Needs: []string{"authors", "tags"}, Run: seedPosts},
+		{Name: "pages", Needs: []string{"admin"}, Run: seedPages},
+	}
+}
+
+// Names returns the names of the seeders.
+func Names() []string {
+	seeders := Seeders()
+	names := make([]string, len(seeders))
+	for i, seeder := range seeders {
+		names[i] = seeder.Name
+	}
+	return names
+}
+
+// Run runs the named seeders and those they need, or every seeder if no
+// name is given, and returns the names of those it ran.
+func Run(ctx context.Context, db *sql.DB, d dialect.Dialect, opts Options, names ...string) ([]string, error) {
+	if opts.Scale <= 0 {
+		opts.Scale = 1
+	}
+	if opts.AdminPassword == "" {
+		return nil, errors.New("the admin password is required")
+	}
+
+	selected, err := resolve(names)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []string
+	for _, seeder := range Seeders() {
+		if !selected[seeder.Name] {
+			continue
+		}
+		if err := run(ctx, db, d, opts, seeder); err != nil {
+			return ran, fmt.Errorf("%s seeder: %w", seeder.Name, err)
+		}
+		ran = append(ran, seeder.Name)
+	}
+	return ran, nil
+}
+
+// resolve returns the named seeders along with those they need
+func resolve(names []string) (map[string]bool, error) {
+	seeders := make(map[string]Seeder)
+	for _, seeder := range Seeders() {
+		seeders[seeder.Name] = seeder
+	}
+	if len(names) == 0 {
+		names = Names()
+	}
+
+	selected := make(map[string]bool)
+	var add func(name string) error
+	add = func(name string) error {
+		seeder, ok := seeders[name]
+		if !ok {
+			return fmt.Errorf("unknown seeder %q, expected one of %s", name, strings.Join(Names(), ", "))
+		}
+		if selected[name] {
+			return nil
+		}
+		selected[name] = true
+		for _, need := range seeder.Needs {
+			if err := add(need); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, name := range names {
+		if err := add(name); err != nil {
+			return nil, err
+		}
+	}
+	return selected, nil
+}
+
+func run(ctx context.Context, db *sql.DB, d dialect.Dialect, opts Options, seeder Seeder) error {
+	hash := fnv.New64a()
+	hash.Write([]byte(seeder.Name))
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	s := &Seeding{
+		Tx:      tx,
+		Dialect: d,
+		Rand:    rand.New(rand.NewPCG(opts.Seed, hash.Sum64())),
+		Options: opts,
+		Epoch:   time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC),
+	}
+	if err := seeder.Run(ctx, s); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindID returns the ID of the row of table whose column equals value, or
+// zero if there is none.
+func (s *Seeding) FindID(ctx context.Context, table, column string, value interface{}) (int64, error) {
+	var id int64
+	err := s.Tx.QueryRowContext(ctx, s.Dialect.Rebind(`SELECT id FROM `+table+` WHERE `+column+` = ?`), value).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// InsertMissing inserts row into table unless a row with the same value in
+// the key column exists, and returns the ID of the row either way.
+func (s *Seeding) InsertMissing(ctx context.Context, table, key string, row map[string]interface{}) (int64, error) {
+	id, err := s.FindID(ctx, table, key, row[key])
+	if err != nil || id != 0 {
+		return id, err
+	}
+
+	columns, values := s.columns(row)
+	return s.Dialect.InsertID(ctx, s.Tx, insert(table, columns), values...)
+}
+
+// Link inserts the row of a table joining two others unless it exists.
+func (s *Seeding) Link(ctx context.Context, table string, row map[string]interface{}) error {
+	columns, values := s.columns(row)
+	where := strings.Join(columns, " = ? AND ") + " = ?"
+
+	var exists int
+	err := s.Tx.QueryRowContext(ctx, s.Dialect.Rebind(`SELECT 1 FROM `+table+` WHERE `+where), values...).Scan(&exists)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	_, err = s.Tx.ExecContext(ctx, s.Dialect.Rebind(insert(table, columns)), values...)
+	return err
+}
+
+// Days returns the time n days before the epoch.
+func (s *Seeding) Days(n int) time.Time {
+	return s.Epoch.AddDate(0, 0, -n)
+}
+
+// columns returns the columns of row in a stable order with their values,
+// booleans converted for the database
+func (s *Seeding) columns(row map[string]interface{}) ([]string, []interface{}) {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	slices.Sort(columns)
+
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = row[column]
+		if v, ok := values[i].(bool); ok {
+			values[i] = s.Dialect.Bool(v)
+		}
+	}
+	return columns, values
+}
+
+// insert returns an INSERT statement of columns into table
+func insert(table string, columns []string) string {
+	return `INSERT INTO ` + table + ` (` + strings.Join(columns, ", ") + `) VALUES (` +
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + `)`
+}
@@ -0,0 +1,131 @@
+package seed_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/seed"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+)
+
+// migrated returns a migrated SQLite database
+func migrated(t *testing.T) *sql.DB {
+	t.Helper()
+	cfg := config.DatabaseConfig{
+		Driver: "sqlite",
+		Name:   filepath.Join(t.TempDir(), "app.db"),
+	}
+	require.NoError(t, database.RunMigrations(cfg))
+
+	db, err := database.Connect(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func count(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM `+table).Scan(&n))
+	return n
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	db := migrated(t)
+	opts := seed.Options{Seed: 1, AdminPassword: "Secret123!"}
+
+	ran, err := seed.Run(ctx, db, dialect.SQLite, opts)
+	require.NoError(t, err)
+	assert.Equal(t, seed.Names(), ran)
+
+	assert.Equal(t, 4, count(t, db, "users"))
+	assert.Equal(t, 25, count(t, db, "posts"))
+	assert.Equal(t, 4, count(t, db, "pages"))
+	assert.Equal(t, 16, count(t, db, "tags"))
+	links := count(t, db, "post_tags")
+	assert.Positive(t, links)
+
+	var hash string
+	require.NoError(t, db.QueryRow(`SELECT password_hash FROM users WHERE email = ?`, seed.AdminEmail).Scan(&hash))
+	assert.True(t, helpers.ComparePasswords(hash, "Secret123!"))
+
+	// The repositories read what the seeders wrote
+	featured, err := repository.NewPostRepository(db, dialect.SQLite).ListFeatured(ctx, 10)
+	require.NoError(t, err)
+	assert.NotEmpty(t, featured)
+	about, err := repository.NewPageRepository(db, dialect.SQLite).GetBySlug(ctx, "about")
+	require.NoError(t, err)
+	team, err := repository.NewPageRepository(db, dialect.SQLite).GetBySlug(ctx, "team")
+	require.NoError(t, err)
+	require.NotNil(t, team.ParentID)
+	assert.Equal(t, about.ID, *team.ParentID)
+
+	// Seeding again adds nothing
+	_, err = seed.Run(ctx, db, dialect.SQLite, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count(t, db, "users"))
+	assert.Equal(t, 25, count(t, db, "posts"))
+	assert.Equal(t, links, count(t, db, "post_tags"))
+}
+
+func TestRun_Deterministic(t *testing.T) {
+	ctx := context.Background()
+
+	titles := func(db *sql.DB) []string {
+		rows, err := db.Query(`SELECT title || content FROM posts ORDER BY id`)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var titles []string
+		for rows.Next() {
+			var title string
+			require.NoError(t, rows.Scan(&title))
+			titles = append(titles, title)
+		}
+		return titles
+	}
+
+	first, second, other := migrated(t), migrated(t), migrated(t)
+	_, err := seed.Run(ctx, first, dialect.SQLite, seed.Options{Seed: 7, AdminPassword: "x"})
+	require.NoError(t, err)
+	// The posts are the same when seeded on their own
+	_, err = seed.Run(ctx, second, dialect.SQLite, seed.Options{Seed: 7, AdminPassword: "x"}, "posts")
+	require.NoError(t, err)
+	_, err = seed.Run(ctx, other, dialect.SQLite, seed.Options{Seed: 8, AdminPassword: "x"}, "posts")
+	require.NoError(t, err)
+
+	assert.Equal(t, titles(first), titles(second))
+	assert.NotEqual(t, titles(first), titles(other))
+}
+
+func TestRun_Scale(t *testing.T) {
+	db := migrated(t)
+
+	ran, err := seed.Run(context.Background(), db, dialect.SQLite, seed.Options{Seed: 1, Scale: 3, AdminPassword: "x"}, "posts")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"authors", "tags", "posts"}, ran)
+
+	assert.Equal(t, 9, count(t, db, "users"))
+	assert.Equal(t, 75, count(t, db, "posts"))
+	assert.Equal(t, 48, count(t, db, "tags"))
+	assert.Equal(t, 0, count(t, db, "pages"))
+}
+
+func TestRun_Errors(t *testing.T) {
+	db := migrated(t)
+
+	_, err := seed.Run(context.Background(), db, dialect.SQLite, seed.Options{AdminPassword: "x"}, "widgets")
+	assert.ErrorContains(t, err, `unknown seeder "widgets"`)
+
+	_, err = seed.Run(context.Background(), db, dialect.SQLite, seed.Options{})
+	assert.Error(t, err)
+}
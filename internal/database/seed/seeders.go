@@ -0,0 +1,215 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gosimple/slug"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+)
+
+// AdminEmail is the email address of the admin user.
+const AdminEmail = "admin@example.com"
+
+// The number of rows the seeders make at scale 1
+const (
+	authorsPerScale = 3
+	postsPerScale   = 25
+)
+
+// noPassword is a password hash no password matches, for the demo authors
+const noPassword = "!"
+
+func seedAdmin(ctx context.Context, s *Seeding) error {
+	id, err := s.FindID(ctx, "users", "email", AdminEmail)
+	if err != nil || id != 0 {
+		return err
+	}
+
+	// Hashed only when missing, as bcrypt is slow on purpose
+	hash, err := helpers.HashPassword(s.Options.AdminPassword)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.InsertMissing(ctx, "users", "email", map[string]interface{}{
+		"email":          AdminEmail,
+		"password_hash":  hash,
+		"name":           "Admin",
+		"role":           models.RoleAdmin,
+		"email_verified": true,
+		"created_at":     s.Epoch,
+		"updated_at":     s.Epoch,
+	})
+	return err
+}
+
+// authorEmail returns the email address of the i-th demo author
+func authorEmail(i int) string {
+	return fmt.Sprintf("author%d@example.com", i+1)
+}
+
+func seedAuthors(ctx context.Context, s *Seeding) error {
+	for i := range authorsPerScale * s.Options.Scale {
+		joined := s.Days(365 + i)
+		_, err := s.InsertMissing(ctx, "users", "email", map[string]interface{}{
+			"email":          authorEmail(i),
+			"password_hash":  noPassword,
+			"name":           s.name(),
+			"role":           models.RoleEditor,
+			"email_verified": true,
+			"created_at":     joined,
+			"updated_at":     joined,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagNames returns the names of the demo tags, repeating the topics with a
+// number at larger scales
+func tagNames(scale int) []string {
+	names := make([]string, 0, len(topics)*scale)
+	for round := range scale {
+		for _, topic := range topics {
+			if round > 0 {
+				topic = fmt.Sprintf("%s %d", topic, round+1)
+			}
+			names = append(names, topic)
+		}
+	}
+	return names
+}
+
+func seedTags(ctx context.Context, s *Seeding) error {
+	for _, name := range tagNames(s.Options.Scale) {
+		_, err := s.InsertMissing(ctx, "tags", "slug", map[string]interface{}{
+			"name":       name,
+			"slug":       slug.Make(name),
+			"created_at": s.Epoch,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func seedPosts(ctx context.Context, s *Seeding) error {
+	authors, err := s.ids(ctx, "users", "email", authorsPerScale*s.Options.Scale, authorEmail)
+	if err != nil {
+		return err
+	}
+	tags := tagNames(s.Options.Scale)
+	tagIDs, err := s.ids(ctx, "tags", "slug", len(tags), func(i int) string { return slug.Make(tags[i]) })
+	if err != nil {
+		return err
+	}
+
+	for i := range postsPerScale * s.Options.Scale {
+		// Everything is drawn before the lookup, so that an existing post
+		// doesn't change the posts after it
+		title := s.title()
+		content := s.markdown()
+		author := authors[s.Rand.IntN(len(authors))]
+		status := domain.PostStatusPublished
+		if s.Rand.IntN(5) == 0 {
+			status = domain.PostStatusDraft
+		}
+		postTags := make([]int64, 1+s.Rand.IntN(3))
+		for j := range postTags {
+			postTags[j] = tagIDs[s.Rand.IntN(len(tagIDs))]
+		}
+
+		// One post in ten is featured, and the newest come first
+		created := s.Days(i)
+		row := map[string]interface{}{
+			"title":       title,
+			"slug":        fmt.Sprintf("%s-%d", slug.Make(title), i+1),
+			"content":     content,
+			"status":      string(status),
+			"author_id":   author,
+			"is_featured": status == domain.PostStatusPublished && i%10 == 0,
+			"created_at":  created,
+			"updated_at":  created,
+		}
+		if status == domain.PostStatusPublished {
+			row["published_at"] = created
+		}
+
+		id, err := s.InsertMissing(ctx, "posts", "slug", row)
+		if err != nil {
+			return err
+		}
+		for _, tag := range postTags {
+			if err := s.Link(ctx, "post_tags", map[string]interface{}{"post_id": id, "tag_id": tag}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// demoPages are the pages of the site, children after their parent
+var demoPages = []struct {
+	title, slug, parent string
+}{
+	{"About", "about", ""},
+	{"Our Team", "team", "about"},
+	{"Contact", "contact", ""},
+	{"Privacy Policy", "privacy", ""},
+}
+
+func seedPages(ctx context.Context, s *Seeding) error {
+	admin, err := s.FindID(ctx, "users", "email", AdminEmail)
+	if err != nil {
+		return err
+	}
+
+	for i, page := range demoPages {
+		row := map[string]interface{}{
+			"title":        page.title,
+			"slug":         page.slug,
+			"content":      s.markdown(),
+			"status":       string(domain.PageStatusPublished),
+			"author_id":    admin,
+			"sort_order":   i,
+			"published_at": s.Epoch,
+			"created_at":   s.Epoch,
+			"updated_at":   s.Epoch,
+		}
+		if page.parent != "" {
+			parent, err := s.FindID(ctx, "pages", "slug", page.parent)
+			if err != nil {
+				return err
+			}
+			row["parent_id"] = parent
+		}
+
+		if _, err := s.InsertMissing(ctx, "pages", "slug", row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ids returns the IDs of the n rows of table whose column is key(i) for
+// each i, which the seeders a seeder needs have inserted
+func (s *Seeding) ids(ctx context.Context, table, column string, n int, key func(i int) string) ([]int64, error) {
+	ids := make([]int64, n)
+	for i := range ids {
+		id, err := s.FindID(ctx, table, column, key(i))
+		if err != nil {
+			return nil, err
+		}
+		if id == 0 {
+			return nil, fmt.Errorf("%s has no row with %s %s", table, column, key(i))
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+)
+
+// registerTLS registers the TLS configuration a MySQL connection string
+// names when it verifies the server against a CA file. PostgreSQL reads the
+// file itself.
+func registerTLS(cfg config.DatabaseConfig) error {
+	if cfg.Driver != "mysql" || cfg.SSLRootCert == "" {
+		return nil
+	}
+
+	pem, err := os.ReadFile(cfg.SSLRootCert)
+	if err != nil {
+		return fmt.Errorf("failed to read the database CA certificate: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificates found in %s", cfg.SSLRootCert)
+	}
+
+	tlsConfig := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	if cfg.SSLMode == "verify-ca" {
+		// Check the chain but not the host name, as PostgreSQL's verify-ca
+		// does
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(raw [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(raw, roots)
+		}
+	}
+	return mysql.RegisterTLSConfig(cfg.MySQLTLSConfig(), tlsConfig)
+}
+
+// verifyChain checks that the certificates a server presented lead to one
+// of roots
+func verifyChain(raw [][]byte, roots *x509.CertPool) error {
+	if len(raw) == 0 {
+		return fmt.Errorf("the database server presented no certificate")
+	}
+	certs := make([]*x509.Certificate, len(raw))
+	for i, der := range raw {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+	return err
+}
@@ -0,0 +1,165 @@
+// Package txn runs units of work in database transactions.
+//
+// A TxManager begins a transaction and hands it on through the context.
+// Repositories built on a querier from Join run every query in the
+// transaction of the context they are given, so services make several
+// repository calls atomic without the repositories knowing:
+//
+//	err := manager.Do(ctx, func(ctx context.Context) error {
+//		if err := posts.Update(ctx, post); err != nil {
+//			return err
+//		}
+//		return revisions.Create(ctx, revision)
+//	})
+//
+// Do called again inside a transaction runs in a savepoint, so the inner
+// unit of work can fail without undoing the outer one.
+package txn
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+)
+
+// Querier is the part of *sql.DB and *sql.Tx repositories run queries on.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// TxManager runs functions in transactions of a database.
+type TxManager struct {
+	db *sql.DB
+}
+
+// NewTxManager creates a transaction manager for db.
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// Do runs fn in a transaction, which is committed if fn returns nil and
+// rolled back if it returns an error or panics. If ctx already carries a
+// transaction of the database, fn runs in a savepoint of it instead.
+func (m *TxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Do(ctx, m.db, fn)
+}
+
+// Do runs fn in a unit of work on q: a new transaction for a database, or a
+// savepoint for a transaction, including one the context carries for the
+// database.
+func Do(ctx context.Context, q Querier, fn func(ctx context.Context) error) error {
+	switch q := conn(ctx, q).(type) {
+	case *sql.DB:
+		tx, err := q.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		return run(tx.Commit, tx.Rollback, func() error {
+			return fn(context.WithValue(ctx, txKey{}, &transaction{db: q, tx: tx}))
+		})
+
+	case *sql.Tx:
+		name := fmt.Sprintf("sp_%d", savepoints.Add(1))
+		if _, err := q.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+			return err
+		}
+		release := func() error {
+			_, err := q.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+			return err
+		}
+		rollback := func() error {
+			_, err := q.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			return err
+		}
+		return run(release, rollback, func() error { return fn(ctx) })
+
+	default:
+		return fmt.Errorf("cannot start a transaction on %T", q)
+	}
+}
+
+// run calls fn, then commit if it succeeds, or rollback if it fails or
+// panics
+func run(commit, rollback func() error, fn func() error) error {
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(); err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+	return commit()
+}
+
+// Join returns a querier that runs each query in the transaction of its
+// context when that transaction is of q, and on q otherwise. A transaction
+// is returned as it is.
+func Join(q Querier) Querier {
+	if db, ok := q.(*sql.DB); ok {
+		return joined{db: db}
+	}
+	return q
+}
+
+// joined runs queries in the transaction of their context
+type joined struct {
+	db *sql.DB
+}
+
+func (j joined) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return conn(ctx, j.db).ExecContext(ctx, query, args...)
+}
+
+func (j joined) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return conn(ctx, j.db).QueryContext(ctx, query, args...)
+}
+
+func (j joined) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return conn(ctx, j.db).QueryRowContext(ctx, query, args...)
+}
+
+func (j joined) Unwrap() *sql.DB {
+	return j.db
+}
+
+// InTransaction reports whether ctx carries a transaction of db.
+func InTransaction(ctx context.Context, db *sql.DB) bool {
+	t, ok := ctx.Value(txKey{}).(*transaction)
+	return ok && t.db == db
+}
+
+// txKey is the context key of the current transaction
+type txKey struct{}
+
+// transaction is a transaction begun by Do and the database it is of
+type transaction struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// savepoints numbers savepoints so that nested ones get different names
+var savepoints atomic.Int64
+
+// conn returns the transaction of ctx if it is of q's database, and q
+// otherwise. A querier that wraps a database, such as one from Join, stands
+// for that database.
+func conn(ctx context.Context, q Querier) Querier {
+	if w, ok := q.(interface{ Unwrap() *sql.DB }); ok {
+		q = w.Unwrap()
+	}
+	if db, ok := q.(*sql.DB); ok {
+		if t, ok := ctx.Value(txKey{}).(*transaction); ok && t.db == db {
+			return t.tx
+		}
+	}
+	return q
+}
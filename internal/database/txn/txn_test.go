@@ -0,0 +1,249 @@
+package txn_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+)
+
+var errFailed = errors.New("failed")
+
+// open returns a database with an empty notes table
+func open(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "app.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE notes (body TEXT NOT NULL)`)
+	require.NoError(t, err)
+	return db
+}
+
+func insert(ctx context.Context, q txn.Querier, body string) error {
+	_, err := q.ExecContext(ctx, `INSERT INTO notes (body) VALUES (?)`, body)
+	return err
+}
+
+func notes(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query(`SELECT body FROM notes ORDER BY body`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var bodies []string
+	for rows.Next() {
+		var body string
+		require.NoError(t, rows.Scan(&body))
+		bodies = append(bodies, body)
+	}
+	require.NoError(t, rows.Err())
+	return bodies
+}
+
+func TestTxManager_Do(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("commits", func(t *testing.T) {
+		db := open(t)
+		q := txn.Join(db)
+
+		err := txn.NewTxManager(db).Do(ctx, func(ctx context.Context) error {
+			if err := insert(ctx, q, "a"); err != nil {
+				return err
+			}
+			return insert(ctx, q, "b")
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, notes(t, db))
+	})
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		db := open(t)
+		q := txn.Join(db)
+
+		err := txn.NewTxManager(db).Do(ctx, func(ctx context.Context) error {
+			if err := insert(ctx, q, "a"); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		assert.ErrorIs(t, err, errFailed)
+		assert.Empty(t, notes(t, db))
+	})
+
+	t.Run("rolls back on panic", func(t *testing.T) {
+		db := open(t)
+		q := txn.Join(db)
+
+		assert.PanicsWithValue(t, "boom", func() {
+			txn.NewTxManager(db).Do(ctx, func(ctx context.Context) error {
+				require.NoError(t, insert(ctx, q, "a"))
+				panic("boom")
+			})
+		})
+		assert.Empty(t, notes(t, db))
+
+		// The connection went back to the pool usable
+		require.NoError(t, insert(ctx, q, "b"))
+		assert.Equal(t, []string{"b"}, notes(t, db))
+	})
+
+	t.Run("transactions of other databases are separate", func(t *testing.T) {
+		db := open(t)
+		q := txn.Join(db)
+
+		err := txn.NewTxManager(db).Do(ctx, func(txCtx context.Context) error {
+			require.NoError(t, insert(txCtx, q, "a"))
+
+			other := open(t)
+			return txn.NewTxManager(other).Do(txCtx, func(ctx context.Context) error {
+				return insert(ctx, txn.Join(other), "b")
+			})
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, notes(t, db))
+	})
+}
+
+func TestTxManager_DoNested(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("inner failure keeps the outer work", func(t *testing.T) {
+		db := open(t)
+		q := txn.Join(db)
+		manager := txn.NewTxManager(db)
+
+		err := manager.Do(ctx, func(ctx context.Context) error {
+			require.NoError(t, insert(ctx, q, "outer"))
+
+			err := manager.Do(ctx, func(ctx context.Context) error {
+				require.NoError(t, insert(ctx, q, "inner"))
+				return errFailed
+			})
+			assert.ErrorIs(t, err, errFailed)
+
+			return manager.Do(ctx, func(ctx context.Context) error {
+				return insert(ctx, q, "second")
+			})
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"outer", "second"}, notes(t, db))
+	})
+
+	t.Run("inner panic rolls back to the savepoint", func(t *testing.T) {
+		db := open(t)
+		q := txn.Join(db)
+		manager := txn.NewTxManager(db)
+
+		err := manager.Do(ctx, func(ctx context.Context) error {
+			require.NoError(t, insert(ctx, q, "outer"))
+			assert.Panics(t, func() {
+				manager.Do(ctx, func(ctx context.Context) error {
+					require.NoError(t, insert(ctx, q, "inner"))
+					panic("boom")
+				})
+			})
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"outer"}, notes(t, db))
+	})
+
+	t.Run("outer failure undoes the inner work", func(t *testing.T) {
+		db := open(t)
+		q := txn.Join(db)
+		manager := txn.NewTxManager(db)
+
+		err := manager.Do(ctx, func(ctx context.Context) error {
+			require.NoError(t, manager.Do(ctx, func(ctx context.Context) error {
+				return insert(ctx, q, "inner")
+			}))
+			return errFailed
+		})
+		assert.ErrorIs(t, err, errFailed)
+		assert.Empty(t, notes(t, db))
+	})
+}
+
+func TestDo_Tx(t *testing.T) {
+	ctx := context.Background()
+	db := open(t)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	// A querier built on a transaction uses it, in savepoints for units of
+	// work
+	q := txn.Join(tx)
+	require.NoError(t, insert(ctx, q, "a"))
+	err = txn.Do(ctx, q, func(ctx context.Context) error {
+		require.NoError(t, insert(ctx, q, "b"))
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+	require.NoError(t, tx.Commit())
+
+	assert.Equal(t, []string{"a"}, notes(t, db))
+}
+
+// Repositories given the database join the transaction of the context
+func TestTxManager_Repositories(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DatabaseConfig{Driver: "sqlite", Name: filepath.Join(t.TempDir(), "app.db")}
+	require.NoError(t, database.RunMigrations(cfg))
+	db, err := database.Connect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`INSERT INTO users (email, password_hash, name) VALUES ('a@example.com', '!', 'A')`)
+	require.NoError(t, err)
+
+	posts := repository.NewPostRepository(db, dialect.SQLite)
+	slugs := repository.NewSlugHistoryRepository(db, dialect.SQLite)
+	manager := txn.NewTxManager(db)
+
+	count := func(table string) int {
+		var n int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM `+table).Scan(&n))
+		return n
+	}
+
+	err = manager.Do(ctx, func(ctx context.Context) error {
+		post := &domain.Post{Title: "Hello", Slug: "hello", Content: "Body", AuthorID: 1, Status: domain.PostStatusDraft}
+		if err := posts.Create(ctx, post); err != nil {
+			return err
+		}
+		if err := slugs.Record(ctx, domain.ContentTypePost, post.ID, "hi"); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, 0, count("posts"))
+	assert.Equal(t, 0, count("slug_history"))
+
+	err = manager.Do(ctx, func(ctx context.Context) error {
+		post := &domain.Post{Title: "Hello", Slug: "hello", Content: "Body", AuthorID: 1, Status: domain.PostStatusDraft}
+		if err := posts.Create(ctx, post); err != nil {
+			return err
+		}
+		return slugs.Record(ctx, domain.ContentTypePost, post.ID, "hi")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count("posts"))
+	assert.Equal(t, 1, count("slug_history"))
+}
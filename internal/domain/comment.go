@@ -0,0 +1,69 @@
+package domain
+
+import "time"
+
+type CommentStatus string
+
+const (
+	CommentStatusPending  CommentStatus = "pending"
+	CommentStatusApproved CommentStatus = "approved"
+	CommentStatusSpam     CommentStatus = "spam"
+	CommentStatusTrash    CommentStatus = "trash"
+)
+
+// CommentStatuses lists the moderation queues in display order.
+var CommentStatuses = []CommentStatus{CommentStatusPending, CommentStatusApproved, CommentStatusSpam, CommentStatusTrash}
+
+// Comment is a reader's comment on a post. Replies point to their parent and
+// sit one level deeper; top-level comments have depth 0.
+type Comment struct {
+	ID          int64         `json:"id"`
+	PostID      int64         `json:"post_id"`
+	ParentID    *int64        `json:"parent_id,omitempty"`
+	UserID      *int64        `json:"user_id,omitempty"`
+	AuthorName  string        `json:"author_name"`
+	AuthorEmail string        `json:"-"`
+	Body        string        `json:"body"`
+	Status      CommentStatus `json:"status"`
+	Depth       int           `json:"depth"`
+	IPAddress   string        `json:"-"`
+	CreatedAt   time.Time     `json:"created_at"`
+
+	// PostTitle and PostSlug are filled in when listing comments across posts.
+	PostTitle string `json:"post_title,omitempty"`
+	PostSlug  string `json:"post_slug,omitempty"`
+}
+
+func (cs CommentStatus) IsValid() bool {
+	switch cs {
+	case CommentStatusPending, CommentStatusApproved, CommentStatusSpam, CommentStatusTrash:
+		return true
+	}
+	return false
+}
+
+// ThreadComments orders comments depth-first so every reply follows its
+// parent, keeping the input order among siblings. Replies whose parent is not
+// in the list, e.g. because it is awaiting moderation, are left out.
+func ThreadComments(comments []*Comment) []*Comment {
+	children := make(map[int64][]*Comment)
+	var roots []*Comment
+	for _, c := range comments {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+		children[*c.ParentID] = append(children[*c.ParentID], c)
+	}
+
+	out := make([]*Comment, 0, len(comments))
+	var walk func([]*Comment)
+	walk = func(list []*Comment) {
+		for _, c := range list {
+			out = append(out, c)
+			walk(children[c.ID])
+		}
+	}
+	walk(roots)
+	return out
+}
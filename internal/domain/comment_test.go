@@ -0,0 +1,29 @@
+package domain
+
+import "testing"
+
+func TestThreadComments(t *testing.T) {
+	comments := []*Comment{
+		{ID: 1},
+		{ID: 2},
+		{ID: 3, ParentID: parent(1)},
+		{ID: 4, ParentID: parent(3)},
+		{ID: 5, ParentID: parent(1)},
+		{ID: 6, ParentID: parent(99)},
+	}
+
+	var got []int64
+	for _, c := range ThreadComments(comments) {
+		got = append(got, c.ID)
+	}
+
+	want := []int64{1, 3, 4, 5, 2}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// Content types identify the kind of record in tables shared by posts and pages.
+const (
+	ContentTypePost = "post"
+	ContentTypePage = "page"
+)
+
+// ContentRef is the minimal view of published content needed to link to it.
+// Path is only set for hierarchical content such as pages.
+type ContentRef struct {
+	ID        int64     `json:"id"`
+	Slug      string    `json:"slug"`
+	Path      string    `json:"path,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RenderedContent is the cached HTML of a post or page body together with
+// metadata derived from it. Version is the content pipeline version it was
+// rendered with; renders from older versions are rebuilt.
+type RenderedContent struct {
+	ContentType string    `json:"content_type"`
+	ContentID   int64     `json:"content_id"`
+	HTML        string    `json:"html"`
+	TOC         string    `json:"toc"`
+	Excerpt     string    `json:"excerpt"`
+	WordCount   int       `json:"word_count"`
+	ReadingTime int       `json:"reading_time"` // minutes
+	Version     int       `json:"version"`
+	RenderedAt  time.Time `json:"rendered_at"`
+}
+
+// ContentSource is the Markdown body of a post or page waiting to be rendered.
+type ContentSource struct {
+	ID      int64
+	Content string
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// MediaVariant is a resized copy generated for every uploaded image. Images
+// are scaled to fit within the bounds and never enlarged.
+type MediaVariant struct {
+	Name      string
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Image variant names.
+const (
+	VariantThumb  = "thumb"
+	VariantMedium = "medium"
+	VariantLarge  = "large"
+)
+
+// MediaVariants lists the variants generated for uploaded images, smallest
+// first.
+var MediaVariants = []MediaVariant{
+	{Name: VariantThumb, MaxWidth: 300, MaxHeight: 300},
+	{Name: VariantMedium, MaxWidth: 800, MaxHeight: 800},
+	{Name: VariantLarge, MaxWidth: 1600, MaxHeight: 1600},
+}
+
+// Media is an uploaded file in the media library. Path is where it is kept
+// in storage, e.g. /uploads/2026/01/<uuid>.jpg, and variants sit next to it
+// with the variant name appended. Public URLs depend on the storage backend.
+type Media struct {
+	ID        int64     `json:"id"`
+	OwnerID   int64     `json:"owner_id"`
+	FileName  string    `json:"file_name"`
+	Path      string    `json:"path"`
+	MimeType  string    `json:"mime_type"`
+	Size      int64     `json:"size"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	AltText   string    `json:"alt_text"`
+	Checksum  string    `json:"checksum"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MediaReference is a post or page whose content uses a media item.
+type MediaReference struct {
+	Type  string `json:"type"`
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+// VariantExt returns the file extension variants of an image with the given
+// MIME type are saved with. JPEG photos stay JPEG; everything else becomes
+// PNG so transparency survives.
+func VariantExt(mimeType string) string {
+	if mimeType == "image/jpeg" {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+// VariantPath returns the path of one of the image's variants.
+func (m *Media) VariantPath(name string) string {
+	return m.BasePath() + "-" + name + VariantExt(m.MimeType)
+}
+
+// BasePath is the path without its extension. The original and all its
+// variants start with it, which is how references in content are found.
+func (m *Media) BasePath() string {
+	return strings.TrimSuffix(m.Path, path.Ext(m.Path))
+}
+
+// Markdown returns the Markdown image tag for inserting the image, loaded
+// from src, into content.
+func (m *Media) Markdown(src string) string {
+	alt := strings.NewReplacer("[", "", "]", "", "\n", " ").Replace(m.AltText)
+	return fmt.Sprintf("![%s](%s)", alt, src)
+}
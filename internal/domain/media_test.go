@@ -0,0 +1,22 @@
+package domain
+
+import "testing"
+
+func TestMedia_Paths(t *testing.T) {
+	m := &Media{Path: "/uploads/2026/01/abc.jpeg", MimeType: "image/jpeg", AltText: "A [red] bike"}
+
+	if got := m.VariantPath(VariantThumb); got != "/uploads/2026/01/abc-thumb.jpg" {
+		t.Errorf("VariantPath(thumb) = %q", got)
+	}
+	if got := m.BasePath(); got != "/uploads/2026/01/abc" {
+		t.Errorf("BasePath() = %q", got)
+	}
+	if got := m.Markdown("/large.jpg"); got != "![A red bike](/large.jpg)" {
+		t.Errorf("Markdown() = %q", got)
+	}
+
+	gif := &Media{Path: "/uploads/2026/01/def.gif", MimeType: "image/gif"}
+	if got := gif.VariantPath(VariantMedium); got != "/uploads/2026/01/def-medium.png" {
+		t.Errorf("VariantPath(medium) = %q", got)
+	}
+}
@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+type MenuLocation string
+
+const (
+	MenuHeader MenuLocation = "header"
+	MenuFooter MenuLocation = "footer"
+)
+
+// MenuLocations lists the places a menu can be rendered, in display order.
+var MenuLocations = []MenuLocation{MenuHeader, MenuFooter}
+
+// MenuItem is a navigation entry pointing either to a page or to a URL.
+type MenuItem struct {
+	ID        int64        `json:"id"`
+	Location  MenuLocation `json:"location"`
+	Label     string       `json:"label"`
+	PageID    *int64       `json:"page_id,omitempty"`
+	URL       string       `json:"url"`
+	SortOrder int          `json:"sort_order"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// MenuLink is a resolved menu entry ready to render.
+type MenuLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+func (l MenuLocation) IsValid() bool {
+	switch l {
+	case MenuHeader, MenuFooter:
+		return true
+	}
+	return false
+}
@@ -11,17 +11,21 @@ const (
 )
 
 type Page struct {
-	ID          int64      `json:"id"`
-	Title       string     `json:"title"`
-	Slug        string     `json:"slug"`
-	Content     string     `json:"content"`
-	AuthorID    int64      `json:"author_id"`
-	Status      PageStatus `json:"status"`
-	MetaTitle   string     `json:"meta_title"`
-	MetaDesc    string     `json:"meta_desc"`
-	PublishedAt *time.Time `json:"published_at,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID              int64      `json:"id"`
+	Title           string     `json:"title"`
+	Slug            string     `json:"slug"`
+	Content         string     `json:"content"`
+	AuthorID        int64      `json:"author_id"`
+	ParentID        *int64     `json:"parent_id,omitempty"`
+	SortOrder       int        `json:"sort_order"`
+	Status          PageStatus `json:"status"`
+	MetaTitle       string     `json:"meta_title"`
+	MetaDesc        string     `json:"meta_desc"`
+	FeaturedImageID *int64     `json:"featured_image_id,omitempty"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Version         int        `json:"version"`
 }
 
 func (ps PageStatus) IsValid() bool {
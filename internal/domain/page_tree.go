@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// PageNode is a page's position in the page hierarchy.
+type PageNode struct {
+	ID        int64      `json:"id"`
+	ParentID  *int64     `json:"parent_id,omitempty"`
+	Title     string     `json:"title"`
+	Slug      string     `json:"slug"`
+	Status    PageStatus `json:"status"`
+	SortOrder int        `json:"sort_order"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Path is the nested URL of the page, e.g. /about/team/leadership.
+	Path     string      `json:"path"`
+	Depth    int         `json:"depth"`
+	Parent   *PageNode   `json:"-"`
+	Children []*PageNode `json:"children,omitempty"`
+}
+
+// PagePosition places a page under a parent (nil for top level) at a sort order.
+type PagePosition struct {
+	ID        int64  `json:"id"`
+	ParentID  *int64 `json:"parent_id"`
+	SortOrder int    `json:"sort_order"`
+}
+
+// Breadcrumb is one step of the trail from the top level down to a page.
+// URL is empty for the current page.
+type Breadcrumb struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Ancestors returns the parents of the node, top level first.
+func (n *PageNode) Ancestors() []*PageNode {
+	var ancestors []*PageNode
+	for p := n.Parent; p != nil; p = p.Parent {
+		ancestors = append([]*PageNode{p}, ancestors...)
+	}
+	return ancestors
+}
+
+// Breadcrumbs returns the trail of links leading to the node.
+func (n *PageNode) Breadcrumbs() []Breadcrumb {
+	var crumbs []Breadcrumb
+	for _, a := range n.Ancestors() {
+		crumbs = append(crumbs, Breadcrumb{Title: a.Title, URL: a.Path})
+	}
+	return append(crumbs, Breadcrumb{Title: n.Title})
+}
+
+// PageTree links page nodes to their parents and children.
+type PageTree struct {
+	Roots  []*PageNode
+	byID   map[int64]*PageNode
+	bySlug map[string]*PageNode
+}
+
+// NewPageTree builds the hierarchy from a flat list of nodes, filling in
+// Parent, Children, Depth and Path. Nodes whose parent is missing, or whose
+// parent chain loops back to themselves, are placed at the top level.
+// Siblings are ordered by SortOrder, keeping the input order for ties.
+func NewPageTree(nodes []*PageNode) *PageTree {
+	t := &PageTree{
+		byID:   make(map[int64]*PageNode, len(nodes)),
+		bySlug: make(map[string]*PageNode, len(nodes)),
+	}
+	for _, n := range nodes {
+		n.Parent, n.Children = nil, nil
+		t.byID[n.ID] = n
+		t.bySlug[n.Slug] = n
+	}
+
+	for _, n := range nodes {
+		parent := t.parentOf(n)
+		if parent == nil {
+			t.Roots = append(t.Roots, n)
+			continue
+		}
+		n.Parent = parent
+		parent.Children = append(parent.Children, n)
+	}
+
+	t.layout(t.Roots, "", 0)
+	return t
+}
+
+// parentOf returns the node's parent, or nil if it has none, the parent is
+// not in the tree, or attaching it would create a cycle.
+func (t *PageTree) parentOf(n *PageNode) *PageNode {
+	if n.ParentID == nil {
+		return nil
+	}
+	parent, ok := t.byID[*n.ParentID]
+	if !ok {
+		return nil
+	}
+
+	seen := 0
+	for p := parent; p != nil; {
+		if p.ID == n.ID || seen > len(t.byID) {
+			return nil
+		}
+		seen++
+		if p.ParentID == nil {
+			break
+		}
+		p = t.byID[*p.ParentID]
+	}
+
+	return parent
+}
+
+func (t *PageTree) layout(nodes []*PageNode, prefix string, depth int) {
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].SortOrder < nodes[j].SortOrder })
+	for _, n := range nodes {
+		n.Depth = depth
+		n.Path = prefix + "/" + n.Slug
+		t.layout(n.Children, n.Path, depth+1)
+	}
+}
+
+// Node returns the node with the given page ID, or nil.
+func (t *PageTree) Node(id int64) *PageNode {
+	return t.byID[id]
+}
+
+// NodeBySlug returns the node with the given slug, or nil.
+func (t *PageTree) NodeBySlug(slug string) *PageNode {
+	return t.bySlug[slug]
+}
+
+// Find returns the node whose Path equals path, or nil.
+func (t *PageTree) Find(path string) *PageNode {
+	slugs := strings.Split(strings.Trim(path, "/"), "/")
+	n := t.bySlug[slugs[len(slugs)-1]]
+	if n == nil || n.Path != "/"+strings.Join(slugs, "/") {
+		return nil
+	}
+	return n
+}
+
+// Flatten returns every node depth-first, in display order.
+func (t *PageTree) Flatten() []*PageNode {
+	var out []*PageNode
+	var walk func([]*PageNode)
+	walk = func(nodes []*PageNode) {
+		for _, n := range nodes {
+			out = append(out, n)
+			walk(n.Children)
+		}
+	}
+	walk(t.Roots)
+	return out
+}
+
+// Filter returns a new tree with the nodes for which keep returns true. A node
+// is only kept if all its ancestors are kept too, so paths stay reachable.
+func (t *PageTree) Filter(keep func(*PageNode) bool) *PageTree {
+	var kept []*PageNode
+	var walk func([]*PageNode)
+	walk = func(nodes []*PageNode) {
+		for _, n := range nodes {
+			if !keep(n) {
+				continue
+			}
+			c := *n
+			kept = append(kept, &c)
+			walk(n.Children)
+		}
+	}
+	walk(t.Roots)
+	return NewPageTree(kept)
+}
+
+// IsDescendant reports whether the page id sits somewhere below ancestorID.
+func (t *PageTree) IsDescendant(id, ancestorID int64) bool {
+	n := t.byID[id]
+	if n == nil {
+		return false
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.ID == ancestorID {
+			return true
+		}
+	}
+	return false
+}
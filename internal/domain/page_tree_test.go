@@ -0,0 +1,112 @@
+package domain
+
+import "testing"
+
+func parent(id int64) *int64 { return &id }
+
+func TestNewPageTree(t *testing.T) {
+	tree := NewPageTree([]*PageNode{
+		{ID: 1, Title: "About", Slug: "about"},
+		{ID: 2, Title: "Team", Slug: "team", ParentID: parent(1), SortOrder: 2},
+		{ID: 3, Title: "Leadership", Slug: "leadership", ParentID: parent(2)},
+		{ID: 4, Title: "History", Slug: "history", ParentID: parent(1), SortOrder: 1},
+		{ID: 5, Title: "Orphan", Slug: "orphan", ParentID: parent(99)},
+	})
+
+	if len(tree.Roots) != 2 {
+		t.Fatalf("expected 2 top-level pages, got %d", len(tree.Roots))
+	}
+
+	leadership := tree.Node(3)
+	if leadership.Path != "/about/team/leadership" {
+		t.Errorf("expected nested path, got %q", leadership.Path)
+	}
+	if leadership.Depth != 2 {
+		t.Errorf("expected depth 2, got %d", leadership.Depth)
+	}
+	if tree.Node(5).Path != "/orphan" {
+		t.Errorf("expected page with missing parent at top level, got %q", tree.Node(5).Path)
+	}
+
+	var order []string
+	for _, n := range tree.Flatten() {
+		order = append(order, n.Slug)
+	}
+	want := []string{"about", "history", "team", "leadership", "orphan"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("expected order %v, got %v", want, order)
+		}
+	}
+
+	crumbs := leadership.Breadcrumbs()
+	if len(crumbs) != 3 || crumbs[0].URL != "/about" || crumbs[1].URL != "/about/team" || crumbs[2].URL != "" {
+		t.Errorf("unexpected breadcrumbs %+v", crumbs)
+	}
+
+	if !tree.IsDescendant(3, 1) || tree.IsDescendant(1, 3) {
+		t.Error("IsDescendant() returned the wrong relationship")
+	}
+}
+
+func TestNewPageTree_BreaksCycles(t *testing.T) {
+	tree := NewPageTree([]*PageNode{
+		{ID: 1, Slug: "a", ParentID: parent(2)},
+		{ID: 2, Slug: "b", ParentID: parent(1)},
+	})
+
+	if len(tree.Flatten()) != 2 {
+		t.Fatalf("expected every page to stay reachable, got %d", len(tree.Flatten()))
+	}
+}
+
+func TestPageTree_Find(t *testing.T) {
+	tree := NewPageTree([]*PageNode{
+		{ID: 1, Slug: "about"},
+		{ID: 2, Slug: "team", ParentID: parent(1)},
+	})
+
+	tests := []struct {
+		path string
+		want int64
+	}{
+		{"/about/team", 2},
+		{"/about/team/", 2},
+		{"/about", 1},
+		{"/team", 0},
+		{"/missing", 0},
+	}
+
+	for _, tt := range tests {
+		got := tree.Find(tt.path)
+		if tt.want == 0 {
+			if got != nil {
+				t.Errorf("Find(%q) = %d, want nil", tt.path, got.ID)
+			}
+			continue
+		}
+		if got == nil || got.ID != tt.want {
+			t.Errorf("Find(%q) did not return page %d", tt.path, tt.want)
+		}
+	}
+}
+
+func TestPageTree_Filter(t *testing.T) {
+	tree := NewPageTree([]*PageNode{
+		{ID: 1, Slug: "about", Status: PageStatusDraft},
+		{ID: 2, Slug: "team", ParentID: parent(1), Status: PageStatusPublished},
+		{ID: 3, Slug: "contact", Status: PageStatusPublished},
+	})
+
+	published := tree.Filter(func(n *PageNode) bool { return n.Status == PageStatusPublished })
+
+	if published.Node(2) != nil {
+		t.Error("expected child of a draft page to be filtered out")
+	}
+	if published.Node(3) == nil {
+		t.Error("expected published top-level page to be kept")
+	}
+	if tree.Node(2).Parent == nil {
+		t.Error("expected the original tree to be left untouched")
+	}
+}
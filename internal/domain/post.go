@@ -5,30 +5,59 @@ import "time"
 type PostStatus string
 
 const (
-	PostStatusDraft     PostStatus = "draft"
-	PostStatusPublished PostStatus = "published"
-	PostStatusArchived  PostStatus = "archived"
+	PostStatusDraft            PostStatus = "draft"
+	PostStatusInReview         PostStatus = "in_review"
+	PostStatusChangesRequested PostStatus = "changes_requested"
+	PostStatusApproved         PostStatus = "approved"
+	PostStatusPublished        PostStatus = "published"
+	PostStatusArchived         PostStatus = "archived"
 )
 
 type Post struct {
-	ID          int64      `json:"id"`
-	Title       string     `json:"title"`
-	Slug        string     `json:"slug"`
-	Content     string     `json:"content"`
-	AuthorID    int64      `json:"author_id"`
-	Status      PostStatus `json:"status"`
-	MetaTitle   string     `json:"meta_title"`
-	MetaDesc    string     `json:"meta_desc"`
-	IsFeatured  bool       `json:"is_featured"`
-	PublishedAt *time.Time `json:"published_at,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID              int64      `json:"id"`
+	Title           string     `json:"title"`
+	Slug            string     `json:"slug"`
+	Content         string     `json:"content"`
+	AuthorID        int64      `json:"author_id"`
+	Status          PostStatus `json:"status"`
+	MetaTitle       string     `json:"meta_title"`
+	MetaDesc        string     `json:"meta_desc"`
+	IsFeatured      bool       `json:"is_featured"`
+	FeaturedImageID *int64     `json:"featured_image_id,omitempty"`
+	ReviewerID      *int64     `json:"reviewer_id,omitempty"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Version         int        `json:"version"`
 }
 
 func (ps PostStatus) IsValid() bool {
 	switch ps {
-	case PostStatusDraft, PostStatusPublished, PostStatusArchived:
+	case PostStatusDraft, PostStatusInReview, PostStatusChangesRequested, PostStatusApproved,
+		PostStatusPublished, PostStatusArchived:
 		return true
 	}
 	return false
 }
+
+// PostFilter narrows a listing of posts to those by an author, in a category
+// or with a tag. Zero fields don't narrow it.
+type PostFilter struct {
+	AuthorID   int64
+	CategoryID int64
+	TagID      int64
+}
+
+// PostAutosave is an editor's unsaved work on a post, kept apart from the
+// post itself until it is saved. Each user has one slot per post; PostID is
+// zero for a post that hasn't been created yet. BaseUpdatedAt is when the post
+// had last been saved as the editor was opened, used to spot edits made by
+// someone else in the meantime.
+type PostAutosave struct {
+	UserID        int64      `json:"user_id"`
+	PostID        int64      `json:"post_id"`
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	BaseUpdatedAt *time.Time `json:"base_updated_at,omitempty"`
+	SavedAt       time.Time  `json:"saved_at"`
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+type ReviewDecision string
+
+const (
+	ReviewComment          ReviewDecision = "comment"
+	ReviewApproved         ReviewDecision = "approved"
+	ReviewChangesRequested ReviewDecision = "changes_requested"
+)
+
+// PostRevision is a snapshot of a post taken each time it is submitted for
+// review, so feedback stays tied to the text it was written about.
+type PostRevision struct {
+	ID        int64     `json:"id"`
+	PostID    int64     `json:"post_id"`
+	Version   int       `json:"version"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	AuthorID  int64     `json:"author_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostReviewComment is reviewer feedback on a revision, optionally recording
+// a decision.
+type PostReviewComment struct {
+	ID         int64          `json:"id"`
+	PostID     int64          `json:"post_id"`
+	RevisionID int64          `json:"revision_id"`
+	Version    int            `json:"version"`
+	AuthorID   int64          `json:"author_id"`
+	Decision   ReviewDecision `json:"decision"`
+	Body       string         `json:"body"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// Notification is a message shown to a user, e.g. when their post is reviewed.
+type Notification struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	Message   string     `json:"message"`
+	Link      string     `json:"link"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
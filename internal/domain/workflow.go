@@ -0,0 +1,94 @@
+package domain
+
+// WorkflowRole is the part a user plays in moving a post through editorial
+// review. Editors can do everything authors can.
+type WorkflowRole int
+
+const (
+	// WorkflowNone is a user who is neither the post's author nor an editor.
+	WorkflowNone WorkflowRole = iota
+	// WorkflowAuthor is the user who wrote the post.
+	WorkflowAuthor
+	// WorkflowEditor is an editor or admin.
+	WorkflowEditor
+)
+
+// Actor is the user performing a workflow action.
+type Actor struct {
+	UserID int64
+	Editor bool
+}
+
+// RoleFor returns the role the actor plays for a post.
+func (a Actor) RoleFor(post *Post) WorkflowRole {
+	switch {
+	case a.Editor:
+		return WorkflowEditor
+	case a.UserID != 0 && a.UserID == post.AuthorID:
+		return WorkflowAuthor
+	}
+	return WorkflowNone
+}
+
+// postTransitions lists the allowed status changes and the least role needed
+// for each:
+//
+//	draft → in_review → approved → published
+//	             ↘ changes_requested ↗ (back to in_review)
+//
+// Editors may also publish drafts directly, unpublish and archive.
+var postTransitions = map[PostStatus]map[PostStatus]WorkflowRole{
+	PostStatusDraft: {
+		PostStatusInReview:  WorkflowAuthor,
+		PostStatusPublished: WorkflowEditor,
+	},
+	PostStatusInReview: {
+		PostStatusDraft:            WorkflowAuthor,
+		PostStatusApproved:         WorkflowEditor,
+		PostStatusChangesRequested: WorkflowEditor,
+	},
+	PostStatusChangesRequested: {
+		PostStatusInReview: WorkflowAuthor,
+		PostStatusDraft:    WorkflowAuthor,
+	},
+	PostStatusApproved: {
+		PostStatusDraft:            WorkflowAuthor,
+		PostStatusChangesRequested: WorkflowEditor,
+		PostStatusPublished:        WorkflowEditor,
+	},
+	PostStatusPublished: {
+		PostStatusDraft:    WorkflowEditor,
+		PostStatusArchived: WorkflowEditor,
+	},
+	PostStatusArchived: {
+		PostStatusDraft: WorkflowEditor,
+	},
+}
+
+// CanTransition reports whether a user with the given role may move a post
+// from ps to the target status.
+func (ps PostStatus) CanTransition(to PostStatus, role WorkflowRole) bool {
+	need, ok := postTransitions[ps][to]
+	return ok && role != WorkflowNone && role >= need
+}
+
+// Transitions returns the statuses a user with the given role may move a post
+// to from ps, in workflow order.
+func (ps PostStatus) Transitions(role WorkflowRole) []PostStatus {
+	var out []PostStatus
+	for _, to := range postStatusOrder {
+		if ps.CanTransition(to, role) {
+			out = append(out, to)
+		}
+	}
+	return out
+}
+
+var postStatusOrder = []PostStatus{
+	PostStatusDraft,
+	PostStatusInReview,
+	PostStatusChangesRequested,
+	PostStatusApproved,
+	PostStatusPublished,
+	PostStatusArchived,
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPostStatus_CanTransition(t *testing.T) {
+	tests := []struct {
+		from, to PostStatus
+		role     WorkflowRole
+		want     bool
+	}{
+		{PostStatusDraft, PostStatusInReview, WorkflowAuthor, true},
+		{PostStatusDraft, PostStatusInReview, WorkflowNone, false},
+		{PostStatusDraft, PostStatusPublished, WorkflowAuthor, false},
+		{PostStatusDraft, PostStatusPublished, WorkflowEditor, true},
+		{PostStatusInReview, PostStatusApproved, WorkflowAuthor, false},
+		{PostStatusInReview, PostStatusApproved, WorkflowEditor, true},
+		{PostStatusInReview, PostStatusDraft, WorkflowAuthor, true},
+		{PostStatusChangesRequested, PostStatusInReview, WorkflowAuthor, true},
+		{PostStatusChangesRequested, PostStatusPublished, WorkflowEditor, false},
+		{PostStatusApproved, PostStatusPublished, WorkflowAuthor, false},
+		{PostStatusApproved, PostStatusPublished, WorkflowEditor, true},
+		{PostStatusPublished, PostStatusInReview, WorkflowEditor, false},
+		{PostStatusDraft, PostStatusDraft, WorkflowEditor, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransition(tt.to, tt.role); got != tt.want {
+			t.Errorf("%s -> %s as role %d: got %v, want %v", tt.from, tt.to, tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestPostStatus_Transitions(t *testing.T) {
+	got := PostStatusInReview.Transitions(WorkflowEditor)
+	want := []PostStatus{PostStatusDraft, PostStatusChangesRequested, PostStatusApproved}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if got := PostStatusPublished.Transitions(WorkflowAuthor); len(got) != 0 {
+		t.Errorf("expected authors to have no transitions from published, got %v", got)
+	}
+}
+
+func TestActor_RoleFor(t *testing.T) {
+	post := &Post{AuthorID: 7}
+
+	if role := (Actor{UserID: 7}).RoleFor(post); role != WorkflowAuthor {
+		t.Errorf("expected author role, got %d", role)
+	}
+	if role := (Actor{UserID: 8}).RoleFor(post); role != WorkflowNone {
+		t.Errorf("expected no role, got %d", role)
+	}
+	if role := (Actor{UserID: 8, Editor: true}).RoleFor(post); role != WorkflowEditor {
+		t.Errorf("expected editor role, got %d", role)
+	}
+}
@@ -0,0 +1,76 @@
+// Package feed builds RSS 2.0, Atom and JSON Feed documents.
+package feed
+
+import (
+	"fmt"
+	"time"
+)
+
+// Content types for the supported feed formats.
+const (
+	ContentTypeRSS  = "application/rss+xml; charset=utf-8"
+	ContentTypeAtom = "application/atom+xml; charset=utf-8"
+	ContentTypeJSON = "application/feed+json; charset=utf-8"
+)
+
+// Feed is a format-independent description of a feed.
+type Feed struct {
+	Title       string
+	Description string
+	Link        string // HTML page the feed describes
+	FeedURL     string // URL the feed itself is served from
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item is a single feed entry.
+type Item struct {
+	ID          string
+	Title       string
+	Link        string
+	Summary     string
+	ContentHTML string
+	AuthorName  string
+	Categories  []string
+	Published   time.Time
+	Updated     time.Time
+}
+
+// LastModified returns the most recent update time of the feed or its items.
+func (f *Feed) LastModified() time.Time {
+	latest := f.Updated
+	for _, item := range f.Items {
+		if item.Updated.After(latest) {
+			latest = item.Updated
+		}
+	}
+	return latest
+}
+
+// Format identifies one of the supported feed formats.
+type Format string
+
+// Supported feed formats.
+const (
+	FormatRSS  Format = "rss"
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json"
+)
+
+// Encode renders f in the given format and returns the document together with
+// its content type.
+func Encode(f *Feed, format Format) ([]byte, string, error) {
+	switch format {
+	case FormatRSS:
+		body, err := RSS(f)
+		return body, ContentTypeRSS, err
+	case FormatAtom:
+		body, err := Atom(f)
+		return body, ContentTypeAtom, err
+	case FormatJSON:
+		body, err := JSON(f)
+		return body, ContentTypeJSON, err
+	default:
+		return nil, "", fmt.Errorf("unknown feed format %q", format)
+	}
+}
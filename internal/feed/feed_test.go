@@ -0,0 +1,190 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	return &Feed{
+		Title:       "Starter Kit Basic",
+		Description: "Latest posts",
+		Link:        "https://example.com/posts",
+		FeedURL:     "https://example.com/feed.xml",
+		Updated:     published,
+		Items: []Item{
+			{
+				ID:          "https://example.com/posts/hello",
+				Title:       "Hello & Welcome",
+				Link:        "https://example.com/posts/hello",
+				Summary:     "A first post",
+				ContentHTML: "<p>Hello <strong>world</strong></p>",
+				AuthorName:  "Jane Doe",
+				Categories:  []string{"News", "go"},
+				Published:   published,
+				Updated:     published.Add(time.Hour),
+			},
+		},
+	}
+}
+
+func TestFeed_LastModified(t *testing.T) {
+	f := testFeed()
+	want := f.Items[0].Updated
+	if got := f.LastModified(); !got.Equal(want) {
+		t.Errorf("LastModified() = %v, want %v", got, want)
+	}
+}
+
+func TestRSS(t *testing.T) {
+	out, err := RSS(testFeed())
+	if err != nil {
+		t.Fatalf("RSS() error = %v", err)
+	}
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title       string   `xml:"title"`
+				Description string   `xml:"description"`
+				PubDate     string   `xml:"pubDate"`
+				Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Categories  []string `xml:"category"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("RSS output is not valid XML: %v\n%s", err, out)
+	}
+
+	if doc.Version != "2.0" {
+		t.Errorf("version = %q, want 2.0", doc.Version)
+	}
+	if len(doc.Channel.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(doc.Channel.Items))
+	}
+	item := doc.Channel.Items[0]
+	if item.Title != "Hello & Welcome" {
+		t.Errorf("title = %q", item.Title)
+	}
+	if item.Description != "<p>Hello <strong>world</strong></p>" {
+		t.Errorf("description = %q", item.Description)
+	}
+	if item.PubDate != "Sat, 10 Jan 2026 09:00:00 +0000" {
+		t.Errorf("pubDate = %q", item.PubDate)
+	}
+	if item.Creator != "Jane Doe" {
+		t.Errorf("creator = %q", item.Creator)
+	}
+	if len(item.Categories) != 2 {
+		t.Errorf("categories = %v", item.Categories)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	out, err := Atom(testFeed())
+	if err != nil {
+		t.Fatalf("Atom() error = %v", err)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Author  string `xml:"author>name"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("Atom output is not valid XML: %v\n%s", err, out)
+	}
+
+	if doc.Updated != "2026-01-10T10:00:00Z" {
+		t.Errorf("updated = %q", doc.Updated)
+	}
+	if len(doc.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(doc.Entries))
+	}
+	if doc.Entries[0].Author != "Jane Doe" {
+		t.Errorf("author = %q", doc.Entries[0].Author)
+	}
+	if doc.Entries[0].Content.Type != "html" || !strings.Contains(doc.Entries[0].Content.Value, "<strong>") {
+		t.Errorf("content = %+v", doc.Entries[0].Content)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	out, err := JSON(testFeed())
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("JSON output is not valid: %v", err)
+	}
+
+	if doc["version"] != JSONFeedVersion {
+		t.Errorf("version = %v", doc["version"])
+	}
+	items := doc["items"].([]interface{})
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	item := items[0].(map[string]interface{})
+	if item["date_published"] != "2026-01-10T09:00:00Z" {
+		t.Errorf("date_published = %v", item["date_published"])
+	}
+	authors := item["authors"].([]interface{})
+	if authors[0].(map[string]interface{})["name"] != "Jane Doe" {
+		t.Errorf("authors = %v", authors)
+	}
+}
+
+func TestJSON_EmptyFeedHasItemsArray(t *testing.T) {
+	out, err := JSON(&Feed{Title: "Empty"})
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	if !strings.Contains(string(out), `"items": []`) {
+		t.Errorf("expected empty items array, got %s", out)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		format      Format
+		contentType string
+	}{
+		{FormatRSS, ContentTypeRSS},
+		{FormatAtom, ContentTypeAtom},
+		{FormatJSON, ContentTypeJSON},
+	}
+
+	for _, tt := range tests {
+		body, contentType, err := Encode(testFeed(), tt.format)
+		if err != nil {
+			t.Fatalf("Encode(%s) error = %v", tt.format, err)
+		}
+		if contentType != tt.contentType {
+			t.Errorf("Encode(%s) content type = %q, want %q", tt.format, contentType, tt.contentType)
+		}
+		if len(body) == 0 {
+			t.Errorf("Encode(%s) returned empty body", tt.format)
+		}
+	}
+
+	if _, _, err := Encode(testFeed(), Format("yaml")); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-fith-renderer"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+// commentQueueLimit caps how many comments a moderation queue page shows
+const commentQueueLimit = 50
+
+var commentStatusLabels = map[domain.CommentStatus]string{
+	domain.CommentStatusPending:  "Pending",
+	domain.CommentStatusApproved: "Approved",
+	domain.CommentStatusSpam:     "Spam",
+	domain.CommentStatusTrash:    "Trash",
+}
+
+// commentView is a comment ready to render. HTML is sanitized Markdown.
+type commentView struct {
+	ID     int64
+	Author string
+	Date   string
+	HTML   string
+	Depth  int
+}
+
+// commentTab is a moderation queue in the tab bar
+type commentTab struct {
+	Status  domain.CommentStatus
+	Label   string
+	Count   int
+	Current bool
+}
+
+// commentRow is a comment in a moderation queue. From is the queue being
+// shown, so moderating without HTMX returns to it.
+type commentRow struct {
+	ID        int64
+	From      domain.CommentStatus
+	Author    string
+	Email     string
+	IPAddress string
+	PostTitle string
+	PostSlug  string
+	HTML      string
+	Date      string
+	Actions   []commentAction
+}
+
+// commentAction is a moderation button. CommentID and From are repeated on
+// each action because templates cannot reach the row from inside a range.
+type commentAction struct {
+	CommentID int64
+	From      domain.CommentStatus
+	Status    domain.CommentStatus
+	Label     string
+}
+
+// CommentHandler handles reader comments and their moderation
+type CommentHandler struct {
+	commentService *service.CommentService
+	postService    *service.PostService
+	renderer       *fith.Engine
+}
+
+// NewCommentHandler creates a new comment handler
+func NewCommentHandler(commentService *service.CommentService, postService *service.PostService, renderer *fith.Engine) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+		postService:    postService,
+		renderer:       renderer,
+	}
+}
+
+// Create accepts a comment from the form on a post. HTMX requests get a
+// fragment for the form's result area; others are redirected back to the post.
+func (h *CommentHandler) Create(ctx router.Context) error {
+	req := ctx.Request()
+	if err := req.ParseForm(); err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid form data")
+	}
+
+	postID, err := strconv.ParseInt(req.FormValue("post_id"), 10, 64)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid post ID")
+	}
+
+	post, err := h.postService.GetPostByID(req.Context(), postID)
+	if err != nil || post.Status != domain.PostStatusPublished {
+		return ctx.String(http.StatusNotFound, "Post not found")
+	}
+
+	// Bots fill in every field, people never see this one. Pretend the
+	// comment went through so the bot has no reason to try again.
+	if req.FormValue("website") != "" {
+		return h.respond(ctx, post, nil, http.StatusOK, "")
+	}
+
+	comment := &domain.Comment{
+		PostID:      postID,
+		AuthorName:  req.FormValue("name"),
+		AuthorEmail: req.FormValue("email"),
+		Body:        req.FormValue("body"),
+	}
+	if parentID, err := strconv.ParseInt(req.FormValue("parent_id"), 10, 64); err == nil && parentID > 0 {
+		comment.ParentID = &parentID
+	}
+
+	var actor *domain.Actor
+	if user, ok := ctx.Get("user").(*models.User); ok && user != nil {
+		a := actorFor(user)
+		actor = &a
+		comment.AuthorName = user.Username
+		comment.AuthorEmail = user.Email
+	}
+
+	err = h.commentService.Submit(req.Context(), comment, actor, clientIP(req))
+	switch {
+	case err == nil:
+		return h.respond(ctx, post, comment, http.StatusOK, "")
+	case errors.Is(err, service.ErrInvalidComment):
+		return h.respond(ctx, post, nil, http.StatusUnprocessableEntity, strings.TrimPrefix(err.Error(), service.ErrInvalidComment.Error()+": "))
+	case errors.Is(err, service.ErrLoginRequired):
+		return h.respond(ctx, post, nil, http.StatusUnauthorized, "Please sign in to comment.")
+	case errors.Is(err, service.ErrRateLimited):
+		return h.respond(ctx, post, nil, http.StatusTooManyRequests, "You're commenting too quickly. Please wait a few minutes and try again.")
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.String(http.StatusNotFound, "Post not found")
+	}
+
+	log.Printf("Error saving comment on post %d: %v", postID, err)
+	return ctx.String(http.StatusInternalServerError, "Error saving comment")
+}
+
+// respond reports the outcome of a comment submission. comment is nil when
+// nothing was saved.
+func (h *CommentHandler) respond(ctx router.Context, post *domain.Post, comment *domain.Comment, status int, errMsg string) error {
+	if !isHTMX(ctx) {
+		if errMsg != "" {
+			return ctx.String(status, errMsg)
+		}
+		target := fmt.Sprintf("/posts/%s#comments", post.Slug)
+		if comment != nil && comment.Status == domain.CommentStatusApproved {
+			target = fmt.Sprintf("/posts/%s#comment-%d", post.Slug, comment.ID)
+		}
+		http.Redirect(ctx.Response(), ctx.Request(), target, http.StatusSeeOther)
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"error":     errMsg,
+		"published": false,
+		"comment":   commentView{},
+	}
+	if comment != nil && comment.Status == domain.CommentStatusApproved {
+		data["published"] = true
+		data["comment"] = commentViews([]*domain.Comment{comment})[0]
+	}
+
+	html, err := h.renderer.Render("partials/comment.html", data)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	return ctx.HTML(status, html)
+}
+
+// Queue lists the comments in one moderation queue
+func (h *CommentHandler) Queue(ctx router.Context) error {
+	status := domain.CommentStatus(ctx.Query("status"))
+	if status == "" {
+		status = domain.CommentStatusPending
+	}
+	if !status.IsValid() {
+		return ctx.String(http.StatusBadRequest, "Unknown comment status")
+	}
+
+	comments, err := h.commentService.Queue(ctx.Request().Context(), status, commentQueueLimit, 0)
+	if err != nil {
+		log.Printf("Error loading comment queue: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading comments")
+	}
+
+	counts, err := h.commentService.Counts(ctx.Request().Context())
+	if err != nil {
+		log.Printf("Error counting comments: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading comments")
+	}
+
+	tabs := make([]commentTab, 0, len(domain.CommentStatuses))
+	for _, s := range domain.CommentStatuses {
+		tabs = append(tabs, commentTab{Status: s, Label: commentStatusLabels[s], Count: counts[s], Current: s == status})
+	}
+
+	rows := make([]commentRow, 0, len(comments))
+	for _, c := range comments {
+		row := commentRow{
+			ID:        c.ID,
+			From:      status,
+			Author:    c.AuthorName,
+			Email:     c.AuthorEmail,
+			IPAddress: c.IPAddress,
+			PostTitle: c.PostTitle,
+			PostSlug:  c.PostSlug,
+			HTML:      helpers.RenderMarkdownSafe(c.Body),
+			Date:      c.CreatedAt.Format("Jan 2, 2006 15:04"),
+		}
+		for _, s := range domain.CommentStatuses {
+			if s != status {
+				row.Actions = append(row.Actions, commentAction{CommentID: c.ID, From: status, Status: s, Label: moderationLabel(s)})
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	data := map[string]interface{}{
+		"title":    "Comments",
+		"status":   string(status),
+		"label":    strings.ToLower(commentStatusLabels[status]),
+		"tabs":     tabs,
+		"comments": rows,
+	}
+
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
+	html, err := h.renderer.Render("admin/comments.html", data)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	return ctx.HTML(http.StatusOK, html)
+}
+
+// Moderate moves a comment to another queue
+func (h *CommentHandler) Moderate(ctx router.Context) error {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid comment ID")
+	}
+
+	if err := ctx.Request().ParseForm(); err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid form data")
+	}
+
+	status := domain.CommentStatus(ctx.Request().FormValue("status"))
+	comment, err := h.commentService.Moderate(ctx.Request().Context(), id, status)
+	switch {
+	case errors.Is(err, service.ErrInvalidComment):
+		return ctx.String(http.StatusUnprocessableEntity, "Unknown comment status")
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.String(http.StatusNotFound, "Comment not found")
+	case err != nil:
+		log.Printf("Error moderating comment %d: %v", id, err)
+		return ctx.String(http.StatusInternalServerError, "Error updating comment")
+	}
+
+	return h.moderated(ctx, ctx.Request().FormValue("from"), comment.Status)
+}
+
+// Delete permanently removes a comment
+func (h *CommentHandler) Delete(ctx router.Context) error {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid comment ID")
+	}
+
+	if err := h.commentService.Delete(ctx.Request().Context(), id); err != nil {
+		log.Printf("Error deleting comment %d: %v", id, err)
+		return ctx.String(http.StatusInternalServerError, "Error deleting comment")
+	}
+
+	return h.moderated(ctx, ctx.Request().FormValue("from"), "")
+}
+
+// moderated finishes a moderation request. HTMX requests swap the comment's
+// row for the empty response; others go back to the queue they came from.
+func (h *CommentHandler) moderated(ctx router.Context, from string, fallback domain.CommentStatus) error {
+	if isHTMX(ctx) {
+		return ctx.HTML(http.StatusOK, "")
+	}
+
+	queue := domain.CommentStatus(from)
+	if !queue.IsValid() {
+		queue = fallback
+	}
+	if !queue.IsValid() {
+		queue = domain.CommentStatusPending
+	}
+
+	http.Redirect(ctx.Response(), ctx.Request(), "/admin/comments?status="+string(queue), http.StatusSeeOther)
+	return nil
+}
+
+// moderationLabel names the button that moves a comment to a queue
+func moderationLabel(status domain.CommentStatus) string {
+	switch status {
+	case domain.CommentStatusPending:
+		return "Unapprove"
+	case domain.CommentStatusApproved:
+		return "Approve"
+	case domain.CommentStatusSpam:
+		return "Mark as spam"
+	case domain.CommentStatusTrash:
+		return "Trash"
+	}
+	return string(status)
+}
+
+// commentViews renders comments' Markdown for display
+func commentViews(comments []*domain.Comment) []commentView {
+	views := make([]commentView, 0, len(comments))
+	for _, c := range comments {
+		views = append(views, commentView{
+			ID:     c.ID,
+			Author: c.AuthorName,
+			Date:   c.CreatedAt.Format("January 2, 2006 at 15:04"),
+			HTML:   helpers.RenderMarkdownSafe(c.Body),
+			Depth:  c.Depth,
+		})
+	}
+	return views
+}
+
+// isHTMX reports whether the request was made by HTMX
+func isHTMX(ctx router.Context) bool {
+	return ctx.Request().Header.Get("HX-Request") == "true"
+}
+
+// clientIP returns the address of the client connecting to us. Forwarded
+// headers are ignored because anyone can set them to dodge rate limits.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,241 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/handlers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repositories"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/seo"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
+)
+
+var commentColumns = []string{"id", "post_id", "parent_id", "user_id", "author_name", "author_email", "body", "status", "depth", "ip_address", "created_at"}
+
+func newCommentRouter(t *testing.T, cfg config.CommentsConfig) (router.Router, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	renderer := newTestRenderer(t)
+	postService := service.NewPostService(repository.NewPostRepository(db, dialect.Postgres), nil)
+	reviewService := service.NewReviewService(postService, repository.NewReviewRepository(db, dialect.Postgres), repository.NewNotificationRepository(db, dialect.Postgres))
+	commentService := service.NewCommentService(repository.NewCommentRepository(db, dialect.Postgres), postService, cfg)
+	taxonomyService := service.NewTaxonomyService(repository.NewTaxonomyRepository(db, dialect.Postgres))
+	seoBuilder := seo.NewBuilder(config.SiteConfig{Name: "Test Site", URL: "https://example.com"})
+	mediaService := service.NewMediaService(repository.NewMediaRepository(db, dialect.Postgres), storage.NewLocal(t.TempDir(), "/static"))
+	postHandler := handlers.NewPostHandler(postService, reviewService, commentService, taxonomyService, mediaService, newTestRenders(db), service.NewAutosaveService(repository.NewAutosaveRepository(db, dialect.Postgres)), repositories.NewMemoryUserRepository(), seoBuilder, renderer)
+	commentHandler := handlers.NewCommentHandler(commentService, postService, renderer)
+
+	r := router.New()
+	r.GET("/posts/:slug", postHandler.Show)
+	r.POST("/comments", commentHandler.Create)
+	r.GET("/admin/comments", commentHandler.Queue)
+	r.POST("/admin/comments/:id/status", commentHandler.Moderate)
+
+	return r, mock
+}
+
+var anonymousComments = config.CommentsConfig{AllowAnonymous: true, RequireApproval: true, MaxDepth: 3, RateLimit: 5, RateWindow: time.Minute}
+
+func expectPublishedPost(mock sqlmock.Sqlmock) {
+	now := time.Now()
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE id = \$1`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(3, "Hello", "hello", "Body", 1, domain.PostStatusPublished, "", "", false, nil, now, now, now, nil, 1))
+}
+
+func htmxForm(target string, values url.Values) *http.Request {
+	req := postForm(target, values)
+	req.Header.Set("HX-Request", "true")
+	return req
+}
+
+func TestPostHandler_Show_RendersComments(t *testing.T) {
+	r, mock := newCommentRouter(t, anonymousComments)
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE slug = \$1`).
+		WithArgs("hello").
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(3, "Hello", "hello", "Body", 1, domain.PostStatusPublished, "", "", false, nil, now, now, now, nil, 1))
+	expectRender(mock, domain.ContentTypePost, 3)
+	mock.ExpectQuery(`SELECT (.+) FROM comments WHERE post_id = \$1 AND status = \$2`).
+		WithArgs(int64(3), domain.CommentStatusApproved).
+		WillReturnRows(sqlmock.NewRows(commentColumns).
+			AddRow(1, 3, nil, nil, "<Ann>", "", "**Great** post<script>alert(1)</script>", "approved", 0, "", now).
+			AddRow(2, 3, 1, nil, "Bob", "", "Agreed", "approved", 1, "", now))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/hello", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"Comments (2)",
+		`id="comment-1"`,
+		"&lt;Ann&gt;",
+		"<strong>Great</strong>",
+		`style="--depth: 1"`,
+		`hx-post="/comments"`,
+		`name="website"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected post page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "<script>alert") {
+		t.Error("expected comment Markdown to be sanitized")
+	}
+}
+
+func TestCommentHandler_Create_Pending(t *testing.T) {
+	r, mock := newCommentRouter(t, anonymousComments)
+
+	expectPublishedPost(mock)
+	expectPublishedPost(mock)
+	mock.ExpectQuery(`INSERT INTO comments`).
+		WithArgs(int64(3), nil, nil, "Ann", "", "Nice post", domain.CommentStatusPending, 0, "192.0.2.1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, htmxForm("/comments", url.Values{"post_id": {"3"}, "name": {"Ann"}, "body": {"Nice post"}}))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "once a moderator has approved it") {
+		t.Errorf("expected moderation notice, got %s", rec.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCommentHandler_Create_Honeypot(t *testing.T) {
+	r, mock := newCommentRouter(t, anonymousComments)
+
+	expectPublishedPost(mock)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, postForm("/comments", url.Values{"post_id": {"3"}, "name": {"Bot"}, "body": {"Buy now"}, "website": {"http://spam.example"}}))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", rec.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expected the comment not to be saved: %v", err)
+	}
+}
+
+func TestCommentHandler_Create_Invalid(t *testing.T) {
+	r, mock := newCommentRouter(t, anonymousComments)
+
+	expectPublishedPost(mock)
+	expectPublishedPost(mock)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, htmxForm("/comments", url.Values{"post_id": {"3"}, "name": {"Ann"}, "body": {"   "}}))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "comment is empty") {
+		t.Errorf("expected validation message, got %s", rec.Body.String())
+	}
+}
+
+func TestCommentHandler_Create_LoginRequired(t *testing.T) {
+	cfg := anonymousComments
+	cfg.AllowAnonymous = false
+	r, mock := newCommentRouter(t, cfg)
+
+	expectPublishedPost(mock)
+	expectPublishedPost(mock)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, htmxForm("/comments", url.Values{"post_id": {"3"}, "name": {"Ann"}, "body": {"Hi"}}))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestCommentHandler_Queue(t *testing.T) {
+	r, mock := newCommentRouter(t, anonymousComments)
+
+	mock.ExpectQuery(`SELECT (.+) FROM comments c JOIN posts p`).
+		WithArgs(domain.CommentStatusPending, 50, 0).
+		WillReturnRows(sqlmock.NewRows(append(commentColumns, "title", "slug")).
+			AddRow(7, 3, nil, nil, "Ann", "ann@example.com", "Nice post<script>alert(1)</script>", "pending", 0, "192.0.2.1", time.Now(), "Hello", "hello"))
+	mock.ExpectQuery(`SELECT status, COUNT`).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).AddRow("pending", 1).AddRow("spam", 4))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/comments", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"Spam <small>(4)</small>",
+		`<a href="/posts/hello">Hello</a>`,
+		"ann@example.com",
+		`hx-post="/admin/comments/7/status"`,
+		`value="approved"`,
+		`value="spam"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected queue to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "<script>alert") {
+		t.Error("expected comment HTML to be sanitized")
+	}
+}
+
+func TestCommentHandler_Moderate(t *testing.T) {
+	r, mock := newCommentRouter(t, anonymousComments)
+
+	mock.ExpectQuery(`SELECT (.+) FROM comments WHERE id = \$1`).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows(commentColumns).
+			AddRow(7, 3, nil, nil, "Ann", "", "Hi", "pending", 0, "", time.Now()))
+	mock.ExpectExec(`UPDATE comments SET status = \$1 WHERE id = \$2`).
+		WithArgs(domain.CommentStatusApproved, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, postForm("/admin/comments/7/status", url.Values{"status": {"approved"}, "from": {"pending"}}))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", rec.Code)
+	}
+	if loc := rec.Header().Get("Location"); loc != "/admin/comments?status=pending" {
+		t.Errorf("expected redirect back to the pending queue, got %q", loc)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-fith-renderer"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
+)
+
+// hiddenField is a form value carried over unchanged when an edit is
+// resubmitted
+type hiddenField struct {
+	Name  string
+	Value string
+}
+
+// editVersion returns the version of a post or page the submitted editor
+// was opened at, or current when the form doesn't say
+func editVersion(ctx router.Context, current int) int {
+	if v, err := strconv.Atoi(ctx.Request().FormValue("version")); err == nil {
+		return v
+	}
+	return current
+}
+
+// editConflictPage shows an edit that could not be saved because someone
+// else saved the same post or page first. The page compares both versions
+// and lets the editor resubmit a merged version on top of theirs.
+func editConflictPage(ctx router.Context, renderer *fith.Engine, template string, data map[string]interface{}, theirTitle, theirContent string, theirUpdated time.Time, theirVersion int) error {
+	form := ctx.Request().PostForm
+
+	// Everything except the text being merged is resubmitted as entered
+	var fields []hiddenField
+	for name, values := range form {
+		switch name {
+		case "title", "content", "version", "base_updated_at":
+			continue
+		}
+		for _, value := range values {
+			fields = append(fields, hiddenField{Name: name, Value: value})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
+	data["theirTitle"] = theirTitle
+	data["theirContent"] = theirContent
+	data["theirUpdated"] = theirUpdated.Format("January 2, 2006 at 15:04")
+	data["myTitle"] = form.Get("title")
+	data["myContent"] = form.Get("content")
+	data["titleChanged"] = theirTitle != form.Get("title")
+	data["diff"] = helpers.DiffLines(theirContent, form.Get("content"))
+	data["fields"] = fields
+	data["version"] = theirVersion
+
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
+	html, err := renderer.Render(template, data)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+	return ctx.HTML(http.StatusConflict, html)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+// errInvalidFeaturedImage is returned for a featured_image_id that isn't a number
+var errInvalidFeaturedImage = errors.New("invalid featured image")
+
+// featuredImageField reads the featured_image_id form field. An empty value
+// or 0 removes the image and a missing field keeps current. A newly chosen
+// image must be one the user is allowed to use.
+func featuredImageField(ctx router.Context, mediaService *service.MediaService, user *models.User, current *int64) (*int64, error) {
+	if _, ok := ctx.Request().Form["featured_image_id"]; !ok {
+		return current, nil
+	}
+
+	raw := strings.TrimSpace(ctx.Request().FormValue("featured_image_id"))
+	if raw == "" || raw == "0" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return nil, errInvalidFeaturedImage
+	}
+	if current != nil && *current == id {
+		return current, nil
+	}
+
+	if _, err := mediaService.Usable(ctx.Request().Context(), id, actorFor(user)); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// featuredImageError maps errors from featuredImageField to responses
+func featuredImageError(ctx router.Context, err error) error {
+	switch {
+	case errors.Is(err, errInvalidFeaturedImage), errors.Is(err, sql.ErrNoRows):
+		return ctx.String(http.StatusBadRequest, "Invalid featured image")
+	case errors.Is(err, service.ErrNotAllowed):
+		return ctx.String(http.StatusForbidden, "You can only use your own uploads as a featured image")
+	}
+
+	log.Printf("Error checking featured image: %v", err)
+	return ctx.String(http.StatusInternalServerError, "Error checking featured image")
+}
+
+// featuredMedia loads the featured image with the given ID. Content still
+// renders without it, so a failed lookup only gets logged.
+func featuredMedia(ctx context.Context, mediaService *service.MediaService, id *int64) *domain.Media {
+	if id == nil {
+		return nil
+	}
+
+	m, err := mediaService.GetMedia(ctx, *id)
+	if err != nil {
+		log.Printf("Error loading featured image %d: %v", *id, err)
+		return nil
+	}
+	return m
+}
+
+// featuredImagePreview returns the ID and thumbnail URL shown by the
+// featured image picker on post and page forms, both empty without an image
+func featuredImagePreview(ctx context.Context, mediaService *service.MediaService, id *int64) (string, string) {
+	m := featuredMedia(ctx, mediaService, id)
+	if m == nil {
+		return "", ""
+	}
+	return strconv.FormatInt(m.ID, 10), mediaService.URL(m, domain.VariantThumb)
+}
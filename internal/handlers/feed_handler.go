@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/feed"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repositories"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+// feedSize is the number of most recent posts included in a feed.
+const feedSize = 20
+
+// FeedHandler serves RSS, Atom and JSON feeds of published posts
+type FeedHandler struct {
+	postService     *service.PostService
+	taxonomyService *service.TaxonomyService
+	renders         *service.RenderService
+	userRepo        repositories.UserRepository
+	site            config.SiteConfig
+}
+
+// NewFeedHandler creates a new feed handler
+func NewFeedHandler(postService *service.PostService, taxonomyService *service.TaxonomyService, renderService *service.RenderService, userRepo repositories.UserRepository, site config.SiteConfig) *FeedHandler {
+	return &FeedHandler{
+		postService:     postService,
+		taxonomyService: taxonomyService,
+		renders:         renderService,
+		userRepo:        userRepo,
+		site:            site,
+	}
+}
+
+// Site serves the feed of all published posts
+func (h *FeedHandler) Site(format feed.Format) router.HandlerFunc {
+	return func(ctx router.Context) error {
+		posts, err := h.postService.ListPublishedPosts(ctx.Request().Context(), feedSize, 0)
+		if err != nil {
+			log.Printf("Error listing posts for feed: %v", err)
+			return ctx.String(http.StatusInternalServerError, "Error loading feed")
+		}
+
+		return h.serve(ctx, format, h.site.Name, "/posts", posts)
+	}
+}
+
+// Author serves the feed of a single author's published posts
+func (h *FeedHandler) Author(format feed.Format) router.HandlerFunc {
+	return func(ctx router.Context) error {
+		author, err := h.userRepo.FindByUsername(ctx.Param("username"))
+		if err != nil || author == nil {
+			return ctx.String(http.StatusNotFound, "Author not found")
+		}
+
+		posts, err := h.postService.ListPublishedPostsByAuthor(ctx.Request().Context(), int64(author.ID), feedSize, 0)
+		if err != nil {
+			log.Printf("Error listing posts for author feed: %v", err)
+			return ctx.String(http.StatusInternalServerError, "Error loading feed")
+		}
+
+		return h.serve(ctx, format, h.site.Name+" - "+author.FullName(), "/authors/"+author.Username, posts)
+	}
+}
+
+// Category serves the feed of published posts in a category
+func (h *FeedHandler) Category(format feed.Format) router.HandlerFunc {
+	return func(ctx router.Context) error {
+		category, err := h.taxonomyService.GetCategoryBySlug(ctx.Request().Context(), ctx.Param("slug"))
+		if err != nil {
+			return ctx.String(http.StatusNotFound, "Category not found")
+		}
+
+		posts, err := h.postService.ListPublishedPostsByCategory(ctx.Request().Context(), category.ID, feedSize, 0)
+		if err != nil {
+			log.Printf("Error listing posts for category feed: %v", err)
+			return ctx.String(http.StatusInternalServerError, "Error loading feed")
+		}
+
+		return h.serve(ctx, format, h.site.Name+" - "+category.Name, "/categories/"+category.Slug, posts)
+	}
+}
+
+// Tag serves the feed of published posts with a tag
+func (h *FeedHandler) Tag(format feed.Format) router.HandlerFunc {
+	return func(ctx router.Context) error {
+		tag, err := h.taxonomyService.GetTagBySlug(ctx.Request().Context(), ctx.Param("slug"))
+		if err != nil {
+			return ctx.String(http.StatusNotFound, "Tag not found")
+		}
+
+		posts, err := h.postService.ListPublishedPostsByTag(ctx.Request().Context(), tag.ID, feedSize, 0)
+		if err != nil {
+			log.Printf("Error listing posts for tag feed: %v", err)
+			return ctx.String(http.StatusInternalServerError, "Error loading feed")
+		}
+
+		return h.serve(ctx, format, h.site.Name+" - "+tag.Name, "/tags/"+tag.Slug, posts)
+	}
+}
+
+// serve builds the feed document for posts and writes it with conditional GET support
+func (h *FeedHandler) serve(ctx router.Context, format feed.Format, title, path string, posts []*domain.Post) error {
+	items, err := h.items(ctx.Request().Context(), posts)
+	if err != nil {
+		log.Printf("Error building feed items: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading feed")
+	}
+
+	f := &feed.Feed{
+		Title:       title,
+		Description: h.site.Description,
+		Link:        h.site.URL + path,
+		FeedURL:     h.site.URL + ctx.Request().URL.Path,
+		Items:       items,
+	}
+	f.Updated = f.LastModified()
+
+	body, contentType, err := feed.Encode(f, format)
+	if err != nil {
+		log.Printf("Error encoding feed: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading feed")
+	}
+
+	return writeConditional(ctx, contentType, body, f.Updated)
+}
+
+// items converts posts to feed entries, fetching categories, tags and
+// rendered content in one query each
+func (h *FeedHandler) items(ctx context.Context, posts []*domain.Post) ([]feed.Item, error) {
+	categories, err := h.taxonomyService.CategoriesForPosts(ctx, posts)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := h.taxonomyService.TagsForPosts(ctx, posts)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := renderPosts(ctx, h.renders, posts)
+
+	authors := make(map[int64]string)
+	items := make([]feed.Item, 0, len(posts))
+
+	for _, post := range posts {
+		link := h.site.URL + "/posts/" + post.Slug
+
+		published := post.CreatedAt
+		if post.PublishedAt != nil {
+			published = *post.PublishedAt
+		}
+
+		var terms []string
+		for _, category := range categories[post.ID] {
+			terms = append(terms, category.Name)
+		}
+		for _, tag := range tags[post.ID] {
+			terms = append(terms, tag.Name)
+		}
+
+		items = append(items, feed.Item{
+			ID:          link,
+			Title:       post.Title,
+			Link:        link,
+			Summary:     post.MetaDesc,
+			ContentHTML: rendered[post.ID].HTML,
+			AuthorName:  h.authorName(authors, post.AuthorID),
+			Categories:  terms,
+			Published:   published,
+			Updated:     post.UpdatedAt,
+		})
+	}
+
+	return items, nil
+}
+
+// authorName resolves and caches the display name of a post author
+func (h *FeedHandler) authorName(cache map[int64]string, authorID int64) string {
+	if name, ok := cache[authorID]; ok {
+		return name
+	}
+
+	// Unknown authors are left out of the entry rather than failing the feed
+	name := ""
+	if user, err := h.userRepo.FindByID(int(authorID)); err == nil && user != nil {
+		name = user.FullName()
+	}
+
+	cache[authorID] = name
+	return name
+}
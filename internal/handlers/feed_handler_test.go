@@ -0,0 +1,200 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/feed"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/handlers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repositories"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+var postColumns = []string{
+	"id", "title", "slug", "content", "author_id", "status",
+	"meta_title", "meta_desc", "is_featured", "reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+}
+
+var renderColumns = []string{"content_type", "content_id", "html", "toc", "excerpt", "word_count", "reading_time", "version", "rendered_at"}
+
+func newFeedRouter(t *testing.T) (router.Router, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	users := repositories.NewMemoryUserRepository()
+	if err := users.Create(&models.User{Username: "jane", Email: "jane@example.com", FirstName: "Jane", LastName: "Doe"}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	postService := service.NewPostService(repository.NewPostRepository(db, dialect.Postgres), nil)
+	taxonomyService := service.NewTaxonomyService(repository.NewTaxonomyRepository(db, dialect.Postgres))
+	handler := handlers.NewFeedHandler(postService, taxonomyService, newTestRenders(db), users, config.SiteConfig{
+		Name: "Test Site",
+		URL:  "https://example.com",
+	})
+
+	r := router.New()
+	r.GET("/feed.xml", handler.Site(feed.FormatRSS))
+	r.GET("/feed.json", handler.Site(feed.FormatJSON))
+	r.GET("/authors/:username/atom.xml", handler.Author(feed.FormatAtom))
+	r.GET("/tags/:slug/feed.xml", handler.Tag(feed.FormatRSS))
+
+	return r, mock
+}
+
+func expectFeedQueries(mock sqlmock.Sqlmock, updated time.Time) {
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE status = \$1`).
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(1, "Hello", "hello", "Some **bold** text<script>x</script>", 1, domain.PostStatusPublished, "", "Intro", false, nil, updated, updated, updated, nil, 1))
+	mock.ExpectQuery(`SELECT (.+) FROM post_categories`).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "id", "name", "slug", "created_at"}).AddRow(1, 1, "News", "news", updated))
+	mock.ExpectQuery(`SELECT (.+) FROM post_tags`).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "id", "name", "slug", "created_at"}))
+	expectRenderList(mock, domain.ContentTypePost, 1)
+}
+
+func TestFeedHandler_Site(t *testing.T) {
+	r, mock := newFeedRouter(t)
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	expectFeedQueries(mock, updated)
+
+	req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != feed.ContentTypeRSS {
+		t.Errorf("expected RSS content type, got %s", ct)
+	}
+	if w.Header().Get("ETag") == "" {
+		t.Error("expected ETag header")
+	}
+	if lm := w.Header().Get("Last-Modified"); lm != "Fri, 02 Jan 2026 03:04:05 GMT" {
+		t.Errorf("unexpected Last-Modified %q", lm)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{"https://example.com/posts/hello", "Jane Doe", "News", "&lt;strong&gt;bold&lt;/strong&gt;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected feed to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "&lt;script") {
+		t.Error("expected script tags to be sanitized")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFeedHandler_ConditionalGet(t *testing.T) {
+	r, mock := newFeedRouter(t)
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	expectFeedQueries(mock, updated)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/feed.json", nil))
+	etag := w.Header().Get("ETag")
+
+	t.Run("matching ETag", func(t *testing.T) {
+		expectFeedQueries(mock, updated)
+		req := httptest.NewRequest(http.MethodGet, "/feed.json", nil)
+		req.Header.Set("If-None-Match", etag)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotModified {
+			t.Errorf("expected status 304, got %d", w.Code)
+		}
+		if w.Body.Len() != 0 {
+			t.Error("expected empty body for 304")
+		}
+	})
+
+	t.Run("not modified since", func(t *testing.T) {
+		expectFeedQueries(mock, updated)
+		req := httptest.NewRequest(http.MethodGet, "/feed.json", nil)
+		req.Header.Set("If-Modified-Since", updated.Add(time.Hour).Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotModified {
+			t.Errorf("expected status 304, got %d", w.Code)
+		}
+	})
+
+	t.Run("modified since", func(t *testing.T) {
+		expectFeedQueries(mock, updated)
+		req := httptest.NewRequest(http.MethodGet, "/feed.json", nil)
+		req.Header.Set("If-Modified-Since", updated.Add(-time.Hour).Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", w.Code)
+		}
+	})
+}
+
+func TestFeedHandler_Author(t *testing.T) {
+	r, mock := newFeedRouter(t)
+
+	t.Run("unknown author", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/authors/nobody/atom.xml", nil))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("known author", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM posts WHERE status = \$1 AND author_id = \$2`).
+			WithArgs(domain.PostStatusPublished, int64(1), 20, 0).
+			WillReturnRows(sqlmock.NewRows(postColumns))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/authors/jane/atom.xml", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), "Test Site - Jane Doe") {
+			t.Error("expected author name in feed title")
+		}
+	})
+}
+
+func TestFeedHandler_UnknownTag(t *testing.T) {
+	r, mock := newFeedRouter(t)
+
+	mock.ExpectQuery(`SELECT (.+) FROM tags WHERE slug = \$1`).
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "created_at"}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tags/missing/feed.xml", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
@@ -10,7 +10,15 @@ import (
 
 // HealthHandler handles health check requests.
 type HealthHandler struct {
-	db *sql.DB
+	db      *sql.DB
+	monitor DatabaseMonitor
+}
+
+// DatabaseMonitor keeps the status of the database connection, as
+// database.Monitor does.
+type DatabaseMonitor interface {
+	Status() string
+	Ready() bool
 }
 
 // NewHealthHandler creates a new health handler.
@@ -18,6 +26,12 @@ func NewHealthHandler(db *sql.DB) *HealthHandler {
 	return &HealthHandler{db: db}
 }
 
+// SetMonitor reports the status monitor keeps instead of pinging the
+// database on every check.
+func (h *HealthHandler) SetMonitor(monitor DatabaseMonitor) {
+	h.monitor = monitor
+}
+
 // HealthResponse represents the health check response.
 type HealthResponse struct {
 	Status   string `json:"status"`
@@ -31,7 +45,13 @@ func (h *HealthHandler) Check(ctx router.Context) error {
 	}
 
 	// Check database if available
-	if h.db != nil {
+	if h.monitor != nil {
+		response.Database = h.monitor.Status()
+		if !h.monitor.Ready() {
+			response.Status = "unhealthy"
+			return ctx.JSON(http.StatusServiceUnavailable, response)
+		}
+	} else if h.db != nil {
 		if err := h.db.Ping(); err != nil {
 			response.Status = "unhealthy"
 			response.Database = "disconnected"
@@ -44,3 +64,9 @@ func (h *HealthHandler) Check(ctx router.Context) error {
 
 	return ctx.JSON(http.StatusOK, response)
 }
+
+// Live reports that the server is running, whether or not it can serve
+// requests yet, so a process supervisor only restarts it when it hangs.
+func (h *HealthHandler) Live(ctx router.Context) error {
+	return ctx.JSON(http.StatusOK, HealthResponse{Status: "alive"})
+}
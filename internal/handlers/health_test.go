@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	router "github.com/toutaio/toutago-cosan-router"
@@ -46,3 +47,48 @@ func TestHealthHandler(t *testing.T) {
 		})
 	}
 }
+
+// monitor is a database monitor stuck in one status
+type monitor string
+
+func (m monitor) Status() string { return string(m) }
+func (m monitor) Ready() bool    { return m == "connected" }
+
+func TestHealthHandler_Monitor(t *testing.T) {
+	tests := []struct {
+		status         string
+		expectedStatus int
+	}{
+		{"connecting", http.StatusServiceUnavailable},
+		{"connected", http.StatusOK},
+		{"disconnected", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			r := router.New()
+			handler := handlers.NewHealthHandler(nil)
+			handler.SetMonitor(monitor(tt.status))
+			r.GET("/health/ready", handler.Check)
+			r.GET("/health/live", handler.Live)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), `"database":"`+tt.status+`"`) {
+				t.Errorf("expected database status %s, got %s", tt.status, w.Body.String())
+			}
+
+			// The server is alive whatever state the database is in
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+
+			if w.Code != http.StatusOK {
+				t.Errorf("expected live status 200, got %d", w.Code)
+			}
+		})
+	}
+}
@@ -1,28 +1,90 @@
 package handlers
 
 import (
+	"context"
+	"log"
 	"net/http"
 
 	router "github.com/toutaio/toutago-cosan-router"
 	"github.com/toutaio/toutago-fith-renderer"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/content"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
 )
 
+// homeWidgetSize is how many links a term widget shows
+const homeWidgetSize = 10
+
+// postSummary is a post in a listing ready to render
+type postSummary struct {
+	Title     string
+	URL       string
+	Excerpt   string
+	Published string
+	ImageURL  string
+	ImageAlt  string
+	// ReadingTime is in minutes, 0 when unknown
+	ReadingTime int
+}
+
+// homeWidget is a titled list of links in the home page sidebar
+type homeWidget struct {
+	Name  string
+	Title string
+	Links []homeLink
+}
+
+type homeLink struct {
+	Label string
+	URL   string
+	Count int
+}
+
 // HomeHandler handles home page requests.
 type HomeHandler struct {
-	renderer *fith.Engine
+	renderer        *fith.Engine
+	postService     *service.PostService
+	mediaService    *service.MediaService
+	taxonomyService *service.TaxonomyService
+	renders         *service.RenderService
+	cfg             config.HomeConfig
 }
 
-// NewHomeHandler creates a new home handler.
-func NewHomeHandler(renderer *fith.Engine) *HomeHandler {
-	return &HomeHandler{renderer: renderer}
+// NewHomeHandler creates a new home handler. Without a database the services
+// are nil and the home page only shows its welcome text.
+func NewHomeHandler(renderer *fith.Engine, postService *service.PostService, mediaService *service.MediaService, taxonomyService *service.TaxonomyService, renderService *service.RenderService, cfg config.HomeConfig) *HomeHandler {
+	return &HomeHandler{
+		renderer:        renderer,
+		postService:     postService,
+		mediaService:    mediaService,
+		taxonomyService: taxonomyService,
+		renders:         renderService,
+		cfg:             cfg,
+	}
 }
 
-// Index handles the home page.
+// Index handles the home page. Sections that fail to load are left out
+// rather than failing the whole page.
 func (h *HomeHandler) Index(ctx router.Context) error {
+	featured, latest := []postSummary{}, []postSummary{}
+	widgets := []homeWidget{}
+
+	if h.postService != nil {
+		featured, latest = h.posts(ctx.Request().Context())
+		widgets = h.widgets(ctx.Request().Context())
+	}
+
 	data := map[string]interface{}{
-		"title": "Home",
+		"title":    "Home",
+		"featured": featured,
+		"latest":   latest,
+		"widgets":  widgets,
 	}
 
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
 	html, err := h.renderer.Render("pages/home.html", data)
 	if err != nil {
 		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
@@ -30,3 +92,134 @@ func (h *HomeHandler) Index(ctx router.Context) error {
 
 	return ctx.HTML(http.StatusOK, html)
 }
+
+// posts loads the featured and latest posts. Featured posts aren't repeated
+// in the latest list.
+func (h *HomeHandler) posts(ctx context.Context) ([]postSummary, []postSummary) {
+	var featured []*domain.Post
+	if h.cfg.FeaturedCount > 0 {
+		var err error
+		if featured, err = h.postService.ListFeaturedPosts(ctx, h.cfg.FeaturedCount); err != nil {
+			log.Printf("Error loading featured posts: %v", err)
+		}
+	}
+
+	var latest []*domain.Post
+	if h.cfg.LatestCount > 0 {
+		// Fetch enough to fill the list after dropping featured posts
+		posts, err := h.postService.ListPublishedPosts(ctx, h.cfg.LatestCount+len(featured), 0)
+		if err != nil {
+			log.Printf("Error loading latest posts: %v", err)
+		}
+
+		shown := make(map[int64]bool, len(featured))
+		for _, post := range featured {
+			shown[post.ID] = true
+		}
+		for _, post := range posts {
+			if !shown[post.ID] && len(latest) < h.cfg.LatestCount {
+				latest = append(latest, post)
+			}
+		}
+	}
+
+	shown := append(append([]*domain.Post{}, featured...), latest...)
+	images := h.images(ctx, shown)
+	rendered := renderPosts(ctx, h.renders, shown)
+	return h.views(featured, images, rendered, domain.VariantLarge), h.views(latest, images, rendered, domain.VariantMedium)
+}
+
+// images loads the featured images of posts, keyed by media ID
+func (h *HomeHandler) images(ctx context.Context, posts []*domain.Post) map[int64]*domain.Media {
+	var ids []int64
+	for _, post := range posts {
+		if post.FeaturedImageID != nil {
+			ids = append(ids, *post.FeaturedImageID)
+		}
+	}
+	if len(ids) == 0 || h.mediaService == nil {
+		return nil
+	}
+
+	images, err := h.mediaService.ByIDs(ctx, ids)
+	if err != nil {
+		log.Printf("Error loading featured images: %v", err)
+		return nil
+	}
+	return images
+}
+
+func (h *HomeHandler) views(posts []*domain.Post, images map[int64]*domain.Media, rendered map[int64]*domain.RenderedContent, variant string) []postSummary {
+	views := make([]postSummary, 0, len(posts))
+	for _, post := range posts {
+		view := summarize(post, rendered[post.ID])
+		if post.FeaturedImageID != nil {
+			if m, ok := images[*post.FeaturedImageID]; ok {
+				view.ImageURL = h.mediaService.URL(m, variant)
+				view.ImageAlt = m.AltText
+			}
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// summarize turns a post into a listing entry without an image. The excerpt
+// and reading time come from its cached render when there is one.
+func summarize(post *domain.Post, rendered *domain.RenderedContent) postSummary {
+	view := postSummary{
+		Title: post.Title,
+		URL:   "/posts/" + post.Slug,
+	}
+	if rendered != nil {
+		view.Excerpt, view.ReadingTime = rendered.Excerpt, rendered.ReadingTime
+	} else {
+		view.Excerpt = helpers.Truncate(helpers.PlainText(post.Content), content.ExcerptLength)
+	}
+	if post.PublishedAt != nil {
+		view.Published = post.PublishedAt.Format("January 2, 2006")
+	}
+	return view
+}
+
+// widgets builds the configured sidebar widgets, skipping empty ones
+func (h *HomeHandler) widgets(ctx context.Context) []homeWidget {
+	widgets := []homeWidget{}
+	for _, name := range h.cfg.Widgets {
+		var widget homeWidget
+		switch name {
+		case "categories":
+			widget = h.termWidget(ctx, name, "Categories", "category", h.taxonomyService.PopularCategories)
+		case "tags":
+			widget = h.termWidget(ctx, name, "Tags", "tag", h.taxonomyService.PopularTags)
+		case "subscribe":
+			widget = homeWidget{Name: name, Title: "Subscribe", Links: []homeLink{
+				{Label: "RSS", URL: "/feed.xml"},
+				{Label: "Atom", URL: "/atom.xml"},
+				{Label: "JSON Feed", URL: "/feed.json"},
+			}}
+		}
+		if len(widget.Links) > 0 {
+			widgets = append(widgets, widget)
+		}
+	}
+	return widgets
+}
+
+// termWidget lists the most used categories or tags, linking to their posts
+func (h *HomeHandler) termWidget(ctx context.Context, name, title, param string, list func(context.Context, int) ([]*domain.TermCount, error)) homeWidget {
+	terms, err := list(ctx, homeWidgetSize)
+	if err != nil {
+		log.Printf("Error loading %s widget: %v", name, err)
+	}
+
+	widget := homeWidget{Name: name, Title: title}
+	for _, term := range terms {
+		widget.Links = append(widget.Links, homeLink{
+			Label: term.Name,
+			URL:   "/posts?" + param + "=" + term.Slug,
+			Count: term.Count,
+		})
+	}
+	return widget
+}
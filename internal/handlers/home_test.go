@@ -1,13 +1,24 @@
 package handlers_test
 
 import (
+	"database/sql/driver"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	router "github.com/toutaio/toutago-cosan-router"
 	"github.com/toutaio/toutago-fith-renderer"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/content"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/handlers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
 )
 
 func TestHomeHandler(t *testing.T) {
@@ -21,7 +32,7 @@ func TestHomeHandler(t *testing.T) {
 	}
 
 	r := router.New()
-	handler := handlers.NewHomeHandler(renderer)
+	handler := handlers.NewHomeHandler(renderer, nil, nil, nil, nil, defaultHomeConfig())
 	r.GET("/", handler.Index)
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -44,3 +55,81 @@ func TestHomeHandler(t *testing.T) {
 		t.Error("expected response body, got empty")
 	}
 }
+
+func defaultHomeConfig() config.HomeConfig {
+	return config.HomeConfig{FeaturedCount: 5, LatestCount: 3, Widgets: config.HomeWidgets}
+}
+
+func TestHomeHandler_LiveContent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	postService := service.NewPostService(repository.NewPostRepository(db, dialect.Postgres), nil)
+	mediaService := service.NewMediaService(repository.NewMediaRepository(db, dialect.Postgres), storage.NewLocal(t.TempDir(), "/static"))
+	taxonomyService := service.NewTaxonomyService(repository.NewTaxonomyRepository(db, dialect.Postgres))
+	handler := handlers.NewHomeHandler(newTestRenderer(t), postService, mediaService, taxonomyService, newTestRenders(db), defaultHomeConfig())
+
+	r := router.New()
+	r.GET("/", handler.Index)
+
+	published := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	featured := []driver.Value{1, "Big News", "big-news", "Something **happened**.", 1, domain.PostStatusPublished, "", "", true, nil, published, published, published, 7, 1}
+	other := []driver.Value{2, "Small <News>", "small-news", "Less happened.", 1, domain.PostStatusPublished, "", "", false, nil, published, published, published, nil, 1}
+
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE status = \$1 AND is_featured = \$2`).
+		WithArgs(domain.PostStatusPublished, true, 5, 0).
+		WillReturnRows(sqlmock.NewRows(postColumns).AddRow(featured...))
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE status = \$1`).
+		WithArgs(domain.PostStatusPublished, 4, 0).
+		WillReturnRows(sqlmock.NewRows(postColumns).AddRow(featured...).AddRow(other...))
+	mock.ExpectQuery(`SELECT (.+) FROM media WHERE id IN \(\$1\)`).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows(mediaColumns).
+			AddRow(7, 1, "hero.jpg", "/uploads/2026/01/hero.jpg", "image/jpeg", 100, 1600, 900, "A hero", "abc", published))
+	// The featured post has a current cached render; the other is rendered
+	mock.ExpectQuery(`SELECT (.+) FROM rendered_content WHERE content_type = \$1 AND content_id IN`).
+		WithArgs(domain.ContentTypePost, int64(1), int64(2)).
+		WillReturnRows(sqlmock.NewRows(renderColumns).
+			AddRow(domain.ContentTypePost, 1, "<p>Something <strong>happened</strong>.</p>", "", "Something happened.", 2, 1, content.Version, published))
+	mock.ExpectExec(`INSERT INTO rendered_content`).
+		WithArgs(domain.ContentTypePost, int64(2), sqlmock.AnyArg(), sqlmock.AnyArg(), "Less happened.", 2, 1, content.Version, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT (.+) FROM categories t`).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "slug", "post_count"}).AddRow("News", "news", 2))
+	mock.ExpectQuery(`SELECT (.+) FROM tags t`).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "slug", "post_count"}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`class="featured-carousel"`,
+		`src="/static/uploads/2026/01/hero-large.jpg" alt="A hero"`,
+		`href="/posts/big-news"`,
+		"Something happened.",
+		"Small &lt;News&gt;",
+		`href="/posts?category=news"`,
+		`href="/feed.xml"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected home page to contain %q", want)
+		}
+	}
+	if strings.Count(body, `href="/posts/big-news"`) != 2 {
+		t.Error("expected the featured post only in the carousel, linked from its image and title")
+	}
+	if strings.Contains(body, "widget-tags") {
+		t.Error("expected the empty tags widget to be left out")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-fith-renderer"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+// mediaPageSize is how many items a page of the media library shows
+const mediaPageSize = 24
+
+// mediaItem is a media library item ready to render
+type mediaItem struct {
+	ID         int64
+	FileName   string
+	ThumbURL   string
+	AltText    string
+	Markdown   string
+	Dimensions string
+	Size       string
+}
+
+// MediaHandler handles the media library
+type MediaHandler struct {
+	mediaService *service.MediaService
+	renderer     *fith.Engine
+}
+
+// NewMediaHandler creates a new media handler
+func NewMediaHandler(mediaService *service.MediaService, renderer *fith.Engine) *MediaHandler {
+	return &MediaHandler{
+		mediaService: mediaService,
+		renderer:     renderer,
+	}
+}
+
+// Library displays the media library with its upload form
+func (h *MediaHandler) Library(ctx router.Context) error {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok || user == nil {
+		return ctx.String(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	page, _ := strconv.Atoi(ctx.Query("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	// Fetch one extra item to tell whether there is a next page
+	items, err := h.mediaService.Library(ctx.Request().Context(), actorFor(user), mediaPageSize+1, (page-1)*mediaPageSize)
+	if err != nil {
+		log.Printf("Error loading media library: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading media")
+	}
+
+	next := 0
+	if len(items) > mediaPageSize {
+		items = items[:mediaPageSize]
+		next = page + 1
+	}
+
+	data := map[string]interface{}{
+		"title":    "Media Library",
+		"items":    h.items(items),
+		"editor":   user.IsEditor(),
+		"maxSize":  humanSize(helpers.MaxUploadSize),
+		"prevPage": page - 1,
+		"nextPage": next,
+	}
+
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
+	html, err := h.renderer.Render("admin/media.html", data)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	return ctx.HTML(http.StatusOK, html)
+}
+
+// Picker renders the image picker shown on post and page editors. With
+// ?mode=featured the chosen image becomes the featured image instead of being
+// inserted into the content.
+func (h *MediaHandler) Picker(ctx router.Context) error {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok || user == nil {
+		return ctx.String(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	items, err := h.mediaService.Library(ctx.Request().Context(), actorFor(user), mediaPageSize, 0)
+	if err != nil {
+		log.Printf("Error loading media picker: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading media")
+	}
+
+	mode, heading := "insert", "Insert an image"
+	if ctx.Query("mode") == "featured" {
+		mode, heading = "featured", "Choose a featured image"
+	}
+
+	html, err := h.renderer.Render("partials/media-picker.html", map[string]interface{}{
+		"items":   h.items(items),
+		"mode":    mode,
+		"heading": heading,
+	})
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	return ctx.HTML(http.StatusOK, html)
+}
+
+// Upload adds an image to the library. HTMX requests get the new item's
+// card to prepend to the grid.
+func (h *MediaHandler) Upload(ctx router.Context) error {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok || user == nil {
+		return ctx.String(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	req := ctx.Request()
+	// Leave room for the rest of the multipart body around the file
+	req.Body = http.MaxBytesReader(ctx.Response(), req.Body, helpers.MaxUploadSize+1<<20)
+	if err := req.ParseMultipartForm(helpers.MaxUploadSize); err != nil {
+		return h.uploadError(ctx, http.StatusRequestEntityTooLarge, fmt.Sprintf("Files can be at most %s.", humanSize(helpers.MaxUploadSize)))
+	}
+
+	file, header, err := req.FormFile("file")
+	if err != nil {
+		return h.uploadError(ctx, http.StatusUnprocessableEntity, "Choose an image to upload.")
+	}
+	defer file.Close()
+
+	m, err := h.mediaService.Upload(req.Context(), int64(user.ID), header.Filename, file, req.FormValue("alt_text"))
+	switch {
+	case errors.Is(err, service.ErrFileTooLarge):
+		return h.uploadError(ctx, http.StatusRequestEntityTooLarge, fmt.Sprintf("Files can be at most %s.", humanSize(helpers.MaxUploadSize)))
+	case errors.Is(err, service.ErrImageTooLarge):
+		return h.uploadError(ctx, http.StatusUnprocessableEntity, fmt.Sprintf("Images can be at most %d×%d pixels.", helpers.MaxImageWidth, helpers.MaxImageHeight))
+	case errors.Is(err, service.ErrUnsupportedMedia):
+		return h.uploadError(ctx, http.StatusUnsupportedMediaType, "Only JPEG, PNG, GIF and WebP images can be uploaded.")
+	case err != nil:
+		log.Printf("Error uploading media: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error uploading file")
+	}
+
+	if !isHTMX(ctx) {
+		http.Redirect(ctx.Response(), req, "/admin/media", http.StatusSeeOther)
+		return nil
+	}
+
+	html, err := h.renderer.Render("partials/media-card.html", map[string]interface{}{
+		"item": h.items([]*domain.Media{m})[0],
+	})
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	return ctx.HTML(http.StatusOK, html)
+}
+
+// Update changes an item's alt text
+func (h *MediaHandler) Update(ctx router.Context) error {
+	user, id, ok, err := h.target(ctx)
+	if !ok {
+		return err
+	}
+
+	if err := ctx.Request().ParseForm(); err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid form data")
+	}
+
+	_, err = h.mediaService.UpdateAltText(ctx.Request().Context(), id, actorFor(user), ctx.Request().FormValue("alt_text"))
+	if err != nil {
+		return h.mediaError(ctx, id, err)
+	}
+
+	if isHTMX(ctx) {
+		return ctx.HTML(http.StatusOK, `<small class="media-saved">Saved</small>`)
+	}
+
+	http.Redirect(ctx.Response(), ctx.Request(), "/admin/media", http.StatusSeeOther)
+	return nil
+}
+
+// Download redirects to the item's original file
+func (h *MediaHandler) Download(ctx router.Context) error {
+	user, id, ok, err := h.target(ctx)
+	if !ok {
+		return err
+	}
+
+	url, err := h.mediaService.DownloadURL(ctx.Request().Context(), id, actorFor(user))
+	if err != nil {
+		return h.mediaError(ctx, id, err)
+	}
+
+	http.Redirect(ctx.Response(), ctx.Request(), url, http.StatusFound)
+	return nil
+}
+
+// Delete removes an item unless content still uses it
+func (h *MediaHandler) Delete(ctx router.Context) error {
+	user, id, ok, err := h.target(ctx)
+	if !ok {
+		return err
+	}
+
+	refs, err := h.mediaService.Delete(ctx.Request().Context(), id, actorFor(user))
+	if errors.Is(err, service.ErrMediaInUse) {
+		titles := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			titles = append(titles, fmt.Sprintf("%s %q", ref.Type, ref.Title))
+		}
+		msg := "This file is still used by " + strings.Join(titles, ", ") + ". Remove it from there first."
+		if isHTMX(ctx) {
+			ctx.Response().Header().Set("HX-Retarget", fmt.Sprintf("#media-%d-error", id))
+			return ctx.HTML(http.StatusConflict, html.EscapeString(msg))
+		}
+		return ctx.String(http.StatusConflict, msg)
+	}
+	if err != nil {
+		return h.mediaError(ctx, id, err)
+	}
+
+	if isHTMX(ctx) {
+		return ctx.HTML(http.StatusOK, "")
+	}
+
+	http.Redirect(ctx.Response(), ctx.Request(), "/admin/media", http.StatusSeeOther)
+	return nil
+}
+
+// target returns the current user and the media ID in the URL, writing an
+// error response and returning ok false if either is missing
+func (h *MediaHandler) target(ctx router.Context) (*models.User, int64, bool, error) {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok || user == nil {
+		return nil, 0, false, ctx.String(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return nil, 0, false, ctx.String(http.StatusBadRequest, "Invalid media ID")
+	}
+
+	return user, id, true, nil
+}
+
+// uploadError reports a rejected upload. HTMX requests get the message in the
+// upload form's error area.
+func (h *MediaHandler) uploadError(ctx router.Context, status int, msg string) error {
+	if isHTMX(ctx) {
+		ctx.Response().Header().Set("HX-Retarget", "#media-upload-error")
+		return ctx.HTML(status, html.EscapeString(msg))
+	}
+	return ctx.String(status, msg)
+}
+
+// mediaError maps media service errors to responses
+func (h *MediaHandler) mediaError(ctx router.Context, id int64, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.String(http.StatusNotFound, "Media not found")
+	case errors.Is(err, service.ErrNotAllowed):
+		return ctx.String(http.StatusForbidden, "You can only change your own uploads")
+	}
+
+	log.Printf("Error updating media %d: %v", id, err)
+	return ctx.String(http.StatusInternalServerError, "Error updating media")
+}
+
+func (h *MediaHandler) items(items []*domain.Media) []mediaItem {
+	out := make([]mediaItem, 0, len(items))
+	for _, m := range items {
+		out = append(out, mediaItem{
+			ID:         m.ID,
+			FileName:   m.FileName,
+			ThumbURL:   h.mediaService.URL(m, domain.VariantThumb),
+			AltText:    m.AltText,
+			Markdown:   m.Markdown(h.mediaService.URL(m, domain.VariantLarge)),
+			Dimensions: fmt.Sprintf("%d×%d", m.Width, m.Height),
+			Size:       humanSize(m.Size),
+		})
+	}
+	return out
+}
+
+// humanSize formats a byte count for display
+func humanSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%d KB", n/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,183 @@
+package handlers_test
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/handlers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
+)
+
+var mediaColumns = []string{"id", "owner_id", "file_name", "path", "mime_type", "size", "width", "height", "alt_text", "checksum", "created_at"}
+
+// newMediaRouter registers the media routes with a signed-in writer (ID 1)
+// and saves uploads to a temporary directory.
+func newMediaRouter(t *testing.T) (router.Router, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mediaService := service.NewMediaService(repository.NewMediaRepository(db, dialect.Postgres), storage.NewLocal(t.TempDir(), "/static"))
+	handler := handlers.NewMediaHandler(mediaService, newTestRenderer(t))
+
+	writer := &models.User{ID: 1, Username: "writer", Role: models.RoleUser}
+	login := func(next router.HandlerFunc) router.HandlerFunc {
+		return func(ctx router.Context) error {
+			ctx.Set("user", writer)
+			return next(ctx)
+		}
+	}
+
+	r := router.New()
+	r.GET("/admin/media", login(handler.Library))
+	r.POST("/admin/media", login(handler.Upload))
+	r.POST("/admin/media/:id/delete", login(handler.Delete))
+
+	return r, mock
+}
+
+func uploadRequest(t *testing.T, name string, data []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	w.WriteField("alt_text", "A <red> square")
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/media", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("HX-Request", "true")
+	return req
+}
+
+func TestMediaHandler_Upload(t *testing.T) {
+	r, mock := newMediaRouter(t)
+
+	var img bytes.Buffer
+	png.Encode(&img, image.NewNRGBA(image.Rect(0, 0, 40, 20)))
+
+	mock.ExpectQuery(`SELECT (.+) FROM media WHERE owner_id = \$1 AND checksum = \$2`).
+		WithArgs(int64(1), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(mediaColumns))
+	mock.ExpectQuery(`INSERT INTO media`).
+		WithArgs(int64(1), "square.png", sqlmock.AnyArg(), "image/png", sqlmock.AnyArg(), 40, 20, "A <red> square", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, uploadRequest(t, "square.png", img.Bytes()))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`id="media-9"`,
+		"-thumb.png",
+		`alt="A &lt;red&gt; square"`,
+		"40×20",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected card to contain %q", want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMediaHandler_Upload_RejectsNonImages(t *testing.T) {
+	r, mock := newMediaRouter(t)
+
+	mock.ExpectQuery(`SELECT (.+) FROM media WHERE owner_id = \$1 AND checksum = \$2`).
+		WillReturnRows(sqlmock.NewRows(mediaColumns))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, uploadRequest(t, "page.png", []byte("<html><script>alert(1)</script></html>")))
+
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("HX-Retarget"); got != "#media-upload-error" {
+		t.Errorf("expected the error to target the upload form, got %q", got)
+	}
+}
+
+func TestMediaHandler_Delete_InUse(t *testing.T) {
+	r, mock := newMediaRouter(t)
+
+	mock.ExpectQuery(`SELECT (.+) FROM media WHERE id = \$1`).
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows(mediaColumns).
+			AddRow(9, 1, "square.png", "/uploads/2026/01/abc.png", "image/png", 100, 40, 20, "", "x", time.Now()))
+	mock.ExpectQuery(`SELECT 'post', id, title FROM posts WHERE content LIKE \$1 OR featured_image_id = \$2`).
+		WithArgs("%/uploads/2026/01/abc%", int64(9), "%/uploads/2026/01/abc%", int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "id", "title"}).AddRow("post", 3, "Hello <World>"))
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/media/9/delete", nil)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "post &#34;Hello &lt;World&gt;&#34;") {
+		t.Errorf("expected the referencing post to be named, got %s", rec.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expected nothing to be deleted: %v", err)
+	}
+}
+
+func TestMediaHandler_Library(t *testing.T) {
+	r, mock := newMediaRouter(t)
+
+	mock.ExpectQuery(`SELECT (.+) FROM media WHERE owner_id = \$1`).
+		WithArgs(int64(1), 25, 0).
+		WillReturnRows(sqlmock.NewRows(mediaColumns).
+			AddRow(9, 1, "bike.jpg", "/uploads/2026/01/abc.jpg", "image/jpeg", 2048, 1600, 1200, "A bike", "x", time.Now()))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/media", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`src="/static/uploads/2026/01/abc-thumb.jpg"`,
+		"![A bike](/static/uploads/2026/01/abc-large.jpg)",
+		"2 KB",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected library to contain %q", want)
+		}
+	}
+	if strings.Contains(body, `href="/admin/reviews"`) {
+		t.Error("expected editor links to be hidden from writers")
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-fith-renderer"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+// menuTitles names the menu locations in the admin.
+var menuTitles = map[domain.MenuLocation]string{
+	domain.MenuHeader: "Header",
+	domain.MenuFooter: "Footer",
+}
+
+// MenuHandler serves the menu builder in the admin.
+type MenuHandler struct {
+	menuService *service.MenuService
+	pageService *service.PageService
+	renderer    *fith.Engine
+}
+
+// NewMenuHandler creates a new menu handler.
+func NewMenuHandler(menuService *service.MenuService, pageService *service.PageService, renderer *fith.Engine) *MenuHandler {
+	return &MenuHandler{
+		menuService: menuService,
+		pageService: pageService,
+		renderer:    renderer,
+	}
+}
+
+// menuRow is one editable menu item. Pages lists every page with the linked
+// one selected.
+type menuRow struct {
+	Label string
+	URL   string
+	Pages []pageOption
+}
+
+// menuForm is the editor of one menu location. Blank is the row template used
+// when adding items.
+type menuForm struct {
+	Location domain.MenuLocation
+	Title    string
+	Items    []menuRow
+	Blank    menuRow
+}
+
+// Edit displays the header and footer menus.
+func (h *MenuHandler) Edit(ctx router.Context) error {
+	saved := menuTitles[domain.MenuLocation(ctx.Query("saved"))]
+	return h.render(ctx, http.StatusOK, saved, "")
+}
+
+// Save replaces the items of the menu at :location. The form posts the
+// label, page_id and url fields once per row, in display order. A row with a
+// page links to it; otherwise it is an external or site link.
+func (h *MenuHandler) Save(ctx router.Context) error {
+	location := domain.MenuLocation(ctx.Param("location"))
+	if !location.IsValid() {
+		return ctx.String(http.StatusNotFound, "Menu not found")
+	}
+
+	if err := ctx.Request().ParseForm(); err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid form data")
+	}
+
+	form := ctx.Request().PostForm
+	labels, pageIDs, urls := form["label"], form["page_id"], form["url"]
+	if len(pageIDs) != len(labels) || len(urls) != len(labels) {
+		return ctx.String(http.StatusBadRequest, "Invalid form data")
+	}
+
+	items := []*domain.MenuItem{}
+	for i := range labels {
+		item := &domain.MenuItem{
+			Label: strings.TrimSpace(labels[i]),
+			URL:   strings.TrimSpace(urls[i]),
+		}
+		if pageIDs[i] != "" {
+			id, err := strconv.ParseInt(pageIDs[i], 10, 64)
+			if err != nil {
+				return ctx.String(http.StatusBadRequest, "Invalid page ID")
+			}
+			item.PageID = &id
+		}
+		// Skip rows left empty
+		if item.PageID == nil && item.Label == "" && item.URL == "" {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if err := h.menuService.SaveMenu(ctx.Request().Context(), location, items); err != nil {
+		if errors.Is(err, service.ErrInvalidMenuItem) {
+			return h.render(ctx, http.StatusUnprocessableEntity, "", menuTitles[location]+" menu: "+err.Error())
+		}
+		log.Printf("Error saving %s menu: %v", location, err)
+		return ctx.String(http.StatusInternalServerError, "Error saving menu")
+	}
+
+	http.Redirect(ctx.Response(), ctx.Request(), "/admin/menus?saved="+string(location), http.StatusSeeOther)
+	return nil
+}
+
+func (h *MenuHandler) render(ctx router.Context, status int, saved, errMsg string) error {
+	tree, err := h.pageService.PageTree(ctx.Request().Context())
+	if err != nil {
+		log.Printf("Error loading page tree: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading pages")
+	}
+	nodes := tree.Flatten()
+
+	menus := make([]menuForm, 0, len(domain.MenuLocations))
+	for _, location := range domain.MenuLocations {
+		items, err := h.menuService.Items(ctx.Request().Context(), location)
+		if err != nil {
+			log.Printf("Error loading %s menu: %v", location, err)
+			return ctx.String(http.StatusInternalServerError, "Error loading menus")
+		}
+
+		form := menuForm{
+			Location: location,
+			Title:    menuTitles[location],
+			Items:    []menuRow{},
+			Blank:    newMenuRow(nodes, nil),
+		}
+		for _, item := range items {
+			row := newMenuRow(nodes, item.PageID)
+			row.Label = item.Label
+			row.URL = item.URL
+			form.Items = append(form.Items, row)
+		}
+		menus = append(menus, form)
+	}
+
+	data := map[string]interface{}{
+		"title": "Menus",
+		"menus": menus,
+		"saved": saved,
+		"error": errMsg,
+	}
+
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
+	html, err := h.renderer.Render("admin/menus.html", data)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	return ctx.HTML(status, html)
+}
+
+func newMenuRow(nodes []*domain.PageNode, selected *int64) menuRow {
+	row := menuRow{Pages: make([]pageOption, 0, len(nodes))}
+	for _, n := range nodes {
+		row.Pages = append(row.Pages, newPageOption(n, selected))
+	}
+	return row
+}
@@ -0,0 +1,148 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/handlers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+var menuColumns = []string{"id", "location", "label", "page_id", "url", "sort_order", "created_at"}
+
+func newMenuRouter(t *testing.T) (router.Router, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	pageService := service.NewPageService(repository.NewPageRepository(db, dialect.Postgres), nil)
+	menuService := service.NewMenuService(repository.NewMenuRepository(db, dialect.Postgres), pageService)
+	handler := handlers.NewMenuHandler(menuService, pageService, newTestRenderer(t))
+
+	r := router.New()
+	r.GET("/admin/menus", handler.Edit)
+	r.POST("/admin/menus/:location", handler.Save)
+
+	return r, mock
+}
+
+func expectMenus(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT (.+) FROM menu_items WHERE location = \$1`).
+		WithArgs(domain.MenuHeader).
+		WillReturnRows(sqlmock.NewRows(menuColumns).
+			AddRow(1, "header", "", 2, "", 0, time.Now()).
+			AddRow(2, "header", `Docs "beta"`, nil, "/docs", 1, time.Now()))
+	mock.ExpectQuery(`SELECT (.+) FROM menu_items WHERE location = \$1`).
+		WithArgs(domain.MenuFooter).
+		WillReturnRows(sqlmock.NewRows(menuColumns))
+}
+
+func TestMenuHandler_Edit(t *testing.T) {
+	r, mock := newMenuRouter(t)
+	expectPageTree(mock, time.Now())
+	expectMenus(mock)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/menus", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`action="/admin/menus/header"`,
+		`action="/admin/menus/footer"`,
+		`<option value="2" selected>— Team</option>`,
+		`value="Docs &#34;beta&#34;"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected menu builder to contain %q", want)
+		}
+	}
+}
+
+func TestMenuHandler_Save(t *testing.T) {
+	r, mock := newMenuRouter(t)
+	expectPageTree(mock, time.Now())
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM menu_items WHERE location = \$1`).
+		WithArgs(domain.MenuFooter).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO menu_items`).
+		WithArgs(domain.MenuFooter, "", int64(1), "", 0, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO menu_items`).
+		WithArgs(domain.MenuFooter, "GitHub", nil, "https://github.com/toutaio", 1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	form := url.Values{
+		"label":   {"", "", "GitHub"},
+		"page_id": {"1", "", ""},
+		"url":     {"", "", "https://github.com/toutaio"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/admin/menus/footer", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); loc != "/admin/menus?saved=footer" {
+		t.Errorf("unexpected redirect %q", loc)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMenuHandler_Save_InvalidLink(t *testing.T) {
+	r, mock := newMenuRouter(t)
+	expectPageTree(mock, time.Now())
+	// The builder is shown again with the error
+	expectPageTree(mock, time.Now())
+	expectMenus(mock)
+
+	form := url.Values{
+		"label":   {"Click me"},
+		"page_id": {""},
+		"url":     {"javascript:alert(1)"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/admin/menus/header", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Header menu: invalid menu item 1") {
+		t.Errorf("expected validation error in page, got %s", w.Body.String())
+	}
+}
+
+func TestMenuHandler_Save_UnknownLocation(t *testing.T) {
+	r, _ := newMenuRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/menus/sidebar", strings.NewReader(""))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
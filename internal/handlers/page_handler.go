@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,49 +10,97 @@ import (
 
 	router "github.com/toutaio/toutago-cosan-router"
 	"github.com/toutaio/toutago-fith-renderer"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/content"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/seo"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
 )
 
 // PageHandler handles page-related requests
 type PageHandler struct {
-	pageService *service.PageService
-	renderer    *fith.Engine
+	pageService  *service.PageService
+	mediaService *service.MediaService
+	renders      *service.RenderService
+	seo          *seo.Builder
+	renderer     *fith.Engine
 }
 
 // NewPageHandler creates a new page handler
-func NewPageHandler(pageService *service.PageService, renderer *fith.Engine) *PageHandler {
+func NewPageHandler(pageService *service.PageService, mediaService *service.MediaService, renderService *service.RenderService, seoBuilder *seo.Builder, renderer *fith.Engine) *PageHandler {
 	return &PageHandler{
-		pageService: pageService,
-		renderer:    renderer,
+		pageService:  pageService,
+		mediaService: mediaService,
+		renders:      renderService,
+		seo:          seoBuilder,
+		renderer:     renderer,
 	}
 }
 
+// pageSummary is a page as the pages listing shows it
+type pageSummary struct {
+	Title     string
+	URL       string
+	Excerpt   string
+	Published string
+}
+
 // Index displays list of pages
 func (h *PageHandler) Index(ctx router.Context) error {
-	// Parse pagination
-	page, _ := strconv.Atoi(ctx.Query("page"))
-	if page < 1 {
-		page = 1
+	// Pages of the listing are reached through cursors, e.g. /pages?after=...
+	req, err := pagination.Parse(ctx.Query("after"), ctx.Query("before"), pagination.DefaultLimit)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid page cursor")
 	}
-	perPage := 20
-	offset := (page - 1) * perPage
 
-	// Get pages
-	pages, err := h.pageService.ListPublishedPages(ctx.Request().Context(), perPage, offset)
+	result, err := h.pageService.PaginatePublishedPages(ctx.Request().Context(), req)
 	if err != nil {
 		log.Printf("Error listing pages: %v", err)
 		return ctx.String(http.StatusInternalServerError, "Error loading pages")
 	}
 
+	// Link to the nested URL so the listing doesn't go through a redirect
+	tree, err := h.pageService.PageTree(ctx.Request().Context())
+	if err != nil {
+		log.Printf("Error loading page tree: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading pages")
+	}
+
+	summaries := make([]pageSummary, 0, len(result.Items))
+	for _, page := range result.Items {
+		url := "/pages/" + page.Slug
+		if node := tree.Node(page.ID); node != nil {
+			url = node.Path
+		}
+		summary := pageSummary{
+			Title:   page.Title,
+			URL:     url,
+			Excerpt: helpers.Truncate(helpers.PlainText(page.Content), content.ExcerptLength),
+		}
+		if page.PublishedAt != nil {
+			summary.Published = page.PublishedAt.Format("January 2, 2006")
+		}
+		summaries = append(summaries, summary)
+	}
+
+	nav, err := renderPagination(h.renderer, result, ctx.Request().URL)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	meta := h.seo.ForPath("Pages", "", "/pages")
+
 	data := map[string]interface{}{
-		"title": "Pages",
-		"pages": pages,
-		"page":  page,
+		"title":      meta.Title,
+		"meta":       meta.HTML(),
+		"pages":      summaries,
+		"pagination": nav,
 	}
 
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
 	html, err := h.renderer.Render("pages/index.html", data)
 	if err != nil {
 		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
@@ -60,23 +109,79 @@ func (h *PageHandler) Index(ctx router.Context) error {
 	return ctx.HTML(http.StatusOK, html)
 }
 
-// Show displays a single page
+// Show redirects /pages/:slug to the page's nested URL
 func (h *PageHandler) Show(ctx router.Context) error {
 	slug := ctx.Param("slug")
 	if slug == "" {
 		return ctx.String(http.StatusBadRequest, "Slug is required")
 	}
 
-	page, err := h.pageService.GetPageBySlug(ctx.Request().Context(), slug)
+	// Also resolves old slugs of renamed pages
+	_, node, err := h.pageService.ResolvePath(ctx.Request().Context(), slug)
 	if err != nil {
 		return ctx.String(http.StatusNotFound, "Page not found")
 	}
 
-	data := map[string]interface{}{
-		"title": page.Title,
-		"page":  page,
+	http.Redirect(ctx.Response(), ctx.Request(), node.Path, http.StatusMovedPermanently)
+	return nil
+}
+
+// ShowPath displays a page by its nested URL, e.g. /about/team/leadership
+func (h *PageHandler) ShowPath(ctx router.Context) error {
+	path := "/" + strings.Trim(ctx.Param("path"), "/")
+
+	page, node, err := h.pageService.ResolvePath(ctx.Request().Context(), path)
+	if err != nil {
+		return ctx.String(http.StatusNotFound, "Page not found")
+	}
+
+	// Moved or renamed pages keep working at their old URLs
+	if node.Path != path {
+		http.Redirect(ctx.Response(), ctx.Request(), node.Path, http.StatusMovedPermanently)
+		return nil
 	}
 
+	featuredURL, featuredAlt := "", ""
+	if m := featuredMedia(ctx.Request().Context(), h.mediaService, page.FeaturedImageID); m != nil {
+		featuredURL = h.mediaService.URL(m, domain.VariantLarge)
+		featuredAlt = m.AltText
+	}
+
+	meta := h.seo.ForPage(page, node.Path, featuredURL)
+	body := h.renders.Get(ctx.Request().Context(), domain.ContentTypePage, page.ID, page.Content)
+
+	// Only nested pages get a breadcrumb trail
+	breadcrumbs := []domain.Breadcrumb{}
+	if node.Parent != nil {
+		breadcrumbs = node.Breadcrumbs()
+	}
+
+	children := []*domain.PageNode{}
+	for _, child := range node.Children {
+		if child.Status == domain.PageStatusPublished {
+			children = append(children, child)
+		}
+	}
+
+	published := ""
+	if page.PublishedAt != nil {
+		published = page.PublishedAt.Format("January 2, 2006")
+	}
+
+	data := map[string]interface{}{
+		"title":       meta.Title,
+		"meta":        meta.HTML(),
+		"page":        page,
+		"content":     body.HTML,
+		"toc":         body.TOC,
+		"published":   published,
+		"featuredURL": featuredURL,
+		"featuredAlt": featuredAlt,
+		"breadcrumbs": breadcrumbs,
+		"children":    children,
+	}
+
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
 	html, err := h.renderer.Render("pages/show.html", data)
 	if err != nil {
 		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
@@ -87,10 +192,20 @@ func (h *PageHandler) Show(ctx router.Context) error {
 
 // New displays form to create new page
 func (h *PageHandler) New(ctx router.Context) error {
+	parents, err := h.parentOptions(ctx, nil)
+	if err != nil {
+		log.Printf("Error loading page tree: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading pages")
+	}
+
 	data := map[string]interface{}{
-		"title": "New Page",
+		"title":            "New Page",
+		"parents":          parents,
+		"featuredImageID":  "",
+		"featuredImageURL": "",
 	}
 
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
 	html, err := h.renderer.Render("pages/new.html", data)
 	if err != nil {
 		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
@@ -120,22 +235,44 @@ func (h *PageHandler) Create(ctx router.Context) error {
 		return ctx.String(http.StatusBadRequest, "Title and content are required")
 	}
 
-	// Generate slug
-	slug := helpers.GenerateSlug(title)
+	parentID, sortOrder, err := parsePosition(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid parent page or sort order")
+	}
+
+	featuredImageID, err := featuredImageField(ctx, h.mediaService, user, nil)
+	if err != nil {
+		return featuredImageError(ctx, err)
+	}
+
+	// Use the custom slug if given, otherwise derive it from the title
+	slug := helpers.GenerateSlug(ctx.Request().FormValue("slug"))
+	if slug == "" {
+		slug = helpers.GenerateSlug(title)
+	}
 
 	// Create page
 	page := &domain.Page{
-		Title:    title,
-		Slug:     slug,
-		Content:  content,
-		AuthorID: int64(user.ID),
-		Status:   domain.PageStatus(status),
+		Title:           title,
+		Slug:            slug,
+		Content:         content,
+		AuthorID:        int64(user.ID),
+		ParentID:        parentID,
+		SortOrder:       sortOrder,
+		Status:          domain.PageStatus(status),
+		MetaTitle:       strings.TrimSpace(ctx.Request().FormValue("meta_title")),
+		MetaDesc:        strings.TrimSpace(ctx.Request().FormValue("meta_desc")),
+		FeaturedImageID: featuredImageID,
 	}
 
 	if err := h.pageService.CreatePage(ctx.Request().Context(), page); err != nil {
+		if errors.Is(err, service.ErrInvalidParent) {
+			return ctx.String(http.StatusBadRequest, "Invalid parent page")
+		}
 		log.Printf("Error creating page: %v", err)
 		return ctx.String(http.StatusInternalServerError, "Error creating page: "+err.Error())
 	}
+	cacheRender(ctx.Request().Context(), h.renders, domain.ContentTypePage, page.ID, page.Content)
 
 	http.Redirect(ctx.Response(), ctx.Request(), fmt.Sprintf("/pages/%s", page.Slug), http.StatusSeeOther)
 	return nil
@@ -154,11 +291,23 @@ func (h *PageHandler) Edit(ctx router.Context) error {
 		return ctx.String(http.StatusNotFound, "Page not found")
 	}
 
+	parents, err := h.parentOptions(ctx, page)
+	if err != nil {
+		log.Printf("Error loading page tree: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading pages")
+	}
+
+	featuredImageID, featuredImageURL := featuredImagePreview(ctx.Request().Context(), h.mediaService, page.FeaturedImageID)
+
 	data := map[string]interface{}{
-		"title": "Edit Page",
-		"page":  page,
+		"title":            "Edit Page",
+		"page":             page,
+		"parents":          parents,
+		"featuredImageID":  featuredImageID,
+		"featuredImageURL": featuredImageURL,
 	}
 
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
 	html, err := h.renderer.Render("pages/edit.html", data)
 	if err != nil {
 		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
@@ -205,23 +354,66 @@ func (h *PageHandler) Update(ctx router.Context) error {
 		return ctx.String(http.StatusBadRequest, "Title and content are required")
 	}
 
+	parentID, sortOrder, err := parsePosition(ctx)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid parent page or sort order")
+	}
+
+	featuredImageID, err := featuredImageField(ctx, h.mediaService, user, page.FeaturedImageID)
+	if err != nil {
+		return featuredImageError(ctx, err)
+	}
+
 	// Update page
 	page.Title = title
+	page.ParentID = parentID
+	page.SortOrder = sortOrder
 	page.Content = content
-	page.Slug = helpers.GenerateSlug(title)
+	page.MetaTitle = strings.TrimSpace(ctx.Request().FormValue("meta_title"))
+	page.MetaDesc = strings.TrimSpace(ctx.Request().FormValue("meta_desc"))
+	page.FeaturedImageID = featuredImageID
+	// Only change the slug when explicitly edited so existing links keep working
+	if slug := helpers.GenerateSlug(ctx.Request().FormValue("slug")); slug != "" {
+		page.Slug = slug
+	}
 	if status != "" {
 		page.Status = domain.PageStatus(status)
 	}
 
+	page.Version = editVersion(ctx, page.Version)
+
 	if err := h.pageService.UpdatePage(ctx.Request().Context(), page); err != nil {
+		if errors.Is(err, service.ErrConflict) {
+			return h.conflict(ctx, page.ID)
+		}
+		if errors.Is(err, service.ErrInvalidParent) {
+			return ctx.String(http.StatusBadRequest, "A page cannot be placed under itself or one of its subpages")
+		}
 		log.Printf("Error updating page: %v", err)
 		return ctx.String(http.StatusInternalServerError, "Error updating page")
 	}
+	cacheRender(ctx.Request().Context(), h.renders, domain.ContentTypePage, page.ID, page.Content)
 
 	http.Redirect(ctx.Response(), ctx.Request(), fmt.Sprintf("/pages/%s", page.Slug), http.StatusSeeOther)
 	return nil
 }
 
+// conflict shows the edit conflict page for a page someone else saved while
+// it was being edited
+func (h *PageHandler) conflict(ctx router.Context, id int64) error {
+	current, err := h.pageService.GetPageByID(ctx.Request().Context(), id)
+	if err != nil {
+		log.Printf("Error loading page %d: %v", id, err)
+		return ctx.String(http.StatusInternalServerError, "Error loading page")
+	}
+
+	data := map[string]interface{}{
+		"title": "Edit Conflict",
+		"page":  current,
+	}
+	return editConflictPage(ctx, h.renderer, "pages/conflict.html", data, current.Title, current.Content, current.UpdatedAt, current.Version)
+}
+
 // Delete handles page deletion
 func (h *PageHandler) Delete(ctx router.Context) error {
 	// Get authenticated user
@@ -251,6 +443,9 @@ func (h *PageHandler) Delete(ctx router.Context) error {
 		log.Printf("Error deleting page: %v", err)
 		return ctx.String(http.StatusInternalServerError, "Error deleting page")
 	}
+	if err := h.renders.Delete(ctx.Request().Context(), domain.ContentTypePage, id); err != nil {
+		log.Printf("Error deleting rendered page %d: %v", id, err)
+	}
 
 	http.Redirect(ctx.Response(), ctx.Request(), "/pages", http.StatusSeeOther)
 	return nil
@@ -289,3 +484,66 @@ func (h *PageHandler) Unpublish(ctx router.Context) error {
 	http.Redirect(ctx.Response(), ctx.Request(), fmt.Sprintf("/pages/%d/edit", id), http.StatusSeeOther)
 	return nil
 }
+
+// pageOption is an entry of a page picker, indented to show the hierarchy.
+type pageOption struct {
+	ID       int64
+	Title    string
+	Indent   string
+	Selected bool
+}
+
+// parentOptions lists the pages that can become the parent of page, which is
+// nil for a new page. The page itself and its subpages are left out.
+func (h *PageHandler) parentOptions(ctx router.Context, page *domain.Page) ([]pageOption, error) {
+	tree, err := h.pageService.PageTree(ctx.Request().Context())
+	if err != nil {
+		return nil, err
+	}
+
+	var selected *int64
+	if page != nil {
+		selected = page.ParentID
+	}
+
+	options := []pageOption{}
+	for _, n := range tree.Flatten() {
+		if page != nil && (n.ID == page.ID || tree.IsDescendant(n.ID, page.ID)) {
+			continue
+		}
+		options = append(options, newPageOption(n, selected))
+	}
+	return options, nil
+}
+
+func newPageOption(n *domain.PageNode, selected *int64) pageOption {
+	return pageOption{
+		ID:       n.ID,
+		Title:    n.Title,
+		Indent:   strings.Repeat("— ", n.Depth),
+		Selected: selected != nil && *selected == n.ID,
+	}
+}
+
+// parsePosition reads the parent_id and sort_order form fields.
+func parsePosition(ctx router.Context) (*int64, int, error) {
+	var parentID *int64
+	if raw := ctx.Request().FormValue("parent_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, 0, err
+		}
+		parentID = &id
+	}
+
+	sortOrder := 0
+	if raw := ctx.Request().FormValue("sort_order"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, 0, err
+		}
+		sortOrder = n
+	}
+
+	return parentID, sortOrder, nil
+}
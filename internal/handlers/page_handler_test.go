@@ -0,0 +1,208 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/handlers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/seo"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
+)
+
+var pageColumns = []string{
+	"id", "title", "slug", "content", "author_id", "status", "parent_id", "sort_order",
+	"meta_title", "meta_desc", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+}
+
+var pageNodeColumns = []string{"id", "parent_id", "title", "slug", "status", "sort_order", "updated_at"}
+
+func newPageRouter(t *testing.T) (router.Router, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	pageService := service.NewPageService(repository.NewPageRepository(db, dialect.Postgres), nil)
+	seoBuilder := seo.NewBuilder(config.SiteConfig{Name: "Test Site", URL: "https://example.com"})
+	mediaService := service.NewMediaService(repository.NewMediaRepository(db, dialect.Postgres), storage.NewLocal(t.TempDir(), "/static"))
+	handler := handlers.NewPageHandler(pageService, mediaService, newTestRenders(db), seoBuilder, newTestRenderer(t))
+
+	r := router.New()
+	r.GET("/pages", handler.Index)
+	r.GET("/pages/:slug", handler.Show)
+	r.GET("/*path", handler.ShowPath)
+
+	return r, mock
+}
+
+// expectPageTree expects the page tree query for /about, /about/team,
+// /about/team/leadership and the draft /about/jobs.
+func expectPageTree(mock sqlmock.Sqlmock, updated time.Time) {
+	mock.ExpectQuery(`SELECT id, parent_id, title, slug, status, sort_order, updated_at FROM pages`).
+		WillReturnRows(sqlmock.NewRows(pageNodeColumns).
+			AddRow(1, nil, "About", "about", domain.PageStatusPublished, 0, updated).
+			AddRow(2, 1, "Team", "team", domain.PageStatusPublished, 0, updated).
+			AddRow(3, 2, "Leadership", "leadership", domain.PageStatusPublished, 0, updated).
+			AddRow(4, 1, "Jobs", "jobs", domain.PageStatusDraft, 1, updated))
+}
+
+func TestPageHandler_Index(t *testing.T) {
+	r, mock := newPageRouter(t)
+	published := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM pages WHERE status = \$1 ORDER BY published_at DESC, id DESC LIMIT \$2 OFFSET \$3`).
+		WithArgs(domain.PageStatusPublished, 21, 0).
+		WillReturnRows(sqlmock.NewRows(pageColumns).
+			AddRow(1, "About", "about", "All about us.", 1, domain.PageStatusPublished, nil, 0, "", "", published, published, published, nil, 1).
+			AddRow(2, "Team", "team", "Meet the team.", 1, domain.PageStatusPublished, 1, 0, "", "", published, published, published, nil, 1))
+	expectPageTree(mock, published)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pages", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Pages link to their nested URLs. A listing that fits on one page
+	// needs no links and no count query.
+	body := w.Body.String()
+	for _, want := range []string{`href="/about"`, `href="/about/team"`, "2 in total"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, ">Next<") || strings.Contains(body, ">Previous<") {
+		t.Error("expected no page links")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPageHandler_ShowPath(t *testing.T) {
+	r, mock := newPageRouter(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	expectPageTree(mock, now)
+	mock.ExpectQuery(`SELECT (.+) FROM pages WHERE id = \$1`).
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows(pageColumns).
+			AddRow(2, "Team", "team", "Meet the team.", 1, domain.PageStatusPublished, 1, 0, "", "", now, now, now, nil, 1))
+	expectRender(mock, domain.ContentTypePage, 2)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/about/team", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`<link rel="canonical" href="https://example.com/about/team">`,
+		`<nav aria-label="breadcrumb">`,
+		`<li><a href="/about">About</a></li>`,
+		`<li>Team</li>`,
+		`<li><a href="/about/team/leadership">Leadership</a></li>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "/about/jobs") {
+		t.Error("expected draft sibling to be left out")
+	}
+}
+
+func TestPageHandler_ShowPath_EscapesTitle(t *testing.T) {
+	r, mock := newPageRouter(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	expectPageTree(mock, now)
+	mock.ExpectQuery(`SELECT (.+) FROM pages WHERE id = \$1`).
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows(pageColumns).
+			AddRow(2, "<b>Team</b>", "team", "Meet the team.", 1, domain.PageStatusPublished, 1, 0, "</title><script>alert(1)</script>", "", now, now, now, nil, 1))
+	expectRender(mock, domain.ContentTypePage, 2)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/about/team", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`<title>&lt;/title&gt;&lt;script&gt;alert(1)&lt;/script&gt; - Starter Kit Basic</title>`,
+		`<h1>&lt;b&gt;Team&lt;/b&gt;</h1>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+	for _, unwanted := range []string{"<script>alert(1)", "<b>Team</b>"} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("expected %q to be escaped", unwanted)
+		}
+	}
+}
+
+func TestPageHandler_ShowPath_RedirectsToCanonicalPath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"missing parent segment", "/team"},
+		{"old pages URL", "/pages/team"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newPageRouter(t)
+			now := time.Now()
+
+			expectPageTree(mock, now)
+			mock.ExpectQuery(`SELECT (.+) FROM pages WHERE id = \$1`).
+				WithArgs(int64(2)).
+				WillReturnRows(sqlmock.NewRows(pageColumns).
+					AddRow(2, "Team", "team", "Content", 1, domain.PageStatusPublished, 1, 0, "", "", nil, now, now, nil, 1))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != http.StatusMovedPermanently {
+				t.Fatalf("expected status 301, got %d", w.Code)
+			}
+			if loc := w.Header().Get("Location"); loc != "/about/team" {
+				t.Errorf("expected redirect to /about/team, got %q", loc)
+			}
+		})
+	}
+}
+
+func TestPageHandler_ShowPath_NotFound(t *testing.T) {
+	r, mock := newPageRouter(t)
+	expectPageTree(mock, time.Now())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/about/missing", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
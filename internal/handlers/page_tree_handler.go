@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-fith-renderer"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+// maxTreeBody bounds the size of a page tree update.
+const maxTreeBody = 1 << 20
+
+// PageTreeHandler serves the drag-and-drop page tree in the admin.
+type PageTreeHandler struct {
+	pageService *service.PageService
+	renderer    *fith.Engine
+}
+
+// NewPageTreeHandler creates a new page tree handler.
+func NewPageTreeHandler(pageService *service.PageService, renderer *fith.Engine) *PageTreeHandler {
+	return &PageTreeHandler{
+		pageService: pageService,
+		renderer:    renderer,
+	}
+}
+
+// Show displays every page in tree order.
+func (h *PageTreeHandler) Show(ctx router.Context) error {
+	tree, err := h.pageService.PageTree(ctx.Request().Context())
+	if err != nil {
+		log.Printf("Error loading page tree: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading pages")
+	}
+
+	data := map[string]interface{}{
+		"title": "Page Tree",
+		"nodes": append([]*domain.PageNode{}, tree.Flatten()...),
+	}
+
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
+	html, err := h.renderer.Render("admin/pages.html", data)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	return ctx.HTML(http.StatusOK, html)
+}
+
+// Save applies the positions posted by the page tree as a JSON array of
+// {"id", "parent_id", "sort_order"} objects.
+func (h *PageTreeHandler) Save(ctx router.Context) error {
+	body := http.MaxBytesReader(ctx.Response(), ctx.Request().Body, maxTreeBody)
+
+	var positions []domain.PagePosition
+	if err := json.NewDecoder(body).Decode(&positions); err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid page tree"})
+	}
+
+	if err := h.pageService.MovePages(ctx.Request().Context(), positions); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidParent):
+			return ctx.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "A page cannot be placed under itself or one of its subpages"})
+		case errors.Is(err, service.ErrInvalidPosition):
+			return ctx.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		}
+		log.Printf("Error moving pages: %v", err)
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "Error saving page tree"})
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]string{"status": "saved"})
+}
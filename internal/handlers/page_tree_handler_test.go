@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/handlers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+func newPageTreeRouter(t *testing.T) (router.Router, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	pageService := service.NewPageService(repository.NewPageRepository(db, dialect.Postgres), nil)
+	handler := handlers.NewPageTreeHandler(pageService, newTestRenderer(t))
+
+	r := router.New()
+	r.GET("/admin/pages", handler.Show)
+	r.POST("/admin/pages/tree", handler.Save)
+
+	return r, mock
+}
+
+func TestPageTreeHandler_Show(t *testing.T) {
+	r, mock := newPageTreeRouter(t)
+	expectPageTree(mock, time.Now())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/pages", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`data-id="3" data-depth="2"`,
+		`/about/team/leadership`,
+		`data-id="4" data-depth="1"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page tree to contain %q", want)
+		}
+	}
+}
+
+func TestPageTreeHandler_Save(t *testing.T) {
+	r, mock := newPageTreeRouter(t)
+	expectPageTree(mock, time.Now())
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE pages SET parent_id = \$1, sort_order = \$2 WHERE id = \$3`).
+		WithArgs(nil, 0, int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/pages/tree", strings.NewReader(`[{"id":4,"parent_id":null,"sort_order":0}]`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPageTreeHandler_Save_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"malformed JSON", `{`, http.StatusBadRequest},
+		{"page under its own subpage", `[{"id":1,"parent_id":3,"sort_order":0}]`, http.StatusUnprocessableEntity},
+		{"unknown page", `[{"id":99,"parent_id":null,"sort_order":0}]`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock := newPageTreeRouter(t)
+			expectPageTree(mock, time.Now())
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/pages/tree", strings.NewReader(tt.body)))
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), `"error"`) {
+				t.Errorf("expected a JSON error, got %s", w.Body.String())
+			}
+		})
+	}
+}
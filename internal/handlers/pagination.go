@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"net/url"
+
+	"github.com/toutaio/toutago-fith-renderer"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
+)
+
+// renderPagination renders the links between the pages of a listing at u
+// with the shared partials/pagination template. The engine doesn't run
+// includes, so index templates output the HTML it returns.
+func renderPagination[T any](renderer *fith.Engine, result *pagination.Result[T], u *url.URL) (string, error) {
+	return renderer.Render("partials/pagination.html", result.Links(u))
+}
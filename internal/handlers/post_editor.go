@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+// autosaveTimeLayout is how the editor remembers when the post it opened was
+// last saved, precise enough to compare with the stored time
+const autosaveTimeLayout = time.RFC3339Nano
+
+// Preview renders the editor's Markdown the way the post will show once
+// saved, for the live preview next to the editor.
+func (h *PostHandler) Preview(ctx router.Context) error {
+	if err := ctx.Request().ParseForm(); err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid form data")
+	}
+
+	md := ctx.Request().FormValue("content")
+	if strings.TrimSpace(md) == "" {
+		return ctx.HTML(http.StatusOK, `<p class="preview-empty">Nothing to preview yet.</p>`)
+	}
+
+	rc := h.renders.Preview(ctx.Request().Context(), md)
+	return ctx.HTML(http.StatusOK, rc.TOC+rc.HTML)
+}
+
+// Autosave stores the editor's unsaved work in the user's autosave slot for
+// the post, or for a new post when there is no post ID. When someone else
+// saved the post since the editor was opened, their version is returned with
+// a 409 so the changes can be merged.
+func (h *PostHandler) Autosave(ctx router.Context) error {
+	user, post, err := h.autosaveTarget(ctx)
+	if user == nil {
+		return err
+	}
+
+	if err := ctx.Request().ParseForm(); err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid form data")
+	}
+
+	draft := &domain.PostAutosave{
+		UserID:  int64(user.ID),
+		Title:   ctx.Request().FormValue("title"),
+		Content: ctx.Request().FormValue("content"),
+	}
+	if base, err := time.Parse(autosaveTimeLayout, ctx.Request().FormValue("base_updated_at")); err == nil {
+		draft.BaseUpdatedAt = &base
+	}
+
+	err = h.autosaves.Save(ctx.Request().Context(), draft, post)
+	if errors.Is(err, service.ErrEditConflict) {
+		return h.autosaveConflict(ctx, post)
+	}
+	if err != nil {
+		log.Printf("Error autosaving post %d: %v", draft.PostID, err)
+		return ctx.HTML(http.StatusInternalServerError, "Autosave failed")
+	}
+
+	return ctx.HTML(http.StatusOK, "Draft saved at "+draft.SavedAt.Format("15:04"))
+}
+
+// DiscardAutosave forgets the user's autosave of a post after they chose not
+// to recover it.
+func (h *PostHandler) DiscardAutosave(ctx router.Context) error {
+	user, post, err := h.autosaveTarget(ctx)
+	if user == nil {
+		return err
+	}
+
+	var postID int64
+	if post != nil {
+		postID = post.ID
+	}
+	if err := h.autosaves.Discard(ctx.Request().Context(), int64(user.ID), postID); err != nil {
+		log.Printf("Error discarding autosave of post %d: %v", postID, err)
+		return ctx.String(http.StatusInternalServerError, "Error discarding draft")
+	}
+
+	if isHTMX(ctx) {
+		return ctx.HTML(http.StatusOK, "")
+	}
+	target := "/posts/new"
+	if post != nil {
+		target = "/posts/" + strconv.FormatInt(post.ID, 10) + "/edit"
+	}
+	http.Redirect(ctx.Response(), ctx.Request(), target, http.StatusSeeOther)
+	return nil
+}
+
+// autosaveTarget returns the signed in user and the post being edited, which
+// is nil for a new post. A nil user means the response has been written.
+func (h *PostHandler) autosaveTarget(ctx router.Context) (*models.User, *domain.Post, error) {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok || user == nil {
+		return nil, nil, ctx.String(http.StatusUnauthorized, "Unauthorized")
+	}
+	if ctx.Param("id") == "" {
+		return user, nil, nil
+	}
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return nil, nil, ctx.String(http.StatusBadRequest, "Invalid post ID")
+	}
+
+	post, err := h.postService.GetPostByID(ctx.Request().Context(), id)
+	if err != nil {
+		return nil, nil, ctx.String(http.StatusNotFound, "Post not found")
+	}
+	if post.AuthorID != int64(user.ID) && user.Role != models.RoleAdmin {
+		return nil, nil, ctx.String(http.StatusForbidden, "You don't have permission to edit this post")
+	}
+
+	return user, post, nil
+}
+
+// autosaveConflict shows the version of a post someone else saved in the
+// editor's conflict area
+func (h *PostHandler) autosaveConflict(ctx router.Context, post *domain.Post) error {
+	out, err := h.renderer.Render("partials/post-conflict.html", map[string]interface{}{
+		"updatedAt":    post.UpdatedAt.Format(autosaveTimeLayout),
+		"updatedLabel": post.UpdatedAt.Format("January 2, 2006 at 15:04"),
+		"title":        post.Title,
+		"content":      post.Content,
+	})
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	ctx.Response().Header().Set("HX-Retarget", "#autosave-conflict")
+	return ctx.HTML(http.StatusConflict, out)
+}
+
+// recoveryData adds the user's recoverable autosave of post, or of a new post
+// when post is nil, to the editor's template data
+func (h *PostHandler) recoveryData(ctx router.Context, user *models.User, post *domain.Post, data map[string]interface{}) {
+	data["hasDraft"] = false
+	data["draftSaved"], data["draftTitle"], data["draftContent"] = "", "", ""
+
+	draft, err := h.autosaves.Recover(ctx.Request().Context(), int64(user.ID), post)
+	if err != nil {
+		log.Printf("Error loading autosave: %v", err)
+	}
+	if draft == nil {
+		return
+	}
+
+	data["hasDraft"] = true
+	data["draftSaved"] = draft.SavedAt.Format("January 2, 2006 at 15:04")
+	data["draftTitle"] = draft.Title
+	data["draftContent"] = draft.Content
+}
+
+// discardAutosave forgets the autosave a save has made obsolete
+func (h *PostHandler) discardAutosave(ctx router.Context, user *models.User, postID int64) {
+	if err := h.autosaves.Discard(ctx.Request().Context(), int64(user.ID), postID); err != nil {
+		log.Printf("Error discarding autosave of post %d: %v", postID, err)
+	}
+}
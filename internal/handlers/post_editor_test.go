@@ -0,0 +1,218 @@
+package handlers_test
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+var autosaveColumns = []string{"user_id", "post_id", "title", "content", "base_updated_at", "saved_at"}
+
+func TestPostHandler_Preview(t *testing.T) {
+	r, _ := newPostRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, postForm("/posts/preview", url.Values{
+		"content": {"## Intro\n\nSome **bold** text<script>alert(1)</script>\n\n```go\nfunc main() {}\n```"},
+	}))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{`<h2 id="intro">Intro</h2>`, "<strong>bold</strong>", `class="chroma"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected preview to contain %q, got %s", want, body)
+		}
+	}
+	if strings.Contains(body, "<script") {
+		t.Error("expected the preview to be sanitized")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, postForm("/posts/preview", url.Values{"content": {"  "}}))
+	if !strings.Contains(w.Body.String(), "Nothing to preview yet.") {
+		t.Errorf("expected an empty preview message, got %s", w.Body.String())
+	}
+}
+
+func TestPostHandler_Autosave(t *testing.T) {
+	r, mock := newPostRouter(t)
+	opened := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	post := []driver.Value{3, "Hello", "hello", "Saved body", 1, domain.PostStatusDraft, "", "", false, nil, nil, opened, opened, nil, 1}
+
+	t.Run("new post", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO post_autosaves`).
+			WithArgs(int64(1), int64(0), "Draft title", "Draft body", nil, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, postForm("/posts/autosave", url.Values{"title": {"Draft title"}, "content": {"Draft body"}}))
+
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "Draft saved at ") {
+			t.Errorf("expected the draft to be saved, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("unchanged post", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM posts WHERE id = \$1`).
+			WithArgs(int64(3)).
+			WillReturnRows(sqlmock.NewRows(postColumns).AddRow(post...))
+		mock.ExpectExec(`INSERT INTO post_autosaves`).
+			WithArgs(int64(1), int64(3), "Hello", "Edited body", opened, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, postForm("/posts/3/autosave", url.Values{
+			"title":           {"Hello"},
+			"content":         {"Edited body"},
+			"base_updated_at": {opened.Format(time.RFC3339Nano)},
+		}))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("saved by someone else", func(t *testing.T) {
+		later := opened.Add(time.Hour)
+		mock.ExpectQuery(`SELECT (.+) FROM posts WHERE id = \$1`).
+			WithArgs(int64(3)).
+			WillReturnRows(sqlmock.NewRows(postColumns).
+				AddRow(3, "Hello", "hello", "Their <b>body</b>", 1, domain.PostStatusDraft, "", "", false, nil, nil, opened, later, nil, 1))
+		mock.ExpectExec(`INSERT INTO post_autosaves`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		req := postForm("/posts/3/autosave", url.Values{
+			"title":           {"Hello"},
+			"content":         {"My body"},
+			"base_updated_at": {opened.Format(time.RFC3339Nano)},
+		})
+		req.Header.Set("HX-Request", "true")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("HX-Retarget"); got != "#autosave-conflict" {
+			t.Errorf("expected the conflict area to be targeted, got %q", got)
+		}
+		body := w.Body.String()
+		for _, want := range []string{"Their &lt;b&gt;body&lt;/b&gt;", `data-updated-at="` + later.Format(time.RFC3339Nano) + `"`} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected conflict to contain %q, got %s", want, body)
+			}
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPostHandler_Edit_OffersRecovery(t *testing.T) {
+	r, mock := newPostRouter(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE id = \$1`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(3, "Hello", "hello", "Saved body", 1, domain.PostStatusDraft, "", "", false, nil, nil, now, now, nil, 1))
+	mock.ExpectQuery(`SELECT (.+) FROM post_categories`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "id", "name", "slug", "created_at"}))
+	mock.ExpectQuery(`SELECT (.+) FROM post_tags`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "id", "name", "slug", "created_at"}))
+	mock.ExpectQuery(`SELECT (.+) FROM post_autosaves WHERE user_id = \$1 AND post_id = \$2`).
+		WithArgs(int64(1), int64(3)).
+		WillReturnRows(sqlmock.NewRows(autosaveColumns).AddRow(1, 3, "Hello", "Unsaved <em>work</em>", now, now.Add(time.Minute)))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/3/edit", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"You have unsaved changes from January 2, 2026 at 03:05.",
+		"Unsaved &lt;em&gt;work&lt;/em&gt;",
+		`hx-post="/posts/3/autosave/discard"`,
+		`name="base_updated_at" value="` + now.Format(time.RFC3339Nano) + `"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected editor to contain %q", want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPostHandler_New_WithoutAutosave(t *testing.T) {
+	r, mock := newPostRouter(t)
+
+	mock.ExpectQuery(`SELECT (.+) FROM post_autosaves`).
+		WithArgs(int64(1), int64(0)).
+		WillReturnRows(sqlmock.NewRows(autosaveColumns))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/new", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "autosave-recovery") {
+		t.Error("expected no recovery prompt without an autosave")
+	}
+	if !strings.Contains(w.Body.String(), `hx-post="/posts/autosave"`) {
+		t.Error("expected the new post editor to autosave")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPostHandler_Edit_EscapesFields(t *testing.T) {
+	r, mock := newPostRouter(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE id = \$1`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(3, `"><script>t</script>`, "hello", "Body", 1, domain.PostStatusDraft, `"><script>mt</script>`, `</textarea><script>md</script>`, false, nil, nil, now, now, nil, 1))
+	mock.ExpectQuery(`SELECT (.+) FROM post_categories`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "id", "name", "slug", "created_at"}).
+			AddRow(3, 1, `"><script>c</script>`, "c", now))
+	mock.ExpectQuery(`SELECT (.+) FROM post_tags`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "id", "name", "slug", "created_at"}).
+			AddRow(3, 2, `"><script>g</script>`, "g", now))
+	mock.ExpectQuery(`SELECT (.+) FROM post_autosaves`).
+		WillReturnRows(sqlmock.NewRows(autosaveColumns))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/3/edit", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, raw := range []string{"<script>t</script>", "<script>mt</script>", "<script>md</script>", "<script>c</script>", "<script>g</script>"} {
+		if strings.Contains(body, raw) {
+			t.Errorf("expected %q to be escaped in the editor", raw)
+		}
+	}
+	if !strings.Contains(body, "&lt;script&gt;mt&lt;/script&gt;") {
+		t.Error("expected the escaped meta title in the editor")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,47 +13,99 @@ import (
 	"github.com/toutaio/toutago-fith-renderer"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repositories"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/seo"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
 )
 
 // PostHandler handles post-related requests
 type PostHandler struct {
-	postService *service.PostService
-	renderer    *fith.Engine
+	postService     *service.PostService
+	reviewService   *service.ReviewService
+	commentService  *service.CommentService
+	taxonomyService *service.TaxonomyService
+	mediaService    *service.MediaService
+	renders         *service.RenderService
+	autosaves       *service.AutosaveService
+	userRepo        repositories.UserRepository
+	seo             *seo.Builder
+	renderer        *fith.Engine
 }
 
 // NewPostHandler creates a new post handler
-func NewPostHandler(postService *service.PostService, renderer *fith.Engine) *PostHandler {
+func NewPostHandler(postService *service.PostService, reviewService *service.ReviewService, commentService *service.CommentService, taxonomyService *service.TaxonomyService, mediaService *service.MediaService, renderService *service.RenderService, autosaveService *service.AutosaveService, userRepo repositories.UserRepository, seoBuilder *seo.Builder, renderer *fith.Engine) *PostHandler {
 	return &PostHandler{
-		postService: postService,
-		renderer:    renderer,
+		postService:     postService,
+		reviewService:   reviewService,
+		commentService:  commentService,
+		taxonomyService: taxonomyService,
+		mediaService:    mediaService,
+		renders:         renderService,
+		autosaves:       autosaveService,
+		userRepo:        userRepo,
+		seo:             seoBuilder,
+		renderer:        renderer,
 	}
 }
 
 // Index displays list of posts
 func (h *PostHandler) Index(ctx router.Context) error {
-	// Parse pagination
-	page, _ := strconv.Atoi(ctx.Query("page"))
-	if page < 1 {
-		page = 1
-	}
-	perPage := 20
-	offset := (page - 1) * perPage
-
-	// Get posts
-	posts, err := h.postService.ListPublishedPosts(ctx.Request().Context(), perPage, offset)
+	// Pages are reached through cursors, e.g. /posts?after=...
+	req, err := pagination.Parse(ctx.Query("after"), ctx.Query("before"), pagination.DefaultLimit)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid page cursor")
+	}
+
+	// Listings can be narrowed to a category or tag, e.g. /posts?tag=go
+	rctx := ctx.Request().Context()
+	heading := "Posts"
+	var filter domain.PostFilter
+	switch {
+	case ctx.Query("category") != "":
+		category, lookupErr := h.taxonomyService.GetCategoryBySlug(rctx, ctx.Query("category"))
+		if lookupErr != nil {
+			return termLookupError(ctx, "Category", lookupErr)
+		}
+		heading, filter.CategoryID = "Posts in "+category.Name, category.ID
+	case ctx.Query("tag") != "":
+		tag, lookupErr := h.taxonomyService.GetTagBySlug(rctx, ctx.Query("tag"))
+		if lookupErr != nil {
+			return termLookupError(ctx, "Tag", lookupErr)
+		}
+		heading, filter.TagID = "Posts tagged "+tag.Name, tag.ID
+	}
+
+	result, err := h.postService.PaginatePublishedPosts(rctx, filter, req)
 	if err != nil {
 		log.Printf("Error listing posts: %v", err)
 		return ctx.String(http.StatusInternalServerError, "Error loading posts")
 	}
 
+	rendered := renderPosts(rctx, h.renders, result.Items)
+	summaries := make([]postSummary, 0, len(result.Items))
+	for _, post := range result.Items {
+		summaries = append(summaries, summarize(post, rendered[post.ID]))
+	}
+
+	nav, err := renderPagination(h.renderer, result, ctx.Request().URL)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	meta := h.seo.ForPath(heading, "", "/posts")
+
 	data := map[string]interface{}{
-		"title": "Posts",
-		"posts": posts,
-		"page":  page,
+		"title":      meta.Title,
+		"meta":       meta.HTML(),
+		"heading":    heading,
+		"posts":      summaries,
+		"pagination": nav,
 	}
 
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
 	html, err := h.renderer.Render("posts/index.html", data)
 	if err != nil {
 		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
@@ -69,14 +123,71 @@ func (h *PostHandler) Show(ctx router.Context) error {
 
 	post, err := h.postService.GetPostBySlug(ctx.Request().Context(), slug)
 	if err != nil {
+		// Redirect old URLs of renamed posts to their current slug
+		if moved, lookupErr := h.postService.GetPostByPreviousSlug(ctx.Request().Context(), slug); lookupErr == nil {
+			http.Redirect(ctx.Response(), ctx.Request(), fmt.Sprintf("/posts/%s", moved.Slug), http.StatusMovedPermanently)
+			return nil
+		}
 		return ctx.String(http.StatusNotFound, "Post not found")
 	}
 
-	data := map[string]interface{}{
-		"title": post.Title,
-		"post":  post,
+	// The featured image heads the post and is what link previews show
+	featuredURL, featuredAlt := "", ""
+	if m := featuredMedia(ctx.Request().Context(), h.mediaService, post.FeaturedImageID); m != nil {
+		featuredURL = h.mediaService.URL(m, domain.VariantLarge)
+		featuredAlt = m.AltText
+	}
+
+	// An unknown author is left out of the metadata rather than failing the page
+	author := ""
+	if user, err := h.userRepo.FindByID(int(post.AuthorID)); err == nil && user != nil {
+		author = user.FullName()
+	}
+
+	meta := h.seo.ForPost(post, author, featuredURL)
+	body := h.renders.Get(ctx.Request().Context(), domain.ContentTypePost, post.ID, post.Content)
+
+	published := ""
+	if post.PublishedAt != nil {
+		published = post.PublishedAt.Format("January 2, 2006")
+	}
+
+	// Comments are only taken on published posts. A failure to load them
+	// shouldn't take the post down with it.
+	open := post.Status == domain.PostStatusPublished
+	comments := []commentView{}
+	if open {
+		list, err := h.commentService.ListApproved(ctx.Request().Context(), post.ID)
+		if err != nil {
+			log.Printf("Error loading comments for post %d: %v", post.ID, err)
+		}
+		comments = commentViews(list)
 	}
 
+	user, _ := ctx.Get("user").(*models.User)
+	userName := ""
+	if user != nil {
+		userName = user.Username
+	}
+
+	data := map[string]interface{}{
+		"title":        meta.Title,
+		"meta":         meta.HTML(),
+		"post":         post,
+		"content":      body.HTML,
+		"toc":          body.TOC,
+		"readingTime":  body.ReadingTime,
+		"published":    published,
+		"featuredURL":  featuredURL,
+		"featuredAlt":  featuredAlt,
+		"comments":     comments,
+		"commentCount": len(comments),
+		"commentsOpen": open,
+		"canComment":   user != nil || h.commentService.AllowAnonymous(),
+		"userName":     userName,
+	}
+
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
 	html, err := h.renderer.Render("posts/show.html", data)
 	if err != nil {
 		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
@@ -87,10 +198,22 @@ func (h *PostHandler) Show(ctx router.Context) error {
 
 // New displays form to create new post
 func (h *PostHandler) New(ctx router.Context) error {
+	user, _ := ctx.Get("user").(*models.User)
+
 	data := map[string]interface{}{
-		"title": "New Post",
+		"title":            "New Post",
+		"featuredImageID":  "",
+		"featuredImageURL": "",
+		"canFeature":       user != nil && user.IsEditor(),
+		"featuredChecked":  "",
+	}
+	if user != nil {
+		h.recoveryData(ctx, user, nil, data)
+	} else {
+		data["hasDraft"] = false
 	}
 
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
 	html, err := h.renderer.Render("posts/new.html", data)
 	if err != nil {
 		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
@@ -120,16 +243,30 @@ func (h *PostHandler) Create(ctx router.Context) error {
 		return ctx.String(http.StatusBadRequest, "Title and content are required")
 	}
 
-	// Generate slug
-	slug := helpers.GenerateSlug(title)
+	featuredImageID, err := featuredImageField(ctx, h.mediaService, user, nil)
+	if err != nil {
+		return featuredImageError(ctx, err)
+	}
+
+	// Use the custom slug if given, otherwise derive it from the title
+	slug := helpers.GenerateSlug(ctx.Request().FormValue("slug"))
+	if slug == "" {
+		slug = helpers.GenerateSlug(title)
+	}
 
-	// Create post
+	// Create post. New posts start as drafts; any other status is reached
+	// through the editorial workflow below. Only editors choose what the
+	// home page features.
 	post := &domain.Post{
-		Title:    title,
-		Slug:     slug,
-		Content:  content,
-		AuthorID: int64(user.ID),
-		Status:   domain.PostStatus(status),
+		Title:           title,
+		Slug:            slug,
+		Content:         content,
+		AuthorID:        int64(user.ID),
+		Status:          domain.PostStatusDraft,
+		MetaTitle:       strings.TrimSpace(ctx.Request().FormValue("meta_title")),
+		MetaDesc:        strings.TrimSpace(ctx.Request().FormValue("meta_desc")),
+		FeaturedImageID: featuredImageID,
+		IsFeatured:      user.IsEditor() && ctx.Request().FormValue("is_featured") != "",
 	}
 
 	if err := h.postService.CreatePost(ctx.Request().Context(), post); err != nil {
@@ -137,12 +274,35 @@ func (h *PostHandler) Create(ctx router.Context) error {
 		return ctx.String(http.StatusInternalServerError, "Error creating post: "+err.Error())
 	}
 
+	if err := h.saveTerms(ctx, post.ID); err != nil {
+		log.Printf("Error saving post categories and tags: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error saving categories and tags")
+	}
+	cacheRender(ctx.Request().Context(), h.renders, domain.ContentTypePost, post.ID, post.Content)
+	h.discardAutosave(ctx, user, 0)
+
+	if to := domain.PostStatus(status); to != "" && to != domain.PostStatusDraft {
+		if _, err := h.reviewService.Transition(ctx.Request().Context(), post.ID, actorFor(user), to, ""); err != nil {
+			if errors.Is(err, service.ErrNotAllowed) {
+				return ctx.String(http.StatusForbidden, "The post was saved as a draft, but you don't have permission to change its status")
+			}
+			log.Printf("Error changing post status: %v", err)
+			return ctx.String(http.StatusInternalServerError, "Error changing post status")
+		}
+	}
+
 	http.Redirect(ctx.Response(), ctx.Request(), fmt.Sprintf("/posts/%s", post.Slug), http.StatusSeeOther)
 	return nil
 }
 
 // Edit displays form to edit post
 func (h *PostHandler) Edit(ctx router.Context) error {
+	// Get authenticated user
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok || user == nil {
+		return ctx.String(http.StatusUnauthorized, "Unauthorized")
+	}
+
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -154,11 +314,34 @@ func (h *PostHandler) Edit(ctx router.Context) error {
 		return ctx.String(http.StatusNotFound, "Post not found")
 	}
 
-	data := map[string]interface{}{
-		"title": "Edit Post",
-		"post":  post,
+	// Check authorization
+	if post.AuthorID != int64(user.ID) && user.Role != models.RoleAdmin {
+		return ctx.String(http.StatusForbidden, "You don't have permission to edit this post")
 	}
 
+	categories, tags, err := h.termNames(ctx, post)
+	if err != nil {
+		log.Printf("Error loading post categories and tags: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading post")
+	}
+
+	featuredImageID, featuredImageURL := featuredImagePreview(ctx.Request().Context(), h.mediaService, post.FeaturedImageID)
+
+	data := map[string]interface{}{
+		"title":            "Edit Post",
+		"post":             post,
+		"status":           statusLabels[post.Status],
+		"categories":       categories,
+		"tags":             tags,
+		"featuredImageID":  featuredImageID,
+		"featuredImageURL": featuredImageURL,
+		"canFeature":       user.IsEditor(),
+		"featuredChecked":  checkedAttr(post.IsFeatured),
+		"autosaveBase":     post.UpdatedAt.Format(autosaveTimeLayout),
+	}
+	h.recoveryData(ctx, user, post, data)
+
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
 	html, err := h.renderer.Render("posts/edit.html", data)
 	if err != nil {
 		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
@@ -199,29 +382,72 @@ func (h *PostHandler) Update(ctx router.Context) error {
 
 	title := strings.TrimSpace(ctx.Request().FormValue("title"))
 	content := strings.TrimSpace(ctx.Request().FormValue("content"))
-	status := ctx.Request().FormValue("status")
 
 	if title == "" || content == "" {
 		return ctx.String(http.StatusBadRequest, "Title and content are required")
 	}
 
-	// Update post
+	featuredImageID, err := featuredImageField(ctx, h.mediaService, user, post.FeaturedImageID)
+	if err != nil {
+		return featuredImageError(ctx, err)
+	}
+
+	// Update post. The status only changes through the editorial workflow.
 	post.Title = title
 	post.Content = content
-	post.Slug = helpers.GenerateSlug(title)
-	if status != "" {
-		post.Status = domain.PostStatus(status)
+	post.MetaTitle = strings.TrimSpace(ctx.Request().FormValue("meta_title"))
+	post.MetaDesc = strings.TrimSpace(ctx.Request().FormValue("meta_desc"))
+	post.FeaturedImageID = featuredImageID
+	if user.IsEditor() {
+		post.IsFeatured = ctx.Request().FormValue("is_featured") != ""
 	}
+	// Only change the slug when explicitly edited so existing links keep working
+	if slug := helpers.GenerateSlug(ctx.Request().FormValue("slug")); slug != "" {
+		post.Slug = slug
+	}
+	// An approval covers the text that was reviewed, so authors editing an
+	// approved post send it back to draft
+	if post.Status == domain.PostStatusApproved && !user.IsEditor() {
+		post.Status = domain.PostStatusDraft
+	}
+
+	post.Version = editVersion(ctx, post.Version)
 
 	if err := h.postService.UpdatePost(ctx.Request().Context(), post); err != nil {
+		if errors.Is(err, service.ErrConflict) {
+			return h.conflict(ctx, post.ID)
+		}
 		log.Printf("Error updating post: %v", err)
 		return ctx.String(http.StatusInternalServerError, "Error updating post")
 	}
 
+	if err := h.saveTerms(ctx, post.ID); err != nil {
+		log.Printf("Error saving post categories and tags: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error saving categories and tags")
+	}
+	cacheRender(ctx.Request().Context(), h.renders, domain.ContentTypePost, post.ID, post.Content)
+	h.discardAutosave(ctx, user, post.ID)
+
 	http.Redirect(ctx.Response(), ctx.Request(), fmt.Sprintf("/posts/%s", post.Slug), http.StatusSeeOther)
 	return nil
 }
 
+// conflict shows the edit conflict page for a post someone else saved while
+// it was being edited
+func (h *PostHandler) conflict(ctx router.Context, id int64) error {
+	current, err := h.postService.GetPostByID(ctx.Request().Context(), id)
+	if err != nil {
+		log.Printf("Error loading post %d: %v", id, err)
+		return ctx.String(http.StatusInternalServerError, "Error loading post")
+	}
+
+	data := map[string]interface{}{
+		"title": "Edit Conflict",
+		"post":  current,
+	}
+	return editConflictPage(ctx, h.renderer, "posts/conflict.html", data, current.Title, current.Content, current.UpdatedAt, current.Version)
+}
+
 // Delete handles post deletion
 func (h *PostHandler) Delete(ctx router.Context) error {
 	// Get authenticated user
@@ -251,41 +477,109 @@ func (h *PostHandler) Delete(ctx router.Context) error {
 		log.Printf("Error deleting post: %v", err)
 		return ctx.String(http.StatusInternalServerError, "Error deleting post")
 	}
+	if err := h.renders.Delete(ctx.Request().Context(), domain.ContentTypePost, id); err != nil {
+		log.Printf("Error deleting rendered post %d: %v", id, err)
+	}
+	if err := h.autosaves.DiscardPost(ctx.Request().Context(), id); err != nil {
+		log.Printf("Error deleting autosaves of post %d: %v", id, err)
+	}
 
 	http.Redirect(ctx.Response(), ctx.Request(), "/posts", http.StatusSeeOther)
 	return nil
 }
 
-// Publish handles publishing a post
+// Publish handles publishing a post. Only editors and admins can publish.
 func (h *PostHandler) Publish(ctx router.Context) error {
+	return h.changeStatus(ctx, domain.PostStatusPublished, "publishing")
+}
+
+// Unpublish handles unpublishing a post, moving it back to draft
+func (h *PostHandler) Unpublish(ctx router.Context) error {
+	return h.changeStatus(ctx, domain.PostStatusDraft, "unpublishing")
+}
+
+// changeStatus moves a post through the editorial workflow on behalf of an
+// editor and returns to the edit form
+func (h *PostHandler) changeStatus(ctx router.Context, to domain.PostStatus, action string) error {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok || user == nil {
+		return ctx.String(http.StatusUnauthorized, "Unauthorized")
+	}
+	if !middleware.CanPublish(user.Role) {
+		return ctx.String(http.StatusForbidden, "Only editors can publish posts")
+	}
+
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
 		return ctx.String(http.StatusBadRequest, "Invalid post ID")
 	}
 
-	if err := h.postService.PublishPost(ctx.Request().Context(), id); err != nil {
-		log.Printf("Error publishing post: %v", err)
-		return ctx.String(http.StatusInternalServerError, "Error publishing post")
+	if _, err := h.reviewService.Transition(ctx.Request().Context(), id, actorFor(user), to, ""); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ctx.String(http.StatusNotFound, "Post not found")
+		case errors.Is(err, service.ErrNotAllowed):
+			return ctx.String(http.StatusConflict, "This post can't be changed to "+string(to)+" from its current status")
+		case errors.Is(err, service.ErrConflict):
+			return ctx.String(http.StatusConflict, "This post was changed by someone else, please try again")
+		}
+		log.Printf("Error %s post: %v", action, err)
+		return ctx.String(http.StatusInternalServerError, "Error "+action+" post")
 	}
 
 	http.Redirect(ctx.Response(), ctx.Request(), fmt.Sprintf("/posts/%d/edit", id), http.StatusSeeOther)
 	return nil
 }
 
-// Unpublish handles unpublishing a post
-func (h *PostHandler) Unpublish(ctx router.Context) error {
-	idStr := ctx.Param("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+// checkedAttr returns the checked attribute for a checkbox that is on
+func checkedAttr(on bool) string {
+	if on {
+		return "checked"
+	}
+	return ""
+}
+
+// termLookupError responds to a failed category or tag lookup in a listing filter
+func termLookupError(ctx router.Context, kind string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.String(http.StatusNotFound, kind+" not found")
+	}
+	log.Printf("Error loading %s: %v", strings.ToLower(kind), err)
+	return ctx.String(http.StatusInternalServerError, "Error loading posts")
+}
+
+// saveTerms assigns the comma-separated categories and tags from the form to a post
+func (h *PostHandler) saveTerms(ctx router.Context, postID int64) error {
+	categories := service.ParseTerms(ctx.Request().FormValue("categories"))
+	if err := h.taxonomyService.SetPostCategories(ctx.Request().Context(), postID, categories); err != nil {
+		return err
+	}
+
+	tags := service.ParseTerms(ctx.Request().FormValue("tags"))
+	return h.taxonomyService.SetPostTags(ctx.Request().Context(), postID, tags)
+}
+
+// termNames returns a post's categories and tags as comma-separated lists for the edit form
+func (h *PostHandler) termNames(ctx router.Context, post *domain.Post) (string, string, error) {
+	posts := []*domain.Post{post}
+
+	categories, err := h.taxonomyService.CategoriesForPosts(ctx.Request().Context(), posts)
 	if err != nil {
-		return ctx.String(http.StatusBadRequest, "Invalid post ID")
+		return "", "", err
+	}
+	tags, err := h.taxonomyService.TagsForPosts(ctx.Request().Context(), posts)
+	if err != nil {
+		return "", "", err
 	}
 
-	if err := h.postService.UnpublishPost(ctx.Request().Context(), id); err != nil {
-		log.Printf("Error unpublishing post: %v", err)
-		return ctx.String(http.StatusInternalServerError, "Error unpublishing post")
+	var categoryNames, tagNames []string
+	for _, category := range categories[post.ID] {
+		categoryNames = append(categoryNames, category.Name)
+	}
+	for _, tag := range tags[post.ID] {
+		tagNames = append(tagNames, tag.Name)
 	}
 
-	http.Redirect(ctx.Response(), ctx.Request(), fmt.Sprintf("/posts/%d/edit", id), http.StatusSeeOther)
-	return nil
+	return strings.Join(categoryNames, ", "), strings.Join(tagNames, ", "), nil
 }
@@ -0,0 +1,468 @@
+package handlers_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-fith-renderer"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/content"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/handlers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repositories"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/seo"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
+)
+
+// newTestRenderer loads the real templates with a fixed header menu in place
+// of the database-backed menu function.
+func newTestRenderer(t *testing.T) *fith.Engine {
+	t.Helper()
+
+	renderer, err := fith.New(&fith.Config{TemplateDir: "../../templates"})
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+	renderer.RegisterFunction("menu", func(args ...interface{}) (interface{}, error) {
+		if args[0] == string(domain.MenuHeader) {
+			return []domain.MenuLink{{Label: "Home", URL: "/"}, {Label: "About", URL: "/about"}}, nil
+		}
+		return []domain.MenuLink{}, nil
+	})
+
+	return renderer
+}
+
+// newTestPipeline renders content the way the server does, minus the
+// shortcodes that need services.
+func newTestPipeline() *content.Pipeline {
+	p := content.New(content.Options{UploadsURL: "/static/uploads"})
+	p.Register("youtube", content.YouTube)
+	return p
+}
+
+// newTestRenders caches renders from newTestPipeline in the mocked database
+func newTestRenders(db *sql.DB) *service.RenderService {
+	return service.NewRenderService(repository.NewRenderRepository(db, dialect.Postgres), newTestPipeline())
+}
+
+// expectRender expects a post or page without a cached render to be
+// rendered and cached when shown
+func expectRender(mock sqlmock.Sqlmock, contentType string, id int64) {
+	mock.ExpectQuery(`SELECT (.+) FROM rendered_content WHERE content_type = \$1 AND content_id = \$2`).
+		WithArgs(contentType, id).
+		WillReturnRows(sqlmock.NewRows(renderColumns))
+	mock.ExpectExec(`INSERT INTO rendered_content`).
+		WithArgs(contentType, id, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), content.Version, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectRenderList expects listed posts or pages without cached renders to
+// be rendered and cached
+func expectRenderList(mock sqlmock.Sqlmock, contentType string, ids ...int64) {
+	mock.ExpectQuery(`SELECT (.+) FROM rendered_content WHERE content_type = \$1 AND content_id IN`).
+		WillReturnRows(sqlmock.NewRows(renderColumns))
+	for _, id := range ids {
+		mock.ExpectExec(`INSERT INTO rendered_content`).
+			WithArgs(contentType, id, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), content.Version, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
+func newPostRouter(t *testing.T) (router.Router, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	renderer := newTestRenderer(t)
+
+	users := repositories.NewMemoryUserRepository()
+	if err := users.Create(&models.User{Username: "writer", Email: "writer@example.com", FirstName: "Ada", LastName: "Lovelace"}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	postService := service.NewPostService(repository.NewPostRepository(db, dialect.Postgres), nil)
+	reviewService := service.NewReviewService(postService, repository.NewReviewRepository(db, dialect.Postgres), repository.NewNotificationRepository(db, dialect.Postgres))
+	commentService := service.NewCommentService(repository.NewCommentRepository(db, dialect.Postgres), postService, config.CommentsConfig{AllowAnonymous: true, RequireApproval: true, MaxDepth: 3})
+	taxonomyService := service.NewTaxonomyService(repository.NewTaxonomyRepository(db, dialect.Postgres))
+	seoBuilder := seo.NewBuilder(config.SiteConfig{Name: "Test Site", URL: "https://example.com"})
+	mediaService := service.NewMediaService(repository.NewMediaRepository(db, dialect.Postgres), storage.NewLocal(t.TempDir(), "/static"))
+	handler := handlers.NewPostHandler(postService, reviewService, commentService, taxonomyService, mediaService, newTestRenders(db), service.NewAutosaveService(repository.NewAutosaveRepository(db, dialect.Postgres)), users, seoBuilder, renderer)
+
+	writer := &models.User{ID: 1, Username: "writer", Role: models.RoleUser}
+	login := func(next router.HandlerFunc) router.HandlerFunc {
+		return func(ctx router.Context) error {
+			ctx.Set("user", writer)
+			return next(ctx)
+		}
+	}
+
+	r := router.New()
+	r.GET("/posts", handler.Index)
+	r.POST("/posts", login(handler.Create))
+	r.GET("/posts/new", login(handler.New))
+	r.POST("/posts/preview", login(handler.Preview))
+	r.POST("/posts/autosave", login(handler.Autosave))
+	r.GET("/posts/:slug", handler.Show)
+	r.POST("/posts/:id", login(handler.Update))
+	r.GET("/posts/:id/edit", login(handler.Edit))
+	r.POST("/posts/:id/autosave", login(handler.Autosave))
+
+	return r, mock
+}
+
+func TestPostHandler_Show_RendersSEOMetadata(t *testing.T) {
+	r, mock := newPostRouter(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE slug = \$1`).
+		WithArgs("hello").
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(1, "Hello", "hello", "Welcome to the blog.", 1, domain.PostStatusPublished, "Hello & Welcome", "", false, nil, now, now, now, nil, 1))
+	expectRender(mock, domain.ContentTypePost, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM comments WHERE post_id = \$1 AND status = \$2`).
+		WithArgs(int64(1), domain.CommentStatusApproved).
+		WillReturnRows(sqlmock.NewRows(commentColumns))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/hello", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`<meta name="description" content="Welcome to the blog.">`,
+		`<link rel="canonical" href="https://example.com/posts/hello">`,
+		`<meta property="og:title" content="Hello &amp; Welcome">`,
+		`<meta property="og:type" content="article">`,
+		`"@type":"BlogPosting"`,
+		`"author":{"@type":"Person","name":"Ada Lovelace"}`,
+		"| 1 min read",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+}
+
+func TestPostHandler_Show_EscapesTitle(t *testing.T) {
+	r, mock := newPostRouter(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE slug = \$1`).
+		WithArgs("hello").
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(1, "<b>Hello</b>", "hello", "Welcome to the blog.", 1, domain.PostStatusPublished, "</title><script>alert(1)</script>", "", false, nil, now, now, now, nil, 1))
+	expectRender(mock, domain.ContentTypePost, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM comments WHERE post_id = \$1 AND status = \$2`).
+		WithArgs(int64(1), domain.CommentStatusApproved).
+		WillReturnRows(sqlmock.NewRows(commentColumns))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/hello", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`<title>&lt;/title&gt;&lt;script&gt;alert(1)&lt;/script&gt; - Starter Kit Basic</title>`,
+		`<h1>&lt;b&gt;Hello&lt;/b&gt;</h1>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+	for _, unwanted := range []string{"<script>alert(1)", "<b>Hello</b>"} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("expected %q to be escaped", unwanted)
+		}
+	}
+}
+
+func TestPostHandler_Show_RendersQueryToolbar(t *testing.T) {
+	r, mock := newPostRouter(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE slug = \$1`).
+		WithArgs("hello").
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(1, "Hello", "hello", "Welcome to the blog.", 1, domain.PostStatusPublished, "", "", false, nil, now, now, now, nil, 1))
+	expectRender(mock, domain.ContentTypePost, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM comments WHERE post_id = \$1 AND status = \$2`).
+		WithArgs(int64(1), domain.CommentStatusApproved).
+		WillReturnRows(sqlmock.NewRows(commentColumns))
+
+	w := httptest.NewRecorder()
+	middleware.QueryStats(0, true)(r).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/hello", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `<details id="query-toolbar"`) {
+		t.Error("expected the page to show the query toolbar")
+	}
+}
+
+func TestPostHandler_Show_RendersContent(t *testing.T) {
+	r, mock := newPostRouter(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	body := "## Setup\n\n```go\nfunc main() {}\n```\n\n## Usage\n\n{{< youtube dQw4w9WgXcQ >}}\n\n## Notes\n\n<script>alert(1)</script>\n"
+
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE slug = \$1`).
+		WithArgs("hello").
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(1, "Hello", "hello", body, 1, domain.PostStatusPublished, "", "", false, nil, now, now, now, nil, 1))
+	expectRender(mock, domain.ContentTypePost, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM comments WHERE post_id = \$1 AND status = \$2`).
+		WithArgs(int64(1), domain.CommentStatusApproved).
+		WillReturnRows(sqlmock.NewRows(commentColumns))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/hello", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	page := w.Body.String()
+	for _, want := range []string{
+		`<nav class="toc" aria-label="Table of contents">`,
+		`<h2 id="setup">Setup</h2>`,
+		`<span class="kd">func</span>`,
+		`<iframe src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ"`,
+		`href="/static/css/highlight.css"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+	if strings.Contains(page, "alert(1)") {
+		t.Error("expected script to be sanitized")
+	}
+}
+
+func TestPostHandler_Show_NotFound(t *testing.T) {
+	r, mock := newPostRouter(t)
+
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE slug = \$1`).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/missing", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestPostHandler_Show_FeaturedImage(t *testing.T) {
+	r, mock := newPostRouter(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE slug = \$1`).
+		WithArgs("hello").
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(1, "Hello", "hello", "![inline](/static/uploads/inline.png)", 1, domain.PostStatusPublished, "", "", true, nil, now, now, now, 7, 1))
+	mock.ExpectQuery(`SELECT (.+) FROM media WHERE id = \$1`).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows(mediaColumns).
+			AddRow(7, 1, "hero.jpg", "/uploads/2026/01/hero.jpg", "image/jpeg", 100, 1600, 900, "A hero", "abc", now))
+	expectRender(mock, domain.ContentTypePost, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM comments WHERE post_id = \$1 AND status = \$2`).
+		WithArgs(int64(1), domain.CommentStatusApproved).
+		WillReturnRows(sqlmock.NewRows(commentColumns))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/hello", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`<meta property="og:image" content="https://example.com/static/uploads/2026/01/hero-large.jpg">`,
+		`<img class="content-featured-image" src="/static/uploads/2026/01/hero-large.jpg" alt="A hero">`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+}
+
+func TestPostHandler_Create_FeaturedImageNotOwned(t *testing.T) {
+	r, mock := newPostRouter(t)
+
+	mock.ExpectQuery(`SELECT (.+) FROM media WHERE id = \$1`).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows(mediaColumns).
+			AddRow(7, 2, "hero.jpg", "/uploads/2026/01/hero.jpg", "image/jpeg", 100, 1600, 900, "", "abc", time.Now()))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, postForm("/posts", url.Values{
+		"title":             {"Hello"},
+		"content":           {"Body"},
+		"featured_image_id": {"7"},
+	}))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPostHandler_Update_Conflict(t *testing.T) {
+	r, mock := newPostRouter(t)
+	saved := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// Someone else saved version 2 while the editor had version 1 open
+	for i := 0; i < 3; i++ {
+		mock.ExpectQuery(`SELECT (.+) FROM posts WHERE id = \$1`).
+			WithArgs(int64(3)).
+			WillReturnRows(sqlmock.NewRows(postColumns).
+				AddRow(3, "Hello", "hello", "Intro\nTheir <b>line</b>\nOutro", 1, domain.PostStatusDraft, "", "", false, nil, nil, saved, saved, nil, 2))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, postForm("/posts/3", url.Values{
+		"version":   {"1"},
+		"title":     {"Hello again"},
+		"content":   {"Intro\nMy line\nOutro"},
+		"meta_desc": {"Summary"},
+	}))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"January 2, 2026 at 03:04",
+		`<span class="diff-line diff-delete">Their &lt;b&gt;line&lt;/b&gt;</span>`,
+		`<span class="diff-line diff-insert">My line</span>`,
+		`<span class="diff-line diff-equal">Intro</span>`,
+		`<input type="hidden" name="version" value="2">`,
+		`<input type="hidden" name="meta_desc" value="Summary">`,
+		`value="Hello again"`,
+		"The title was changed",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected conflict page to contain %q", want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPostHandler_Index_FilteredByTag(t *testing.T) {
+	r, mock := newPostRouter(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT (.+) FROM tags WHERE slug = \$1`).
+		WithArgs("go").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "created_at"}).AddRow(4, "Go", "go", now))
+	mock.ExpectQuery(`SELECT (.+) FROM posts p JOIN post_tags pt`).
+		WithArgs(domain.PostStatusPublished, int64(4), 21, 0).
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(1, "Hello", "hello", "Some **Go** news.", 1, domain.PostStatusPublished, "", "", false, nil, now, now, now, nil, 1))
+	expectRenderList(mock, domain.ContentTypePost, 1)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts?tag=go", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{"<h1>Posts tagged Go</h1>", `href="/posts/hello"`, "Some Go news."} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, ">Next<") {
+		t.Error("expected no next page link")
+	}
+}
+
+func TestPostHandler_Index_Cursor(t *testing.T) {
+	r, mock := newPostRouter(t)
+	day := func(id int64) time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(id)) }
+	after := pagination.Cursor{Time: day(30), ID: 30}
+
+	// A full page and one post more, newest first
+	rows := sqlmock.NewRows(postColumns)
+	var ids []int64
+	for id := int64(29); id >= 9; id-- {
+		rows.AddRow(id, "Post", "post", "Body", 1, domain.PostStatusPublished, "", "", false, nil, day(id), day(id), day(id), nil, 1)
+		if id > 9 {
+			ids = append(ids, id)
+		}
+	}
+	mock.ExpectQuery(`SELECT (.+) FROM posts p WHERE p.status = \$1 AND \(p.published_at < \$2 OR \(p.published_at = \$3 AND p.id < \$4\)\) ORDER BY p.published_at DESC, p.id DESC`).
+		WithArgs(domain.PostStatusPublished, after.Time, after.Time, after.ID, 21, 0).
+		WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM posts p WHERE p.status = \$1`).
+		WithArgs(domain.PostStatusPublished).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(45))
+	expectRenderList(mock, domain.ContentTypePost, ids...)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts?after="+after.Encode(), nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := w.Body.String()
+	prev := pagination.Cursor{Time: day(29), ID: 29}
+	next := pagination.Cursor{Time: day(10), ID: 10}
+	for _, want := range []string{
+		`href="/posts?before=` + prev.Encode() + `"`,
+		`href="/posts?after=` + next.Encode() + `"`,
+		"45 in total",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPostHandler_Index_InvalidCursor(t *testing.T) {
+	r, _ := newPostRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts?after=nope", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+// cacheRender renders a saved post or page body ahead of its first view.
+// Show renders it on demand anyway, so a failure only gets logged.
+func cacheRender(ctx context.Context, renders *service.RenderService, contentType string, id int64, md string) {
+	if _, err := renders.Render(ctx, contentType, id, md); err != nil {
+		log.Printf("Error caching rendered %s %d: %v", contentType, id, err)
+	}
+}
+
+// renderPosts returns the renders of posts in a listing keyed by post ID.
+// Without a render service it returns nil and listings fall back to
+// summarizing the Markdown.
+func renderPosts(ctx context.Context, renders *service.RenderService, posts []*domain.Post) map[int64]*domain.RenderedContent {
+	if renders == nil || len(posts) == 0 {
+		return nil
+	}
+
+	sources := make([]*domain.ContentSource, len(posts))
+	for i, post := range posts {
+		sources[i] = &domain.ContentSource{ID: post.ID, Content: post.Content}
+	}
+	return renders.GetAll(ctx, domain.ContentTypePost, sources)
+}
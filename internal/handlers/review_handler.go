@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-fith-renderer"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repositories"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+// statusLabels are the human-readable names of post statuses
+var statusLabels = map[domain.PostStatus]string{
+	domain.PostStatusDraft:            "Draft",
+	domain.PostStatusInReview:         "In review",
+	domain.PostStatusChangesRequested: "Changes requested",
+	domain.PostStatusApproved:         "Approved",
+	domain.PostStatusPublished:        "Published",
+	domain.PostStatusArchived:         "Archived",
+}
+
+var decisionLabels = map[domain.ReviewDecision]string{
+	domain.ReviewComment:          "Comment",
+	domain.ReviewApproved:         "Approved",
+	domain.ReviewChangesRequested: "Changes requested",
+}
+
+// reviewRow is a post in the review queue
+type reviewRow struct {
+	ID       int64
+	Title    string
+	Author   string
+	Reviewer string
+	Mine     bool
+	Updated  string
+}
+
+// reviewComment is a review comment ready to render
+type reviewComment struct {
+	Author   string
+	Decision string
+	Body     string
+	Version  int
+	Date     string
+}
+
+// statusAction is a workflow button on the review page. Comment is set when
+// the action asks for feedback.
+type statusAction struct {
+	PostID  int64
+	Status  domain.PostStatus
+	Label   string
+	Comment bool
+}
+
+// notificationRow is a notification ready to render
+type notificationRow struct {
+	Message string
+	Link    string
+	Date    string
+	Unread  bool
+}
+
+// ReviewHandler handles the editorial review workflow
+type ReviewHandler struct {
+	reviewService *service.ReviewService
+	postService   *service.PostService
+	userRepo      repositories.UserRepository
+	renderer      *fith.Engine
+}
+
+// NewReviewHandler creates a new review handler
+func NewReviewHandler(reviewService *service.ReviewService, postService *service.PostService, userRepo repositories.UserRepository, renderer *fith.Engine) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: reviewService,
+		postService:   postService,
+		userRepo:      userRepo,
+		renderer:      renderer,
+	}
+}
+
+// Queue lists the posts waiting for an editor
+func (h *ReviewHandler) Queue(ctx router.Context) error {
+	user, _ := ctx.Get("user").(*models.User)
+
+	queue, err := h.reviewService.Queue(ctx.Request().Context())
+	if err != nil {
+		log.Printf("Error loading review queue: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading review queue")
+	}
+
+	data := map[string]interface{}{
+		"title":    "Review Queue",
+		"inReview": h.reviewRows(queue.InReview, user),
+		"approved": h.reviewRows(queue.Approved, user),
+	}
+
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
+	html, err := h.renderer.Render("admin/reviews.html", data)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	return ctx.HTML(http.StatusOK, html)
+}
+
+// Show displays a post's review history and the workflow actions open to
+// the current user
+func (h *ReviewHandler) Show(ctx router.Context) error {
+	user, post, err := h.loadPost(ctx)
+	if err != nil || post == nil {
+		return err
+	}
+
+	return h.renderReview(ctx, user, post, http.StatusOK, "")
+}
+
+// UpdateStatus moves a post through the workflow: submit, approve, request
+// changes, publish and so on
+func (h *ReviewHandler) UpdateStatus(ctx router.Context) error {
+	user, post, err := h.loadPost(ctx)
+	if err != nil || post == nil {
+		return err
+	}
+
+	if err := ctx.Request().ParseForm(); err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid form data")
+	}
+
+	to := domain.PostStatus(ctx.Request().FormValue("status"))
+	if !to.IsValid() {
+		return ctx.String(http.StatusBadRequest, "Invalid status")
+	}
+
+	_, err = h.reviewService.Transition(ctx.Request().Context(), post.ID, actorFor(user), to, ctx.Request().FormValue("comment"))
+	if err != nil {
+		return h.workflowError(ctx, user, post, err)
+	}
+
+	http.Redirect(ctx.Response(), ctx.Request(), fmt.Sprintf("/posts/%d/review", post.ID), http.StatusSeeOther)
+	return nil
+}
+
+// Comment adds feedback to the submitted revision without changing the status
+func (h *ReviewHandler) Comment(ctx router.Context) error {
+	user, post, err := h.loadPost(ctx)
+	if err != nil || post == nil {
+		return err
+	}
+
+	if err := ctx.Request().ParseForm(); err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid form data")
+	}
+
+	if err := h.reviewService.Comment(ctx.Request().Context(), post.ID, actorFor(user), ctx.Request().FormValue("body")); err != nil {
+		return h.workflowError(ctx, user, post, err)
+	}
+
+	http.Redirect(ctx.Response(), ctx.Request(), fmt.Sprintf("/posts/%d/review", post.ID), http.StatusSeeOther)
+	return nil
+}
+
+// AssignReviewer sets the editor reviewing a post by username. An empty
+// username clears the assignment.
+func (h *ReviewHandler) AssignReviewer(ctx router.Context) error {
+	user, post, err := h.loadPost(ctx)
+	if err != nil || post == nil {
+		return err
+	}
+
+	if err := ctx.Request().ParseForm(); err != nil {
+		return ctx.String(http.StatusBadRequest, "Invalid form data")
+	}
+
+	var reviewerID *int64
+	if username := strings.TrimSpace(ctx.Request().FormValue("reviewer")); username != "" {
+		reviewer, err := h.userRepo.FindByUsername(username)
+		if err != nil || reviewer == nil || !reviewer.IsEditor() {
+			return h.renderReview(ctx, user, post, http.StatusUnprocessableEntity, "Reviewers must be editors. No editor is called "+username+".")
+		}
+		id := int64(reviewer.ID)
+		reviewerID = &id
+	}
+
+	if err := h.reviewService.AssignReviewer(ctx.Request().Context(), post.ID, actorFor(user), reviewerID); err != nil {
+		return h.workflowError(ctx, user, post, err)
+	}
+
+	http.Redirect(ctx.Response(), ctx.Request(), fmt.Sprintf("/posts/%d/review", post.ID), http.StatusSeeOther)
+	return nil
+}
+
+// Notifications lists the current user's notifications and marks them read
+func (h *ReviewHandler) Notifications(ctx router.Context) error {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok || user == nil {
+		return ctx.String(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	notifications, err := h.reviewService.Notifications(ctx.Request().Context(), int64(user.ID), 50)
+	if err != nil {
+		log.Printf("Error loading notifications: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading notifications")
+	}
+
+	rows := make([]notificationRow, 0, len(notifications))
+	for _, n := range notifications {
+		rows = append(rows, notificationRow{
+			Message: n.Message,
+			Link:    n.Link,
+			Date:    n.CreatedAt.Format("January 2, 2006 15:04"),
+			Unread:  n.ReadAt == nil,
+		})
+	}
+
+	data := map[string]interface{}{
+		"title":         "Notifications",
+		"notifications": rows,
+	}
+
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
+	html, err := h.renderer.Render("pages/notifications.html", data)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	// Only mark them read once they have been shown
+	if err := h.reviewService.MarkNotificationsRead(ctx.Request().Context(), int64(user.ID)); err != nil {
+		log.Printf("Error marking notifications read: %v", err)
+	}
+
+	return ctx.HTML(http.StatusOK, html)
+}
+
+// loadPost returns the current user and the post named in the URL, writing
+// an error response and returning a nil post if either is missing or the
+// user is neither the author nor an editor
+func (h *ReviewHandler) loadPost(ctx router.Context) (*models.User, *domain.Post, error) {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok || user == nil {
+		return nil, nil, ctx.String(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return nil, nil, ctx.String(http.StatusBadRequest, "Invalid post ID")
+	}
+
+	post, err := h.postService.GetPostByID(ctx.Request().Context(), id)
+	if err != nil {
+		return nil, nil, ctx.String(http.StatusNotFound, "Post not found")
+	}
+
+	if actorFor(user).RoleFor(post) == domain.WorkflowNone {
+		return nil, nil, ctx.String(http.StatusForbidden, "You don't have permission to review this post")
+	}
+
+	return user, post, nil
+}
+
+// workflowError maps review service errors to responses
+func (h *ReviewHandler) workflowError(ctx router.Context, user *models.User, post *domain.Post, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.String(http.StatusNotFound, "Post not found")
+	case errors.Is(err, service.ErrNotAllowed):
+		return h.renderReview(ctx, user, post, http.StatusForbidden, "You can't do that at this stage of the review.")
+	case errors.Is(err, service.ErrCommentRequired):
+		return h.renderReview(ctx, user, post, http.StatusUnprocessableEntity, "Please add a comment explaining what should change.")
+	case errors.Is(err, service.ErrNotSubmitted):
+		return h.renderReview(ctx, user, post, http.StatusUnprocessableEntity, "Comments can be added once the post has been submitted for review.")
+	case errors.Is(err, service.ErrConflict):
+		// Show the post as it was saved by the other change
+		current, loadErr := h.postService.GetPostByID(ctx.Request().Context(), post.ID)
+		if loadErr != nil {
+			log.Printf("Error loading post %d: %v", post.ID, loadErr)
+			return ctx.String(http.StatusInternalServerError, "Error loading post")
+		}
+		return h.renderReview(ctx, user, current, http.StatusConflict, "Someone else changed this post while you were reviewing it. Please check it and try again.")
+	}
+
+	log.Printf("Error updating review of post %d: %v", post.ID, err)
+	return ctx.String(http.StatusInternalServerError, "Error updating review")
+}
+
+func (h *ReviewHandler) renderReview(ctx router.Context, user *models.User, post *domain.Post, status int, errMsg string) error {
+	rev, comments, err := h.reviewService.History(ctx.Request().Context(), post.ID)
+	if err != nil {
+		log.Printf("Error loading review history: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error loading review history")
+	}
+
+	version, revisionContent := 0, ""
+	if rev != nil {
+		version, revisionContent = rev.Version, rev.Content
+	}
+
+	rows := make([]reviewComment, 0, len(comments))
+	for _, c := range comments {
+		rows = append(rows, reviewComment{
+			Author:   h.userName(c.AuthorID),
+			Decision: decisionLabels[c.Decision],
+			Body:     c.Body,
+			Version:  c.Version,
+			Date:     c.CreatedAt.Format("January 2, 2006 15:04"),
+		})
+	}
+
+	actor := actorFor(user)
+	actions := []statusAction{}
+	for _, to := range post.Status.Transitions(actor.RoleFor(post)) {
+		actions = append(actions, statusAction{
+			PostID:  post.ID,
+			Status:  to,
+			Label:   actionLabel(post.Status, to),
+			Comment: to == domain.PostStatusApproved || to == domain.PostStatusChangesRequested,
+		})
+	}
+
+	reviewer := ""
+	if post.ReviewerID != nil {
+		reviewer = h.userName(*post.ReviewerID)
+	}
+
+	data := map[string]interface{}{
+		"title":           "Review",
+		"post":            post,
+		"status":          statusLabels[post.Status],
+		"author":          h.userName(post.AuthorID),
+		"reviewer":        reviewer,
+		"editor":          actor.Editor,
+		"version":         version,
+		"revisionContent": revisionContent,
+		"comments":        rows,
+		"actions":         actions,
+		"error":           errMsg,
+	}
+
+	data["queryToolbar"] = middleware.QueryToolbar(ctx.Request().Context())
+	html, err := h.renderer.Render("posts/review.html", data)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "Error rendering template: "+err.Error())
+	}
+
+	return ctx.HTML(status, html)
+}
+
+func (h *ReviewHandler) reviewRows(posts []*domain.Post, user *models.User) []reviewRow {
+	rows := make([]reviewRow, 0, len(posts))
+	for _, p := range posts {
+		row := reviewRow{
+			ID:      p.ID,
+			Title:   p.Title,
+			Author:  h.userName(p.AuthorID),
+			Updated: p.UpdatedAt.Format("January 2, 2006"),
+		}
+		if p.ReviewerID != nil {
+			row.Reviewer = h.userName(*p.ReviewerID)
+			row.Mine = user != nil && *p.ReviewerID == int64(user.ID)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// userName returns a user's username, or a placeholder if they no longer exist
+func (h *ReviewHandler) userName(id int64) string {
+	if user, err := h.userRepo.FindByID(int(id)); err == nil && user != nil {
+		return user.Username
+	}
+	return fmt.Sprintf("User #%d", id)
+}
+
+// actorFor returns the workflow actor for an authenticated user
+func actorFor(user *models.User) domain.Actor {
+	return domain.Actor{UserID: int64(user.ID), Editor: user.IsEditor()}
+}
+
+// actionLabel names the button that moves a post from one status to another
+func actionLabel(from, to domain.PostStatus) string {
+	switch to {
+	case domain.PostStatusInReview:
+		if from == domain.PostStatusChangesRequested {
+			return "Resubmit for review"
+		}
+		return "Submit for review"
+	case domain.PostStatusApproved:
+		return "Approve"
+	case domain.PostStatusChangesRequested:
+		return "Request changes"
+	case domain.PostStatusPublished:
+		return "Publish"
+	case domain.PostStatusArchived:
+		return "Archive"
+	case domain.PostStatusDraft:
+		switch from {
+		case domain.PostStatusPublished:
+			return "Unpublish"
+		case domain.PostStatusInReview:
+			return "Withdraw from review"
+		}
+		return "Move back to draft"
+	}
+	return statusLabels[to]
+}
@@ -0,0 +1,317 @@
+package handlers_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/handlers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/models"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repositories"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/seo"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
+)
+
+// Users created by newReviewRouter, in ID order
+var (
+	reviewWriter = &models.User{Email: "writer@example.com", Username: "writer", Role: models.RoleUser}
+	reviewEditor = &models.User{Email: "editor@example.com", Username: "editor", Role: models.RoleEditor}
+)
+
+// newReviewRouter registers the review routes. The user query parameter
+// (writer or editor) stands in for the session.
+func newReviewRouter(t *testing.T) (router.Router, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	users := repositories.NewMemoryUserRepository()
+	for _, u := range []*models.User{reviewWriter, reviewEditor} {
+		u := *u
+		if err := users.Create(&u); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+
+	renderer := newTestRenderer(t)
+	postService := service.NewPostService(repository.NewPostRepository(db, dialect.Postgres), nil)
+	reviewService := service.NewReviewService(postService, repository.NewReviewRepository(db, dialect.Postgres), repository.NewNotificationRepository(db, dialect.Postgres))
+	commentService := service.NewCommentService(repository.NewCommentRepository(db, dialect.Postgres), postService, config.CommentsConfig{AllowAnonymous: true, RequireApproval: true, MaxDepth: 3})
+	taxonomyService := service.NewTaxonomyService(repository.NewTaxonomyRepository(db, dialect.Postgres))
+	seoBuilder := seo.NewBuilder(config.SiteConfig{Name: "Test Site", URL: "https://example.com"})
+	mediaService := service.NewMediaService(repository.NewMediaRepository(db, dialect.Postgres), storage.NewLocal(t.TempDir(), "/static"))
+	postHandler := handlers.NewPostHandler(postService, reviewService, commentService, taxonomyService, mediaService, newTestRenders(db), service.NewAutosaveService(repository.NewAutosaveRepository(db, dialect.Postgres)), users, seoBuilder, renderer)
+	reviewHandler := handlers.NewReviewHandler(reviewService, postService, users, renderer)
+
+	login := func(next router.HandlerFunc) router.HandlerFunc {
+		return func(ctx router.Context) error {
+			if name := ctx.Query("user"); name != "" {
+				user, _ := users.FindByUsername(name)
+				ctx.Set("user", user)
+			}
+			return next(ctx)
+		}
+	}
+
+	r := router.New()
+	r.POST("/posts/:id/publish", login(postHandler.Publish))
+	r.GET("/posts/:id/review", login(reviewHandler.Show))
+	r.POST("/posts/:id/status", login(reviewHandler.UpdateStatus))
+	r.GET("/admin/reviews", login(reviewHandler.Queue))
+
+	return r, mock
+}
+
+func expectPost(mock sqlmock.Sqlmock, status domain.PostStatus) {
+	now := time.Now()
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE id = \$1`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(3, "Hello <World>", "hello", "Body", 1, status, "", "", false, nil, nil, now, now, nil, 1))
+}
+
+func postForm(target string, values url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestPostHandler_Publish_RequiresEditor(t *testing.T) {
+	r, mock := newReviewRouter(t)
+
+	for target, want := range map[string]int{
+		"/posts/3/publish":             http.StatusUnauthorized,
+		"/posts/3/publish?user=writer": http.StatusForbidden,
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", target, want, rec.Code)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expected no queries: %v", err)
+	}
+}
+
+func TestPostHandler_Publish_Approved(t *testing.T) {
+	r, mock := newReviewRouter(t)
+
+	expectPost(mock, domain.PostStatusApproved)
+	mock.ExpectExec(`UPDATE posts SET`).
+		WithArgs("Hello <World>", "hello", "Body", domain.PostStatusPublished, "", "", false, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(3), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO notifications`).
+		WithArgs(int64(1), `Your post "Hello <World>" was published`, "/posts/hello", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/posts/3/publish?user=editor", nil))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReviewHandler_Submit(t *testing.T) {
+	r, mock := newReviewRouter(t)
+
+	expectPost(mock, domain.PostStatusDraft)
+	expectPost(mock, domain.PostStatusDraft)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\) \+ 1 FROM post_revisions WHERE post_id = \$1`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO post_revisions`).
+		WithArgs(int64(3), 1, "Hello <World>", "Body", int64(1), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectExec(`UPDATE posts SET`).
+		WithArgs("Hello <World>", "hello", "Body", domain.PostStatusInReview, "", "", false, nil, nil, nil, sqlmock.AnyArg(), int64(3), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, postForm("/posts/3/status?user=writer", url.Values{"status": {"in_review"}}))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if loc := rec.Header().Get("Location"); loc != "/posts/3/review" {
+		t.Errorf("expected redirect to the review page, got %q", loc)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReviewHandler_Submit_Conflict(t *testing.T) {
+	r, mock := newReviewRouter(t)
+
+	expectPost(mock, domain.PostStatusDraft)
+	expectPost(mock, domain.PostStatusDraft)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\) \+ 1 FROM post_revisions WHERE post_id = \$1`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO post_revisions`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	// Someone else saved the post after it was loaded
+	mock.ExpectExec(`UPDATE posts SET`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectPost(mock, domain.PostStatusDraft)
+	mock.ExpectQuery(`SELECT (.+) FROM post_revisions`).
+		WithArgs(int64(3)).
+		WillReturnError(sql.ErrNoRows)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, postForm("/posts/3/status?user=writer", url.Values{"status": {"in_review"}}))
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "Someone else changed this post") {
+		t.Error("expected the review page to explain the conflict")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReviewHandler_UpdateStatus_AuthorCannotApprove(t *testing.T) {
+	r, mock := newReviewRouter(t)
+
+	expectPost(mock, domain.PostStatusInReview)
+	expectPost(mock, domain.PostStatusInReview)
+	mock.ExpectQuery(`SELECT (.+) FROM post_revisions`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "version", "title", "content", "author_id", "created_at"}).
+			AddRow(11, 3, 1, "Hello <World>", "Body", 1, time.Now()))
+	mock.ExpectQuery(`SELECT (.+) FROM review_comments`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "revision_id", "version", "author_id", "decision", "body", "created_at"}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, postForm("/posts/3/status?user=writer", url.Values{"status": {"approved"}}))
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "at this stage of the review") {
+		t.Error("expected the review page to explain the refusal")
+	}
+	if strings.Contains(body, `value="approved"`) {
+		t.Error("expected authors not to be offered approval")
+	}
+	if !strings.Contains(body, `value="draft"`) {
+		t.Error("expected authors to be able to withdraw the post")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReviewHandler_Show_Editor(t *testing.T) {
+	r, mock := newReviewRouter(t)
+
+	expectPost(mock, domain.PostStatusInReview)
+	mock.ExpectQuery(`SELECT (.+) FROM post_revisions`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "version", "title", "content", "author_id", "created_at"}).
+			AddRow(11, 3, 2, "Hello <World>", "<script>x</script>", 1, time.Now()))
+	mock.ExpectQuery(`SELECT (.+) FROM review_comments`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "revision_id", "version", "author_id", "decision", "body", "created_at"}).
+			AddRow(1, 3, 10, 1, 2, "changes_requested", "Add a <b>conclusion</b>", time.Now()))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/3/review?user=editor", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"Hello &lt;World&gt;",
+		"version 2",
+		"&lt;script&gt;x&lt;/script&gt;",
+		"Changes requested on version 1",
+		"Add a &lt;b&gt;conclusion&lt;/b&gt;",
+		`value="approved"`,
+		`value="changes_requested"`,
+		`action="/posts/3/reviewer"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected review page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "<script>x") {
+		t.Error("expected revision content to be escaped")
+	}
+}
+
+func TestReviewHandler_Show_Forbidden(t *testing.T) {
+	r, mock := newReviewRouter(t)
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE id = \$1`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(3, "Someone else's", "other", "Body", 9, domain.PostStatusDraft, "", "", false, nil, nil, now, now, nil, 1))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/3/review?user=writer", nil))
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestReviewHandler_Queue(t *testing.T) {
+	r, mock := newReviewRouter(t)
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE status = \$1`).
+		WithArgs(domain.PostStatusInReview, 100, 0).
+		WillReturnRows(sqlmock.NewRows(postColumns).
+			AddRow(3, "Hello <World>", "hello", "Body", 1, domain.PostStatusInReview, "", "", false, 2, nil, now, now, nil, 1))
+	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE status = \$1`).
+		WithArgs(domain.PostStatusApproved, 100, 0).
+		WillReturnRows(sqlmock.NewRows(postColumns))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/reviews?user=editor", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<a href="/posts/3/review">Hello &lt;World&gt;</a>`,
+		"<td>writer</td>",
+		"<mark>you</mark>",
+		"No approved posts are waiting to be published.",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected queue to contain %q", want)
+		}
+	}
+}
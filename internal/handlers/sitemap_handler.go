@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/sitemap"
+)
+
+// SitemapHandler serves the XML sitemap and robots.txt
+type SitemapHandler struct {
+	postService *service.PostService
+	pageService *service.PageService
+	site        config.SiteConfig
+	robots      config.RobotsConfig
+	cache       sitemap.Cache
+}
+
+// NewSitemapHandler creates a new sitemap handler. The cached sitemap is
+// rebuilt whenever a post or page is published, unpublished or changed.
+func NewSitemapHandler(postService *service.PostService, pageService *service.PageService, site config.SiteConfig, robots config.RobotsConfig) *SitemapHandler {
+	h := &SitemapHandler{
+		postService: postService,
+		pageService: pageService,
+		site:        site,
+		robots:      robots,
+	}
+
+	postService.OnPublishedChange(h.cache.Invalidate)
+	pageService.OnPublishedChange(h.cache.Invalidate)
+
+	return h
+}
+
+// Index serves /sitemap.xml, which is a sitemap index once the site has
+// more URLs than fit in a single sitemap
+func (h *SitemapHandler) Index(ctx router.Context) error {
+	set, err := h.cache.Get(func() (*sitemap.Set, error) {
+		return h.build(ctx.Request().Context())
+	})
+	if err != nil {
+		log.Printf("Error building sitemap: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error generating sitemap")
+	}
+
+	return writeConditional(ctx, sitemap.ContentType, set.Root, set.LastModified)
+}
+
+// Part serves one sitemap of a sitemap index
+func (h *SitemapHandler) Part(ctx router.Context) error {
+	n, err := strconv.Atoi(strings.TrimSuffix(ctx.Param("file"), ".xml"))
+	if err != nil {
+		return ctx.String(http.StatusNotFound, "Sitemap not found")
+	}
+
+	set, err := h.cache.Get(func() (*sitemap.Set, error) {
+		return h.build(ctx.Request().Context())
+	})
+	if err != nil {
+		log.Printf("Error building sitemap: %v", err)
+		return ctx.String(http.StatusInternalServerError, "Error generating sitemap")
+	}
+
+	if n < 1 || n > len(set.Parts) {
+		return ctx.String(http.StatusNotFound, "Sitemap not found")
+	}
+
+	return writeConditional(ctx, sitemap.ContentType, set.Parts[n-1], set.LastModified)
+}
+
+// Robots serves /robots.txt
+func (h *SitemapHandler) Robots(ctx router.Context) error {
+	body := sitemap.Robots(h.robots.AllowIndexing, h.robots.Disallow, h.site.URL+"/sitemap.xml")
+	return writeConditional(ctx, "text/plain; charset=utf-8", body, time.Time{})
+}
+
+// build collects the URLs of the home page and every published post and page.
+// Only published content is listed; drafts and archived items are never included.
+func (h *SitemapHandler) build(ctx context.Context) (*sitemap.Set, error) {
+	posts, err := h.postService.ListPublishedRefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := h.pageService.ListPublishedRefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]sitemap.URL, 0, len(posts)+len(pages)+1)
+	urls = append(urls, sitemap.URL{Loc: h.site.URL + "/"})
+	for _, post := range posts {
+		urls = append(urls, sitemap.URL{Loc: h.site.URL + "/posts/" + post.Slug, LastMod: post.UpdatedAt})
+	}
+	for _, page := range pages {
+		urls = append(urls, sitemap.URL{Loc: h.site.URL + page.Path, LastMod: page.UpdatedAt})
+	}
+
+	return sitemap.Build(h.site.URL, urls, sitemap.MaxURLs)
+}
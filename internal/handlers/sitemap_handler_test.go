@@ -0,0 +1,145 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	router "github.com/toutaio/toutago-cosan-router"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/handlers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/repository"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/service"
+)
+
+func newSitemapRouter(t *testing.T, robots config.RobotsConfig) (router.Router, sqlmock.Sqlmock, *service.PostService) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	postService := service.NewPostService(repository.NewPostRepository(db, dialect.Postgres), nil)
+	pageService := service.NewPageService(repository.NewPageRepository(db, dialect.Postgres), nil)
+	handler := handlers.NewSitemapHandler(postService, pageService, config.SiteConfig{URL: "https://example.com"}, robots)
+
+	r := router.New()
+	r.GET("/sitemap.xml", handler.Index)
+	r.GET("/sitemaps/:file", handler.Part)
+	r.GET("/robots.txt", handler.Robots)
+
+	return r, mock, postService
+}
+
+func expectSitemapQueries(mock sqlmock.Sqlmock, updated time.Time) {
+	mock.ExpectQuery(`SELECT id, slug, updated_at FROM posts WHERE status = \$1`).
+		WithArgs(domain.PostStatusPublished).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "updated_at"}).AddRow(1, "hello", updated))
+	mock.ExpectQuery(`SELECT id, parent_id, title, slug, status, sort_order, updated_at FROM pages`).
+		WillReturnRows(sqlmock.NewRows(pageNodeColumns).
+			AddRow(2, nil, "About", "about", domain.PageStatusPublished, 0, updated).
+			AddRow(3, 2, "Team", "team", domain.PageStatusPublished, 0, updated).
+			AddRow(4, 2, "Draft", "draft", domain.PageStatusDraft, 1, updated))
+}
+
+func TestSitemapHandler_Index(t *testing.T) {
+	r, mock, postService := newSitemapRouter(t, config.RobotsConfig{AllowIndexing: true})
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	expectSitemapQueries(mock, updated)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "/about/draft") {
+		t.Error("expected draft page to be left out of the sitemap")
+	}
+	for _, want := range []string{
+		"<loc>https://example.com/</loc>",
+		"<loc>https://example.com/posts/hello</loc>",
+		"<loc>https://example.com/about</loc>",
+		"<loc>https://example.com/about/team</loc>",
+		"<lastmod>2026-01-02T03:04:05Z</lastmod>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected sitemap to contain %q", want)
+		}
+	}
+
+	t.Run("served from cache", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", w.Code)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("rebuilt after content changes", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM posts WHERE id = \$1`).
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		if err := postService.DeletePost(context.Background(), 1); err != nil {
+			t.Fatalf("DeletePost() error = %v", err)
+		}
+
+		mock.ExpectQuery(`SELECT id, slug, updated_at FROM posts WHERE status = \$1`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "updated_at"}))
+		mock.ExpectQuery(`SELECT id, parent_id, title, slug, status, sort_order, updated_at FROM pages`).
+			WillReturnRows(sqlmock.NewRows(pageNodeColumns))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+
+		if strings.Contains(w.Body.String(), "/posts/hello") {
+			t.Error("expected deleted post to be removed from sitemap")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestSitemapHandler_PartNotFound(t *testing.T) {
+	r, mock, _ := newSitemapRouter(t, config.RobotsConfig{AllowIndexing: true})
+	expectSitemapQueries(mock, time.Now())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sitemaps/1.xml", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a site without a sitemap index, got %d", w.Code)
+	}
+}
+
+func TestSitemapHandler_Robots(t *testing.T) {
+	r, _, _ := newSitemapRouter(t, config.RobotsConfig{AllowIndexing: true, Disallow: []string{"/dashboard"}})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("expected plain text, got %s", ct)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Disallow: /dashboard") || !strings.Contains(body, "Sitemap: https://example.com/sitemap.xml") {
+		t.Errorf("unexpected robots.txt: %q", body)
+	}
+}
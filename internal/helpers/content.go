@@ -1,16 +1,21 @@
 package helpers
 
 import (
+	"html"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/gomarkdown/markdown"
-	"github.com/gomarkdown/markdown/html"
+	mdhtml "github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
 	"github.com/gosimple/slug"
 	"github.com/microcosm-cc/bluemonday"
 )
 
-// GenerateSlug creates a URL-friendly slug from a string
+// GenerateSlug creates a URL-friendly slug from a string.
+// Unicode is transliterated and underscores are treated as word separators.
 func GenerateSlug(text string) string {
-	return slug.Make(text)
+	return slug.Make(strings.ReplaceAll(text, "_", " "))
 }
 
 // RenderMarkdown converts markdown to HTML
@@ -21,9 +26,9 @@ func RenderMarkdown(md string) string {
 	doc := p.Parse([]byte(md))
 
 	// Create HTML renderer with options
-	htmlFlags := html.CommonFlags | html.HrefTargetBlank
-	opts := html.RendererOptions{Flags: htmlFlags}
-	renderer := html.NewRenderer(opts)
+	htmlFlags := mdhtml.CommonFlags | mdhtml.HrefTargetBlank
+	opts := mdhtml.RendererOptions{Flags: htmlFlags}
+	renderer := mdhtml.NewRenderer(opts)
 
 	return string(markdown.Render(doc, renderer))
 }
@@ -40,3 +45,33 @@ func RenderMarkdownSafe(md string) string {
 	html := RenderMarkdown(md)
 	return SanitizeHTML(html)
 }
+
+// PlainText renders markdown and strips all markup, leaving whitespace-collapsed text
+func PlainText(md string) string {
+	return HTMLText(RenderMarkdown(md))
+}
+
+// HTMLText strips all tags from rendered HTML, leaving whitespace-collapsed text
+func HTMLText(rawHTML string) string {
+	text := bluemonday.StrictPolicy().Sanitize(rawHTML)
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
+
+// Truncate shortens text to at most max characters, cutting at a word
+// boundary where possible and appending an ellipsis.
+func Truncate(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:max-1])
+	// Drop the trailing partial word unless the cut falls exactly on a space
+	if runes[max-1] != ' ' {
+		if i := strings.LastIndex(cut, " "); i > 0 {
+			cut = cut[:i]
+		}
+	}
+
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
@@ -40,6 +40,21 @@ func TestGenerateSlug(t *testing.T) {
 			input: "Multiple   Spaces   Here",
 			want:  "multiple-spaces-here",
 		},
+		{
+			name:  "with underscores",
+			input: "hello_world_test",
+			want:  "hello-world-test",
+		},
+		{
+			name:  "repeated hyphens",
+			input: "--hello--world--",
+			want:  "hello-world",
+		},
+		{
+			name:  "empty string",
+			input: "",
+			want:  "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -132,3 +147,43 @@ func TestSanitizeHTML(t *testing.T) {
 		})
 	}
 }
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"strips markdown", "# Title\n\nSome **bold** and [a link](https://example.com).", "Title Some bold and a link."},
+		{"strips html", "<script>alert(1)</script>Hello <em>world</em>", "Hello world"},
+		{"unescapes entities", "Fish & Chips", "Fish & Chips"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlainText(tt.input); got != tt.want {
+				t.Errorf("PlainText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		input string
+		max   int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"the quick brown fox jumps", 15, "the quick…"},
+		{"héllo wörld ünïcode", 12, "héllo wörld…"},
+		{"nospacesatallhere", 8, "nospace…"},
+	}
+
+	for _, tt := range tests {
+		if got := Truncate(tt.input, tt.max); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.input, tt.max, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package helpers
+
+import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Diff operations of a DiffLine
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine is one line of a line-by-line comparison of two texts
+type DiffLine struct {
+	Op   string
+	Text string
+}
+
+// DiffLines compares two texts line by line, listing the lines of from that
+// were removed before the lines of to that replaced them.
+func DiffLines(from, to string) []DiffLine {
+	a := splitLines(from)
+	b := splitLines(to)
+
+	var lines []DiffLine
+	for _, op := range difflib.NewMatcher(a, b).GetOpCodes() {
+		switch op.Tag {
+		case 'e':
+			lines = appendDiff(lines, DiffEqual, a[op.I1:op.I2])
+		case 'd':
+			lines = appendDiff(lines, DiffDelete, a[op.I1:op.I2])
+		case 'i':
+			lines = appendDiff(lines, DiffInsert, b[op.J1:op.J2])
+		case 'r':
+			lines = appendDiff(lines, DiffDelete, a[op.I1:op.I2])
+			lines = appendDiff(lines, DiffInsert, b[op.J1:op.J2])
+		}
+	}
+	return lines
+}
+
+func appendDiff(lines []DiffLine, op string, texts []string) []DiffLine {
+	for _, text := range texts {
+		lines = append(lines, DiffLine{Op: op, Text: text})
+	}
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
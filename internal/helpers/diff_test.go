@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []DiffLine
+	}{
+		{
+			name: "identical",
+			from: "one\ntwo",
+			to:   "one\ntwo",
+			want: []DiffLine{{DiffEqual, "one"}, {DiffEqual, "two"}},
+		},
+		{
+			name: "changed line",
+			from: "one\ntwo\nthree",
+			to:   "one\n2\nthree",
+			want: []DiffLine{{DiffEqual, "one"}, {DiffDelete, "two"}, {DiffInsert, "2"}, {DiffEqual, "three"}},
+		},
+		{
+			name: "added and removed lines",
+			from: "one\r\ntwo",
+			to:   "zero\none",
+			want: []DiffLine{{DiffInsert, "zero"}, {DiffEqual, "one"}, {DiffDelete, "two"}},
+		},
+		{
+			name: "empty",
+			from: "",
+			to:   "new",
+			want: []DiffLine{{DiffInsert, "new"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffLines(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
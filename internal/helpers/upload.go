@@ -1,15 +1,22 @@
 package helpers
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoder
+	_ "image/jpeg" // register JPEG decoder
+	_ "image/png"  // register PNG decoder
 	"io"
 	"mime/multipart"
-	"os"
-	"path/filepath"
-	"strings"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
+	_ "golang.org/x/image/webp" // register WebP decoder
 )
 
 // AllowedImageTypes defines the allowed image MIME types
@@ -21,91 +28,93 @@ var AllowedImageTypes = map[string]bool{
 	"image/webp": true,
 }
 
+// ImageExtensions maps detected image types to the extension saved files get
+var ImageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
 // MaxUploadSize is the maximum file size allowed (10MB)
 const MaxUploadSize = 10 << 20 // 10 MB
 
+// Image dimension limits. A small file can declare a huge canvas, so these
+// are checked from the header before an image is decoded.
+const (
+	MaxImageWidth  = 10000
+	MaxImageHeight = 10000
+	MaxImagePixels = 40_000_000
+)
+
+var (
+	// ErrFileTooLarge is returned when an upload is over the size limit
+	ErrFileTooLarge = errors.New("file is too large")
+	// ErrFileType is returned when an upload's content is not an allowed image
+	ErrFileType = errors.New("file type is not allowed")
+	// ErrImageDimensions is returned when an image is wider, taller or has
+	// more pixels than allowed
+	ErrImageDimensions = errors.New("image dimensions are too large")
+)
+
 // UploadConfig holds configuration for file uploads
 type UploadConfig struct {
-	UploadDir    string
+	Storage      storage.Storage
+	Prefix       string // directory within the storage, e.g. "uploads"
 	MaxFileSize  int64
 	AllowedTypes map[string]bool
 }
 
-// DefaultUploadConfig returns the default upload configuration
+// DefaultUploadConfig returns the default upload configuration, saving to
+// ./static/uploads
 func DefaultUploadConfig() *UploadConfig {
 	return &UploadConfig{
-		UploadDir:    "./static/uploads",
+		Storage:      storage.NewLocal("./static", "/static"),
+		Prefix:       "uploads",
 		MaxFileSize:  MaxUploadSize,
 		AllowedTypes: AllowedImageTypes,
 	}
 }
 
-// SaveUploadedFile saves an uploaded file and returns its path
-func SaveUploadedFile(file multipart.File, header *multipart.FileHeader, config *UploadConfig) (string, error) {
+// SaveUploadedFile checks an uploaded image and saves it, returning its path
+// in storage. The file's type is detected from its content; the client's
+// Content-Type and file name are ignored.
+func SaveUploadedFile(ctx context.Context, file multipart.File, header *multipart.FileHeader, config *UploadConfig) (string, error) {
 	if config == nil {
 		config = DefaultUploadConfig()
 	}
 
-	// Check file size
-	if header.Size > config.MaxFileSize {
-		return "", fmt.Errorf("file size exceeds maximum allowed size of %d bytes", config.MaxFileSize)
+	data, err := ReadLimited(file, config.MaxFileSize)
+	if err != nil {
+		return "", err
 	}
 
-	// Check file type
-	contentType := header.Header.Get("Content-Type")
-	if !config.AllowedTypes[contentType] {
-		return "", fmt.Errorf("file type %s is not allowed", contentType)
+	contentType, err := CheckImage(data, config.AllowedTypes)
+	if err != nil {
+		return "", err
 	}
 
-	// Generate unique filename
-	ext := filepath.Ext(header.Filename)
-	filename := fmt.Sprintf("%s-%d%s", uuid.New().String(), time.Now().Unix(), ext)
-
-	// Create year/month directory structure
+	// Generate unique filename under a year/month directory
 	now := time.Now()
-	subDir := fmt.Sprintf("%d/%02d", now.Year(), now.Month())
-	fullDir := filepath.Join(config.UploadDir, subDir)
+	path := fmt.Sprintf("/%s/%d/%02d/%s-%d%s", config.Prefix, now.Year(), now.Month(), uuid.New().String(), now.Unix(), ImageExtensions[contentType])
 
-	// Create directory if it doesn't exist
-	if err := os.MkdirAll(fullDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create upload directory: %w", err)
-	}
-
-	// Create destination file
-	dst := filepath.Join(fullDir, filename)
-	out, err := os.Create(dst)
-	if err != nil {
-		return "", fmt.Errorf("failed to create destination file: %w", err)
-	}
-	defer out.Close()
-
-	// Copy file content
-	if _, err := io.Copy(out, file); err != nil {
+	if err := config.Storage.Put(ctx, path, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
 		return "", fmt.Errorf("failed to save file: %w", err)
 	}
 
-	// Return relative path from static directory
-	relativePath := filepath.Join("/uploads", subDir, filename)
-	return strings.ReplaceAll(relativePath, "\\", "/"), nil
+	return path, nil
 }
 
-// DeleteUploadedFile deletes an uploaded file
-func DeleteUploadedFile(path string) error {
+// DeleteUploadedFile deletes an uploaded file from storage
+func DeleteUploadedFile(ctx context.Context, path string, config *UploadConfig) error {
 	if path == "" {
 		return nil
 	}
-
-	// Remove leading slash and prepend static directory
-	path = strings.TrimPrefix(path, "/")
-	fullPath := filepath.Join("./static", path)
-
-	// Check if file exists
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		return nil // File doesn't exist, nothing to delete
+	if config == nil {
+		config = DefaultUploadConfig()
 	}
 
-	// Delete file
-	if err := os.Remove(fullPath); err != nil {
+	if err := config.Storage.Delete(ctx, path); err != nil {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
 
@@ -114,16 +123,64 @@ func DeleteUploadedFile(path string) error {
 
 // ValidateImageFile validates an image file without saving it
 func ValidateImageFile(header *multipart.FileHeader) error {
-	// Check file size
-	if header.Size > MaxUploadSize {
-		return fmt.Errorf("file size exceeds maximum allowed size of %d bytes", MaxUploadSize)
+	file, err := header.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
 	}
+	defer file.Close()
 
-	// Check file type
-	contentType := header.Header.Get("Content-Type")
-	if !AllowedImageTypes[contentType] {
-		return fmt.Errorf("file type %s is not allowed", contentType)
+	data, err := ReadLimited(file, MaxUploadSize)
+	if err != nil {
+		return err
+	}
+
+	_, err = CheckImage(data, AllowedImageTypes)
+	return err
+}
+
+// ReadLimited reads all of r, stopping with ErrFileTooLarge as soon as more
+// than max bytes arrive rather than trusting a declared size.
+func ReadLimited(r io.Reader, max int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrFileTooLarge, max)
+	}
+	return data, nil
+}
+
+// CheckImage detects an image's type from its magic bytes and makes sure the
+// whole file decodes as that type within the dimension limits. It returns the
+// detected MIME type.
+func CheckImage(data []byte, allowed map[string]bool) (string, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := ImageExtensions[contentType]; !ok || !allowed[contentType] {
+		return "", fmt.Errorf("%w: %s", ErrFileType, contentType)
 	}
 
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrFileType, err)
+	}
+	if err := CheckImageDimensions(cfg.Width, cfg.Height); err != nil {
+		return "", err
+	}
+
+	// A valid header can still be followed by garbage, so decode it all
+	if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrFileType, err)
+	}
+
+	return contentType, nil
+}
+
+// CheckImageDimensions enforces MaxImageWidth, MaxImageHeight and
+// MaxImagePixels.
+func CheckImageDimensions(width, height int) error {
+	if width > MaxImageWidth || height > MaxImageHeight || int64(width)*int64(height) > MaxImagePixels {
+		return fmt.Errorf("%w: %dx%d", ErrImageDimensions, width, height)
+	}
 	return nil
 }
@@ -0,0 +1,148 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pngHeader returns just the signature and IHDR chunk of a PNG declaring the
+// given size, the shape of a decompression bomb's header.
+func pngHeader(t *testing.T, w, h int) []byte {
+	t.Helper()
+	data := encodePNG(t, 1, 1)[:33]
+	data[16], data[17], data[18], data[19] = byte(w>>24), byte(w>>16), byte(w>>8), byte(w)
+	data[20], data[21], data[22], data[23] = byte(h>>24), byte(h>>16), byte(h>>8), byte(h)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+// fileHeader builds a multipart file header as a browser would send it.
+func fileHeader(t *testing.T, name, contentType string, data []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="file"; filename="`+name+`"`)
+	h.Set("Content-Type", contentType)
+	part, err := w.CreatePart(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	w.Close()
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+func TestCheckImage(t *testing.T) {
+	var jpg bytes.Buffer
+	jpeg.Encode(&jpg, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil)
+
+	valid := encodePNG(t, 10, 10)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr error
+	}{
+		{"png", valid, "image/png", nil},
+		{"jpeg", jpg.Bytes(), "image/jpeg", nil},
+		{"html", []byte("<html><script>alert(1)</script></html>"), "", ErrFileType},
+		{"truncated png", valid[:len(valid)-20], "", ErrFileType},
+		{"too wide", pngHeader(t, MaxImageWidth+1, 1), "", ErrImageDimensions},
+		{"too many pixels", pngHeader(t, 9000, 9000), "", ErrImageDimensions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckImage(tt.data, AllowedImageTypes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected type %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadLimited(t *testing.T) {
+	if _, err := ReadLimited(strings.NewReader("12345"), 5); err != nil {
+		t.Errorf("expected a file at the limit to be read, got %v", err)
+	}
+	if _, err := ReadLimited(strings.NewReader("123456"), 5); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("expected ErrFileTooLarge, got %v", err)
+	}
+}
+
+func TestValidateImageFile_IgnoresClientContentType(t *testing.T) {
+	header := fileHeader(t, "evil.png", "image/png", []byte("<html><script>alert(1)</script></html>"))
+	if err := ValidateImageFile(header); !errors.Is(err, ErrFileType) {
+		t.Errorf("expected ErrFileType for HTML labelled as PNG, got %v", err)
+	}
+
+	header = fileHeader(t, "photo.txt", "text/plain", encodePNG(t, 2, 2))
+	if err := ValidateImageFile(header); err != nil {
+		t.Errorf("expected a real PNG to pass whatever its label, got %v", err)
+	}
+}
+
+func TestSaveUploadedFile_UsesDetectedExtension(t *testing.T) {
+	dir := t.TempDir()
+	header := fileHeader(t, "page.html", "text/html", encodePNG(t, 2, 2))
+
+	file, err := header.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	config := &UploadConfig{Storage: storage.NewLocal(dir, "/static"), Prefix: "uploads", MaxFileSize: MaxUploadSize, AllowedTypes: AllowedImageTypes}
+	path, err := SaveUploadedFile(context.Background(), file, header, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filepath.Ext(path) != ".png" {
+		t.Errorf("expected a .png path, got %s", path)
+	}
+	if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+		t.Errorf("expected the file to be saved: %v", err)
+	}
+
+	if err := DeleteUploadedFile(context.Background(), path, config); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, path)); !os.IsNotExist(err) {
+		t.Errorf("expected the file to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// EXIF orientation values. The others (2, 4, 5, 7) are mirrored variants
+// that cameras rarely produce but are handled all the same.
+const (
+	OrientationNormal    = 1
+	OrientationRotate180 = 3
+	OrientationRotate90  = 6
+	OrientationRotate270 = 8
+)
+
+const orientationTag = 0x0112
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// Orientation returns the EXIF orientation of a JPEG or WebP image, or
+// OrientationNormal if it has none or the metadata cannot be read.
+func Orientation(data []byte, format string) int {
+	var tiff []byte
+	switch format {
+	case FormatJPEG:
+		tiff = jpegExif(data)
+	case FormatWebP:
+		tiff = webpChunk(data, "EXIF")
+		tiff = bytes.TrimPrefix(tiff, exifHeader)
+	}
+
+	if o := tiffOrientation(tiff); o >= 1 && o <= 8 {
+		return o
+	}
+	return OrientationNormal
+}
+
+// jpegExif returns the TIFF structure inside a JPEG's EXIF segment.
+func jpegExif(data []byte) []byte {
+	for _, seg := range jpegSegments(data) {
+		if seg.marker == 0xE1 && bytes.HasPrefix(seg.payload, exifHeader) {
+			return seg.payload[len(exifHeader):]
+		}
+	}
+	return nil
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of a TIFF
+// structure, returning 0 if it is missing or malformed.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == orientationTag {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 0
+}
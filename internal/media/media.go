@@ -0,0 +1,133 @@
+// Package media processes uploaded images: it turns them upright according
+// to their EXIF orientation, strips metadata such as GPS coordinates, and
+// generates resized variants, all in pure Go.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	"image/png"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+	_ "golang.org/x/image/webp" // register WebP decoder
+)
+
+// Image formats, as named by image.Decode.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+)
+
+// JPEG quality used when an image has to be re-encoded.
+const (
+	originalQuality = 90
+	variantQuality  = 82
+)
+
+var formatTypes = map[string]struct{ mime, ext string }{
+	FormatJPEG: {"image/jpeg", ".jpg"},
+	FormatPNG:  {"image/png", ".png"},
+	FormatGIF:  {"image/gif", ".gif"},
+	FormatWebP: {"image/webp", ".webp"},
+}
+
+// ErrUnsupported is returned for files that are not images in a supported
+// format.
+var ErrUnsupported = errors.New("unsupported image format")
+
+// Encoded is an encoded image ready to be saved.
+type Encoded struct {
+	Name   string
+	Data   []byte
+	Width  int
+	Height int
+}
+
+// Processed is an uploaded image ready to be stored.
+type Processed struct {
+	MimeType string
+	Ext      string
+	Width    int
+	Height   int
+	Original []byte
+	Variants []Encoded
+}
+
+// Process decodes an uploaded image and prepares it for storage. The original
+// keeps its bytes with metadata removed, unless its EXIF orientation says it
+// must be rotated, in which case it is re-encoded upright (as JPEG for JPEGs
+// and PNG otherwise). Each variant is scaled from the upright image.
+//
+// The format comes from the file's magic bytes. Images over the helpers
+// dimension limits are rejected with helpers.ErrImageDimensions before any
+// pixels are decoded.
+func Process(data []byte, variants []domain.MediaVariant) (*Processed, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if err := helpers.CheckImageDimensions(cfg.Width, cfg.Height); err != nil {
+		return nil, err
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	ft, ok := formatTypes[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, format)
+	}
+
+	p := &Processed{MimeType: ft.mime, Ext: ft.ext}
+
+	if o := Orientation(data, format); o != OrientationNormal {
+		img = Orient(img, o)
+		if format != FormatJPEG {
+			p.MimeType, p.Ext = "image/png", ".png"
+		}
+		if p.Original, err = encode(img, p.MimeType, originalQuality); err != nil {
+			return nil, err
+		}
+	} else if p.Original, err = StripMetadata(data, format); err != nil {
+		return nil, err
+	}
+
+	p.Width, p.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	for _, v := range variants {
+		scaled := Fit(img, v.MaxWidth, v.MaxHeight)
+		out, err := encode(scaled, p.MimeType, variantQuality)
+		if err != nil {
+			return nil, err
+		}
+		p.Variants = append(p.Variants, Encoded{
+			Name:   v.Name,
+			Data:   out,
+			Width:  scaled.Bounds().Dx(),
+			Height: scaled.Bounds().Dy(),
+		})
+	}
+
+	return p, nil
+}
+
+// encode writes img as JPEG if mimeType is JPEG and as PNG otherwise, matching
+// domain.VariantExt.
+func encode(img image.Image, mimeType string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if mimeType == "image/jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	return buf.Bytes(), err
+}
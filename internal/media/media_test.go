@@ -0,0 +1,198 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+)
+
+// exifSegment builds an APP1 segment holding only an orientation tag.
+func exifSegment(orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	copy(tiff, "II")
+	binary.LittleEndian.PutUint16(tiff[2:], 42)
+	binary.LittleEndian.PutUint32(tiff[4:], 8)
+	binary.LittleEndian.PutUint16(tiff[8:], 1)
+	binary.LittleEndian.PutUint16(tiff[10:], orientationTag)
+	binary.LittleEndian.PutUint16(tiff[12:], 3) // SHORT
+	binary.LittleEndian.PutUint32(tiff[14:], 1)
+	binary.LittleEndian.PutUint16(tiff[18:], orientation)
+
+	payload := append(append([]byte(nil), exifHeader...), tiff...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+func testJPEG(t *testing.T, w, h int, orientation uint16) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if orientation == 0 {
+		return data
+	}
+	return append(append(append([]byte(nil), data[:2]...), exifSegment(orientation)...), data[2:]...)
+}
+
+func TestOrientation(t *testing.T) {
+	if got := Orientation(testJPEG(t, 4, 2, 6), FormatJPEG); got != OrientationRotate90 {
+		t.Errorf("expected orientation 6, got %d", got)
+	}
+	if got := Orientation(testJPEG(t, 4, 2, 0), FormatJPEG); got != OrientationNormal {
+		t.Errorf("expected normal orientation without EXIF, got %d", got)
+	}
+	if got := Orientation([]byte("not an image"), FormatJPEG); got != OrientationNormal {
+		t.Errorf("expected normal orientation for garbage, got %d", got)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 2×1 image: red then blue
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	tests := []struct {
+		orientation int
+		w, h        int
+		first       color.NRGBA // pixel at 0,0
+	}{
+		{OrientationNormal, 2, 1, red},
+		{2, 2, 1, blue},
+		{OrientationRotate180, 2, 1, blue},
+		{OrientationRotate90, 1, 2, red},
+		{OrientationRotate270, 1, 2, blue},
+	}
+
+	for _, tt := range tests {
+		out := Orient(src, tt.orientation)
+		if b := out.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("orientation %d: expected %dx%d, got %dx%d", tt.orientation, tt.w, tt.h, b.Dx(), b.Dy())
+			continue
+		}
+		if got := color.NRGBAModel.Convert(out.At(0, 0)); got != tt.first {
+			t.Errorf("orientation %d: expected %v at 0,0, got %v", tt.orientation, tt.first, got)
+		}
+	}
+}
+
+func TestFitSize(t *testing.T) {
+	tests := []struct {
+		w, h, maxW, maxH int
+		wantW, wantH     int
+	}{
+		{100, 50, 300, 300, 100, 50},
+		{1200, 600, 300, 300, 300, 150},
+		{600, 1200, 300, 300, 150, 300},
+		{5000, 1, 300, 300, 300, 1},
+	}
+	for _, tt := range tests {
+		w, h := FitSize(tt.w, tt.h, tt.maxW, tt.maxH)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("FitSize(%d, %d) = %dx%d, want %dx%d", tt.w, tt.h, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestProcess_RotatesAndStripsJPEG(t *testing.T) {
+	variants := []domain.MediaVariant{{Name: "small", MaxWidth: 2, MaxHeight: 2}}
+
+	p, err := Process(testJPEG(t, 8, 4, OrientationRotate90), variants)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.MimeType != "image/jpeg" || p.Ext != ".jpg" {
+		t.Errorf("expected JPEG, got %s %s", p.MimeType, p.Ext)
+	}
+	if p.Width != 4 || p.Height != 8 {
+		t.Errorf("expected upright 4x8 image, got %dx%d", p.Width, p.Height)
+	}
+	if bytes.Contains(p.Original, exifHeader) {
+		t.Error("expected EXIF to be removed from the original")
+	}
+	if len(p.Variants) != 1 || p.Variants[0].Width != 1 || p.Variants[0].Height != 2 {
+		t.Errorf("expected a 1x2 variant, got %+v", p.Variants)
+	}
+}
+
+func TestProcess_StripsWithoutReencoding(t *testing.T) {
+	plain := testJPEG(t, 4, 2, 0)
+	tagged := testJPEG(t, 4, 2, OrientationNormal)
+
+	p, err := Process(tagged, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(p.Original, plain) {
+		t.Error("expected the original to be the image minus its EXIF segment")
+	}
+}
+
+func TestProcess_Unsupported(t *testing.T) {
+	_, err := Process([]byte("<html><script>alert(1)</script></html>"), nil)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestProcess_RejectsHugeDimensions(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Claim a 20000x20000 canvas in IHDR; nothing past the header is read
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 20000)
+	binary.BigEndian.PutUint32(data[20:], 20000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err := Process(data, domain.MediaVariants)
+	if !errors.Is(err, helpers.ErrImageDimensions) {
+		t.Errorf("expected ErrImageDimensions, got %v", err)
+	}
+}
+
+func TestStripMetadata_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// Insert a tEXt chunk after IHDR (8 byte signature + 25 byte chunk)
+	text := []byte("Comment\x00secret")
+	chunk := make([]byte, 8, 12+len(text))
+	binary.BigEndian.PutUint32(chunk, uint32(len(text)))
+	copy(chunk[4:], "tEXt")
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	tagged := append(append(append([]byte(nil), data[:33]...), chunk...), data[33:]...)
+
+	out, err := StripMetadata(tagged, FormatPNG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Error("expected the tEXt chunk to be removed")
+	}
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Errorf("expected a valid PNG: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformed = errors.New("malformed image data")
+
+// jpegSegment is a marker segment from the header of a JPEG file. raw holds
+// the whole segment including its marker and length.
+type jpegSegment struct {
+	marker  byte
+	payload []byte
+	raw     []byte
+}
+
+// jpegSegments returns the marker segments before the image data.
+func jpegSegments(data []byte) []jpegSegment {
+	var segs []jpegSegment
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return segs
+		}
+		marker := data[i+1]
+		// Start of scan: image data follows
+		if marker == 0xDA {
+			return segs
+		}
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if n < 2 || i+2+n > len(data) {
+			return segs
+		}
+		segs = append(segs, jpegSegment{marker: marker, payload: data[i+4 : i+2+n], raw: data[i : i+2+n]})
+		i += 2 + n
+	}
+	return segs
+}
+
+// StripMetadata removes EXIF, XMP, comments and other metadata from an image
+// without re-encoding it. GIFs are returned unchanged as they carry none.
+func StripMetadata(data []byte, format string) ([]byte, error) {
+	switch format {
+	case FormatJPEG:
+		return stripJPEG(data)
+	case FormatPNG:
+		return stripPNG(data)
+	case FormatWebP:
+		return stripWebP(data)
+	}
+	return data, nil
+}
+
+// stripJPEG drops APP1-APP15 and comment segments, keeping APP0 (JFIF) and
+// everything needed to decode the image.
+func stripJPEG(data []byte) ([]byte, error) {
+	segs := jpegSegments(data)
+	if segs == nil {
+		return nil, errMalformed
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:2])
+	end := 2
+	for _, seg := range segs {
+		end += len(seg.raw)
+		if (seg.marker >= 0xE1 && seg.marker <= 0xEF) || seg.marker == 0xFE {
+			continue
+		}
+		out.Write(seg.raw)
+	}
+	out.Write(data[end:])
+	return out.Bytes(), nil
+}
+
+// pngMetadataChunks are the ancillary PNG chunks that carry metadata rather
+// than affect how the image looks.
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errMalformed
+	}
+
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	for i := len(pngSignature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, errMalformed
+		}
+		n := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + n
+		if end > len(data) {
+			return nil, errMalformed
+		}
+		if !pngMetadataChunks[string(data[i+4:i+8])] {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return out.Bytes(), nil
+}
+
+// webpChunk returns the payload of the first RIFF chunk with the given
+// four-character code in a WebP file.
+func webpChunk(data []byte, fourCC string) []byte {
+	for _, c := range webpChunks(data) {
+		if c.fourCC == fourCC {
+			return c.payload
+		}
+	}
+	return nil
+}
+
+type riffChunk struct {
+	fourCC  string
+	payload []byte
+	raw     []byte
+}
+
+func webpChunks(data []byte) []riffChunk {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil
+	}
+
+	var chunks []riffChunk
+	for i := 12; i+8 <= len(data); {
+		n := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + n + n%2 // chunks are padded to an even length
+		if i+8+n > len(data) {
+			return chunks
+		}
+		if end > len(data) {
+			end = len(data)
+		}
+		chunks = append(chunks, riffChunk{fourCC: string(data[i : i+4]), payload: data[i+8 : i+8+n], raw: data[i:end]})
+		i = end
+	}
+	return chunks
+}
+
+// stripWebP drops the EXIF and XMP chunks and clears their flags in the
+// extended header.
+func stripWebP(data []byte) ([]byte, error) {
+	chunks := webpChunks(data)
+	if chunks == nil {
+		return nil, errMalformed
+	}
+
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for _, c := range chunks {
+		switch c.fourCC {
+		case "EXIF", "XMP ":
+			continue
+		case "VP8X":
+			raw := append([]byte(nil), c.raw...)
+			if len(raw) > 8 {
+				raw[8] &^= 0x08 | 0x04 // EXIF and XMP present
+			}
+			body.Write(raw)
+			continue
+		}
+		body.Write(c.raw)
+	}
+
+	out := make([]byte, 8, 8+body.Len())
+	copy(out, "RIFF")
+	binary.LittleEndian.PutUint32(out[4:], uint32(body.Len()))
+	return append(out, body.Bytes()...), nil
+}
@@ -12,7 +12,8 @@ const (
 	// SessionCookieName is the name of the session cookie.
 	SessionCookieName = "session_id"
 	// UserContextKey is the context key for storing the authenticated user.
-	UserContextKey = "auth_user"
+	// Handlers read it with ctx.Get("user").
+	UserContextKey = "user"
 )
 
 // AuthMiddleware provides authentication middleware.
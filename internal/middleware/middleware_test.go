@@ -3,6 +3,7 @@ package middleware_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	router "github.com/toutaio/toutago-cosan-router"
@@ -103,6 +104,83 @@ func TestSecurityHeaders(t *testing.T) {
 	}
 }
 
+func TestUploadHeaders(t *testing.T) {
+	r := router.New()
+
+	r.GET("/static/*", middleware.UploadHeaders("/static/uploads/")(func(ctx router.Context) error {
+		return ctx.String(http.StatusOK, "OK")
+	}))
+
+	tests := []struct {
+		path        string
+		disposition string
+	}{
+		{"/static/uploads/2026/01/photo.JPG", `inline; filename="photo.JPG"`},
+		{"/static/uploads/2026/01/page.html", `attachment; filename="page.html"`},
+		{"/static//uploads/a.html", `attachment; filename="a.html"`},
+		{"/static/./uploads/a.html", `attachment; filename="a.html"`},
+		{"/static/css/../uploads/a.html", `attachment; filename="a.html"`},
+		{"/static/css/custom.css", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if got := w.Header().Get("Content-Disposition"); got != tt.disposition {
+				t.Errorf("expected Content-Disposition %q, got %q", tt.disposition, got)
+			}
+
+			csp := w.Header().Get("Content-Security-Policy")
+			if tt.disposition == "" && csp != "" {
+				t.Errorf("expected no CSP outside uploads, got %q", csp)
+			}
+			if tt.disposition != "" && !strings.Contains(csp, "sandbox") {
+				t.Errorf("expected a sandboxing CSP, got %q", csp)
+			}
+		})
+	}
+}
+
+func TestRequireReady(t *testing.T) {
+	ready := false
+	r := router.New()
+	r.Use(router.MiddlewareFunc(middleware.RequireReady(func() bool { return ready }, "/health")))
+	r.GET("/posts", func(ctx router.Context) error {
+		return ctx.String(http.StatusOK, "OK")
+	})
+	r.GET("/health", func(ctx router.Context) error {
+		return ctx.String(http.StatusOK, "OK")
+	})
+
+	tests := []struct {
+		name           string
+		ready          bool
+		path           string
+		expectedStatus int
+	}{
+		{"not ready", false, "/posts", http.StatusServiceUnavailable},
+		{"exempt while not ready", false, "/health", http.StatusOK},
+		{"ready", true, "/posts", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready = tt.ready
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if retry := w.Header().Get("Retry-After"); (w.Code == http.StatusServiceUnavailable) != (retry != "") {
+				t.Errorf("unexpected Retry-After %q with status %d", retry, w.Code)
+			}
+		})
+	}
+}
+
 func TestRequestID(t *testing.T) {
 	r := router.New()
 
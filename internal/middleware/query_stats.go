@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/instrument"
+)
+
+// QueryStats records the database queries of each request under its
+// request ID, and logs a warning when one statement runs more than
+// repeatLimit times, which usually means a query in a loop (N+1). With
+// debug set, for development, responses report the number of queries and
+// their time in headers, and QueryToolbar lists them for pages to show.
+//
+// It has to wrap the router, so handlers get the request carrying the
+// recorder. The request ID is made here when the client sent none, and
+// RequestID passes it on.
+func QueryStats(repeatLimit int, debug bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requestID := req.Header.Get("X-Request-ID")
+			if requestID == "" {
+				requestID = newRequestID()
+				req.Header.Set("X-Request-ID", requestID)
+			}
+			ctx, rec := instrument.WithRecorder(req.Context(), requestID)
+			if debug {
+				ctx = context.WithValue(ctx, toolbarKey{}, &toolbarState{rec: rec, repeatLimit: repeatLimit})
+			}
+			req = req.WithContext(ctx)
+
+			// Headers go out with the first write, so in debug mode the
+			// response is held back until the count is final
+			var buf *bufferedWriter
+			if debug {
+				buf = &bufferedWriter{ResponseWriter: w}
+				w = buf
+			}
+			next.ServeHTTP(w, req)
+
+			var repeats []instrument.Repeat
+			if repeatLimit > 0 {
+				repeats = rec.Repeated(repeatLimit)
+			}
+			if buf != nil {
+				buf.flush(rec)
+			}
+			for _, r := range repeats {
+				log.Printf("Possible N+1 query [request %s] %s %s: %d runs of %s", requestID, req.Method, req.URL.Path, r.Count, r.SQL)
+			}
+		})
+	}
+}
+
+// bufferedWriter keeps a response until flush
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// flush sends the response with the query stats of rec in its headers
+func (w *bufferedWriter) flush(rec *instrument.Recorder) {
+	count, total := rec.Stats()
+	ms := strconv.FormatFloat(float64(total.Microseconds())/1000, 'f', 2, 64)
+	h := w.Header()
+	h.Set("X-DB-Queries", strconv.Itoa(count))
+	h.Set("X-DB-Time", ms+"ms")
+	h.Add("Server-Timing", fmt.Sprintf(`db;dur=%s;desc="%d queries"`, ms, count))
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	w.ResponseWriter.Write(w.body.Bytes())
+}
+
+// toolbarKey is the context key of the toolbar state of a request
+type toolbarKey struct{}
+
+// toolbarState is what QueryToolbar needs from QueryStats
+type toolbarState struct {
+	rec         *instrument.Recorder
+	repeatLimit int
+}
+
+// QueryToolbar returns the HTML of a toolbar listing the queries the request
+// of ctx has run so far, for a page layout to output before </body>. It is
+// empty unless QueryStats runs in debug mode.
+func QueryToolbar(ctx context.Context) string {
+	state, ok := ctx.Value(toolbarKey{}).(*toolbarState)
+	if !ok {
+		return ""
+	}
+
+	count, total := state.rec.Stats()
+	data := toolbarData{
+		Count:   count,
+		Total:   total.Round(time.Microsecond),
+		Queries: state.rec.Queries(),
+	}
+	if state.repeatLimit > 0 {
+		data.Repeats = state.rec.Repeated(state.repeatLimit)
+	}
+
+	var buf bytes.Buffer
+	if err := queryToolbar.Execute(&buf, data); err != nil {
+		log.Printf("Failed to render query toolbar: %v", err)
+		return ""
+	}
+	return buf.String()
+}
+
+// toolbarData is what the query toolbar shows
+type toolbarData struct {
+	Count   int
+	Total   time.Duration
+	Queries []instrument.Query
+	Repeats []instrument.Repeat
+}
+
+// queryToolbar lists the queries of a page in development. It is parsed
+// with html/template, so the SQL and errors it shows are escaped.
+var queryToolbar = template.Must(template.New("toolbar").Parse(`
+<details id="query-toolbar" style="position:fixed;right:0;bottom:0;z-index:1000;max-width:min(60rem,100vw);max-height:50vh;overflow:auto;margin:0;padding:.25rem .75rem;background:#1f2937;color:#f9fafb;font:12px/1.5 monospace;opacity:.95">
+<summary>{{.Count}} queries in {{.Total}}{{if .Repeats}} · {{len .Repeats}} repeated{{end}}</summary>
+{{range .Repeats}}<p style="margin:.25rem 0;color:#fbbf24">Possible N+1: {{.Count}} runs of {{.SQL}}</p>
+{{end}}<ol style="margin:.25rem 0;padding-left:2rem">
+{{range .Queries}}<li style="list-style:decimal">{{.Duration}}, {{.Rows}} rows: {{.SQL}}{{if .Err}} <span style="color:#f87171">{{.Err}}</span>{{end}}</li>
+{{end}}</ol>
+</details>
+`))
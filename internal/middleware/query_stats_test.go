@@ -0,0 +1,102 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/instrument"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/middleware"
+)
+
+func TestQueryStats(t *testing.T) {
+	db, err := instrument.Open("sqlite3", filepath.Join(t.TempDir(), "app.db"), instrument.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// The page looks its three posts up one by one, then shows the toolbar
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := range 3 {
+			var n int
+			if err := db.QueryRowContext(r.Context(), `SELECT ?`, i).Scan(&n); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><body><h1>Posts</h1>" + middleware.QueryToolbar(r.Context()) + "</body></html>"))
+	})
+
+	tests := []struct {
+		name    string
+		debug   bool
+		queries string
+		toolbar bool
+	}{
+		{"production", false, "", false},
+		{"development", true, "3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+			req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+			req.Header.Set("X-Request-ID", "req-42")
+			w := httptest.NewRecorder()
+			middleware.QueryStats(2, tt.debug)(page).ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			if got := w.Header().Get("X-DB-Queries"); got != tt.queries {
+				t.Errorf("expected X-DB-Queries %q, got %q", tt.queries, got)
+			}
+			if tt.debug && !strings.Contains(w.Header().Get("Server-Timing"), `desc="3 queries"`) {
+				t.Errorf("expected a db Server-Timing, got %q", w.Header().Get("Server-Timing"))
+			}
+
+			body := w.Body.String()
+			if strings.Contains(body, "query-toolbar") != tt.toolbar {
+				t.Errorf("expected toolbar %v, got %s", tt.toolbar, body)
+			}
+			if tt.toolbar && !strings.Contains(body, "3 queries in") {
+				t.Errorf("expected the toolbar to count 3 queries, got %s", body)
+			}
+			if tt.toolbar && !strings.Contains(body, "Possible N+1: 3 runs of SELECT ?") {
+				t.Errorf("expected the toolbar to show the repeated query, got %s", body)
+			}
+			if !strings.Contains(logs.String(), "Possible N+1 query [request req-42] GET /posts: 3 runs of SELECT ?") {
+				t.Errorf("expected an N+1 warning, got %q", logs.String())
+			}
+		})
+	}
+}
+
+func TestQueryStats_RequestID(t *testing.T) {
+	var seen string
+	handler := middleware.QueryStats(0, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get("X-Request-ID")
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if seen == "" {
+		t.Error("expected a request ID for RequestID to pass on")
+	}
+}
+
+func TestQueryToolbar_WithoutQueryStats(t *testing.T) {
+	if got := middleware.QueryToolbar(httptest.NewRequest(http.MethodGet, "/", nil).Context()); got != "" {
+		t.Errorf("expected no toolbar outside QueryStats, got %q", got)
+	}
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"errors"
 	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
 )
 
 // Content status constants
@@ -100,12 +102,10 @@ func (p *Post) IsDeleted() bool {
 	return p.DeletedAt != nil
 }
 
-// GenerateExcerpt creates an excerpt from content
+// GenerateExcerpt creates a plain-text excerpt of at most maxLength
+// characters from the Markdown content, cut at a word boundary
 func (p *Post) GenerateExcerpt(maxLength int) string {
-	if len(p.Content) <= maxLength {
-		return p.Content
-	}
-	return p.Content[:maxLength] + "..."
+	return helpers.Truncate(helpers.PlainText(p.Content), maxLength)
 }
 
 // Validate validates the post version
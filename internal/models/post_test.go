@@ -152,7 +152,19 @@ func TestPost_GenerateExcerpt(t *testing.T) {
 			name:    "long content truncated",
 			content: "This is a very long piece of content that should be truncated to the specified length and should end with an ellipsis to indicate that there is more content available.",
 			length:  50,
-			want:    "This is a very long piece of content that should b...",
+			want:    "This is a very long piece of content that should…",
+		},
+		{
+			name:    "multi-byte characters are not split",
+			content: "Ünïcödé çöntént ïs çüt öñ rüñé bøüñdärïës",
+			length:  12,
+			want:    "Ünïcödé…",
+		},
+		{
+			name:    "markdown is stripped",
+			content: "Some **bold** and [linked](https://example.com) text",
+			length:  200,
+			want:    "Some bold and linked text",
 		},
 		{
 			name:    "exactly at length",
@@ -0,0 +1,173 @@
+// Package pagination pages through listings with cursors rather than
+// offsets.
+//
+// Listings are ordered newest first by publication time, with the ID
+// breaking ties, and a cursor is the position of a row in that order. A page
+// is the rows after or before a cursor, so the database seeks to it along an
+// index instead of counting past every row in front of it, and posts
+// published while a reader browses don't shift the pages they are on.
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page sizes of listings
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ErrInvalidCursor is returned for a token that isn't one Encode made.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of a row in a listing.
+type Cursor struct {
+	Time time.Time
+	ID   int64
+}
+
+// Encode returns the cursor as an opaque token for URLs. The time keeps its
+// offset, so that the database compares it with the value it was read from.
+func (c Cursor) Encode() string {
+	raw := c.Time.Format(time.RFC3339Nano) + "|" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode reads a cursor from a token made by Encode.
+func Decode(token string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if c.Time, err = time.Parse(time.RFC3339Nano, at); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Request asks for a page of a listing: the first one, or the one after or
+// before a cursor.
+type Request struct {
+	After  *Cursor
+	Before *Cursor
+	Limit  int
+}
+
+// Parse makes a request from the after and before tokens of a URL, either
+// of which may be empty, for pages of limit rows.
+func Parse(after, before string, limit int) (Request, error) {
+	req := Request{Limit: limit}
+	switch {
+	case after != "" && before != "":
+		return Request{}, ErrInvalidCursor
+	case after != "":
+		c, err := Decode(after)
+		if err != nil {
+			return Request{}, err
+		}
+		req.After = &c
+	case before != "":
+		c, err := Decode(before)
+		if err != nil {
+			return Request{}, err
+		}
+		req.Before = &c
+	}
+	return req, nil
+}
+
+// PageSize returns the number of rows on a page, DefaultLimit when the
+// request sets none and at most MaxLimit.
+func (r Request) PageSize() int {
+	if r.Limit <= 0 {
+		return DefaultLimit
+	}
+	return min(r.Limit, MaxLimit)
+}
+
+// Result is a page of a listing, the cursors of the pages next to it, nil
+// where there is none, and the number of rows in the whole listing.
+type Result[T any] struct {
+	Items []T
+	Next  *Cursor
+	Prev  *Cursor
+	Total int
+}
+
+// Paginate makes the page req asked for from rows fetched for it: up to one
+// more than its page size, in listing order, or in reverse order before a
+// cursor, so that the extra row tells whether there is a page further on.
+// key returns the cursor of a row.
+func Paginate[T any](rows []T, req Request, key func(T) Cursor) *Result[T] {
+	size := req.PageSize()
+	more := len(rows) > size
+	if more {
+		rows = rows[:size]
+	}
+	if req.Before != nil {
+		slices.Reverse(rows)
+	}
+
+	result := &Result[T]{Items: rows}
+	if len(rows) == 0 {
+		return result
+	}
+
+	// Coming from a cursor there are rows on its side of the page
+	first, last := key(rows[0]), key(rows[len(rows)-1])
+	switch {
+	case req.Before != nil:
+		result.Next = &last
+		if more {
+			result.Prev = &first
+		}
+	case req.After != nil:
+		result.Prev = &first
+		if more {
+			result.Next = &last
+		}
+	case more:
+		result.Next = &last
+	}
+	return result
+}
+
+// Links is the view model of the links between the pages of a listing, which
+// the partials/pagination template renders.
+type Links struct {
+	Prev  string
+	Next  string
+	Total int
+}
+
+// Links returns the links to the pages next to r on the listing at u,
+// keeping the other parameters of its query, such as filters.
+func (r *Result[T]) Links(u *url.URL) Links {
+	link := func(param string, c *Cursor) string {
+		if c == nil {
+			return ""
+		}
+		query := u.Query()
+		query.Del("after")
+		query.Del("before")
+		query.Set(param, c.Encode())
+		return u.Path + "?" + query.Encode()
+	}
+	return Links{Prev: link("before", r.Prev), Next: link("after", r.Next), Total: r.Total}
+}
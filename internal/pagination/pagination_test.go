@@ -0,0 +1,121 @@
+package pagination
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCursor_EncodeDecode(t *testing.T) {
+	for _, c := range []Cursor{
+		{Time: time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.UTC), ID: 42},
+		{Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CEST", 2*60*60)), ID: 1},
+	} {
+		got, err := Decode(c.Encode())
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", c.Encode(), err)
+		}
+		if got.ID != c.ID || !got.Time.Equal(c.Time) || got.Time.Format(time.RFC3339Nano) != c.Time.Format(time.RFC3339Nano) {
+			t.Errorf("expected %v, got %v", c, got)
+		}
+	}
+
+	for _, token := range []string{"", "!!", "bm9wZQ", Cursor{}.Encode() + "x"} {
+		if _, err := Decode(token); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Decode(%q): expected ErrInvalidCursor, got %v", token, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	token := Cursor{Time: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), ID: 7}.Encode()
+
+	req, err := Parse("", "", 0)
+	if err != nil || req.After != nil || req.Before != nil || req.PageSize() != DefaultLimit {
+		t.Errorf("expected the first page of %d, got %+v, %v", DefaultLimit, req, err)
+	}
+
+	req, err = Parse(token, "", 500)
+	if err != nil || req.After == nil || req.After.ID != 7 || req.Before != nil || req.PageSize() != MaxLimit {
+		t.Errorf("expected a page of %d after 7, got %+v, %v", MaxLimit, req, err)
+	}
+
+	req, err = Parse("", token, 5)
+	if err != nil || req.Before == nil || req.Before.ID != 7 || req.After != nil || req.PageSize() != 5 {
+		t.Errorf("expected a page of 5 before 7, got %+v, %v", req, err)
+	}
+
+	if _, err := Parse(token, token, 5); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for both cursors, got %v", err)
+	}
+	if _, err := Parse("nope", "", 5); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	key := func(id int) Cursor { return Cursor{ID: int64(id)} }
+	at := func(id int64) *Cursor { return &Cursor{ID: id} }
+	ids := func(c *Cursor) int64 {
+		if c == nil {
+			return 0
+		}
+		return c.ID
+	}
+
+	tests := []struct {
+		name       string
+		rows       []int
+		req        Request
+		want       []int
+		prev, next int64
+	}{
+		{"only page", []int{9, 8}, Request{Limit: 2}, []int{9, 8}, 0, 0},
+		{"first page", []int{9, 8, 7}, Request{Limit: 2}, []int{9, 8}, 0, 8},
+		{"middle page", []int{7, 6, 5}, Request{After: at(8), Limit: 2}, []int{7, 6}, 7, 6},
+		{"last page", []int{5}, Request{After: at(6), Limit: 2}, []int{5}, 5, 0},
+		{"back a page", []int{7, 8, 9}, Request{Before: at(6), Limit: 2}, []int{8, 7}, 8, 7},
+		{"back to the start", []int{8, 9}, Request{Before: at(7), Limit: 2}, []int{9, 8}, 0, 8},
+		{"empty", nil, Request{After: at(1), Limit: 2}, nil, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Paginate(tt.rows, tt.req, key)
+			if len(result.Items) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, result.Items)
+			}
+			for i := range tt.want {
+				if result.Items[i] != tt.want[i] {
+					t.Fatalf("expected %v, got %v", tt.want, result.Items)
+				}
+			}
+			if ids(result.Prev) != tt.prev || ids(result.Next) != tt.next {
+				t.Errorf("expected prev %d and next %d, got %d and %d", tt.prev, tt.next, ids(result.Prev), ids(result.Next))
+			}
+		})
+	}
+}
+
+func TestResult_Links(t *testing.T) {
+	prev := Cursor{Time: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC), ID: 3}
+	next := Cursor{Time: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), ID: 2}
+	u, _ := url.Parse("/posts?tag=go&after=old")
+
+	links := (&Result[int]{Prev: &prev, Next: &next, Total: 40}).Links(u)
+	if links.Prev != "/posts?before="+prev.Encode()+"&tag=go" {
+		t.Errorf("unexpected previous link %q", links.Prev)
+	}
+	if links.Next != "/posts?after="+next.Encode()+"&tag=go" {
+		t.Errorf("unexpected next link %q", links.Next)
+	}
+	if links.Total != 40 {
+		t.Errorf("expected total 40, got %d", links.Total)
+	}
+
+	links = (&Result[int]{}).Links(u)
+	if links.Prev != "" || links.Next != "" {
+		t.Errorf("expected no links, got %+v", links)
+	}
+}
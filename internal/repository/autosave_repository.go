@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+// AutosaveRepository stores the unsaved editor work of each user, one slot
+// per post.
+type AutosaveRepository struct {
+	db      txn.Querier
+	dialect dialect.Dialect
+}
+
+func NewAutosaveRepository(db txn.Querier, d dialect.Dialect) *AutosaveRepository {
+	return &AutosaveRepository{db: txn.Join(db), dialect: d}
+}
+
+// Get returns a user's autosave of a post, or of a new post when postID is
+// zero.
+func (r *AutosaveRepository) Get(ctx context.Context, userID, postID int64) (*domain.PostAutosave, error) {
+	query := `
+		SELECT user_id, post_id, title, content, base_updated_at, saved_at
+		FROM post_autosaves
+		WHERE user_id = ? AND post_id = ?
+	`
+
+	draft := &domain.PostAutosave{}
+	var baseUpdatedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), userID, postID).Scan(
+		&draft.UserID,
+		&draft.PostID,
+		&draft.Title,
+		&draft.Content,
+		&baseUpdatedAt,
+		&draft.SavedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if baseUpdatedAt.Valid {
+		draft.BaseUpdatedAt = &baseUpdatedAt.Time
+	}
+
+	return draft, nil
+}
+
+// Save stores an autosave, replacing the user's previous one for the post.
+func (r *AutosaveRepository) Save(ctx context.Context, draft *domain.PostAutosave) error {
+	query := `
+		INSERT INTO post_autosaves (user_id, post_id, title, content, base_updated_at, saved_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	` + r.dialect.Upsert(
+		[]string{"user_id", "post_id"},
+		[]string{"title", "content", "base_updated_at", "saved_at"},
+	)
+
+	draft.SavedAt = time.Now()
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(query),
+		draft.UserID,
+		draft.PostID,
+		draft.Title,
+		draft.Content,
+		draft.BaseUpdatedAt,
+		draft.SavedAt,
+	)
+	return err
+}
+
+func (r *AutosaveRepository) Delete(ctx context.Context, userID, postID int64) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM post_autosaves WHERE user_id = ? AND post_id = ?`), userID, postID)
+	return err
+}
+
+// DeleteByPost removes every user's autosave of a deleted post.
+func (r *AutosaveRepository) DeleteByPost(ctx context.Context, postID int64) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM post_autosaves WHERE post_id = ?`), postID)
+	return err
+}
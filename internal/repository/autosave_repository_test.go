@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+var autosaveColumns = []string{"user_id", "post_id", "title", "content", "base_updated_at", "saved_at"}
+
+func TestAutosaveRepository_Save(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewAutosaveRepository(db, d)
+		base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		upsert := `ON CONFLICT \(user_id, post_id\) DO UPDATE SET title = EXCLUDED.title`
+		if d.Name() == dialect.DriverMySQL {
+			upsert = `ON DUPLICATE KEY UPDATE title = VALUES\(title\)`
+		}
+		mock.ExpectExec(d.Rebind(`INSERT INTO post_autosaves (.+) VALUES \(\?, \?, \?, \?, \?, \?\) `+upsert)).
+			WithArgs(int64(1), int64(3), "Title", "Body", base, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		draft := &domain.PostAutosave{UserID: 1, PostID: 3, Title: "Title", Content: "Body", BaseUpdatedAt: &base}
+		require.NoError(t, repo.Save(context.Background(), draft))
+		assert.False(t, draft.SavedAt.IsZero())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAutosaveRepository_Get(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewAutosaveRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM post_autosaves WHERE user_id = \? AND post_id = \?`)).
+			WithArgs(int64(1), int64(0)).
+			WillReturnRows(sqlmock.NewRows(autosaveColumns).AddRow(1, 0, "New", "Draft", nil, now))
+
+		draft, err := repo.Get(ctx, 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "Draft", draft.Content)
+		assert.Nil(t, draft.BaseUpdatedAt)
+
+		mock.ExpectQuery(`SELECT (.+) FROM post_autosaves`).
+			WithArgs(int64(1), int64(3)).
+			WillReturnRows(sqlmock.NewRows(autosaveColumns))
+
+		_, err = repo.Get(ctx, 1, 3)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAutosaveRepository_DeleteByPost(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		mock.ExpectExec(d.Rebind(`DELETE FROM post_autosaves WHERE post_id = \?`)).
+			WithArgs(int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		require.NoError(t, NewAutosaveRepository(db, d).DeleteByPost(context.Background(), 3))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,39 @@
+package repository
+
+import "strings"
+
+// Columns lists, by table, the columns the repositories read or write.
+// `migrate schema check` compares it against the live database, so a query
+// using a new column should add it here along with its migration.
+var Columns = map[string][]string{
+	"posts": {
+		"id", "title", "slug", "content", "author_id", "status", "meta_title", "meta_desc", "is_featured",
+		"reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+	},
+	"pages": {
+		"id", "title", "slug", "content", "author_id", "status", "parent_id", "sort_order", "meta_title", "meta_desc",
+		"published_at", "created_at", "updated_at", "featured_image_id", "version",
+	},
+	"slug_history":     {"content_type", "content_id", "slug", "created_at"},
+	"categories":       {"id", "name", "slug", "created_at"},
+	"tags":             {"id", "name", "slug", "created_at"},
+	"post_categories":  {"post_id", "category_id"},
+	"post_tags":        {"post_id", "tag_id"},
+	"menu_items":       {"id", "location", "label", "page_id", "url", "sort_order", "created_at"},
+	"post_revisions":   {"id", "post_id", "version", "title", "content", "author_id", "created_at"},
+	"review_comments":  {"id", "post_id", "revision_id", "author_id", "decision", "body", "created_at"},
+	"notifications":    {"id", "user_id", "message", "link", "read_at", "created_at"},
+	"comments":         {"id", "post_id", "parent_id", "user_id", "author_name", "author_email", "body", "status", "depth", "ip_address", "created_at"},
+	"media":            splitColumns(mediaColumns),
+	"rendered_content": splitColumns(renderColumns),
+	"post_autosaves":   {"user_id", "post_id", "title", "content", "base_updated_at", "saved_at"},
+}
+
+// splitColumns splits a comma separated column list
+func splitColumns(list string) []string {
+	columns := strings.Split(list, ",")
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+	}
+	return columns
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+// CommentRepository stores reader comments on posts.
+type CommentRepository struct {
+	db      txn.Querier
+	dialect dialect.Dialect
+}
+
+func NewCommentRepository(db txn.Querier, d dialect.Dialect) *CommentRepository {
+	return &CommentRepository{db: txn.Join(db), dialect: d}
+}
+
+func (r *CommentRepository) Create(ctx context.Context, c *domain.Comment) error {
+	query := `
+		INSERT INTO comments (post_id, parent_id, user_id, author_name, author_email, body, status, depth, ip_address, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	id, err := r.dialect.InsertID(
+		ctx,
+		r.db,
+		query,
+		c.PostID,
+		c.ParentID,
+		c.UserID,
+		c.AuthorName,
+		c.AuthorEmail,
+		c.Body,
+		c.Status,
+		c.Depth,
+		c.IPAddress,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+
+	c.ID = id
+	c.CreatedAt = now
+	return nil
+}
+
+func (r *CommentRepository) GetByID(ctx context.Context, id int64) (*domain.Comment, error) {
+	query := `
+		SELECT id, post_id, parent_id, user_id, author_name, author_email, body, status, depth, ip_address, created_at
+		FROM comments
+		WHERE id = ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return comments[0], nil
+}
+
+// ListByPost returns a post's comments with the given status, oldest first.
+func (r *CommentRepository) ListByPost(ctx context.Context, postID int64, status domain.CommentStatus) ([]*domain.Comment, error) {
+	query := `
+		SELECT id, post_id, parent_id, user_id, author_name, author_email, body, status, depth, ip_address, created_at
+		FROM comments
+		WHERE post_id = ? AND status = ?
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), postID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanComments(rows, false)
+}
+
+// ListByStatus returns comments across all posts for moderation, newest first,
+// with the title and slug of the post each belongs to.
+func (r *CommentRepository) ListByStatus(ctx context.Context, status domain.CommentStatus, limit, offset int) ([]*domain.Comment, error) {
+	query := `
+		SELECT c.id, c.post_id, c.parent_id, c.user_id, c.author_name, c.author_email, c.body, c.status, c.depth, c.ip_address, c.created_at,
+			p.title, p.slug
+		FROM comments c
+		JOIN posts p ON p.id = c.post_id
+		WHERE c.status = ?
+		ORDER BY c.created_at DESC, c.id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanComments(rows, true)
+}
+
+// CountByStatus returns the number of comments in each moderation queue.
+func (r *CommentRepository) CountByStatus(ctx context.Context) (map[domain.CommentStatus]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM comments GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.CommentStatus]int)
+	for rows.Next() {
+		var status domain.CommentStatus
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *CommentRepository) UpdateStatus(ctx context.Context, id int64, status domain.CommentStatus) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(`UPDATE comments SET status = ? WHERE id = ?`), status, id)
+	return err
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM comments WHERE id = ?`), id)
+	return err
+}
+
+func scanComments(rows *sql.Rows, withPost bool) ([]*domain.Comment, error) {
+	var comments []*domain.Comment
+
+	for rows.Next() {
+		c := &domain.Comment{}
+		var parentID, userID sql.NullInt64
+
+		dest := []interface{}{
+			&c.ID,
+			&c.PostID,
+			&parentID,
+			&userID,
+			&c.AuthorName,
+			&c.AuthorEmail,
+			&c.Body,
+			&c.Status,
+			&c.Depth,
+			&c.IPAddress,
+			&c.CreatedAt,
+		}
+		if withPost {
+			dest = append(dest, &c.PostTitle, &c.PostSlug)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		if parentID.Valid {
+			c.ParentID = &parentID.Int64
+		}
+		if userID.Valid {
+			c.UserID = &userID.Int64
+		}
+
+		comments = append(comments, c)
+	}
+
+	return comments, rows.Err()
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+var commentColumns = []string{"id", "post_id", "parent_id", "user_id", "author_name", "author_email", "body", "status", "depth", "ip_address", "created_at"}
+
+func TestCommentRepository_Create(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewCommentRepository(db, d)
+		parentID := int64(4)
+
+		expectInsertID(mock, d, `INSERT INTO comments`, 9,
+			int64(3), &parentID, nil, "Ann", "ann@example.com", "Nice post", domain.CommentStatusPending, 1, "10.0.0.1", sqlmock.AnyArg())
+
+		c := &domain.Comment{
+			PostID:      3,
+			ParentID:    &parentID,
+			AuthorName:  "Ann",
+			AuthorEmail: "ann@example.com",
+			Body:        "Nice post",
+			Status:      domain.CommentStatusPending,
+			Depth:       1,
+			IPAddress:   "10.0.0.1",
+		}
+		require.NoError(t, repo.Create(context.Background(), c))
+		assert.Equal(t, int64(9), c.ID)
+		assert.NotZero(t, c.CreatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCommentRepository_GetByID(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewCommentRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM comments WHERE id = \?`)).
+			WithArgs(int64(9)).
+			WillReturnRows(sqlmock.NewRows(commentColumns).
+				AddRow(9, 3, 4, 2, "Ann", "", "Nice post", "approved", 1, "", time.Now()))
+
+		c, err := repo.GetByID(ctx, 9)
+		require.NoError(t, err)
+		require.NotNil(t, c.ParentID)
+		assert.Equal(t, int64(4), *c.ParentID)
+		require.NotNil(t, c.UserID)
+		assert.Equal(t, int64(2), *c.UserID)
+		assert.Equal(t, domain.CommentStatusApproved, c.Status)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM comments WHERE id = \?`)).
+			WithArgs(int64(10)).
+			WillReturnRows(sqlmock.NewRows(commentColumns))
+
+		_, err = repo.GetByID(ctx, 10)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCommentRepository_ListByPost(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewCommentRepository(db, d)
+		now := time.Now()
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM comments WHERE post_id = \? AND status = \? ORDER BY created_at, id`)).
+			WithArgs(int64(3), domain.CommentStatusApproved).
+			WillReturnRows(sqlmock.NewRows(commentColumns).
+				AddRow(1, 3, nil, nil, "Ann", "", "First", "approved", 0, "", now).
+				AddRow(2, 3, 1, 2, "Bob", "", "Reply", "approved", 1, "", now))
+
+		list, err := repo.ListByPost(context.Background(), 3, domain.CommentStatusApproved)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Nil(t, list[0].ParentID)
+		assert.Nil(t, list[0].UserID)
+		assert.Equal(t, int64(1), *list[1].ParentID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCommentRepository_ListByStatus(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewCommentRepository(db, d)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM comments c JOIN posts p ON p.id = c.post_id WHERE c.status = \? (.+) LIMIT \? OFFSET \?`)).
+			WithArgs(domain.CommentStatusPending, 50, 0).
+			WillReturnRows(sqlmock.NewRows(append(commentColumns, "title", "slug")).
+				AddRow(5, 3, nil, nil, "Ann", "", "Hi", "pending", 0, "", time.Now(), "Hello", "hello"))
+
+		list, err := repo.ListByStatus(context.Background(), domain.CommentStatusPending, 50, 0)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, "Hello", list[0].PostTitle)
+		assert.Equal(t, "hello", list[0].PostSlug)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCommentRepository_CountByStatus(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewCommentRepository(db, d)
+
+		mock.ExpectQuery(`SELECT status, COUNT\(\*\) FROM comments GROUP BY status`).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+				AddRow("pending", 4).
+				AddRow("spam", 2))
+
+		counts, err := repo.CountByStatus(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 4, counts[domain.CommentStatusPending])
+		assert.Equal(t, 2, counts[domain.CommentStatusSpam])
+		assert.Equal(t, 0, counts[domain.CommentStatusApproved])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCommentRepository_UpdateStatusAndDelete(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewCommentRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectExec(d.Rebind(`UPDATE comments SET status = \? WHERE id = \?`)).
+			WithArgs(domain.CommentStatusSpam, int64(5)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(d.Rebind(`DELETE FROM comments WHERE id = \?`)).
+			WithArgs(int64(5)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.UpdateStatus(ctx, 5, domain.CommentStatusSpam))
+		assert.NoError(t, repo.Delete(ctx, 5))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
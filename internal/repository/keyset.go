@@ -0,0 +1,20 @@
+package repository
+
+import "github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
+
+// keyset returns the condition, its arguments and the ORDER BY clause that
+// select the page req asks for from a listing ordered newest first by the
+// columns at and id. The condition is empty for the first page. Before a
+// cursor the rows come oldest first, as pagination.Paginate expects.
+func keyset(req pagination.Request, at, id string) (cond string, args []interface{}, order string) {
+	switch {
+	case req.After != nil:
+		c := req.After
+		return `(` + at + ` < ? OR (` + at + ` = ? AND ` + id + ` < ?))`, []interface{}{c.Time, c.Time, c.ID}, at + ` DESC, ` + id + ` DESC`
+	case req.Before != nil:
+		c := req.Before
+		return `(` + at + ` > ? OR (` + at + ` = ? AND ` + id + ` > ?))`, []interface{}{c.Time, c.Time, c.ID}, at + ` ASC, ` + id + ` ASC`
+	default:
+		return "", nil, at + ` DESC, ` + id + ` DESC`
+	}
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+// MediaRepository stores the media library.
+type MediaRepository struct {
+	db      txn.Querier
+	dialect dialect.Dialect
+}
+
+func NewMediaRepository(db txn.Querier, d dialect.Dialect) *MediaRepository {
+	return &MediaRepository{db: txn.Join(db), dialect: d}
+}
+
+const mediaColumns = `id, owner_id, file_name, path, mime_type, size, width, height, alt_text, checksum, created_at`
+
+func (r *MediaRepository) Create(ctx context.Context, m *domain.Media) error {
+	query := `
+		INSERT INTO media (owner_id, file_name, path, mime_type, size, width, height, alt_text, checksum, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	id, err := r.dialect.InsertID(
+		ctx,
+		r.db,
+		query,
+		m.OwnerID,
+		m.FileName,
+		m.Path,
+		m.MimeType,
+		m.Size,
+		m.Width,
+		m.Height,
+		m.AltText,
+		m.Checksum,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+
+	m.ID = id
+	m.CreatedAt = now
+	return nil
+}
+
+func (r *MediaRepository) GetByID(ctx context.Context, id int64) (*domain.Media, error) {
+	return r.getOne(ctx, `SELECT `+mediaColumns+` FROM media WHERE id = ?`, id)
+}
+
+// GetByChecksum finds a file the owner has already uploaded.
+func (r *MediaRepository) GetByChecksum(ctx context.Context, ownerID int64, checksum string) (*domain.Media, error) {
+	return r.getOne(ctx, `SELECT `+mediaColumns+` FROM media WHERE owner_id = ? AND checksum = ? ORDER BY id LIMIT 1`, ownerID, checksum)
+}
+
+// List returns the whole library, newest first.
+func (r *MediaRepository) List(ctx context.Context, limit, offset int) ([]*domain.Media, error) {
+	return r.list(ctx, `SELECT `+mediaColumns+` FROM media ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`, limit, offset)
+}
+
+// ListByOwner returns one user's uploads, newest first.
+func (r *MediaRepository) ListByOwner(ctx context.Context, ownerID int64, limit, offset int) ([]*domain.Media, error) {
+	return r.list(ctx, `SELECT `+mediaColumns+` FROM media WHERE owner_id = ? ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`, ownerID, limit, offset)
+}
+
+func (r *MediaRepository) UpdateAltText(ctx context.Context, id int64, alt string) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(`UPDATE media SET alt_text = ? WHERE id = ?`), alt, id)
+	return err
+}
+
+func (r *MediaRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM media WHERE id = ?`), id)
+	return err
+}
+
+// ListByIDs returns the media with the given IDs, in no particular order.
+func (r *MediaRepository) ListByIDs(ctx context.Context, ids []int64) ([]*domain.Media, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	return r.list(ctx, `SELECT `+mediaColumns+` FROM media WHERE id IN (`+placeholders(len(ids))+`)`, int64Args(ids)...)
+}
+
+// ListReferences returns the posts and pages that use a media item, either
+// as their featured image or in content mentioning basePath, which matches
+// the original file and every variant of it.
+func (r *MediaRepository) ListReferences(ctx context.Context, id int64, basePath string) ([]*domain.MediaReference, error) {
+	query := `
+		SELECT 'post', id, title FROM posts WHERE content LIKE ? OR featured_image_id = ?
+		UNION ALL
+		SELECT 'page', id, title FROM pages WHERE content LIKE ? OR featured_image_id = ?
+		ORDER BY 1, 2
+	`
+
+	pattern := "%" + escapeLike(basePath) + "%"
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), pattern, id, pattern, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []*domain.MediaReference
+	for rows.Next() {
+		ref := &domain.MediaReference{}
+		if err := rows.Scan(&ref.Type, &ref.ID, &ref.Title); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
+func (r *MediaRepository) getOne(ctx context.Context, query string, args ...interface{}) (*domain.Media, error) {
+	list, err := r.list(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return list[0], nil
+}
+
+func (r *MediaRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.Media, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*domain.Media
+	for rows.Next() {
+		m := &domain.Media{}
+		err := rows.Scan(
+			&m.ID,
+			&m.OwnerID,
+			&m.FileName,
+			&m.Path,
+			&m.MimeType,
+			&m.Size,
+			&m.Width,
+			&m.Height,
+			&m.AltText,
+			&m.Checksum,
+			&m.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, m)
+	}
+
+	return items, rows.Err()
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+var mediaRowColumns = []string{"id", "owner_id", "file_name", "path", "mime_type", "size", "width", "height", "alt_text", "checksum", "created_at"}
+
+func TestMediaRepository_Create(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewMediaRepository(db, d)
+
+		expectInsertID(mock, d, `INSERT INTO media`, 5,
+			int64(2), "bike.jpg", "/uploads/2026/01/abc.jpg", "image/jpeg", int64(1024), 800, 600, "A bike", "deadbeef", sqlmock.AnyArg())
+
+		m := &domain.Media{
+			OwnerID:  2,
+			FileName: "bike.jpg",
+			Path:     "/uploads/2026/01/abc.jpg",
+			MimeType: "image/jpeg",
+			Size:     1024,
+			Width:    800,
+			Height:   600,
+			AltText:  "A bike",
+			Checksum: "deadbeef",
+		}
+		require.NoError(t, repo.Create(context.Background(), m))
+		assert.Equal(t, int64(5), m.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMediaRepository_Get(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewMediaRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM media WHERE id = \?`)).
+			WithArgs(int64(5)).
+			WillReturnRows(sqlmock.NewRows(mediaRowColumns).
+				AddRow(5, 2, "bike.jpg", "/uploads/2026/01/abc.jpg", "image/jpeg", 1024, 800, 600, "A bike", "deadbeef", time.Now()))
+
+		m, err := repo.GetByID(ctx, 5)
+		require.NoError(t, err)
+		assert.Equal(t, "A bike", m.AltText)
+		assert.Equal(t, 800, m.Width)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM media WHERE owner_id = \? AND checksum = \?`)).
+			WithArgs(int64(2), "cafe").
+			WillReturnRows(sqlmock.NewRows(mediaRowColumns))
+
+		_, err = repo.GetByChecksum(ctx, 2, "cafe")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMediaRepository_List(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewMediaRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM media ORDER BY created_at DESC, id DESC LIMIT \? OFFSET \?`)).
+			WithArgs(24, 0).
+			WillReturnRows(sqlmock.NewRows(mediaRowColumns).
+				AddRow(6, 3, "b.png", "/uploads/b.png", "image/png", 10, 1, 1, "", "b", now).
+				AddRow(5, 2, "a.jpg", "/uploads/a.jpg", "image/jpeg", 10, 1, 1, "", "a", now))
+
+		all, err := repo.List(ctx, 24, 0)
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM media WHERE owner_id = \? ORDER BY (.+) LIMIT \? OFFSET \?`)).
+			WithArgs(int64(2), 24, 0).
+			WillReturnRows(sqlmock.NewRows(mediaRowColumns).
+				AddRow(5, 2, "a.jpg", "/uploads/a.jpg", "image/jpeg", 10, 1, 1, "", "a", now))
+
+		mine, err := repo.ListByOwner(ctx, 2, 24, 0)
+		require.NoError(t, err)
+		assert.Len(t, mine, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMediaRepository_UpdateAndDelete(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewMediaRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectExec(d.Rebind(`UPDATE media SET alt_text = \? WHERE id = \?`)).
+			WithArgs("A red bike", int64(5)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(d.Rebind(`DELETE FROM media WHERE id = \?`)).
+			WithArgs(int64(5)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.UpdateAltText(ctx, 5, "A red bike"))
+		assert.NoError(t, repo.Delete(ctx, 5))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMediaRepository_ListByIDs(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewMediaRepository(db, d)
+		now := time.Now()
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM media WHERE id IN \(\?, \?\)`)).
+			WithArgs(int64(4), int64(7)).
+			WillReturnRows(sqlmock.NewRows(mediaRowColumns).
+				AddRow(7, 2, "b.png", "/uploads/b.png", "image/png", 10, 1, 1, "", "x", now))
+
+		items, err := repo.ListByIDs(context.Background(), []int64{4, 7})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, int64(7), items[0].ID)
+
+		items, err = repo.ListByIDs(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Empty(t, items)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMediaRepository_ListReferences(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewMediaRepository(db, d)
+
+		mock.ExpectQuery(d.Rebind(`SELECT 'post', id, title FROM posts WHERE content LIKE \? OR featured_image_id = \? UNION ALL SELECT 'page'`)).
+			WithArgs(`%/uploads/2026/01/my\_file%`, int64(5), `%/uploads/2026/01/my\_file%`, int64(5)).
+			WillReturnRows(sqlmock.NewRows([]string{"type", "id", "title"}).
+				AddRow("post", 3, "Hello").
+				AddRow("page", 1, "About"))
+
+		refs, err := repo.ListReferences(context.Background(), 5, "/uploads/2026/01/my_file")
+		require.NoError(t, err)
+		require.Len(t, refs, 2)
+		assert.Equal(t, &domain.MediaReference{Type: "post", ID: 3, Title: "Hello"}, refs[0])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+// MenuRepository stores the navigation menu items of each location.
+type MenuRepository struct {
+	db      txn.Querier
+	dialect dialect.Dialect
+}
+
+func NewMenuRepository(db txn.Querier, d dialect.Dialect) *MenuRepository {
+	return &MenuRepository{db: txn.Join(db), dialect: d}
+}
+
+// ListByLocation returns the items of a menu in display order.
+func (r *MenuRepository) ListByLocation(ctx context.Context, location domain.MenuLocation) ([]*domain.MenuItem, error) {
+	query := `
+		SELECT id, location, label, page_id, url, sort_order, created_at
+		FROM menu_items
+		WHERE location = ?
+		ORDER BY sort_order, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), location)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*domain.MenuItem
+	for rows.Next() {
+		item := &domain.MenuItem{}
+		var pageID sql.NullInt64
+		if err := rows.Scan(&item.ID, &item.Location, &item.Label, &pageID, &item.URL, &item.SortOrder, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		if pageID.Valid {
+			item.PageID = &pageID.Int64
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// ReplaceLocation swaps all items of a menu for the given ones in a single transaction.
+func (r *MenuRepository) ReplaceLocation(ctx context.Context, location domain.MenuLocation, items []*domain.MenuItem) error {
+	return txn.Do(ctx, r.db, func(ctx context.Context) error {
+		if _, err := r.db.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM menu_items WHERE location = ?`), location); err != nil {
+			return err
+		}
+
+		query := r.dialect.Rebind(`
+			INSERT INTO menu_items (location, label, page_id, url, sort_order, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`)
+		now := time.Now()
+		for _, item := range items {
+			if _, err := r.db.ExecContext(ctx, query, location, item.Label, item.PageID, item.URL, item.SortOrder, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
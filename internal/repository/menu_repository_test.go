@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+func TestMenuRepository_ListByLocation(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewMenuRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{"id", "location", "label", "page_id", "url", "sort_order", "created_at"}).
+			AddRow(1, "header", "", 5, "", 0, now).
+			AddRow(2, "header", "GitHub", nil, "https://github.com/toutaio", 1, now)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM menu_items WHERE location = \? ORDER BY sort_order, id`)).
+			WithArgs(domain.MenuHeader).
+			WillReturnRows(rows)
+
+		items, err := repo.ListByLocation(ctx, domain.MenuHeader)
+		assert.NoError(t, err)
+		require.Len(t, items, 2)
+		require.NotNil(t, items[0].PageID)
+		assert.Equal(t, int64(5), *items[0].PageID)
+		assert.Nil(t, items[1].PageID)
+		assert.Equal(t, "https://github.com/toutaio", items[1].URL)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMenuRepository_ReplaceLocation(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewMenuRepository(db, d)
+		ctx := context.Background()
+		pageID := int64(5)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(d.Rebind(`DELETE FROM menu_items WHERE location = \?`)).
+			WithArgs(domain.MenuFooter).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`INSERT INTO menu_items`).
+			WithArgs(domain.MenuFooter, "About", &pageID, "", 0, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO menu_items`).
+			WithArgs(domain.MenuFooter, "Docs", nil, "/docs", 1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		err := repo.ReplaceLocation(ctx, domain.MenuFooter, []*domain.MenuItem{
+			{Label: "About", PageID: &pageID, SortOrder: 0},
+			{Label: "Docs", URL: "/docs", SortOrder: 1},
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMenuRepository_ReplaceLocation_RollsBackOnError(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewMenuRepository(db, d)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM menu_items`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+
+		err := repo.ReplaceLocation(context.Background(), domain.MenuHeader, nil)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+// NotificationRepository stores messages for users.
+type NotificationRepository struct {
+	db      txn.Querier
+	dialect dialect.Dialect
+}
+
+func NewNotificationRepository(db txn.Querier, d dialect.Dialect) *NotificationRepository {
+	return &NotificationRepository{db: txn.Join(db), dialect: d}
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, n *domain.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, message, link, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	id, err := r.dialect.InsertID(ctx, r.db, query, n.UserID, n.Message, n.Link, now)
+	if err != nil {
+		return err
+	}
+
+	n.ID = id
+	n.CreatedAt = now
+	return nil
+}
+
+// ListByUser returns a user's most recent notifications, newest first.
+func (r *NotificationRepository) ListByUser(ctx context.Context, userID int64, limit int) ([]*domain.Notification, error) {
+	query := `
+		SELECT id, user_id, message, link, read_at, created_at
+		FROM notifications
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*domain.Notification
+	for rows.Next() {
+		n := &domain.Notification{}
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Message, &n.Link, &readAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// MarkAllRead marks every unread notification of a user as read.
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID int64) error {
+	query := `UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(query), time.Now(), userID)
+	return err
+}
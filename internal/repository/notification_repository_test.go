@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+func TestNotificationRepository(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewNotificationRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		expectInsertID(mock, d, `INSERT INTO notifications`, 1,
+			int64(7), "Your post was approved", "/posts/3/edit", sqlmock.AnyArg())
+
+		n := &domain.Notification{UserID: 7, Message: "Your post was approved", Link: "/posts/3/edit"}
+		require.NoError(t, repo.Create(ctx, n))
+		assert.Equal(t, int64(1), n.ID)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM notifications WHERE user_id = \? ORDER BY created_at DESC, id DESC LIMIT \?`)).
+			WithArgs(int64(7), 20).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "message", "link", "read_at", "created_at"}).
+				AddRow(2, 7, "Changes requested", "/posts/3/edit", nil, now).
+				AddRow(1, 7, "Your post was approved", "/posts/3/edit", now, now))
+
+		list, err := repo.ListByUser(ctx, 7, 20)
+		assert.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Nil(t, list[0].ReadAt)
+		assert.NotNil(t, list[1].ReadAt)
+
+		mock.ExpectExec(d.Rebind(`UPDATE notifications SET read_at = \? WHERE user_id = \? AND read_at IS NULL`)).
+			WithArgs(sqlmock.AnyArg(), int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.MarkAllRead(ctx, 7))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
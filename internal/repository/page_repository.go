@@ -5,181 +5,300 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
 )
 
 type PageRepository struct {
-	db *sql.DB
+	db      txn.Querier
+	dialect dialect.Dialect
 }
 
-func NewPageRepository(db *sql.DB) *PageRepository {
-	return &PageRepository{db: db}
+func NewPageRepository(db txn.Querier, d dialect.Dialect) *PageRepository {
+	return &PageRepository{db: txn.Join(db), dialect: d}
 }
 
 func (r *PageRepository) Create(ctx context.Context, page *domain.Page) error {
 	query := `
-		INSERT INTO pages (title, slug, content, status, meta_title, meta_desc, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, created_at, updated_at
+		INSERT INTO pages (title, slug, content, author_id, status, parent_id, sort_order, meta_title, meta_desc, featured_image_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now()
-	return r.db.QueryRowContext(
+	id, err := r.dialect.InsertID(
 		ctx,
+		r.db,
 		query,
 		page.Title,
 		page.Slug,
 		page.Content,
+		page.AuthorID,
 		page.Status,
+		page.ParentID,
+		page.SortOrder,
 		page.MetaTitle,
 		page.MetaDesc,
+		page.FeaturedImageID,
 		now,
 		now,
-	).Scan(&page.ID, &page.CreatedAt, &page.UpdatedAt)
+	)
+	if err != nil {
+		return err
+	}
+
+	// New pages start at the column's default version
+	page.ID = id
+	page.CreatedAt = now
+	page.UpdatedAt = now
+	page.Version = 1
+	return nil
 }
 
 func (r *PageRepository) GetByID(ctx context.Context, id int64) (*domain.Page, error) {
 	query := `
-		SELECT id, title, slug, content, status, meta_title, meta_desc, published_at, created_at, updated_at
+		SELECT id, title, slug, content, author_id, status, parent_id, sort_order, meta_title, meta_desc, published_at, created_at, updated_at, featured_image_id, version
 		FROM pages
-		WHERE id = $1
+		WHERE id = ?
 	`
 
 	page := &domain.Page{}
+	var parentID sql.NullInt64
 	var publishedAt sql.NullTime
+	var featuredImageID sql.NullInt64
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), id).Scan(
 		&page.ID,
 		&page.Title,
 		&page.Slug,
 		&page.Content,
+		&page.AuthorID,
 		&page.Status,
+		&parentID,
+		&page.SortOrder,
 		&page.MetaTitle,
 		&page.MetaDesc,
 		&publishedAt,
 		&page.CreatedAt,
 		&page.UpdatedAt,
+		&featuredImageID,
+		&page.Version,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if parentID.Valid {
+		page.ParentID = &parentID.Int64
+	}
 	if publishedAt.Valid {
 		page.PublishedAt = &publishedAt.Time
 	}
+	if featuredImageID.Valid {
+		page.FeaturedImageID = &featuredImageID.Int64
+	}
 
 	return page, nil
 }
 
 func (r *PageRepository) GetBySlug(ctx context.Context, slug string) (*domain.Page, error) {
 	query := `
-		SELECT id, title, slug, content, status, meta_title, meta_desc, published_at, created_at, updated_at
+		SELECT id, title, slug, content, author_id, status, parent_id, sort_order, meta_title, meta_desc, published_at, created_at, updated_at, featured_image_id, version
 		FROM pages
-		WHERE slug = $1
+		WHERE slug = ?
 	`
 
 	page := &domain.Page{}
+	var parentID sql.NullInt64
 	var publishedAt sql.NullTime
+	var featuredImageID sql.NullInt64
 
-	err := r.db.QueryRowContext(ctx, query, slug).Scan(
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), slug).Scan(
 		&page.ID,
 		&page.Title,
 		&page.Slug,
 		&page.Content,
+		&page.AuthorID,
 		&page.Status,
+		&parentID,
+		&page.SortOrder,
 		&page.MetaTitle,
 		&page.MetaDesc,
 		&publishedAt,
 		&page.CreatedAt,
 		&page.UpdatedAt,
+		&featuredImageID,
+		&page.Version,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if parentID.Valid {
+		page.ParentID = &parentID.Int64
+	}
 	if publishedAt.Valid {
 		page.PublishedAt = &publishedAt.Time
 	}
+	if featuredImageID.Valid {
+		page.FeaturedImageID = &featuredImageID.Int64
+	}
 
 	return page, nil
 }
 
+// Update saves page if it is still at the version it was loaded with and
+// moves it to the next version. It returns sql.ErrNoRows when the page was
+// changed or deleted in the meantime.
 func (r *PageRepository) Update(ctx context.Context, page *domain.Page) error {
 	query := `
 		UPDATE pages
-		SET title = $1, slug = $2, content = $3, status = $4, meta_title = $5, meta_desc = $6, updated_at = $7
-		WHERE id = $8
+		SET title = ?, slug = ?, content = ?, status = ?, parent_id = ?, sort_order = ?,
+			meta_title = ?, meta_desc = ?, featured_image_id = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ?
 	`
 
-	_, err := r.db.ExecContext(
+	result, err := r.db.ExecContext(
 		ctx,
-		query,
+		r.dialect.Rebind(query),
 		page.Title,
 		page.Slug,
 		page.Content,
+		page.AuthorID,
 		page.Status,
+		page.ParentID,
+		page.SortOrder,
 		page.MetaTitle,
 		page.MetaDesc,
+		page.FeaturedImageID,
 		time.Now(),
 		page.ID,
+		page.Version,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return nextVersion(result, &page.Version)
 }
 
 func (r *PageRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM pages WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	query := `DELETE FROM pages WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(query), id)
 	return err
 }
 
 func (r *PageRepository) List(ctx context.Context, limit, offset int) ([]*domain.Page, error) {
 	query := `
-		SELECT id, title, slug, content, status, meta_title, meta_desc, published_at, created_at, updated_at
+		SELECT id, title, slug, content, author_id, status, parent_id, sort_order, meta_title, meta_desc, published_at, created_at, updated_at, featured_image_id, version
 		FROM pages
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	return r.scanPages(rows)
+	return r.list(ctx, query, limit, offset)
 }
 
 func (r *PageRepository) ListByStatus(ctx context.Context, status domain.PageStatus, limit, offset int) ([]*domain.Page, error) {
 	query := `
-		SELECT id, title, slug, content, status, meta_title, meta_desc, published_at, created_at, updated_at
+		SELECT id, title, slug, content, author_id, status, parent_id, sort_order, meta_title, meta_desc, published_at, created_at, updated_at, featured_image_id, version
 		FROM pages
-		WHERE status = $1
+		WHERE status = ?
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, status, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	return r.scanPages(rows)
+	return r.list(ctx, query, limit, offset, status)
 }
 
 func (r *PageRepository) ListByAuthor(ctx context.Context, authorID int64, limit, offset int) ([]*domain.Page, error) {
 	query := `
-		SELECT id, title, slug, content, status, meta_title, meta_desc, published_at, created_at, updated_at
+		SELECT id, title, slug, content, author_id, status, parent_id, sort_order, meta_title, meta_desc, published_at, created_at, updated_at, featured_image_id, version
 		FROM pages
-		WHERE author_id = $1
+		WHERE author_id = ?
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, authorID, limit, offset)
+	return r.list(ctx, query, limit, offset, authorID)
+}
+
+// ListPublished returns the page req asks for of the published pages,
+// newest first by publication time.
+func (r *PageRepository) ListPublished(ctx context.Context, req pagination.Request) ([]*domain.Page, error) {
+	where, args := "status = ?", []interface{}{domain.PageStatusPublished}
+	cond, keyArgs, order := keyset(req, "published_at", "id")
+	if cond != "" {
+		where += " AND " + cond
+		args = append(args, keyArgs...)
+	}
+
+	query := `
+		SELECT id, title, slug, content, author_id, status, parent_id, sort_order, meta_title, meta_desc, published_at, created_at, updated_at, featured_image_id, version
+		FROM pages
+		WHERE ` + where + `
+		ORDER BY ` + order
+
+	// One more page than fits tells whether there is another
+	return r.list(ctx, query, req.PageSize()+1, 0, args...)
+}
+
+// CountPublished returns the number of published pages.
+func (r *PageRepository) CountPublished(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM pages WHERE status = ?`
+
+	var n int
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), domain.PageStatusPublished).Scan(&n)
+	return n, err
+}
+
+// ListNodes returns the hierarchy fields of every page, ordered by position.
+func (r *PageRepository) ListNodes(ctx context.Context) ([]*domain.PageNode, error) {
+	query := `
+		SELECT id, parent_id, title, slug, status, sort_order, updated_at
+		FROM pages
+		ORDER BY sort_order, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []*domain.PageNode
+	for rows.Next() {
+		node := &domain.PageNode{}
+		var parentID sql.NullInt64
+		if err := rows.Scan(&node.ID, &parentID, &node.Title, &node.Slug, &node.Status, &node.SortOrder, &node.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if parentID.Valid {
+			node.ParentID = &parentID.Int64
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, rows.Err()
+}
+
+// UpdatePositions moves pages to new parents and sort orders in a single transaction.
+func (r *PageRepository) UpdatePositions(ctx context.Context, positions []domain.PagePosition) error {
+	query := r.dialect.Rebind(`UPDATE pages SET parent_id = ?, sort_order = ? WHERE id = ?`)
+	return txn.Do(ctx, r.db, func(ctx context.Context) error {
+		for _, p := range positions {
+			if _, err := r.db.ExecContext(ctx, query, p.ParentID, p.SortOrder, p.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// list runs a SELECT of pages with the page of results appended
+func (r *PageRepository) list(ctx context.Context, query string, limit, offset int, args ...interface{}) ([]*domain.Page, error) {
+	paging, pagingArgs := r.dialect.LimitOffset(limit, offset)
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query+" "+paging), append(args, pagingArgs...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -193,28 +312,41 @@ func (r *PageRepository) scanPages(rows *sql.Rows) ([]*domain.Page, error) {
 
 	for rows.Next() {
 		page := &domain.Page{}
+		var parentID sql.NullInt64
 		var publishedAt sql.NullTime
+		var featuredImageID sql.NullInt64
 
 		err := rows.Scan(
 			&page.ID,
 			&page.Title,
 			&page.Slug,
 			&page.Content,
+			&page.AuthorID,
 			&page.Status,
+			&parentID,
+			&page.SortOrder,
 			&page.MetaTitle,
 			&page.MetaDesc,
 			&publishedAt,
 			&page.CreatedAt,
 			&page.UpdatedAt,
+			&featuredImageID,
+			&page.Version,
 		)
 
 		if err != nil {
 			return nil, err
 		}
 
+		if parentID.Valid {
+			page.ParentID = &parentID.Int64
+		}
 		if publishedAt.Valid {
 			page.PublishedAt = &publishedAt.Time
 		}
+		if featuredImageID.Valid {
+			page.FeaturedImageID = &featuredImageID.Int64
+		}
 
 		pages = append(pages, page)
 	}
@@ -9,210 +9,333 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
 )
 
 func TestPageRepository_Create(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPageRepository(db)
-	ctx := context.Background()
-	now := time.Now()
-
-	page := &domain.Page{
-		Title:     "Test Page",
-		Slug:      "test-page",
-		Content:   "This is test content",
-		Status:    domain.PageStatusDraft,
-		MetaTitle: "Test Meta Title",
-		MetaDesc:  "Test meta description",
-	}
-
-	mock.ExpectQuery(`INSERT INTO pages`).
-		WithArgs(page.Title, page.Slug, page.Content, page.Status, page.MetaTitle, page.MetaDesc, sqlmock.AnyArg(), sqlmock.AnyArg()).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
-			AddRow(1, now, now))
-
-	err = repo.Create(ctx, page)
-	assert.NoError(t, err)
-	assert.Equal(t, int64(1), page.ID)
-	assert.NotZero(t, page.CreatedAt)
-	assert.NotZero(t, page.UpdatedAt)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+
+		page := &domain.Page{
+			Title:     "Test Page",
+			Slug:      "test-page",
+			Content:   "This is test content",
+			AuthorID:  3,
+			Status:    domain.PageStatusDraft,
+			MetaTitle: "Test Meta Title",
+			MetaDesc:  "Test meta description",
+		}
+
+		expectInsertID(mock, d, `INSERT INTO pages`, 1,
+			page.Title, page.Slug, page.Content, page.AuthorID, page.Status, page.ParentID, page.SortOrder, page.MetaTitle, page.MetaDesc, page.FeaturedImageID, sqlmock.AnyArg(), sqlmock.AnyArg())
+
+		err := repo.Create(ctx, page)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), page.ID)
+		assert.Equal(t, 1, page.Version)
+		assert.NotZero(t, page.CreatedAt)
+		assert.NotZero(t, page.UpdatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPageRepository_GetByID(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPageRepository(db)
-	ctx := context.Background()
-	now := time.Now()
-
-	rows := sqlmock.NewRows([]string{
-		"id", "title", "slug", "content", "status",
-		"meta_title", "meta_desc", "published_at", "created_at", "updated_at",
-	}).AddRow(
-		1, "Test Page", "test-page", "Content", domain.PageStatusPublished,
-		"Meta Title", "Meta Desc", now, now, now,
-	)
-
-	mock.ExpectQuery(`SELECT (.+) FROM pages WHERE id = \$1`).
-		WithArgs(int64(1)).
-		WillReturnRows(rows)
-
-	page, err := repo.GetByID(ctx, 1)
-	assert.NoError(t, err)
-	assert.NotNil(t, page)
-	assert.Equal(t, int64(1), page.ID)
-	assert.Equal(t, "Test Page", page.Title)
-	assert.Equal(t, "test-page", page.Slug)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status", "parent_id", "sort_order",
+			"meta_title", "meta_desc", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(
+			1, "Test Page", "test-page", "Content", 1, domain.PageStatusPublished, nil, 0,
+			"Meta Title", "Meta Desc", now, now, now, nil,
+			1,
+		)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM pages WHERE id = \?`)).
+			WithArgs(int64(1)).
+			WillReturnRows(rows)
+
+		page, err := repo.GetByID(ctx, 1)
+		assert.NoError(t, err)
+		assert.NotNil(t, page)
+		assert.Equal(t, int64(1), page.ID)
+		assert.Equal(t, "Test Page", page.Title)
+		assert.Equal(t, "test-page", page.Slug)
+		assert.Equal(t, int64(1), page.AuthorID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPageRepository_GetBySlug(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPageRepository(db)
-	ctx := context.Background()
-	now := time.Now()
-
-	rows := sqlmock.NewRows([]string{
-		"id", "title", "slug", "content", "status",
-		"meta_title", "meta_desc", "published_at", "created_at", "updated_at",
-	}).AddRow(
-		1, "Test Page", "test-page", "Content", domain.PageStatusPublished,
-		"Meta Title", "Meta Desc", now, now, now,
-	)
-
-	mock.ExpectQuery(`SELECT (.+) FROM pages WHERE slug = \$1`).
-		WithArgs("test-page").
-		WillReturnRows(rows)
-
-	page, err := repo.GetBySlug(ctx, "test-page")
-	assert.NoError(t, err)
-	assert.NotNil(t, page)
-	assert.Equal(t, "test-page", page.Slug)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status", "parent_id", "sort_order",
+			"meta_title", "meta_desc", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(
+			1, "Test Page", "test-page", "Content", 1, domain.PageStatusPublished, nil, 0,
+			"Meta Title", "Meta Desc", now, now, now, nil,
+			1,
+		)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM pages WHERE slug = \?`)).
+			WithArgs("test-page").
+			WillReturnRows(rows)
+
+		page, err := repo.GetBySlug(ctx, "test-page")
+		assert.NoError(t, err)
+		assert.NotNil(t, page)
+		assert.Equal(t, "test-page", page.Slug)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPageRepository_Update(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPageRepository(db)
-	ctx := context.Background()
-
-	page := &domain.Page{
-		ID:        1,
-		Title:     "Updated Page",
-		Slug:      "updated-page",
-		Content:   "Updated content",
-		Status:    domain.PageStatusPublished,
-		MetaTitle: "Updated Meta",
-		MetaDesc:  "Updated desc",
-	}
-
-	mock.ExpectExec(`UPDATE pages SET`).
-		WithArgs(page.Title, page.Slug, page.Content, page.Status, page.MetaTitle, page.MetaDesc, sqlmock.AnyArg(), page.ID).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	err = repo.Update(ctx, page)
-	assert.NoError(t, err)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+
+		page := &domain.Page{
+			ID:        1,
+			Title:     "Updated Page",
+			Slug:      "updated-page",
+			Content:   "Updated content",
+			Status:    domain.PageStatusPublished,
+			MetaTitle: "Updated Meta",
+			MetaDesc:  "Updated desc",
+			Version:   2,
+		}
+
+		mock.ExpectExec(`UPDATE pages SET`).
+			WithArgs(page.Title, page.Slug, page.Content, page.AuthorID, page.Status, page.ParentID, page.SortOrder, page.MetaTitle, page.MetaDesc, page.FeaturedImageID, sqlmock.AnyArg(), page.ID, 2).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.Update(ctx, page)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, page.Version)
+
+		// Saving the version that was just replaced changes nothing
+		mock.ExpectExec(`UPDATE pages SET (.+) WHERE id = \S+ AND version = \S+`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		page.Version = 2
+		err = repo.Update(ctx, page)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.Equal(t, 2, page.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPageRepository_Delete(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPageRepository(db)
-	ctx := context.Background()
-
-	mock.ExpectExec(`DELETE FROM pages WHERE id = \$1`).
-		WithArgs(int64(1)).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-
-	err = repo.Delete(ctx, 1)
-	assert.NoError(t, err)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectExec(d.Rebind(`DELETE FROM pages WHERE id = \?`)).
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Delete(ctx, 1)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPageRepository_List(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPageRepository(db)
-	ctx := context.Background()
-	now := time.Now()
-
-	rows := sqlmock.NewRows([]string{
-		"id", "title", "slug", "content", "status",
-		"meta_title", "meta_desc", "published_at", "created_at", "updated_at",
-	}).
-		AddRow(1, "Page 1", "page-1", "Content 1", domain.PageStatusPublished, "Meta 1", "Desc 1", now, now, now).
-		AddRow(2, "Page 2", "page-2", "Content 2", domain.PageStatusPublished, "Meta 2", "Desc 2", now, now, now)
-
-	mock.ExpectQuery(`SELECT (.+) FROM pages ORDER BY created_at DESC LIMIT \$1 OFFSET \$2`).
-		WithArgs(10, 0).
-		WillReturnRows(rows)
-
-	pages, err := repo.List(ctx, 10, 0)
-	assert.NoError(t, err)
-	assert.Len(t, pages, 2)
-	assert.Equal(t, "Page 1", pages[0].Title)
-	assert.Equal(t, "Page 2", pages[1].Title)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status", "parent_id", "sort_order",
+			"meta_title", "meta_desc", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).
+			AddRow(1, "Page 1", "page-1", "Content 1", 1, domain.PageStatusPublished, nil, 0, "Meta 1", "Desc 1", now, now, now, nil, 1).
+			AddRow(2, "Page 2", "page-2", "Content 2", 1, domain.PageStatusPublished, nil, 0, "Meta 2", "Desc 2", now, now, now, nil, 1)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM pages ORDER BY created_at DESC LIMIT \? OFFSET \?`)).
+			WithArgs(10, 0).
+			WillReturnRows(rows)
+
+		pages, err := repo.List(ctx, 10, 0)
+		assert.NoError(t, err)
+		assert.Len(t, pages, 2)
+		assert.Equal(t, "Page 1", pages[0].Title)
+		assert.Equal(t, "Page 2", pages[1].Title)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPageRepository_ListByStatus(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPageRepository(db)
-	ctx := context.Background()
-	now := time.Now()
-
-	rows := sqlmock.NewRows([]string{
-		"id", "title", "slug", "content", "status",
-		"meta_title", "meta_desc", "published_at", "created_at", "updated_at",
-	}).AddRow(1, "Page 1", "page-1", "Content 1", domain.PageStatusPublished, "Meta 1", "Desc 1", now, now, now)
-
-	mock.ExpectQuery(`SELECT (.+) FROM pages WHERE status = \$1 ORDER BY created_at DESC LIMIT \$2 OFFSET \$3`).
-		WithArgs(domain.PageStatusPublished, 10, 0).
-		WillReturnRows(rows)
-
-	pages, err := repo.ListByStatus(ctx, domain.PageStatusPublished, 10, 0)
-	assert.NoError(t, err)
-	assert.Len(t, pages, 1)
-	assert.Equal(t, domain.PageStatusPublished, pages[0].Status)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status", "parent_id", "sort_order",
+			"meta_title", "meta_desc", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(1, "Page 1", "page-1", "Content 1", 1, domain.PageStatusPublished, nil, 0, "Meta 1", "Desc 1", now, now, now, nil, 1)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM pages WHERE status = \? ORDER BY created_at DESC LIMIT \? OFFSET \?`)).
+			WithArgs(domain.PageStatusPublished, 10, 0).
+			WillReturnRows(rows)
+
+		pages, err := repo.ListByStatus(ctx, domain.PageStatusPublished, 10, 0)
+		assert.NoError(t, err)
+		assert.Len(t, pages, 1)
+		assert.Equal(t, domain.PageStatusPublished, pages[0].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPageRepository_ListPublished(t *testing.T) {
+	cursor := pagination.Cursor{Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), ID: 9}
+
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status", "parent_id", "sort_order",
+			"meta_title", "meta_desc", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(1, "Page 1", "page-1", "Content 1", 1, domain.PageStatusPublished, nil, 0, "", "", now, now, now, nil, 1)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM pages WHERE status = \? `+
+			`AND \(published_at < \? OR \(published_at = \? AND id < \?\)\) ORDER BY published_at DESC, id DESC LIMIT \? OFFSET \?`)).
+			WithArgs(domain.PageStatusPublished, cursor.Time, cursor.Time, cursor.ID, 6, 0).
+			WillReturnRows(rows)
+		mock.ExpectQuery(d.Rebind(`SELECT COUNT\(\*\) FROM pages WHERE status = \?`)).
+			WithArgs(domain.PageStatusPublished).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+
+		pages, err := repo.ListPublished(ctx, pagination.Request{After: &cursor, Limit: 5})
+		require.NoError(t, err)
+		assert.Len(t, pages, 1)
+
+		n, err := repo.CountPublished(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 6, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPageRepository_GetByID_NotFound(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPageRepository(db)
-	ctx := context.Background()
-
-	mock.ExpectQuery(`SELECT (.+) FROM pages WHERE id = \$1`).
-		WithArgs(int64(999)).
-		WillReturnError(sql.ErrNoRows)
-
-	page, err := repo.GetByID(ctx, 999)
-	assert.Error(t, err)
-	assert.Nil(t, page)
-	assert.Equal(t, sql.ErrNoRows, err)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM pages WHERE id = \?`)).
+			WithArgs(int64(999)).
+			WillReturnError(sql.ErrNoRows)
+
+		page, err := repo.GetByID(ctx, 999)
+		assert.Error(t, err)
+		assert.Nil(t, page)
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPageRepository_GetByID_WithParent(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status", "parent_id", "sort_order",
+			"meta_title", "meta_desc", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(
+			2, "Team", "team", "Content", 1, domain.PageStatusPublished, 1, 3,
+			"", "", nil, now, now, nil,
+			1,
+		)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM pages WHERE id = \?`)).
+			WithArgs(int64(2)).
+			WillReturnRows(rows)
+
+		page, err := repo.GetByID(ctx, 2)
+		require.NoError(t, err)
+		require.NotNil(t, page.ParentID)
+		assert.Equal(t, int64(1), *page.ParentID)
+		assert.Equal(t, 3, page.SortOrder)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPageRepository_ListNodes(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{"id", "parent_id", "title", "slug", "status", "sort_order", "updated_at"}).
+			AddRow(1, nil, "About", "about", domain.PageStatusPublished, 0, now).
+			AddRow(2, 1, "Team", "team", domain.PageStatusDraft, 1, now)
+
+		mock.ExpectQuery(`SELECT id, parent_id, title, slug, status, sort_order, updated_at FROM pages ORDER BY sort_order, id`).
+			WillReturnRows(rows)
+
+		nodes, err := repo.ListNodes(ctx)
+		assert.NoError(t, err)
+		require.Len(t, nodes, 2)
+		assert.Nil(t, nodes[0].ParentID)
+		require.NotNil(t, nodes[1].ParentID)
+		assert.Equal(t, int64(1), *nodes[1].ParentID)
+		assert.Equal(t, domain.PageStatusDraft, nodes[1].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPageRepository_UpdatePositions(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+		parentID := int64(1)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(d.Rebind(`UPDATE pages SET parent_id = \?, sort_order = \? WHERE id = \?`)).
+			WithArgs(nil, 0, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(d.Rebind(`UPDATE pages SET parent_id = \?, sort_order = \? WHERE id = \?`)).
+			WithArgs(int64(1), 0, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.UpdatePositions(ctx, []domain.PagePosition{
+			{ID: 1, SortOrder: 0},
+			{ID: 2, ParentID: &parentID, SortOrder: 0},
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPageRepository_UpdatePositions_RollsBackOnError(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPageRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE pages SET parent_id`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+
+		err := repo.UpdatePositions(ctx, []domain.PagePosition{{ID: 1}, {ID: 2}})
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
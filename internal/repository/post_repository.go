@@ -5,27 +5,31 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
 )
 
 type PostRepository struct {
-	db *sql.DB
+	db      txn.Querier
+	dialect dialect.Dialect
 }
 
-func NewPostRepository(db *sql.DB) *PostRepository {
-	return &PostRepository{db: db}
+func NewPostRepository(db txn.Querier, d dialect.Dialect) *PostRepository {
+	return &PostRepository{db: txn.Join(db), dialect: d}
 }
 
 func (r *PostRepository) Create(ctx context.Context, post *domain.Post) error {
 	query := `
-		INSERT INTO posts (title, slug, content, author_id, status, meta_title, meta_desc, is_featured, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id, created_at, updated_at
+		INSERT INTO posts (title, slug, content, author_id, status, meta_title, meta_desc, is_featured, featured_image_id, published_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now()
-	return r.db.QueryRowContext(
+	id, err := r.dialect.InsertID(
 		ctx,
+		r.db,
 		query,
 		post.Title,
 		post.Slug,
@@ -34,23 +38,37 @@ func (r *PostRepository) Create(ctx context.Context, post *domain.Post) error {
 		post.Status,
 		post.MetaTitle,
 		post.MetaDesc,
-		post.IsFeatured,
+		r.dialect.Bool(post.IsFeatured),
+		post.FeaturedImageID,
+		post.PublishedAt,
 		now,
 		now,
-	).Scan(&post.ID, &post.CreatedAt, &post.UpdatedAt)
+	)
+	if err != nil {
+		return err
+	}
+
+	// New posts start at the column's default version
+	post.ID = id
+	post.CreatedAt = now
+	post.UpdatedAt = now
+	post.Version = 1
+	return nil
 }
 
 func (r *PostRepository) GetByID(ctx context.Context, id int64) (*domain.Post, error) {
 	query := `
-		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, published_at, created_at, updated_at
+		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, reviewer_id, published_at, created_at, updated_at, featured_image_id, version
 		FROM posts
-		WHERE id = $1
+		WHERE id = ?
 	`
 
 	post := &domain.Post{}
+	var reviewerID sql.NullInt64
 	var publishedAt sql.NullTime
+	var featuredImageID sql.NullInt64
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), id).Scan(
 		&post.ID,
 		&post.Title,
 		&post.Slug,
@@ -60,33 +78,44 @@ func (r *PostRepository) GetByID(ctx context.Context, id int64) (*domain.Post, e
 		&post.MetaTitle,
 		&post.MetaDesc,
 		&post.IsFeatured,
+		&reviewerID,
 		&publishedAt,
 		&post.CreatedAt,
 		&post.UpdatedAt,
+		&featuredImageID,
+		&post.Version,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if reviewerID.Valid {
+		post.ReviewerID = &reviewerID.Int64
+	}
 	if publishedAt.Valid {
 		post.PublishedAt = &publishedAt.Time
 	}
+	if featuredImageID.Valid {
+		post.FeaturedImageID = &featuredImageID.Int64
+	}
 
 	return post, nil
 }
 
 func (r *PostRepository) GetBySlug(ctx context.Context, slug string) (*domain.Post, error) {
 	query := `
-		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, published_at, created_at, updated_at
+		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, reviewer_id, published_at, created_at, updated_at, featured_image_id, version
 		FROM posts
-		WHERE slug = $1
+		WHERE slug = ?
 	`
 
 	post := &domain.Post{}
+	var reviewerID sql.NullInt64
 	var publishedAt sql.NullTime
+	var featuredImageID sql.NullInt64
 
-	err := r.db.QueryRowContext(ctx, query, slug).Scan(
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), slug).Scan(
 		&post.ID,
 		&post.Title,
 		&post.Slug,
@@ -96,97 +125,249 @@ func (r *PostRepository) GetBySlug(ctx context.Context, slug string) (*domain.Po
 		&post.MetaTitle,
 		&post.MetaDesc,
 		&post.IsFeatured,
+		&reviewerID,
 		&publishedAt,
 		&post.CreatedAt,
 		&post.UpdatedAt,
+		&featuredImageID,
+		&post.Version,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if reviewerID.Valid {
+		post.ReviewerID = &reviewerID.Int64
+	}
 	if publishedAt.Valid {
 		post.PublishedAt = &publishedAt.Time
 	}
+	if featuredImageID.Valid {
+		post.FeaturedImageID = &featuredImageID.Int64
+	}
 
 	return post, nil
 }
 
+// Update saves post if it is still at the version it was loaded with and
+// moves it to the next version. It returns sql.ErrNoRows when the post was
+// changed or deleted in the meantime.
 func (r *PostRepository) Update(ctx context.Context, post *domain.Post) error {
 	query := `
 		UPDATE posts
-		SET title = $1, slug = $2, content = $3, status = $4, meta_title = $5, meta_desc = $6, is_featured = $7, updated_at = $8
-		WHERE id = $9
+		SET title = ?, slug = ?, content = ?, status = ?, meta_title = ?, meta_desc = ?, is_featured = ?,
+			featured_image_id = ?, reviewer_id = ?, published_at = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ?
 	`
 
-	_, err := r.db.ExecContext(
+	result, err := r.db.ExecContext(
 		ctx,
-		query,
+		r.dialect.Rebind(query),
 		post.Title,
 		post.Slug,
 		post.Content,
 		post.Status,
 		post.MetaTitle,
 		post.MetaDesc,
-		post.IsFeatured,
+		r.dialect.Bool(post.IsFeatured),
+		post.FeaturedImageID,
+		post.ReviewerID,
+		post.PublishedAt,
 		time.Now(),
 		post.ID,
+		post.Version,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return nextVersion(result, &post.Version)
+}
+
+// nextVersion checks that a versioned update matched a row and advances the
+// in-memory version to match the stored one
+func nextVersion(result sql.Result, version *int) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	*version++
+	return nil
 }
 
 func (r *PostRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM posts WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	query := `DELETE FROM posts WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(query), id)
 	return err
 }
 
 func (r *PostRepository) List(ctx context.Context, limit, offset int) ([]*domain.Post, error) {
 	query := `
-		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, published_at, created_at, updated_at
+		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, reviewer_id, published_at, created_at, updated_at, featured_image_id, version
 		FROM posts
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	return r.scanPosts(rows)
+	return r.list(ctx, query, limit, offset)
 }
 
 func (r *PostRepository) ListByStatus(ctx context.Context, status domain.PostStatus, limit, offset int) ([]*domain.Post, error) {
 	query := `
-		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, published_at, created_at, updated_at
+		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, reviewer_id, published_at, created_at, updated_at, featured_image_id, version
 		FROM posts
-		WHERE status = $1
+		WHERE status = ?
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, status, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	return r.scanPosts(rows)
+	return r.list(ctx, query, limit, offset, status)
 }
 
 func (r *PostRepository) ListByAuthor(ctx context.Context, authorID int64, limit, offset int) ([]*domain.Post, error) {
 	query := `
-		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, published_at, created_at, updated_at
+		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, reviewer_id, published_at, created_at, updated_at, featured_image_id, version
+		FROM posts
+		WHERE author_id = ?
+		ORDER BY created_at DESC
+	`
+
+	return r.list(ctx, query, limit, offset, authorID)
+}
+
+func (r *PostRepository) ListByStatusAndAuthor(ctx context.Context, status domain.PostStatus, authorID int64, limit, offset int) ([]*domain.Post, error) {
+	query := `
+		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, reviewer_id, published_at, created_at, updated_at, featured_image_id, version
 		FROM posts
-		WHERE author_id = $1
+		WHERE status = ? AND author_id = ?
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, authorID, limit, offset)
+	return r.list(ctx, query, limit, offset, status, authorID)
+}
+
+func (r *PostRepository) ListByStatusAndCategory(ctx context.Context, status domain.PostStatus, categoryID int64, limit, offset int) ([]*domain.Post, error) {
+	query := `
+		SELECT p.id, p.title, p.slug, p.content, p.author_id, p.status, p.meta_title, p.meta_desc, p.is_featured, p.reviewer_id, p.published_at, p.created_at, p.updated_at, p.featured_image_id, p.version
+		FROM posts p
+		JOIN post_categories pc ON pc.post_id = p.id
+		WHERE p.status = ? AND pc.category_id = ?
+		ORDER BY p.created_at DESC
+	`
+
+	return r.list(ctx, query, limit, offset, status, categoryID)
+}
+
+func (r *PostRepository) ListByStatusAndTag(ctx context.Context, status domain.PostStatus, tagID int64, limit, offset int) ([]*domain.Post, error) {
+	query := `
+		SELECT p.id, p.title, p.slug, p.content, p.author_id, p.status, p.meta_title, p.meta_desc, p.is_featured, p.reviewer_id, p.published_at, p.created_at, p.updated_at, p.featured_image_id, p.version
+		FROM posts p
+		JOIN post_tags pt ON pt.post_id = p.id
+		WHERE p.status = ? AND pt.tag_id = ?
+		ORDER BY p.created_at DESC
+	`
+
+	return r.list(ctx, query, limit, offset, status, tagID)
+}
+
+// ListFeatured returns the most recently published featured posts.
+func (r *PostRepository) ListFeatured(ctx context.Context, limit int) ([]*domain.Post, error) {
+	query := `
+		SELECT id, title, slug, content, author_id, status, meta_title, meta_desc, is_featured, reviewer_id, published_at, created_at, updated_at, featured_image_id, version
+		FROM posts
+		WHERE status = ? AND is_featured = ?
+		ORDER BY published_at DESC, id DESC
+	`
+
+	return r.list(ctx, query, limit, 0, domain.PostStatusPublished, r.dialect.Bool(true))
+}
+
+// ListPublished returns the page req asks for of the published posts
+// matching filter, newest first by publication time.
+func (r *PostRepository) ListPublished(ctx context.Context, filter domain.PostFilter, req pagination.Request) ([]*domain.Post, error) {
+	from, where, args := publishedPosts(filter)
+	cond, keyArgs, order := keyset(req, "p.published_at", "p.id")
+	if cond != "" {
+		where += " AND " + cond
+		args = append(args, keyArgs...)
+	}
+
+	query := `
+		SELECT p.id, p.title, p.slug, p.content, p.author_id, p.status, p.meta_title, p.meta_desc, p.is_featured, p.reviewer_id, p.published_at, p.created_at, p.updated_at, p.featured_image_id, p.version
+		FROM ` + from + `
+		WHERE ` + where + `
+		ORDER BY ` + order
+
+	// One more post than fits the page tells whether there is another
+	return r.list(ctx, query, req.PageSize()+1, 0, args...)
+}
+
+// CountPublished returns the number of published posts matching filter.
+func (r *PostRepository) CountPublished(ctx context.Context, filter domain.PostFilter) (int, error) {
+	from, where, args := publishedPosts(filter)
+	query := `SELECT COUNT(*) FROM ` + from + ` WHERE ` + where
+
+	var n int
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), args...).Scan(&n)
+	return n, err
+}
+
+// publishedPosts returns the FROM and WHERE clauses, and the arguments of
+// the latter, that select the published posts matching filter as p
+func publishedPosts(filter domain.PostFilter) (from, where string, args []interface{}) {
+	from, where = "posts p", "p.status = ?"
+	args = []interface{}{domain.PostStatusPublished}
+	if filter.CategoryID != 0 {
+		from += " JOIN post_categories pc ON pc.post_id = p.id"
+		where += " AND pc.category_id = ?"
+		args = append(args, filter.CategoryID)
+	}
+	if filter.TagID != 0 {
+		from += " JOIN post_tags pt ON pt.post_id = p.id"
+		where += " AND pt.tag_id = ?"
+		args = append(args, filter.TagID)
+	}
+	if filter.AuthorID != 0 {
+		where += " AND p.author_id = ?"
+		args = append(args, filter.AuthorID)
+	}
+	return from, where, args
+}
+
+// ListRefsByStatus returns the ID, slug and update time of every post with the given status.
+func (r *PostRepository) ListRefsByStatus(ctx context.Context, status domain.PostStatus) ([]*domain.ContentRef, error) {
+	query := `
+		SELECT id, slug, updated_at
+		FROM posts
+		WHERE status = ?
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []*domain.ContentRef
+	for rows.Next() {
+		ref := &domain.ContentRef{}
+		if err := rows.Scan(&ref.ID, &ref.Slug, &ref.UpdatedAt); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
+// list runs a SELECT of posts with the page of results appended
+func (r *PostRepository) list(ctx context.Context, query string, limit, offset int, args ...interface{}) ([]*domain.Post, error) {
+	paging, pagingArgs := r.dialect.LimitOffset(limit, offset)
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query+" "+paging), append(args, pagingArgs...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -200,7 +381,9 @@ func (r *PostRepository) scanPosts(rows *sql.Rows) ([]*domain.Post, error) {
 
 	for rows.Next() {
 		post := &domain.Post{}
+		var reviewerID sql.NullInt64
 		var publishedAt sql.NullTime
+		var featuredImageID sql.NullInt64
 
 		err := rows.Scan(
 			&post.ID,
@@ -212,18 +395,27 @@ func (r *PostRepository) scanPosts(rows *sql.Rows) ([]*domain.Post, error) {
 			&post.MetaTitle,
 			&post.MetaDesc,
 			&post.IsFeatured,
+			&reviewerID,
 			&publishedAt,
 			&post.CreatedAt,
 			&post.UpdatedAt,
+			&featuredImageID,
+			&post.Version,
 		)
 
 		if err != nil {
 			return nil, err
 		}
 
+		if reviewerID.Valid {
+			post.ReviewerID = &reviewerID.Int64
+		}
 		if publishedAt.Valid {
 			post.PublishedAt = &publishedAt.Time
 		}
+		if featuredImageID.Valid {
+			post.FeaturedImageID = &featuredImageID.Int64
+		}
 
 		posts = append(posts, post)
 	}
@@ -3,245 +3,448 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
 )
 
-func TestPostRepository_Create(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPostRepository(db)
-	ctx := context.Background()
-	now := time.Now()
-
-	post := &domain.Post{
-		Title:      "Test Post",
-		Slug:       "test-post",
-		Content:    "This is test content",
-		AuthorID:   1,
-		Status:     domain.PostStatusDraft,
-		MetaTitle:  "Test Meta Title",
-		MetaDesc:   "Test meta description",
-		IsFeatured: false,
+// eachDialect runs a repository test against a fresh sqlmock database once
+// per supported dialect. Expected queries are written with \? placeholders
+// and passed through d.Rebind, so `id = \?` expects `id = $1` on PostgreSQL.
+func eachDialect(t *testing.T, test func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock)) {
+	for _, d := range []dialect.Dialect{dialect.Postgres, dialect.MySQL, dialect.SQLite} {
+		t.Run(d.Name(), func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			test(t, d, db, mock)
+		})
 	}
+}
 
-	mock.ExpectQuery(`INSERT INTO posts`).
-		WithArgs(post.Title, post.Slug, post.Content, post.AuthorID, post.Status, post.MetaTitle, post.MetaDesc, post.IsFeatured, sqlmock.AnyArg(), sqlmock.AnyArg()).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
-			AddRow(1, now, now))
+// expectInsertID expects an INSERT matching pattern that returns id the way
+// d reports new IDs
+func expectInsertID(mock sqlmock.Sqlmock, d dialect.Dialect, pattern string, id int64, args ...driver.Value) {
+	switch d.Name() {
+	case dialect.DriverPostgres:
+		mock.ExpectQuery(pattern + ` (.+) RETURNING id$`).
+			WithArgs(args...).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+	default:
+		mock.ExpectExec(pattern).
+			WithArgs(args...).
+			WillReturnResult(sqlmock.NewResult(id, 1))
+	}
+}
 
-	err = repo.Create(ctx, post)
-	assert.NoError(t, err)
-	assert.Equal(t, int64(1), post.ID)
-	assert.NotZero(t, post.CreatedAt)
-	assert.NotZero(t, post.UpdatedAt)
-	assert.NoError(t, mock.ExpectationsWereMet())
+func TestPostRepository_Create(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+
+		post := &domain.Post{
+			Title:      "Test Post",
+			Slug:       "test-post",
+			Content:    "This is test content",
+			AuthorID:   1,
+			Status:     domain.PostStatusDraft,
+			MetaTitle:  "Test Meta Title",
+			MetaDesc:   "Test meta description",
+			IsFeatured: false,
+		}
+
+		expectInsertID(mock, d, `INSERT INTO posts`, 1,
+			post.Title, post.Slug, post.Content, post.AuthorID, post.Status, post.MetaTitle, post.MetaDesc, d.Bool(false), post.FeaturedImageID, post.PublishedAt, sqlmock.AnyArg(), sqlmock.AnyArg())
+
+		err := repo.Create(ctx, post)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), post.ID)
+		assert.Equal(t, 1, post.Version)
+		assert.NotZero(t, post.CreatedAt)
+		assert.NotZero(t, post.UpdatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPostRepository_GetByID(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPostRepository(db)
-	ctx := context.Background()
-	now := time.Now()
-
-	rows := sqlmock.NewRows([]string{
-		"id", "title", "slug", "content", "author_id", "status",
-		"meta_title", "meta_desc", "is_featured", "published_at", "created_at", "updated_at",
-	}).AddRow(
-		1, "Test Post", "test-post", "Content", 1, domain.PostStatusPublished,
-		"Meta Title", "Meta Desc", true, now, now, now,
-	)
-
-	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE id = \$1`).
-		WithArgs(int64(1)).
-		WillReturnRows(rows)
-
-	post, err := repo.GetByID(ctx, 1)
-	assert.NoError(t, err)
-	assert.NotNil(t, post)
-	assert.Equal(t, int64(1), post.ID)
-	assert.Equal(t, "Test Post", post.Title)
-	assert.Equal(t, "test-post", post.Slug)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status",
+			"meta_title", "meta_desc", "is_featured", "reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(
+			1, "Test Post", "test-post", "Content", 1, domain.PostStatusPublished,
+			"Meta Title", "Meta Desc", d.Bool(true), 5, now, now, now, nil,
+			1,
+		)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts WHERE id = \?`)).
+			WithArgs(int64(1)).
+			WillReturnRows(rows)
+
+		post, err := repo.GetByID(ctx, 1)
+		assert.NoError(t, err)
+		assert.NotNil(t, post)
+		assert.Equal(t, int64(1), post.ID)
+		assert.Equal(t, "Test Post", post.Title)
+		assert.True(t, post.IsFeatured)
+		assert.Equal(t, "test-post", post.Slug)
+		require.NotNil(t, post.ReviewerID)
+		assert.Equal(t, int64(5), *post.ReviewerID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPostRepository_GetBySlug(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPostRepository(db)
-	ctx := context.Background()
-	now := time.Now()
-
-	rows := sqlmock.NewRows([]string{
-		"id", "title", "slug", "content", "author_id", "status",
-		"meta_title", "meta_desc", "is_featured", "published_at", "created_at", "updated_at",
-	}).AddRow(
-		1, "Test Post", "test-post", "Content", 1, domain.PostStatusPublished,
-		"Meta Title", "Meta Desc", true, now, now, now,
-	)
-
-	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE slug = \$1`).
-		WithArgs("test-post").
-		WillReturnRows(rows)
-
-	post, err := repo.GetBySlug(ctx, "test-post")
-	assert.NoError(t, err)
-	assert.NotNil(t, post)
-	assert.Equal(t, "test-post", post.Slug)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status",
+			"meta_title", "meta_desc", "is_featured", "reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(
+			1, "Test Post", "test-post", "Content", 1, domain.PostStatusPublished,
+			"Meta Title", "Meta Desc", true, nil, now, now, now, nil,
+			1,
+		)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts WHERE slug = \?`)).
+			WithArgs("test-post").
+			WillReturnRows(rows)
+
+		post, err := repo.GetBySlug(ctx, "test-post")
+		assert.NoError(t, err)
+		assert.NotNil(t, post)
+		assert.Equal(t, "test-post", post.Slug)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPostRepository_Update(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPostRepository(db)
-	ctx := context.Background()
-
-	post := &domain.Post{
-		ID:         1,
-		Title:      "Updated Post",
-		Slug:       "updated-post",
-		Content:    "Updated content",
-		AuthorID:   1,
-		Status:     domain.PostStatusPublished,
-		MetaTitle:  "Updated Meta",
-		MetaDesc:   "Updated desc",
-		IsFeatured: true,
-	}
-
-	mock.ExpectExec(`UPDATE posts SET`).
-		WithArgs(post.Title, post.Slug, post.Content, post.Status, post.MetaTitle, post.MetaDesc, post.IsFeatured, sqlmock.AnyArg(), post.ID).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	err = repo.Update(ctx, post)
-	assert.NoError(t, err)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+
+		post := &domain.Post{
+			ID:         1,
+			Title:      "Updated Post",
+			Slug:       "updated-post",
+			Content:    "Updated content",
+			AuthorID:   1,
+			Status:     domain.PostStatusPublished,
+			MetaTitle:  "Updated Meta",
+			MetaDesc:   "Updated desc",
+			IsFeatured: true,
+			Version:    2,
+		}
+
+		mock.ExpectExec(`UPDATE posts SET`).
+			WithArgs(post.Title, post.Slug, post.Content, post.Status, post.MetaTitle, post.MetaDesc, d.Bool(true), post.FeaturedImageID, post.ReviewerID, post.PublishedAt, sqlmock.AnyArg(), post.ID, 2).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.Update(ctx, post)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, post.Version)
+
+		// Saving the version that was just replaced changes nothing
+		mock.ExpectExec(`UPDATE posts SET (.+) WHERE id = \S+ AND version = \S+`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		post.Version = 2
+		err = repo.Update(ctx, post)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.Equal(t, 2, post.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPostRepository_Delete(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPostRepository(db)
-	ctx := context.Background()
-
-	mock.ExpectExec(`DELETE FROM posts WHERE id = \$1`).
-		WithArgs(int64(1)).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-
-	err = repo.Delete(ctx, 1)
-	assert.NoError(t, err)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectExec(d.Rebind(`DELETE FROM posts WHERE id = \?`)).
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Delete(ctx, 1)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPostRepository_List(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPostRepository(db)
-	ctx := context.Background()
-	now := time.Now()
-
-	rows := sqlmock.NewRows([]string{
-		"id", "title", "slug", "content", "author_id", "status",
-		"meta_title", "meta_desc", "is_featured", "published_at", "created_at", "updated_at",
-	}).
-		AddRow(1, "Post 1", "post-1", "Content 1", 1, domain.PostStatusPublished, "Meta 1", "Desc 1", true, now, now, now).
-		AddRow(2, "Post 2", "post-2", "Content 2", 1, domain.PostStatusPublished, "Meta 2", "Desc 2", false, now, now, now)
-
-	mock.ExpectQuery(`SELECT (.+) FROM posts ORDER BY created_at DESC LIMIT \$1 OFFSET \$2`).
-		WithArgs(10, 0).
-		WillReturnRows(rows)
-
-	posts, err := repo.List(ctx, 10, 0)
-	assert.NoError(t, err)
-	assert.Len(t, posts, 2)
-	assert.Equal(t, "Post 1", posts[0].Title)
-	assert.Equal(t, "Post 2", posts[1].Title)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status",
+			"meta_title", "meta_desc", "is_featured", "reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).
+			AddRow(1, "Post 1", "post-1", "Content 1", 1, domain.PostStatusPublished, "Meta 1", "Desc 1", true, nil, now, now, now, nil, 1).
+			AddRow(2, "Post 2", "post-2", "Content 2", 1, domain.PostStatusPublished, "Meta 2", "Desc 2", false, nil, now, now, now, nil, 1)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts ORDER BY created_at DESC LIMIT \? OFFSET \?`)).
+			WithArgs(10, 0).
+			WillReturnRows(rows)
+
+		posts, err := repo.List(ctx, 10, 0)
+		assert.NoError(t, err)
+		assert.Len(t, posts, 2)
+		assert.Equal(t, "Post 1", posts[0].Title)
+		assert.Equal(t, "Post 2", posts[1].Title)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPostRepository_ListByStatus(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPostRepository(db)
-	ctx := context.Background()
-	now := time.Now()
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status",
+			"meta_title", "meta_desc", "is_featured", "reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(1, "Post 1", "post-1", "Content 1", 1, domain.PostStatusPublished, "Meta 1", "Desc 1", true, nil, now, now, now, nil, 1)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts WHERE status = \? ORDER BY created_at DESC LIMIT \? OFFSET \?`)).
+			WithArgs(domain.PostStatusPublished, 10, 0).
+			WillReturnRows(rows)
+
+		posts, err := repo.ListByStatus(ctx, domain.PostStatusPublished, 10, 0)
+		assert.NoError(t, err)
+		assert.Len(t, posts, 1)
+		assert.Equal(t, domain.PostStatusPublished, posts[0].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
 
-	rows := sqlmock.NewRows([]string{
-		"id", "title", "slug", "content", "author_id", "status",
-		"meta_title", "meta_desc", "is_featured", "published_at", "created_at", "updated_at",
-	}).AddRow(1, "Post 1", "post-1", "Content 1", 1, domain.PostStatusPublished, "Meta 1", "Desc 1", true, now, now, now)
+func TestPostRepository_ListByAuthor(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status",
+			"meta_title", "meta_desc", "is_featured", "reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(1, "Post 1", "post-1", "Content 1", 1, domain.PostStatusPublished, "Meta 1", "Desc 1", true, nil, now, now, now, nil, 1)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts WHERE author_id = \? ORDER BY created_at DESC LIMIT \? OFFSET \?`)).
+			WithArgs(int64(1), 10, 0).
+			WillReturnRows(rows)
+
+		posts, err := repo.ListByAuthor(ctx, 1, 10, 0)
+		assert.NoError(t, err)
+		assert.Len(t, posts, 1)
+		assert.Equal(t, int64(1), posts[0].AuthorID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
 
-	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE status = \$1 ORDER BY created_at DESC LIMIT \$2 OFFSET \$3`).
-		WithArgs(domain.PostStatusPublished, 10, 0).
-		WillReturnRows(rows)
+func TestPostRepository_ListByStatusAndAuthor(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status",
+			"meta_title", "meta_desc", "is_featured", "reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(1, "Post 1", "post-1", "Content 1", 2, domain.PostStatusPublished, "", "", false, nil, now, now, now, nil, 1)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts WHERE status = \? AND author_id = \? ORDER BY created_at DESC LIMIT \? OFFSET \?`)).
+			WithArgs(domain.PostStatusPublished, int64(2), 20, 0).
+			WillReturnRows(rows)
+
+		posts, err := repo.ListByStatusAndAuthor(ctx, domain.PostStatusPublished, 2, 20, 0)
+		assert.NoError(t, err)
+		assert.Len(t, posts, 1)
+		assert.Equal(t, int64(2), posts[0].AuthorID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
 
-	posts, err := repo.ListByStatus(ctx, domain.PostStatusPublished, 10, 0)
-	assert.NoError(t, err)
-	assert.Len(t, posts, 1)
-	assert.Equal(t, domain.PostStatusPublished, posts[0].Status)
-	assert.NoError(t, mock.ExpectationsWereMet())
+func TestPostRepository_ListByStatusAndCategory(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status",
+			"meta_title", "meta_desc", "is_featured", "reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(1, "Post 1", "post-1", "Content 1", 1, domain.PostStatusPublished, "", "", false, nil, now, now, now, nil, 1)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts p JOIN post_categories pc ON pc.post_id = p.id WHERE p.status = \? AND pc.category_id = \?`)).
+			WithArgs(domain.PostStatusPublished, int64(3), 20, 0).
+			WillReturnRows(rows)
+
+		posts, err := repo.ListByStatusAndCategory(ctx, domain.PostStatusPublished, 3, 20, 0)
+		assert.NoError(t, err)
+		assert.Len(t, posts, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
-func TestPostRepository_ListByAuthor(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
+func TestPostRepository_ListByStatusAndTag(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status",
+			"meta_title", "meta_desc", "is_featured", "reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		})
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts p JOIN post_tags pt ON pt.post_id = p.id WHERE p.status = \? AND pt.tag_id = \?`)).
+			WithArgs(domain.PostStatusPublished, int64(4), 20, 0).
+			WillReturnRows(rows)
+
+		posts, err := repo.ListByStatusAndTag(ctx, domain.PostStatusPublished, 4, 20, 0)
+		assert.NoError(t, err)
+		assert.Empty(t, posts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
 
-	repo := NewPostRepository(db)
-	ctx := context.Background()
-	now := time.Now()
+func TestPostRepository_ListFeatured(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "slug", "content", "author_id", "status",
+			"meta_title", "meta_desc", "is_featured", "reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+		}).AddRow(1, "Hello", "hello", "Body", 1, domain.PostStatusPublished, "", "", d.Bool(true), nil, now, now, now, 7, 1)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts WHERE status = \? AND is_featured = \? ORDER BY published_at DESC`)).
+			WithArgs(domain.PostStatusPublished, d.Bool(true), 5, 0).
+			WillReturnRows(rows)
+
+		posts, err := repo.ListFeatured(ctx, 5)
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.True(t, posts[0].IsFeatured)
+		require.NotNil(t, posts[0].FeaturedImageID)
+		assert.Equal(t, int64(7), *posts[0].FeaturedImageID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
 
-	rows := sqlmock.NewRows([]string{
+func TestPostRepository_ListPublished(t *testing.T) {
+	columns := []string{
 		"id", "title", "slug", "content", "author_id", "status",
-		"meta_title", "meta_desc", "is_featured", "published_at", "created_at", "updated_at",
-	}).AddRow(1, "Post 1", "post-1", "Content 1", 1, domain.PostStatusPublished, "Meta 1", "Desc 1", true, now, now, now)
+		"meta_title", "meta_desc", "is_featured", "reviewer_id", "published_at", "created_at", "updated_at", "featured_image_id", "version",
+	}
+	cursor := pagination.Cursor{Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), ID: 9}
+
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		// The first page fetches one post more than fits
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts p WHERE p.status = \? ORDER BY p.published_at DESC, p.id DESC LIMIT \? OFFSET \?`)).
+			WithArgs(domain.PostStatusPublished, 3, 0).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, "Post 1", "post-1", "Content 1", 1, domain.PostStatusPublished, "", "", false, nil, now, now, now, nil, 1))
+
+		posts, err := repo.ListPublished(ctx, domain.PostFilter{}, pagination.Request{Limit: 2})
+		require.NoError(t, err)
+		assert.Len(t, posts, 1)
+
+		// Later pages seek past the cursor within the filter
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts p JOIN post_categories pc ON pc.post_id = p.id JOIN post_tags pt ON pt.post_id = p.id `+
+			`WHERE p.status = \? AND pc.category_id = \? AND pt.tag_id = \? AND p.author_id = \? `+
+			`AND \(p.published_at < \? OR \(p.published_at = \? AND p.id < \?\)\) ORDER BY p.published_at DESC, p.id DESC LIMIT \? OFFSET \?`)).
+			WithArgs(domain.PostStatusPublished, int64(3), int64(4), int64(2), cursor.Time, cursor.Time, cursor.ID, 21, 0).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		filter := domain.PostFilter{AuthorID: 2, CategoryID: 3, TagID: 4}
+		_, err = repo.ListPublished(ctx, filter, pagination.Request{After: &cursor})
+		require.NoError(t, err)
+
+		// Earlier pages seek the other way, oldest first
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts p WHERE p.status = \? `+
+			`AND \(p.published_at > \? OR \(p.published_at = \? AND p.id > \?\)\) ORDER BY p.published_at ASC, p.id ASC LIMIT \? OFFSET \?`)).
+			WithArgs(domain.PostStatusPublished, cursor.Time, cursor.Time, cursor.ID, 11, 0).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err = repo.ListPublished(ctx, domain.PostFilter{}, pagination.Request{Before: &cursor, Limit: 10})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostRepository_CountPublished(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
 
-	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE author_id = \$1 ORDER BY created_at DESC LIMIT \$2 OFFSET \$3`).
-		WithArgs(int64(1), 10, 0).
-		WillReturnRows(rows)
+		mock.ExpectQuery(d.Rebind(`SELECT COUNT\(\*\) FROM posts p JOIN post_tags pt ON pt.post_id = p.id WHERE p.status = \? AND pt.tag_id = \?`)).
+			WithArgs(domain.PostStatusPublished, int64(4)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
 
-	posts, err := repo.ListByAuthor(ctx, 1, 10, 0)
-	assert.NoError(t, err)
-	assert.Len(t, posts, 1)
-	assert.Equal(t, int64(1), posts[0].AuthorID)
-	assert.NoError(t, mock.ExpectationsWereMet())
+		n, err := repo.CountPublished(ctx, domain.PostFilter{TagID: 4})
+		require.NoError(t, err)
+		assert.Equal(t, 42, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPostRepository_GetByID_NotFound(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
-
-	repo := NewPostRepository(db)
-	ctx := context.Background()
-
-	mock.ExpectQuery(`SELECT (.+) FROM posts WHERE id = \$1`).
-		WithArgs(int64(999)).
-		WillReturnError(sql.ErrNoRows)
-
-	post, err := repo.GetByID(ctx, 999)
-	assert.Error(t, err)
-	assert.Nil(t, post)
-	assert.Equal(t, sql.ErrNoRows, err)
-	assert.NoError(t, mock.ExpectationsWereMet())
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM posts WHERE id = \?`)).
+			WithArgs(int64(999)).
+			WillReturnError(sql.ErrNoRows)
+
+		post, err := repo.GetByID(ctx, 999)
+		assert.Error(t, err)
+		assert.Nil(t, post)
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostRepository_ListRefsByStatus(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewPostRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{"id", "slug", "updated_at"}).
+			AddRow(1, "first", now).
+			AddRow(2, "second", now)
+
+		mock.ExpectQuery(d.Rebind(`SELECT id, slug, updated_at FROM posts WHERE status = \? ORDER BY id`)).
+			WithArgs(domain.PostStatusPublished).
+			WillReturnRows(rows)
+
+		refs, err := repo.ListRefsByStatus(ctx, domain.PostStatusPublished)
+		assert.NoError(t, err)
+		assert.Len(t, refs, 2)
+		assert.Equal(t, "second", refs[1].Slug)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+// RenderRepository stores the rendered HTML cache of posts and pages.
+type RenderRepository struct {
+	db      txn.Querier
+	dialect dialect.Dialect
+}
+
+func NewRenderRepository(db txn.Querier, d dialect.Dialect) *RenderRepository {
+	return &RenderRepository{db: txn.Join(db), dialect: d}
+}
+
+const renderColumns = `content_type, content_id, html, toc, excerpt, word_count, reading_time, version, rendered_at`
+
+// contentTables maps content types to the tables their sources live in
+var contentTables = map[string]string{
+	domain.ContentTypePost: "posts",
+	domain.ContentTypePage: "pages",
+}
+
+func (r *RenderRepository) Get(ctx context.Context, contentType string, id int64) (*domain.RenderedContent, error) {
+	list, err := r.list(ctx, `SELECT `+renderColumns+` FROM rendered_content WHERE content_type = ? AND content_id = ?`, contentType, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return list[0], nil
+}
+
+// GetMany returns the renders of the given posts or pages keyed by ID.
+// Content that hasn't been rendered yet is missing from the map.
+func (r *RenderRepository) GetMany(ctx context.Context, contentType string, ids []int64) (map[int64]*domain.RenderedContent, error) {
+	if len(ids) == 0 {
+		return map[int64]*domain.RenderedContent{}, nil
+	}
+
+	args := append([]interface{}{contentType}, int64Args(ids)...)
+	list, err := r.list(ctx, `SELECT `+renderColumns+` FROM rendered_content WHERE content_type = ? AND content_id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	renders := make(map[int64]*domain.RenderedContent, len(list))
+	for _, rc := range list {
+		renders[rc.ContentID] = rc
+	}
+	return renders, nil
+}
+
+// Save stores a render, replacing the previous one.
+func (r *RenderRepository) Save(ctx context.Context, rc *domain.RenderedContent) error {
+	query := `
+		INSERT INTO rendered_content (` + renderColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	` + r.dialect.Upsert(
+		[]string{"content_type", "content_id"},
+		[]string{"html", "toc", "excerpt", "word_count", "reading_time", "version", "rendered_at"},
+	)
+
+	rc.RenderedAt = time.Now()
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(query),
+		rc.ContentType,
+		rc.ContentID,
+		rc.HTML,
+		rc.TOC,
+		rc.Excerpt,
+		rc.WordCount,
+		rc.ReadingTime,
+		rc.Version,
+		rc.RenderedAt,
+	)
+	return err
+}
+
+func (r *RenderRepository) Delete(ctx context.Context, contentType string, id int64) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM rendered_content WHERE content_type = ? AND content_id = ?`), contentType, id)
+	return err
+}
+
+// ListStale returns posts or pages that have never been rendered or were
+// rendered by a different pipeline version.
+func (r *RenderRepository) ListStale(ctx context.Context, contentType string, version, limit int) ([]*domain.ContentSource, error) {
+	table, ok := contentTables[contentType]
+	if !ok {
+		return nil, fmt.Errorf("unknown content type %q", contentType)
+	}
+
+	query := `
+		SELECT c.id, c.content FROM ` + table + ` c
+		LEFT JOIN rendered_content r ON r.content_type = ? AND r.content_id = c.id
+		WHERE r.version IS NULL OR r.version <> ?
+		ORDER BY c.id
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), contentType, version, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []*domain.ContentSource
+	for rows.Next() {
+		src := &domain.ContentSource{}
+		if err := rows.Scan(&src.ID, &src.Content); err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+
+	return sources, rows.Err()
+}
+
+func (r *RenderRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.RenderedContent, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*domain.RenderedContent
+	for rows.Next() {
+		rc := &domain.RenderedContent{}
+		err := rows.Scan(
+			&rc.ContentType,
+			&rc.ContentID,
+			&rc.HTML,
+			&rc.TOC,
+			&rc.Excerpt,
+			&rc.WordCount,
+			&rc.ReadingTime,
+			&rc.Version,
+			&rc.RenderedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, rc)
+	}
+
+	return items, rows.Err()
+}
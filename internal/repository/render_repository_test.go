@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+var renderRowColumns = []string{"content_type", "content_id", "html", "toc", "excerpt", "word_count", "reading_time", "version", "rendered_at"}
+
+func TestRenderRepository_Save(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewRenderRepository(db, d)
+
+		upsert := `ON CONFLICT \(content_type, content_id\) DO UPDATE SET html = EXCLUDED.html`
+		if d.Name() == dialect.DriverMySQL {
+			upsert = `ON DUPLICATE KEY UPDATE html = VALUES\(html\)`
+		}
+		mock.ExpectExec(d.Rebind(`INSERT INTO rendered_content (.+) VALUES \(\?, \?, \?, \?, \?, \?, \?, \?, \?\) `+upsert)).
+			WithArgs("post", int64(3), "<p>Hi</p>", "", "Hi", 1, 1, 2, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		rc := &domain.RenderedContent{ContentType: "post", ContentID: 3, HTML: "<p>Hi</p>", Excerpt: "Hi", WordCount: 1, ReadingTime: 1, Version: 2}
+		require.NoError(t, repo.Save(context.Background(), rc))
+		assert.False(t, rc.RenderedAt.IsZero())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRenderRepository_Get(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewRenderRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM rendered_content WHERE content_type = \? AND content_id = \?`)).
+			WithArgs("page", int64(4)).
+			WillReturnRows(sqlmock.NewRows(renderRowColumns).AddRow("page", 4, "<p>About</p>", "", "About", 1, 1, 1, time.Now()))
+
+		rc, err := repo.Get(ctx, "page", 4)
+		require.NoError(t, err)
+		assert.Equal(t, "<p>About</p>", rc.HTML)
+
+		mock.ExpectQuery(`SELECT (.+) FROM rendered_content`).
+			WithArgs("page", int64(5)).
+			WillReturnRows(sqlmock.NewRows(renderRowColumns))
+
+		_, err = repo.Get(ctx, "page", 5)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRenderRepository_GetMany(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewRenderRepository(db, d)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM rendered_content WHERE content_type = \? AND content_id IN \(\?, \?\)`)).
+			WithArgs("post", int64(1), int64(2)).
+			WillReturnRows(sqlmock.NewRows(renderRowColumns).AddRow("post", 2, "<p>Two</p>", "", "Two", 1, 1, 1, time.Now()))
+
+		renders, err := repo.GetMany(context.Background(), "post", []int64{1, 2})
+		require.NoError(t, err)
+		assert.Len(t, renders, 1)
+		assert.Equal(t, "Two", renders[2].Excerpt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRenderRepository_ListStale(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewRenderRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectQuery(d.Rebind(`SELECT c.id, c.content FROM posts c LEFT JOIN rendered_content r ON r.content_type = \? AND r.content_id = c.id WHERE r.version IS NULL OR r.version <> \? ORDER BY c.id LIMIT \?`)).
+			WithArgs("post", 3, 50).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "content"}).AddRow(7, "# Old"))
+
+		sources, err := repo.ListStale(ctx, "post", 3, 50)
+		require.NoError(t, err)
+		require.Len(t, sources, 1)
+		assert.Equal(t, int64(7), sources[0].ID)
+
+		_, err = repo.ListStale(ctx, "comment", 3, 50)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
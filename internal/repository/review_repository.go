@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+// ReviewRepository stores the revisions submitted for editorial review and
+// the feedback left on them.
+type ReviewRepository struct {
+	db      txn.Querier
+	dialect dialect.Dialect
+}
+
+func NewReviewRepository(db txn.Querier, d dialect.Dialect) *ReviewRepository {
+	return &ReviewRepository{db: txn.Join(db), dialect: d}
+}
+
+// CreateRevision stores a snapshot of a post, numbering it after the post's
+// previous revisions.
+func (r *ReviewRepository) CreateRevision(ctx context.Context, rev *domain.PostRevision) error {
+	var version int
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`SELECT COALESCE(MAX(version), 0) + 1 FROM post_revisions WHERE post_id = ?`), rev.PostID).
+		Scan(&version)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO post_revisions (post_id, version, title, content, author_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	id, err := r.dialect.InsertID(ctx, r.db, query, rev.PostID, version, rev.Title, rev.Content, rev.AuthorID, now)
+	if err != nil {
+		return err
+	}
+
+	rev.ID = id
+	rev.Version = version
+	rev.CreatedAt = now
+	return nil
+}
+
+// LatestRevision returns the most recent revision of a post.
+func (r *ReviewRepository) LatestRevision(ctx context.Context, postID int64) (*domain.PostRevision, error) {
+	query := `
+		SELECT id, post_id, version, title, content, author_id, created_at
+		FROM post_revisions
+		WHERE post_id = ?
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	rev := &domain.PostRevision{}
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), postID).Scan(
+		&rev.ID,
+		&rev.PostID,
+		&rev.Version,
+		&rev.Title,
+		&rev.Content,
+		&rev.AuthorID,
+		&rev.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return rev, nil
+}
+
+// CreateComment stores feedback on a revision.
+func (r *ReviewRepository) CreateComment(ctx context.Context, comment *domain.PostReviewComment) error {
+	query := `
+		INSERT INTO review_comments (post_id, revision_id, author_id, decision, body, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	id, err := r.dialect.InsertID(
+		ctx,
+		r.db,
+		query,
+		comment.PostID,
+		comment.RevisionID,
+		comment.AuthorID,
+		comment.Decision,
+		comment.Body,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+
+	comment.ID = id
+	comment.CreatedAt = now
+	return nil
+}
+
+// ListComments returns the review history of a post, oldest first, with the
+// version of the revision each comment was left on.
+func (r *ReviewRepository) ListComments(ctx context.Context, postID int64) ([]*domain.PostReviewComment, error) {
+	query := `
+		SELECT c.id, c.post_id, c.revision_id, r.version, c.author_id, c.decision, c.body, c.created_at
+		FROM review_comments c
+		JOIN post_revisions r ON r.id = c.revision_id
+		WHERE c.post_id = ?
+		ORDER BY c.created_at, c.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*domain.PostReviewComment
+	for rows.Next() {
+		c := &domain.PostReviewComment{}
+		if err := rows.Scan(&c.ID, &c.PostID, &c.RevisionID, &c.Version, &c.AuthorID, &c.Decision, &c.Body, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+
+	return comments, rows.Err()
+}
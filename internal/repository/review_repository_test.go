@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+func TestReviewRepository_CreateRevision(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewReviewRepository(db, d)
+
+		mock.ExpectQuery(d.Rebind(`SELECT COALESCE\(MAX\(version\), 0\) \+ 1 FROM post_revisions WHERE post_id = \?`)).
+			WithArgs(int64(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		expectInsertID(mock, d, `INSERT INTO post_revisions`, 11,
+			int64(3), 2, "Title", "Body", int64(7), sqlmock.AnyArg())
+
+		rev := &domain.PostRevision{PostID: 3, Title: "Title", Content: "Body", AuthorID: 7}
+		err := repo.CreateRevision(context.Background(), rev)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), rev.ID)
+		assert.Equal(t, 2, rev.Version)
+		assert.NotZero(t, rev.CreatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestReviewRepository_LatestRevision(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewReviewRepository(db, d)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM post_revisions WHERE post_id = \? ORDER BY version DESC LIMIT 1`)).
+			WithArgs(int64(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "version", "title", "content", "author_id", "created_at"}).
+				AddRow(11, 3, 2, "Title", "Body", 7, time.Now()))
+
+		rev, err := repo.LatestRevision(context.Background(), 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, rev.Version)
+
+		mock.ExpectQuery(`SELECT (.+) FROM post_revisions`).
+			WithArgs(int64(4)).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = repo.LatestRevision(context.Background(), 4)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestReviewRepository_Comments(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewReviewRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		expectInsertID(mock, d, `INSERT INTO review_comments`, 1,
+			int64(3), int64(11), int64(2), domain.ReviewChangesRequested, "Needs a conclusion", sqlmock.AnyArg())
+
+		comment := &domain.PostReviewComment{PostID: 3, RevisionID: 11, AuthorID: 2, Decision: domain.ReviewChangesRequested, Body: "Needs a conclusion"}
+		require.NoError(t, repo.CreateComment(ctx, comment))
+		assert.Equal(t, int64(1), comment.ID)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM review_comments c JOIN post_revisions r ON r.id = c.revision_id WHERE c.post_id = \?`)).
+			WithArgs(int64(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "revision_id", "version", "author_id", "decision", "body", "created_at"}).
+				AddRow(1, 3, 11, 2, 2, "changes_requested", "Needs a conclusion", now))
+
+		comments, err := repo.ListComments(ctx, 3)
+		assert.NoError(t, err)
+		require.Len(t, comments, 1)
+		assert.Equal(t, 2, comments[0].Version)
+		assert.Equal(t, domain.ReviewChangesRequested, comments[0].Decision)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
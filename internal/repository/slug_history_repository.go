@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+)
+
+// SlugHistoryRepository stores slugs that content used to be published under
+// so old URLs can be redirected to the current one.
+type SlugHistoryRepository struct {
+	db      txn.Querier
+	dialect dialect.Dialect
+}
+
+func NewSlugHistoryRepository(db txn.Querier, d dialect.Dialect) *SlugHistoryRepository {
+	return &SlugHistoryRepository{db: txn.Join(db), dialect: d}
+}
+
+// Record remembers slug as a previous slug of the given content, replacing any
+// existing entry for the same slug.
+func (r *SlugHistoryRepository) Record(ctx context.Context, contentType string, contentID int64, slug string) error {
+	if err := r.Release(ctx, contentType, slug); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO slug_history (content_type, content_id, slug, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(query), contentType, contentID, slug, time.Now())
+	return err
+}
+
+// Release forgets a previous slug, typically because live content now uses it.
+func (r *SlugHistoryRepository) Release(ctx context.Context, contentType, slug string) error {
+	query := `DELETE FROM slug_history WHERE content_type = ? AND slug = ?`
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(query), contentType, slug)
+	return err
+}
+
+// FindContentID returns the ID of the content that previously used slug.
+func (r *SlugHistoryRepository) FindContentID(ctx context.Context, contentType, slug string) (int64, error) {
+	query := `
+		SELECT content_id
+		FROM slug_history
+		WHERE content_type = ? AND slug = ?
+	`
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), contentType, slug).Scan(&id)
+	return id, err
+}
+
+// DeleteByContent removes every previous slug of the given content.
+func (r *SlugHistoryRepository) DeleteByContent(ctx context.Context, contentType string, contentID int64) error {
+	query := `DELETE FROM slug_history WHERE content_type = ? AND content_id = ?`
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(query), contentType, contentID)
+	return err
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+func TestSlugHistoryRepository_Record(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewSlugHistoryRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectExec(d.Rebind(`DELETE FROM slug_history WHERE content_type = \? AND slug = \?`)).
+			WithArgs(domain.ContentTypePost, "old-slug").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO slug_history`).
+			WithArgs(domain.ContentTypePost, int64(1), "old-slug", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.Record(ctx, domain.ContentTypePost, 1, "old-slug")
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSlugHistoryRepository_FindContentID(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewSlugHistoryRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectQuery(d.Rebind(`SELECT content_id FROM slug_history WHERE content_type = \? AND slug = \?`)).
+			WithArgs(domain.ContentTypePage, "old-slug").
+			WillReturnRows(sqlmock.NewRows([]string{"content_id"}).AddRow(5))
+
+		id, err := repo.FindContentID(ctx, domain.ContentTypePage, "old-slug")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSlugHistoryRepository_FindContentID_NotFound(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewSlugHistoryRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectQuery(`SELECT content_id FROM slug_history`).
+			WithArgs(domain.ContentTypePost, "missing").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.FindContentID(ctx, domain.ContentTypePost, "missing")
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSlugHistoryRepository_DeleteByContent(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewSlugHistoryRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectExec(d.Rebind(`DELETE FROM slug_history WHERE content_type = \? AND content_id = \?`)).
+			WithArgs(domain.ContentTypePost, int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err := repo.DeleteByContent(ctx, domain.ContentTypePost, 3)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/txn"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+// TaxonomyRepository stores post categories and tags.
+type TaxonomyRepository struct {
+	db      txn.Querier
+	dialect dialect.Dialect
+}
+
+func NewTaxonomyRepository(db txn.Querier, d dialect.Dialect) *TaxonomyRepository {
+	return &TaxonomyRepository{db: txn.Join(db), dialect: d}
+}
+
+func (r *TaxonomyRepository) CreateCategory(ctx context.Context, category *domain.Category) error {
+	query := `
+		INSERT INTO categories (name, slug, created_at)
+		VALUES (?, ?, ?)
+	`
+
+	now := time.Now()
+	id, err := r.dialect.InsertID(ctx, r.db, query, category.Name, category.Slug, now)
+	if err != nil {
+		return err
+	}
+
+	category.ID = id
+	category.CreatedAt = now
+	return nil
+}
+
+func (r *TaxonomyRepository) GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	query := `
+		SELECT id, name, slug, created_at
+		FROM categories
+		WHERE slug = ?
+	`
+
+	category := &domain.Category{}
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), slug).Scan(
+		&category.ID,
+		&category.Name,
+		&category.Slug,
+		&category.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (r *TaxonomyRepository) CreateTag(ctx context.Context, tag *domain.Tag) error {
+	query := `
+		INSERT INTO tags (name, slug, created_at)
+		VALUES (?, ?, ?)
+	`
+
+	now := time.Now()
+	id, err := r.dialect.InsertID(ctx, r.db, query, tag.Name, tag.Slug, now)
+	if err != nil {
+		return err
+	}
+
+	tag.ID = id
+	tag.CreatedAt = now
+	return nil
+}
+
+func (r *TaxonomyRepository) GetTagBySlug(ctx context.Context, slug string) (*domain.Tag, error) {
+	query := `
+		SELECT id, name, slug, created_at
+		FROM tags
+		WHERE slug = ?
+	`
+
+	tag := &domain.Tag{}
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(query), slug).Scan(
+		&tag.ID,
+		&tag.Name,
+		&tag.Slug,
+		&tag.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// SetPostCategories replaces the categories assigned to a post.
+func (r *TaxonomyRepository) SetPostCategories(ctx context.Context, postID int64, categoryIDs []int64) error {
+	return r.replaceLinks(ctx, "post_categories", "category_id", postID, categoryIDs)
+}
+
+// SetPostTags replaces the tags assigned to a post.
+func (r *TaxonomyRepository) SetPostTags(ctx context.Context, postID int64, tagIDs []int64) error {
+	return r.replaceLinks(ctx, "post_tags", "tag_id", postID, tagIDs)
+}
+
+// ListCategoriesByPosts returns the categories of each given post, keyed by post ID.
+func (r *TaxonomyRepository) ListCategoriesByPosts(ctx context.Context, postIDs []int64) (map[int64][]*domain.Category, error) {
+	result := make(map[int64][]*domain.Category)
+	if len(postIDs) == 0 {
+		return result, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT pc.post_id, c.id, c.name, c.slug, c.created_at
+		FROM post_categories pc
+		JOIN categories c ON c.id = pc.category_id
+		WHERE pc.post_id IN (%s)
+		ORDER BY c.name
+	`, placeholders(len(postIDs)))
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), int64Args(postIDs)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int64
+		category := &domain.Category{}
+		if err := rows.Scan(&postID, &category.ID, &category.Name, &category.Slug, &category.CreatedAt); err != nil {
+			return nil, err
+		}
+		result[postID] = append(result[postID], category)
+	}
+
+	return result, rows.Err()
+}
+
+// ListTagsByPosts returns the tags of each given post, keyed by post ID.
+func (r *TaxonomyRepository) ListTagsByPosts(ctx context.Context, postIDs []int64) (map[int64][]*domain.Tag, error) {
+	result := make(map[int64][]*domain.Tag)
+	if len(postIDs) == 0 {
+		return result, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT pt.post_id, t.id, t.name, t.slug, t.created_at
+		FROM post_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.post_id IN (%s)
+		ORDER BY t.name
+	`, placeholders(len(postIDs)))
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), int64Args(postIDs)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int64
+		tag := &domain.Tag{}
+		if err := rows.Scan(&postID, &tag.ID, &tag.Name, &tag.Slug, &tag.CreatedAt); err != nil {
+			return nil, err
+		}
+		result[postID] = append(result[postID], tag)
+	}
+
+	return result, rows.Err()
+}
+
+// ListPopularCategories returns the categories with the most published posts.
+func (r *TaxonomyRepository) ListPopularCategories(ctx context.Context, limit int) ([]*domain.TermCount, error) {
+	return r.termCounts(ctx, "categories", "post_categories", "category_id", limit)
+}
+
+// ListPopularTags returns the tags with the most published posts.
+func (r *TaxonomyRepository) ListPopularTags(ctx context.Context, limit int) ([]*domain.TermCount, error) {
+	return r.termCounts(ctx, "tags", "post_tags", "tag_id", limit)
+}
+
+// termCounts counts published posts per term. table, links and column are
+// constants supplied by the caller, never user input.
+func (r *TaxonomyRepository) termCounts(ctx context.Context, table, links, column string, limit int) ([]*domain.TermCount, error) {
+	query := fmt.Sprintf(`
+		SELECT t.name, t.slug, COUNT(*) AS post_count
+		FROM %s t
+		JOIN %s l ON l.%s = t.id
+		JOIN posts p ON p.id = l.post_id
+		WHERE p.status = ?
+		GROUP BY t.id, t.name, t.slug
+		ORDER BY post_count DESC, t.name
+		LIMIT ?
+	`, table, links, column)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), domain.PostStatusPublished, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var terms []*domain.TermCount
+	for rows.Next() {
+		term := &domain.TermCount{}
+		if err := rows.Scan(&term.Name, &term.Slug, &term.Count); err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+
+	return terms, rows.Err()
+}
+
+// replaceLinks rewrites the rows of a post join table. table and column are
+// constants supplied by the caller, never user input.
+func (r *TaxonomyRepository) replaceLinks(ctx context.Context, table, column string, postID int64, ids []int64) error {
+	deleteQuery := r.dialect.Rebind(fmt.Sprintf(`DELETE FROM %s WHERE post_id = ?`, table))
+	if _, err := r.db.ExecContext(ctx, deleteQuery, postID); err != nil {
+		return err
+	}
+
+	insertQuery := r.dialect.Rebind(fmt.Sprintf(`INSERT INTO %s (post_id, %s) VALUES (?, ?)`, table, column))
+	for _, id := range ids {
+		if _, err := r.db.ExecContext(ctx, insertQuery, postID, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// placeholders returns "?, ?, ..." for n query arguments.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func int64Args(values []int64) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/database/dialect"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+func TestTaxonomyRepository_CreateCategory(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewTaxonomyRepository(db, d)
+		ctx := context.Background()
+
+		category := &domain.Category{Name: "Go", Slug: "go"}
+
+		expectInsertID(mock, d, `INSERT INTO categories`, 1, "Go", "go", sqlmock.AnyArg())
+
+		err := repo.CreateCategory(ctx, category)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), category.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTaxonomyRepository_GetTagBySlug_NotFound(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewTaxonomyRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM tags WHERE slug = \?`)).
+			WithArgs("missing").
+			WillReturnError(sql.ErrNoRows)
+
+		tag, err := repo.GetTagBySlug(ctx, "missing")
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Nil(t, tag)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTaxonomyRepository_SetPostTags(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewTaxonomyRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectExec(d.Rebind(`DELETE FROM post_tags WHERE post_id = \?`)).
+			WithArgs(int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`INSERT INTO post_tags \(post_id, tag_id\)`).
+			WithArgs(int64(7), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO post_tags \(post_id, tag_id\)`).
+			WithArgs(int64(7), int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.SetPostTags(ctx, 7, []int64{1, 2})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTaxonomyRepository_ListCategoriesByPosts(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewTaxonomyRepository(db, d)
+		ctx := context.Background()
+		now := time.Now()
+
+		rows := sqlmock.NewRows([]string{"post_id", "id", "name", "slug", "created_at"}).
+			AddRow(1, 10, "Go", "go", now).
+			AddRow(1, 11, "Web", "web", now).
+			AddRow(2, 10, "Go", "go", now)
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM post_categories pc JOIN categories c ON c.id = pc.category_id WHERE pc.post_id IN \(\?, \?\)`)).
+			WithArgs(int64(1), int64(2)).
+			WillReturnRows(rows)
+
+		categories, err := repo.ListCategoriesByPosts(ctx, []int64{1, 2})
+		assert.NoError(t, err)
+		assert.Len(t, categories[1], 2)
+		assert.Len(t, categories[2], 1)
+		assert.Equal(t, "Go", categories[2][0].Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTaxonomyRepository_ListTagsByPosts_Empty(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewTaxonomyRepository(db, d)
+
+		tags, err := repo.ListTagsByPosts(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Empty(t, tags)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTaxonomyRepository_ListPopularTags(t *testing.T) {
+	eachDialect(t, func(t *testing.T, d dialect.Dialect, db *sql.DB, mock sqlmock.Sqlmock) {
+		repo := NewTaxonomyRepository(db, d)
+		ctx := context.Background()
+
+		mock.ExpectQuery(d.Rebind(`SELECT (.+) FROM tags t JOIN post_tags l (.+) WHERE p.status = \? (.+) LIMIT \?`)).
+			WithArgs(domain.PostStatusPublished, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "slug", "post_count"}).
+				AddRow("Go", "go", 4).
+				AddRow("Web", "web", 1))
+
+		tags, err := repo.ListPopularTags(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, tags, 2)
+		assert.Equal(t, &domain.TermCount{Name: "Go", Slug: "go", Count: 4}, tags[0])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
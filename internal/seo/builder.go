@@ -0,0 +1,114 @@
+package seo
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+)
+
+// markdownImage matches the first ![alt](url) image in Markdown content.
+var markdownImage = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^)\s>]+)`)
+
+// Builder creates page metadata with site-wide defaults and fallbacks.
+type Builder struct {
+	site config.SiteConfig
+}
+
+func NewBuilder(site config.SiteConfig) *Builder {
+	return &Builder{site: site}
+}
+
+// ForPost describes a post. The title falls back to the post title, the
+// description to an excerpt of the content and the image to the featured
+// image URL when given, then the first image in the content, then the site
+// default image.
+func (b *Builder) ForPost(post *domain.Post, authorName, featuredImage string) *Meta {
+	modified := post.UpdatedAt
+	return &Meta{
+		Title:       firstNonEmpty(post.MetaTitle, post.Title),
+		Description: b.description(post.MetaDesc, post.Content),
+		Canonical:   b.URL("/posts/" + post.Slug),
+		Image:       b.image(featuredImage, post.Content),
+		SiteName:    b.site.Name,
+		Type:        "article",
+		Schema:      SchemaBlogPosting,
+		AuthorName:  authorName,
+		Published:   post.PublishedAt,
+		Modified:    &modified,
+	}
+}
+
+// ForPage describes a page served at path, its nested URL in the page tree,
+// with the same fallbacks as ForPost.
+func (b *Builder) ForPage(page *domain.Page, path, featuredImage string) *Meta {
+	modified := page.UpdatedAt
+	return &Meta{
+		Title:       firstNonEmpty(page.MetaTitle, page.Title),
+		Description: b.description(page.MetaDesc, page.Content),
+		Canonical:   b.URL(path),
+		Image:       b.image(featuredImage, page.Content),
+		SiteName:    b.site.Name,
+		Type:        "website",
+		Schema:      SchemaWebPage,
+		Modified:    &modified,
+	}
+}
+
+// ForPath describes a listing or other non-content page.
+func (b *Builder) ForPath(title, description, path string) *Meta {
+	return &Meta{
+		Title:       firstNonEmpty(title, b.site.Name),
+		Description: firstNonEmpty(description, b.site.Description),
+		Canonical:   b.URL(path),
+		Image:       b.site.Image,
+		SiteName:    b.site.Name,
+		Type:        "website",
+		Schema:      SchemaWebPage,
+	}
+}
+
+// URL makes a site path absolute. Absolute URLs are returned unchanged.
+func (b *Builder) URL(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return b.site.URL + path
+}
+
+func (b *Builder) description(explicit, content string) string {
+	if explicit = strings.TrimSpace(explicit); explicit != "" {
+		return explicit
+	}
+	if excerpt := helpers.Truncate(helpers.PlainText(content), DescriptionLength); excerpt != "" {
+		return excerpt
+	}
+	return b.site.Description
+}
+
+func (b *Builder) image(featured, content string) string {
+	if featured = strings.TrimSpace(featured); featured != "" {
+		return b.URL(featured)
+	}
+	if m := markdownImage.FindStringSubmatch(content); m != nil {
+		return b.URL(m[1])
+	}
+	if b.site.Image != "" {
+		return b.URL(b.site.Image)
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+// Package seo builds the metadata rendered in the <head> of public pages:
+// meta description, canonical URL, Open Graph and Twitter card tags and
+// schema.org JSON-LD.
+package seo
+
+import (
+	"encoding/json"
+	"html"
+	"strings"
+	"time"
+)
+
+// DescriptionLength is the length descriptions are trimmed to, roughly what
+// search engines show in a result snippet.
+const DescriptionLength = 160
+
+// Schema.org types used for JSON-LD.
+const (
+	SchemaBlogPosting = "BlogPosting"
+	SchemaWebPage     = "WebPage"
+)
+
+// Meta describes a single public page.
+type Meta struct {
+	Title       string
+	Description string
+	Canonical   string
+	Image       string
+	SiteName    string
+	Type        string // Open Graph type: "website" or "article"
+	Schema      string // schema.org type for JSON-LD; empty to omit it
+	AuthorName  string
+	Published   *time.Time
+	Modified    *time.Time
+}
+
+// HTML renders the meta, link and script tags for the page head. All values
+// are escaped, so the result can be inserted into templates verbatim.
+func (m *Meta) HTML() string {
+	var b strings.Builder
+
+	if m.Description != "" {
+		tag(&b, "name", "description", m.Description)
+	}
+	if m.Canonical != "" {
+		b.WriteString(`<link rel="canonical" href="` + html.EscapeString(m.Canonical) + "\">\n")
+	}
+
+	tag(&b, "property", "og:type", m.Type)
+	tag(&b, "property", "og:title", m.Title)
+	tag(&b, "property", "og:description", m.Description)
+	tag(&b, "property", "og:url", m.Canonical)
+	tag(&b, "property", "og:site_name", m.SiteName)
+	tag(&b, "property", "og:image", m.Image)
+	if m.Type == "article" {
+		tag(&b, "property", "article:published_time", isoTime(m.Published))
+		tag(&b, "property", "article:modified_time", isoTime(m.Modified))
+	}
+
+	card := "summary"
+	if m.Image != "" {
+		card = "summary_large_image"
+	}
+	tag(&b, "name", "twitter:card", card)
+	tag(&b, "name", "twitter:title", m.Title)
+	tag(&b, "name", "twitter:description", m.Description)
+	tag(&b, "name", "twitter:image", m.Image)
+
+	if ld := m.JSONLD(); ld != "" {
+		b.WriteString(`<script type="application/ld+json">` + ld + "</script>\n")
+	}
+
+	return b.String()
+}
+
+// JSONLD returns the schema.org description of the page as JSON. The
+// encoder escapes <, > and &, so it is safe inside a script element.
+func (m *Meta) JSONLD() string {
+	if m.Schema == "" {
+		return ""
+	}
+
+	doc := map[string]interface{}{
+		"@context": "https://schema.org",
+		"@type":    m.Schema,
+		"url":      m.Canonical,
+	}
+	if m.Schema == SchemaBlogPosting {
+		doc["headline"] = m.Title
+		doc["mainEntityOfPage"] = m.Canonical
+	} else {
+		doc["name"] = m.Title
+	}
+	if m.Description != "" {
+		doc["description"] = m.Description
+	}
+	if m.Image != "" {
+		doc["image"] = m.Image
+	}
+	if m.AuthorName != "" {
+		doc["author"] = map[string]string{"@type": "Person", "name": m.AuthorName}
+	}
+	if m.SiteName != "" {
+		doc["publisher"] = map[string]string{"@type": "Organization", "name": m.SiteName}
+	}
+	if published := isoTime(m.Published); published != "" {
+		doc["datePublished"] = published
+	}
+	if modified := isoTime(m.Modified); modified != "" {
+		doc["dateModified"] = modified
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+func tag(b *strings.Builder, attr, key, value string) {
+	if value == "" {
+		return
+	}
+	b.WriteString(`<meta ` + attr + `="` + key + `" content="` + html.EscapeString(value) + "\">\n")
+}
+
+func isoTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,149 @@
+package seo
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+func testBuilder() *Builder {
+	return NewBuilder(config.SiteConfig{
+		Name:        "Test Site",
+		Description: "A test site",
+		URL:         "https://example.com",
+		Image:       "/static/og.png",
+	})
+}
+
+func TestBuilder_ForPost_Fallbacks(t *testing.T) {
+	published := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	post := &domain.Post{
+		Title:       "Hello World",
+		Slug:        "hello-world",
+		Content:     "First paragraph with **bold** text.\n\n![diagram](/static/uploads/diagram.png)",
+		PublishedAt: &published,
+		UpdatedAt:   published,
+	}
+
+	meta := testBuilder().ForPost(post, "Jane Doe", "")
+
+	if meta.Title != "Hello World" {
+		t.Errorf("Title = %q, want post title", meta.Title)
+	}
+	if meta.Description != "First paragraph with bold text." {
+		t.Errorf("Description = %q, want content excerpt", meta.Description)
+	}
+	if meta.Canonical != "https://example.com/posts/hello-world" {
+		t.Errorf("Canonical = %q", meta.Canonical)
+	}
+	if meta.Image != "https://example.com/static/uploads/diagram.png" {
+		t.Errorf("Image = %q, want first content image", meta.Image)
+	}
+}
+
+func TestBuilder_ForPage_ExplicitValues(t *testing.T) {
+	page := &domain.Page{
+		Title:     "About",
+		Slug:      "about",
+		Content:   "No images here.",
+		MetaTitle: "About Us",
+		MetaDesc:  "Who we are",
+	}
+
+	meta := testBuilder().ForPage(page, "/company/about", "")
+
+	if meta.Title != "About Us" || meta.Description != "Who we are" {
+		t.Errorf("expected explicit SEO fields, got %q / %q", meta.Title, meta.Description)
+	}
+	if meta.Image != "https://example.com/static/og.png" {
+		t.Errorf("Image = %q, want site default image", meta.Image)
+	}
+	if meta.Schema != SchemaWebPage {
+		t.Errorf("Schema = %q, want WebPage", meta.Schema)
+	}
+	if meta.Canonical != "https://example.com/company/about" {
+		t.Errorf("Canonical = %q, want nested page URL", meta.Canonical)
+	}
+}
+
+func TestBuilder_FeaturedImage(t *testing.T) {
+	post := &domain.Post{
+		Title:   "Hello World",
+		Slug:    "hello-world",
+		Content: "![diagram](/static/uploads/diagram.png)",
+	}
+
+	meta := testBuilder().ForPost(post, "", "/static/uploads/hero-large.jpg")
+	if meta.Image != "https://example.com/static/uploads/hero-large.jpg" {
+		t.Errorf("Image = %q, want featured image over content image", meta.Image)
+	}
+
+	meta = testBuilder().ForPage(&domain.Page{Title: "About"}, "/about", "https://cdn.example.com/hero.jpg")
+	if meta.Image != "https://cdn.example.com/hero.jpg" {
+		t.Errorf("Image = %q, want absolute featured image unchanged", meta.Image)
+	}
+}
+
+func TestMeta_HTML(t *testing.T) {
+	published := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	meta := &Meta{
+		Title:       `Quotes "and" <tags>`,
+		Description: "Fish & Chips",
+		Canonical:   "https://example.com/posts/x",
+		Image:       "https://example.com/img.png",
+		SiteName:    "Test Site",
+		Type:        "article",
+		Schema:      SchemaBlogPosting,
+		AuthorName:  "Jane Doe",
+		Published:   &published,
+	}
+
+	out := meta.HTML()
+
+	for _, want := range []string{
+		`<meta name="description" content="Fish &amp; Chips">`,
+		`<link rel="canonical" href="https://example.com/posts/x">`,
+		`<meta property="og:title" content="Quotes &#34;and&#34; &lt;tags&gt;">`,
+		`<meta property="og:image" content="https://example.com/img.png">`,
+		`<meta property="article:published_time" content="2026-01-02T03:04:05Z">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		`<script type="application/ld+json">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<tags>") {
+		t.Error("expected title to be escaped")
+	}
+}
+
+func TestMeta_JSONLD(t *testing.T) {
+	meta := &Meta{
+		Title:      "</script><script>alert(1)</script>",
+		Canonical:  "https://example.com/posts/x",
+		Schema:     SchemaBlogPosting,
+		AuthorName: "Jane Doe",
+	}
+
+	ld := meta.JSONLD()
+	if strings.Contains(ld, "</script>") {
+		t.Errorf("expected script tags to be escaped, got %s", ld)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(ld), &doc); err != nil {
+		t.Fatalf("invalid JSON-LD: %v", err)
+	}
+	if doc["@type"] != SchemaBlogPosting || doc["headline"] != meta.Title {
+		t.Errorf("unexpected JSON-LD: %v", doc)
+	}
+
+	if (&Meta{}).JSONLD() != "" {
+		t.Error("expected no JSON-LD without a schema type")
+	}
+}
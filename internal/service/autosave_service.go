@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type AutosaveRepository interface {
+	Get(ctx context.Context, userID, postID int64) (*domain.PostAutosave, error)
+	Save(ctx context.Context, draft *domain.PostAutosave) error
+	Delete(ctx context.Context, userID, postID int64) error
+	DeleteByPost(ctx context.Context, postID int64) error
+}
+
+// ErrEditConflict is returned when a post was saved by someone else after
+// the editor autosaving it was opened.
+var ErrEditConflict = errors.New("post was changed since it was opened")
+
+// AutosaveService keeps the unsaved work of post editors so it can be
+// recovered after a lost tab or crash.
+type AutosaveService struct {
+	repo AutosaveRepository
+}
+
+func NewAutosaveService(repo AutosaveRepository) *AutosaveService {
+	return &AutosaveService{repo: repo}
+}
+
+// Save stores draft in its user's slot for post, which is nil for a post that
+// hasn't been created yet. The draft is stored even when post was saved after
+// draft.BaseUpdatedAt, in which case ErrEditConflict is returned so the
+// editor can merge the other changes.
+func (s *AutosaveService) Save(ctx context.Context, draft *domain.PostAutosave, post *domain.Post) error {
+	if post != nil {
+		draft.PostID = post.ID
+	}
+	if err := s.repo.Save(ctx, draft); err != nil {
+		return err
+	}
+
+	if post != nil && draft.BaseUpdatedAt != nil && post.UpdatedAt.After(*draft.BaseUpdatedAt) {
+		return ErrEditConflict
+	}
+	return nil
+}
+
+// Recover returns the user's autosave of post, or of a new post when post is
+// nil. It returns nil when there is nothing to recover, including drafts that
+// match what was saved.
+func (s *AutosaveService) Recover(ctx context.Context, userID int64, post *domain.Post) (*domain.PostAutosave, error) {
+	var postID int64
+	if post != nil {
+		postID = post.ID
+	}
+
+	draft, err := s.repo.Get(ctx, userID, postID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(draft.Content) == "" && strings.TrimSpace(draft.Title) == "" {
+		return nil, nil
+	}
+	if post != nil && strings.TrimSpace(draft.Title) == post.Title && strings.TrimSpace(draft.Content) == post.Content {
+		return nil, nil
+	}
+	return draft, nil
+}
+
+// Discard forgets the user's autosave of a post, typically once it is saved.
+func (s *AutosaveService) Discard(ctx context.Context, userID, postID int64) error {
+	return s.repo.Delete(ctx, userID, postID)
+}
+
+// DiscardPost forgets every autosave of a deleted post.
+func (s *AutosaveService) DiscardPost(ctx context.Context, postID int64) error {
+	return s.repo.DeleteByPost(ctx, postID)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type MockAutosaveRepository struct {
+	mock.Mock
+}
+
+func (m *MockAutosaveRepository) Get(ctx context.Context, userID, postID int64) (*domain.PostAutosave, error) {
+	args := m.Called(ctx, userID, postID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PostAutosave), args.Error(1)
+}
+
+func (m *MockAutosaveRepository) Save(ctx context.Context, draft *domain.PostAutosave) error {
+	args := m.Called(ctx, draft)
+	return args.Error(0)
+}
+
+func (m *MockAutosaveRepository) Delete(ctx context.Context, userID, postID int64) error {
+	args := m.Called(ctx, userID, postID)
+	return args.Error(0)
+}
+
+func (m *MockAutosaveRepository) DeleteByPost(ctx context.Context, postID int64) error {
+	args := m.Called(ctx, postID)
+	return args.Error(0)
+}
+
+func TestAutosaveService_Save(t *testing.T) {
+	ctx := context.Background()
+	opened := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("new post", func(t *testing.T) {
+		repo := new(MockAutosaveRepository)
+		svc := NewAutosaveService(repo)
+		draft := &domain.PostAutosave{UserID: 1, Title: "New", Content: "Draft"}
+		repo.On("Save", ctx, draft).Return(nil)
+
+		require.NoError(t, svc.Save(ctx, draft, nil))
+		assert.Equal(t, int64(0), draft.PostID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("unchanged post", func(t *testing.T) {
+		repo := new(MockAutosaveRepository)
+		svc := NewAutosaveService(repo)
+		draft := &domain.PostAutosave{UserID: 1, Content: "Draft", BaseUpdatedAt: &opened}
+		repo.On("Save", ctx, draft).Return(nil)
+
+		require.NoError(t, svc.Save(ctx, draft, &domain.Post{ID: 3, UpdatedAt: opened}))
+		assert.Equal(t, int64(3), draft.PostID)
+	})
+
+	t.Run("post saved by someone else", func(t *testing.T) {
+		repo := new(MockAutosaveRepository)
+		svc := NewAutosaveService(repo)
+		draft := &domain.PostAutosave{UserID: 1, Content: "Draft", BaseUpdatedAt: &opened}
+		repo.On("Save", ctx, draft).Return(nil)
+
+		err := svc.Save(ctx, draft, &domain.Post{ID: 3, UpdatedAt: opened.Add(time.Minute)})
+		assert.ErrorIs(t, err, ErrEditConflict)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestAutosaveService_Recover(t *testing.T) {
+	ctx := context.Background()
+	post := &domain.Post{ID: 3, Title: "Hello", Content: "Saved body"}
+
+	tests := []struct {
+		name  string
+		draft *domain.PostAutosave
+		err   error
+		want  bool
+	}{
+		{name: "no autosave", err: sql.ErrNoRows},
+		{name: "same as saved", draft: &domain.PostAutosave{Title: "Hello", Content: "Saved body\n"}},
+		{name: "empty", draft: &domain.PostAutosave{Title: " ", Content: ""}},
+		{name: "unsaved changes", draft: &domain.PostAutosave{Title: "Hello", Content: "Edited body"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockAutosaveRepository)
+			svc := NewAutosaveService(repo)
+			if tt.draft != nil {
+				repo.On("Get", ctx, int64(1), int64(3)).Return(tt.draft, nil)
+			} else {
+				repo.On("Get", ctx, int64(1), int64(3)).Return(nil, tt.err)
+			}
+
+			draft, err := svc.Recover(ctx, 1, post)
+			require.NoError(t, err)
+			if tt.want {
+				assert.Same(t, tt.draft, draft)
+			} else {
+				assert.Nil(t, draft)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type CommentRepository interface {
+	Create(ctx context.Context, c *domain.Comment) error
+	GetByID(ctx context.Context, id int64) (*domain.Comment, error)
+	ListByPost(ctx context.Context, postID int64, status domain.CommentStatus) ([]*domain.Comment, error)
+	ListByStatus(ctx context.Context, status domain.CommentStatus, limit, offset int) ([]*domain.Comment, error)
+	CountByStatus(ctx context.Context) (map[domain.CommentStatus]int, error)
+	UpdateStatus(ctx context.Context, id int64, status domain.CommentStatus) error
+	Delete(ctx context.Context, id int64) error
+}
+
+var (
+	// ErrLoginRequired is returned when a visitor comments without signing in
+	// and anonymous comments are turned off.
+	ErrLoginRequired = errors.New("sign in to comment")
+	// ErrRateLimited is returned when a visitor comments too often.
+	ErrRateLimited = errors.New("too many comments, please wait a few minutes")
+	// ErrInvalidComment is returned when a comment is empty, too long or
+	// replies to a comment it cannot reply to.
+	ErrInvalidComment = errors.New("invalid comment")
+)
+
+const (
+	maxCommentLength     = 5000
+	maxCommentNameLength = 100
+)
+
+// CommentService accepts reader comments on published posts and moves them
+// through moderation.
+type CommentService struct {
+	repo    CommentRepository
+	posts   *PostService
+	cfg     config.CommentsConfig
+	limiter *rateLimiter
+}
+
+func NewCommentService(repo CommentRepository, posts *PostService, cfg config.CommentsConfig) *CommentService {
+	return &CommentService{
+		repo:    repo,
+		posts:   posts,
+		cfg:     cfg,
+		limiter: newRateLimiter(cfg.RateLimit, cfg.RateWindow),
+	}
+}
+
+// AllowAnonymous reports whether visitors can comment without signing in.
+func (s *CommentService) AllowAnonymous() bool {
+	return s.cfg.AllowAnonymous
+}
+
+// Submit validates and stores a new comment. actor is nil for anonymous
+// visitors, who are identified by ip for rate limiting. Replies nested deeper
+// than the configured depth are attached to their parent's parent instead.
+// Comments from editors, or all comments when approval is not required, are
+// approved straight away; the rest wait in the pending queue.
+func (s *CommentService) Submit(ctx context.Context, c *domain.Comment, actor *domain.Actor, ip string) error {
+	post, err := s.posts.repo.GetByID(ctx, c.PostID)
+	if err != nil {
+		return err
+	}
+	if post.Status != domain.PostStatusPublished {
+		return sql.ErrNoRows
+	}
+
+	if actor == nil && !s.cfg.AllowAnonymous {
+		return ErrLoginRequired
+	}
+
+	c.AuthorName = strings.TrimSpace(c.AuthorName)
+	c.AuthorEmail = strings.TrimSpace(c.AuthorEmail)
+	c.Body = strings.TrimSpace(c.Body)
+	if err := validateComment(c); err != nil {
+		return err
+	}
+
+	key := "ip:" + ip
+	if actor != nil {
+		key = "user:" + strconv.FormatInt(actor.UserID, 10)
+		c.UserID = &actor.UserID
+	}
+	if !s.limiter.Allow(key) {
+		return ErrRateLimited
+	}
+
+	c.Depth = 0
+	if c.ParentID != nil {
+		parent, err := s.repo.GetByID(ctx, *c.ParentID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: the comment you replied to no longer exists", ErrInvalidComment)
+			}
+			return err
+		}
+		if parent.PostID != c.PostID || parent.Status != domain.CommentStatusApproved {
+			return fmt.Errorf("%w: the comment you replied to no longer exists", ErrInvalidComment)
+		}
+
+		if parent.Depth >= s.cfg.MaxDepth-1 {
+			c.ParentID = parent.ParentID
+			c.Depth = parent.Depth
+		} else {
+			c.Depth = parent.Depth + 1
+		}
+	}
+
+	c.IPAddress = ip
+	c.Status = domain.CommentStatusPending
+	if !s.cfg.RequireApproval || (actor != nil && actor.Editor) {
+		c.Status = domain.CommentStatusApproved
+	}
+
+	return s.repo.Create(ctx, c)
+}
+
+// ListApproved returns a post's approved comments in thread order.
+func (s *CommentService) ListApproved(ctx context.Context, postID int64) ([]*domain.Comment, error) {
+	comments, err := s.repo.ListByPost(ctx, postID, domain.CommentStatusApproved)
+	if err != nil {
+		return nil, err
+	}
+	return domain.ThreadComments(comments), nil
+}
+
+// Queue lists the comments in a moderation queue, newest first.
+func (s *CommentService) Queue(ctx context.Context, status domain.CommentStatus, limit, offset int) ([]*domain.Comment, error) {
+	if !status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidComment, status)
+	}
+	return s.repo.ListByStatus(ctx, status, limit, offset)
+}
+
+// Counts returns the number of comments in each moderation queue.
+func (s *CommentService) Counts(ctx context.Context) (map[domain.CommentStatus]int, error) {
+	return s.repo.CountByStatus(ctx)
+}
+
+// Moderate moves a comment to another queue.
+func (s *CommentService) Moderate(ctx context.Context, id int64, status domain.CommentStatus) (*domain.Comment, error) {
+	if !status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidComment, status)
+	}
+
+	c, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateStatus(ctx, id, status); err != nil {
+		return nil, err
+	}
+	c.Status = status
+	return c, nil
+}
+
+// Delete permanently removes a comment and its replies.
+func (s *CommentService) Delete(ctx context.Context, id int64) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func validateComment(c *domain.Comment) error {
+	if c.AuthorName == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidComment)
+	}
+	if utf8.RuneCountInString(c.AuthorName) > maxCommentNameLength {
+		return fmt.Errorf("%w: name must be at most %d characters", ErrInvalidComment, maxCommentNameLength)
+	}
+	if c.AuthorEmail != "" {
+		if _, err := mail.ParseAddress(c.AuthorEmail); err != nil {
+			return fmt.Errorf("%w: email address is not valid", ErrInvalidComment)
+		}
+	}
+	if c.Body == "" {
+		return fmt.Errorf("%w: comment is empty", ErrInvalidComment)
+	}
+	if utf8.RuneCountInString(c.Body) > maxCommentLength {
+		return fmt.Errorf("%w: comment must be at most %d characters", ErrInvalidComment, maxCommentLength)
+	}
+	return nil
+}
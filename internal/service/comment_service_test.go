@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type MockCommentRepository struct {
+	mock.Mock
+}
+
+func (m *MockCommentRepository) Create(ctx context.Context, c *domain.Comment) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) GetByID(ctx context.Context, id int64) (*domain.Comment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) ListByPost(ctx context.Context, postID int64, status domain.CommentStatus) ([]*domain.Comment, error) {
+	args := m.Called(ctx, postID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) ListByStatus(ctx context.Context, status domain.CommentStatus, limit, offset int) ([]*domain.Comment, error) {
+	args := m.Called(ctx, status, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) CountByStatus(ctx context.Context) (map[domain.CommentStatus]int, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[domain.CommentStatus]int), args.Error(1)
+}
+
+func (m *MockCommentRepository) UpdateStatus(ctx context.Context, id int64, status domain.CommentStatus) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+var commentsConfig = config.CommentsConfig{
+	AllowAnonymous:  true,
+	RequireApproval: true,
+	MaxDepth:        2,
+	RateLimit:       2,
+	RateWindow:      time.Minute,
+}
+
+func newCommentService(cfg config.CommentsConfig) (*CommentService, *MockCommentRepository, *MockPostRepository) {
+	posts := new(MockPostRepository)
+	comments := new(MockCommentRepository)
+	return NewCommentService(comments, NewPostService(posts, nil), cfg), comments, posts
+}
+
+func publishedPost() *domain.Post {
+	return &domain.Post{ID: 3, AuthorID: 1, Status: domain.PostStatusPublished}
+}
+
+func TestCommentService_Submit_Anonymous(t *testing.T) {
+	svc, comments, posts := newCommentService(commentsConfig)
+	ctx := context.Background()
+
+	posts.On("GetByID", ctx, int64(3)).Return(publishedPost(), nil)
+	comments.On("Create", ctx, mock.AnythingOfType("*domain.Comment")).Return(nil)
+
+	c := &domain.Comment{PostID: 3, AuthorName: "  Ann ", Body: " Nice post\n"}
+	err := svc.Submit(ctx, c, nil, "10.0.0.1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Ann", c.AuthorName)
+	assert.Equal(t, "Nice post", c.Body)
+	assert.Equal(t, domain.CommentStatusPending, c.Status)
+	assert.Equal(t, "10.0.0.1", c.IPAddress)
+	assert.Nil(t, c.UserID)
+	comments.AssertExpectations(t)
+}
+
+func TestCommentService_Submit_EditorIsApproved(t *testing.T) {
+	svc, comments, posts := newCommentService(commentsConfig)
+	ctx := context.Background()
+
+	posts.On("GetByID", ctx, int64(3)).Return(publishedPost(), nil)
+	comments.On("Create", ctx, mock.AnythingOfType("*domain.Comment")).Return(nil)
+
+	c := &domain.Comment{PostID: 3, AuthorName: "editor", Body: "Thanks"}
+	err := svc.Submit(ctx, c, &domain.Actor{UserID: 2, Editor: true}, "10.0.0.1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CommentStatusApproved, c.Status)
+	assert.Equal(t, int64(2), *c.UserID)
+}
+
+func TestCommentService_Submit_NoModeration(t *testing.T) {
+	cfg := commentsConfig
+	cfg.RequireApproval = false
+	svc, comments, posts := newCommentService(cfg)
+	ctx := context.Background()
+
+	posts.On("GetByID", ctx, int64(3)).Return(publishedPost(), nil)
+	comments.On("Create", ctx, mock.AnythingOfType("*domain.Comment")).Return(nil)
+
+	c := &domain.Comment{PostID: 3, AuthorName: "Ann", Body: "Hi"}
+	assert.NoError(t, svc.Submit(ctx, c, nil, "10.0.0.1"))
+	assert.Equal(t, domain.CommentStatusApproved, c.Status)
+}
+
+func TestCommentService_Submit_LoginRequired(t *testing.T) {
+	cfg := commentsConfig
+	cfg.AllowAnonymous = false
+	svc, comments, posts := newCommentService(cfg)
+	ctx := context.Background()
+
+	posts.On("GetByID", ctx, int64(3)).Return(publishedPost(), nil)
+
+	err := svc.Submit(ctx, &domain.Comment{PostID: 3, AuthorName: "Ann", Body: "Hi"}, nil, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrLoginRequired)
+	comments.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCommentService_Submit_UnpublishedPost(t *testing.T) {
+	svc, _, posts := newCommentService(commentsConfig)
+	ctx := context.Background()
+
+	posts.On("GetByID", ctx, int64(3)).Return(&domain.Post{ID: 3, Status: domain.PostStatusDraft}, nil)
+
+	err := svc.Submit(ctx, &domain.Comment{PostID: 3, AuthorName: "Ann", Body: "Hi"}, nil, "10.0.0.1")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCommentService_Submit_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment domain.Comment
+	}{
+		{"missing name", domain.Comment{Body: "Hi"}},
+		{"empty body", domain.Comment{AuthorName: "Ann", Body: "   "}},
+		{"bad email", domain.Comment{AuthorName: "Ann", AuthorEmail: "not an email", Body: "Hi"}},
+		{"too long", domain.Comment{AuthorName: "Ann", Body: string(make([]rune, maxCommentLength+1))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, posts := newCommentService(commentsConfig)
+			ctx := context.Background()
+			posts.On("GetByID", ctx, int64(3)).Return(publishedPost(), nil)
+
+			c := tt.comment
+			c.PostID = 3
+			assert.ErrorIs(t, svc.Submit(ctx, &c, nil, "10.0.0.1"), ErrInvalidComment)
+		})
+	}
+}
+
+func TestCommentService_Submit_RateLimited(t *testing.T) {
+	svc, comments, posts := newCommentService(commentsConfig)
+	ctx := context.Background()
+
+	posts.On("GetByID", ctx, int64(3)).Return(publishedPost(), nil)
+	comments.On("Create", ctx, mock.AnythingOfType("*domain.Comment")).Return(nil)
+
+	submit := func(ip string) error {
+		return svc.Submit(ctx, &domain.Comment{PostID: 3, AuthorName: "Ann", Body: "Hi"}, nil, ip)
+	}
+
+	assert.NoError(t, submit("10.0.0.1"))
+	assert.NoError(t, submit("10.0.0.1"))
+	assert.ErrorIs(t, submit("10.0.0.1"), ErrRateLimited)
+	assert.NoError(t, submit("10.0.0.2"))
+	comments.AssertNumberOfCalls(t, "Create", 3)
+}
+
+func TestCommentService_Submit_Reply(t *testing.T) {
+	svc, comments, posts := newCommentService(commentsConfig)
+	ctx := context.Background()
+
+	posts.On("GetByID", ctx, int64(3)).Return(publishedPost(), nil)
+	comments.On("GetByID", ctx, int64(10)).
+		Return(&domain.Comment{ID: 10, PostID: 3, Status: domain.CommentStatusApproved}, nil)
+	comments.On("GetByID", ctx, int64(11)).
+		Return(&domain.Comment{ID: 11, PostID: 3, ParentID: int64Ptr(10), Depth: 1, Status: domain.CommentStatusApproved}, nil)
+	comments.On("Create", ctx, mock.AnythingOfType("*domain.Comment")).Return(nil)
+
+	reply := &domain.Comment{PostID: 3, ParentID: int64Ptr(10), AuthorName: "Ann", Body: "Reply"}
+	assert.NoError(t, svc.Submit(ctx, reply, nil, "10.0.0.1"))
+	assert.Equal(t, 1, reply.Depth)
+	assert.Equal(t, int64(10), *reply.ParentID)
+
+	// MaxDepth is 2, so replying to a reply continues the same thread
+	nested := &domain.Comment{PostID: 3, ParentID: int64Ptr(11), AuthorName: "Bob", Body: "Reply"}
+	assert.NoError(t, svc.Submit(ctx, nested, nil, "10.0.0.2"))
+	assert.Equal(t, 1, nested.Depth)
+	assert.Equal(t, int64(10), *nested.ParentID)
+}
+
+func TestCommentService_Submit_ReplyToPendingComment(t *testing.T) {
+	svc, comments, posts := newCommentService(commentsConfig)
+	ctx := context.Background()
+
+	posts.On("GetByID", ctx, int64(3)).Return(publishedPost(), nil)
+	comments.On("GetByID", ctx, int64(10)).
+		Return(&domain.Comment{ID: 10, PostID: 3, Status: domain.CommentStatusPending}, nil)
+
+	reply := &domain.Comment{PostID: 3, ParentID: int64Ptr(10), AuthorName: "Ann", Body: "Reply"}
+	assert.ErrorIs(t, svc.Submit(ctx, reply, nil, "10.0.0.1"), ErrInvalidComment)
+	comments.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCommentService_ListApproved(t *testing.T) {
+	svc, comments, _ := newCommentService(commentsConfig)
+	ctx := context.Background()
+
+	comments.On("ListByPost", ctx, int64(3), domain.CommentStatusApproved).Return([]*domain.Comment{
+		{ID: 1},
+		{ID: 2},
+		{ID: 3, ParentID: int64Ptr(1), Depth: 1},
+	}, nil)
+
+	list, err := svc.ListApproved(ctx, 3)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 3) {
+		assert.Equal(t, []int64{1, 3, 2}, []int64{list[0].ID, list[1].ID, list[2].ID})
+	}
+}
+
+func TestCommentService_Moderate(t *testing.T) {
+	svc, comments, _ := newCommentService(commentsConfig)
+	ctx := context.Background()
+
+	comments.On("GetByID", ctx, int64(5)).
+		Return(&domain.Comment{ID: 5, Status: domain.CommentStatusPending}, nil)
+	comments.On("UpdateStatus", ctx, int64(5), domain.CommentStatusSpam).Return(nil)
+
+	c, err := svc.Moderate(ctx, 5, domain.CommentStatusSpam)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CommentStatusSpam, c.Status)
+
+	_, err = svc.Moderate(ctx, 5, domain.CommentStatus("deleted"))
+	assert.ErrorIs(t, err, ErrInvalidComment)
+	comments.AssertExpectations(t)
+}
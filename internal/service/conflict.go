@@ -0,0 +1,29 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrConflict is returned when saving a post or page that someone else saved
+// since it was loaded. The caller's changes are not stored.
+var ErrConflict = errors.New("content was changed by someone else")
+
+// checkVersion reports ErrConflict when content is no longer at the version
+// the caller loaded.
+func checkVersion(loaded, current int) error {
+	if loaded != current {
+		return ErrConflict
+	}
+	return nil
+}
+
+// updateConflict maps a versioned update that matched no row to ErrConflict.
+// It happens when the content is saved by someone else between loading the
+// current version and updating it.
+func updateConflict(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrConflict
+	}
+	return err
+}
@@ -0,0 +1,25 @@
+package service
+
+import "sync"
+
+// listeners holds callbacks run after published content changes, e.g. to
+// invalidate caches built from it.
+type listeners struct {
+	mu  sync.RWMutex
+	fns []func()
+}
+
+func (l *listeners) add(fn func()) {
+	l.mu.Lock()
+	l.fns = append(l.fns, fn)
+	l.mu.Unlock()
+}
+
+func (l *listeners) notify() {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, fn := range l.fns {
+		fn()
+	}
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/media"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
+)
+
+type MediaRepository interface {
+	Create(ctx context.Context, m *domain.Media) error
+	GetByID(ctx context.Context, id int64) (*domain.Media, error)
+	GetByChecksum(ctx context.Context, ownerID int64, checksum string) (*domain.Media, error)
+	List(ctx context.Context, limit, offset int) ([]*domain.Media, error)
+	ListByOwner(ctx context.Context, ownerID int64, limit, offset int) ([]*domain.Media, error)
+	UpdateAltText(ctx context.Context, id int64, alt string) error
+	Delete(ctx context.Context, id int64) error
+	ListByIDs(ctx context.Context, ids []int64) ([]*domain.Media, error)
+	ListReferences(ctx context.Context, id int64, basePath string) ([]*domain.MediaReference, error)
+}
+
+var (
+	// ErrMediaInUse is returned when deleting media that posts or pages
+	// still show.
+	ErrMediaInUse = errors.New("media is still used by content")
+	// ErrFileTooLarge is returned for uploads over helpers.MaxUploadSize.
+	ErrFileTooLarge = helpers.ErrFileTooLarge
+	// ErrImageTooLarge is returned for images over the helpers dimension
+	// limits.
+	ErrImageTooLarge = helpers.ErrImageDimensions
+	// ErrUnsupportedMedia is returned for files that are not images in a
+	// supported format.
+	ErrUnsupportedMedia = media.ErrUnsupported
+)
+
+// downloadURLExpiry is how long presigned download links stay valid.
+const downloadURLExpiry = 15 * time.Minute
+
+// MediaService stores uploaded images with their resized variants and keeps
+// the media library in step with the files in storage.
+type MediaService struct {
+	repo  MediaRepository
+	store storage.Storage
+	now   func() time.Time
+}
+
+// NewMediaService creates a media service saving files to store.
+func NewMediaService(repo MediaRepository, store storage.Storage) *MediaService {
+	return &MediaService{repo: repo, store: store, now: time.Now}
+}
+
+// Upload processes and saves an image for its owner. Uploading a file the
+// owner already has returns the existing item instead of a copy.
+func (s *MediaService) Upload(ctx context.Context, ownerID int64, fileName string, r io.Reader, alt string) (*domain.Media, error) {
+	data, err := helpers.ReadLimited(r, helpers.MaxUploadSize)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	if existing, err := s.repo.GetByChecksum(ctx, ownerID, checksum); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	img, err := media.Process(data, domain.MediaVariants)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	m := &domain.Media{
+		OwnerID:  ownerID,
+		FileName: cleanFileName(fileName),
+		Path:     fmt.Sprintf("/uploads/%d/%02d/%s%s", now.Year(), now.Month(), uuid.New().String(), img.Ext),
+		MimeType: img.MimeType,
+		Size:     int64(len(img.Original)),
+		Width:    img.Width,
+		Height:   img.Height,
+		AltText:  strings.TrimSpace(alt),
+		Checksum: checksum,
+	}
+
+	written, err := s.writeFiles(ctx, m, img)
+	if err != nil {
+		s.removeFiles(ctx, written)
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, m); err != nil {
+		s.removeFiles(ctx, written)
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (s *MediaService) GetMedia(ctx context.Context, id int64) (*domain.Media, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ByIDs loads media by ID, keyed by ID. Unknown IDs are left out.
+func (s *MediaService) ByIDs(ctx context.Context, ids []int64) (map[int64]*domain.Media, error) {
+	items, err := s.repo.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*domain.Media, len(items))
+	for _, m := range items {
+		byID[m.ID] = m
+	}
+	return byID, nil
+}
+
+// Usable loads an item the actor may attach to content, such as a featured
+// image: any item for editors, their own uploads for everyone else.
+func (s *MediaService) Usable(ctx context.Context, id int64, actor domain.Actor) (*domain.Media, error) {
+	return s.owned(ctx, id, actor)
+}
+
+// Library lists the media an actor can use: everything for editors, their
+// own uploads for everyone else.
+func (s *MediaService) Library(ctx context.Context, actor domain.Actor, limit, offset int) ([]*domain.Media, error) {
+	if actor.Editor {
+		return s.repo.List(ctx, limit, offset)
+	}
+	return s.repo.ListByOwner(ctx, actor.UserID, limit, offset)
+}
+
+// UpdateAltText changes the alt text of an item the actor owns or edits.
+func (s *MediaService) UpdateAltText(ctx context.Context, id int64, actor domain.Actor, alt string) (*domain.Media, error) {
+	m, err := s.owned(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	m.AltText = strings.TrimSpace(alt)
+	if err := s.repo.UpdateAltText(ctx, id, m.AltText); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Delete removes an item and its files. While posts or pages still use it,
+// nothing is deleted and the references are returned with ErrMediaInUse.
+func (s *MediaService) Delete(ctx context.Context, id int64, actor domain.Actor) ([]*domain.MediaReference, error) {
+	m, err := s.owned(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := s.repo.ListReferences(ctx, m.ID, m.BasePath())
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) > 0 {
+		return refs, ErrMediaInUse
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+
+	s.removeFiles(ctx, mediaPaths(m))
+	return nil, nil
+}
+
+// DownloadURL returns a link to an item's original file. Backends that
+// support it hand out a short-lived presigned URL, so this works for private
+// buckets too.
+func (s *MediaService) DownloadURL(ctx context.Context, id int64, actor domain.Actor) (string, error) {
+	m, err := s.owned(ctx, id, actor)
+	if err != nil {
+		return "", err
+	}
+	return storage.SignedURL(ctx, s.store, m.Path, downloadURLExpiry)
+}
+
+// URL returns the public URL of an item's variant, or of the original when
+// variant is empty.
+func (s *MediaService) URL(m *domain.Media, variant string) string {
+	if variant == "" {
+		return s.store.URL(m.Path)
+	}
+	return s.store.URL(m.VariantPath(variant))
+}
+
+// owned loads an item, checking the actor may change it.
+func (s *MediaService) owned(ctx context.Context, id int64, actor domain.Actor) (*domain.Media, error) {
+	m, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.Editor && actor.UserID != m.OwnerID {
+		return nil, ErrNotAllowed
+	}
+	return m, nil
+}
+
+// writeFiles saves the original and its variants, returning the paths
+// written so far even on error.
+func (s *MediaService) writeFiles(ctx context.Context, m *domain.Media, img *media.Processed) ([]string, error) {
+	var written []string
+
+	put := func(p string, data []byte, contentType string) error {
+		if err := s.store.Put(ctx, p, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+			return fmt.Errorf("failed to save file: %w", err)
+		}
+		written = append(written, p)
+		return nil
+	}
+
+	if err := put(m.Path, img.Original, m.MimeType); err != nil {
+		return written, err
+	}
+	for _, v := range img.Variants {
+		contentType := mime.TypeByExtension(domain.VariantExt(m.MimeType))
+		if err := put(m.VariantPath(v.Name), v.Data, contentType); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (s *MediaService) removeFiles(ctx context.Context, paths []string) {
+	for _, p := range paths {
+		if err := s.store.Delete(ctx, p); err != nil {
+			log.Printf("Error removing %s: %v", p, err)
+		}
+	}
+}
+
+// mediaPaths lists the original file and all its variants.
+func mediaPaths(m *domain.Media) []string {
+	paths := []string{m.Path}
+	for _, v := range domain.MediaVariants {
+		paths = append(paths, m.VariantPath(v.Name))
+	}
+	return paths
+}
+
+// cleanFileName keeps the base name of a client-supplied file name for
+// display, dropping any directories and control characters.
+func cleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" || name == "" {
+		return "upload"
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return string(bytes.ToValidUTF8([]byte(name), nil))
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/storage"
+)
+
+type MockMediaRepository struct {
+	mock.Mock
+}
+
+func (m *MockMediaRepository) Create(ctx context.Context, item *domain.Media) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockMediaRepository) GetByID(ctx context.Context, id int64) (*domain.Media, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Media), args.Error(1)
+}
+
+func (m *MockMediaRepository) GetByChecksum(ctx context.Context, ownerID int64, checksum string) (*domain.Media, error) {
+	args := m.Called(ctx, ownerID, checksum)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Media), args.Error(1)
+}
+
+func (m *MockMediaRepository) List(ctx context.Context, limit, offset int) ([]*domain.Media, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Media), args.Error(1)
+}
+
+func (m *MockMediaRepository) ListByOwner(ctx context.Context, ownerID int64, limit, offset int) ([]*domain.Media, error) {
+	args := m.Called(ctx, ownerID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Media), args.Error(1)
+}
+
+func (m *MockMediaRepository) UpdateAltText(ctx context.Context, id int64, alt string) error {
+	args := m.Called(ctx, id, alt)
+	return args.Error(0)
+}
+
+func (m *MockMediaRepository) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockMediaRepository) ListByIDs(ctx context.Context, ids []int64) ([]*domain.Media, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Media), args.Error(1)
+}
+
+func (m *MockMediaRepository) ListReferences(ctx context.Context, id int64, basePath string) ([]*domain.MediaReference, error) {
+	args := m.Called(ctx, id, basePath)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.MediaReference), args.Error(1)
+}
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h))))
+	return buf.Bytes()
+}
+
+func newMediaService(t *testing.T) (*MediaService, *MockMediaRepository, string) {
+	root := t.TempDir()
+	repo := new(MockMediaRepository)
+	svc := NewMediaService(repo, storage.NewLocal(root, "/static"))
+	svc.now = func() time.Time { return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC) }
+	return svc, repo, root
+}
+
+func TestMediaService_Upload(t *testing.T) {
+	svc, repo, root := newMediaService(t)
+	ctx := context.Background()
+
+	repo.On("GetByChecksum", ctx, int64(2), mock.AnythingOfType("string")).Return(nil, sql.ErrNoRows)
+	repo.On("Create", ctx, mock.AnythingOfType("*domain.Media")).Return(nil)
+
+	m, err := svc.Upload(ctx, 2, `C:\photos\..\bike.png`, bytes.NewReader(pngBytes(t, 1000, 500)), " A bike ")
+	require.NoError(t, err)
+
+	assert.Equal(t, "bike.png", m.FileName)
+	assert.Equal(t, "A bike", m.AltText)
+	assert.Equal(t, "image/png", m.MimeType)
+	assert.Equal(t, 1000, m.Width)
+	assert.Equal(t, 500, m.Height)
+	assert.Len(t, m.Checksum, 64)
+	assert.True(t, strings.HasPrefix(m.Path, "/uploads/2026/03/"), m.Path)
+
+	for _, p := range mediaPaths(m) {
+		_, err := os.Stat(filepath.Join(root, p))
+		assert.NoError(t, err, "expected %s to be written", p)
+	}
+
+	f, err := os.Open(filepath.Join(root, m.VariantPath(domain.VariantThumb)))
+	require.NoError(t, err)
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	require.NoError(t, err)
+	assert.Equal(t, 300, cfg.Width)
+	assert.Equal(t, 150, cfg.Height)
+}
+
+func TestMediaService_Upload_Duplicate(t *testing.T) {
+	svc, repo, _ := newMediaService(t)
+	ctx := context.Background()
+
+	existing := &domain.Media{ID: 5, OwnerID: 2}
+	repo.On("GetByChecksum", ctx, int64(2), mock.AnythingOfType("string")).Return(existing, nil)
+
+	m, err := svc.Upload(ctx, 2, "bike.png", bytes.NewReader(pngBytes(t, 2, 2)), "")
+	assert.NoError(t, err)
+	assert.Same(t, existing, m)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMediaService_Upload_Rejects(t *testing.T) {
+	svc, repo, root := newMediaService(t)
+	ctx := context.Background()
+
+	repo.On("GetByChecksum", ctx, int64(2), mock.AnythingOfType("string")).Return(nil, sql.ErrNoRows)
+
+	_, err := svc.Upload(ctx, 2, "evil.png", strings.NewReader("<script>alert(1)</script>"), "")
+	assert.ErrorIs(t, err, ErrUnsupportedMedia)
+
+	_, err = svc.Upload(ctx, 2, "huge.png", bytes.NewReader(make([]byte, 10<<20+1)), "")
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+
+	entries, _ := os.ReadDir(root)
+	assert.Empty(t, entries, "expected nothing to be written")
+}
+
+func TestMediaService_Delete_InUse(t *testing.T) {
+	svc, repo, _ := newMediaService(t)
+	ctx := context.Background()
+
+	repo.On("GetByID", ctx, int64(5)).Return(&domain.Media{ID: 5, OwnerID: 2, Path: "/uploads/2026/03/abc.png"}, nil)
+	repo.On("ListReferences", ctx, int64(5), "/uploads/2026/03/abc").
+		Return([]*domain.MediaReference{{Type: domain.ContentTypePost, ID: 3, Title: "Hello"}}, nil)
+
+	refs, err := svc.Delete(ctx, 5, domain.Actor{UserID: 2})
+	assert.ErrorIs(t, err, ErrMediaInUse)
+	assert.Len(t, refs, 1)
+	repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestMediaService_Delete(t *testing.T) {
+	svc, repo, root := newMediaService(t)
+	ctx := context.Background()
+
+	m := &domain.Media{ID: 5, OwnerID: 2, Path: "/uploads/2026/03/abc.png", MimeType: "image/png"}
+	for _, p := range mediaPaths(m) {
+		full := filepath.Join(root, p)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte("x"), 0644))
+	}
+
+	repo.On("GetByID", ctx, int64(5)).Return(m, nil)
+	repo.On("ListReferences", ctx, int64(5), "/uploads/2026/03/abc").Return([]*domain.MediaReference(nil), nil)
+	repo.On("Delete", ctx, int64(5)).Return(nil)
+
+	_, err := svc.Delete(ctx, 5, domain.Actor{UserID: 9, Editor: true})
+	require.NoError(t, err)
+
+	for _, p := range mediaPaths(m) {
+		_, err := os.Stat(filepath.Join(root, p))
+		assert.True(t, os.IsNotExist(err), "expected %s to be removed", p)
+	}
+}
+
+func TestMediaService_NotOwner(t *testing.T) {
+	svc, repo, _ := newMediaService(t)
+	ctx := context.Background()
+
+	repo.On("GetByID", ctx, int64(5)).Return(&domain.Media{ID: 5, OwnerID: 2}, nil)
+
+	_, err := svc.UpdateAltText(ctx, 5, domain.Actor{UserID: 3}, "Mine now")
+	assert.ErrorIs(t, err, ErrNotAllowed)
+
+	_, err = svc.Delete(ctx, 5, domain.Actor{UserID: 3})
+	assert.ErrorIs(t, err, ErrNotAllowed)
+}
+
+func TestMediaService_Library(t *testing.T) {
+	svc, repo, _ := newMediaService(t)
+	ctx := context.Background()
+
+	repo.On("List", ctx, 24, 0).Return([]*domain.Media{{ID: 1}, {ID: 2}}, nil)
+	repo.On("ListByOwner", ctx, int64(2), 24, 0).Return([]*domain.Media{{ID: 2}}, nil)
+
+	all, err := svc.Library(ctx, domain.Actor{UserID: 9, Editor: true}, 24, 0)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	mine, err := svc.Library(ctx, domain.Actor{UserID: 2}, 24, 0)
+	assert.NoError(t, err)
+	assert.Len(t, mine, 1)
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type MenuRepository interface {
+	ListByLocation(ctx context.Context, location domain.MenuLocation) ([]*domain.MenuItem, error)
+	ReplaceLocation(ctx context.Context, location domain.MenuLocation, items []*domain.MenuItem) error
+}
+
+// ErrInvalidMenuItem is returned when a menu item points to a missing page or
+// has an unsafe or incomplete link.
+var ErrInvalidMenuItem = errors.New("invalid menu item")
+
+// defaultMenus are rendered for locations that have no items yet, so a fresh
+// install still has working navigation.
+var defaultMenus = map[domain.MenuLocation][]domain.MenuLink{
+	domain.MenuHeader: {
+		{Label: "Home", URL: "/"},
+		{Label: "Posts", URL: "/posts"},
+		{Label: "Pages", URL: "/pages"},
+	},
+}
+
+// MenuService builds the header and footer navigation from pages and links.
+// Resolved menus are cached until a menu is saved or published pages change.
+type MenuService struct {
+	repo  MenuRepository
+	pages *PageService
+
+	mu    sync.RWMutex
+	cache map[domain.MenuLocation][]domain.MenuLink
+}
+
+func NewMenuService(repo MenuRepository, pages *PageService) *MenuService {
+	s := &MenuService{
+		repo:  repo,
+		pages: pages,
+		cache: make(map[domain.MenuLocation][]domain.MenuLink),
+	}
+	pages.OnPublishedChange(s.Invalidate)
+	return s
+}
+
+// Items returns the stored items of a menu, for editing.
+func (s *MenuService) Items(ctx context.Context, location domain.MenuLocation) ([]*domain.MenuItem, error) {
+	if !location.IsValid() {
+		return nil, fmt.Errorf("unknown menu location %q", location)
+	}
+	return s.repo.ListByLocation(ctx, location)
+}
+
+// Links returns the menu ready to render. Items pointing to pages that are not
+// reachable on the public site are left out; page items without a label use
+// the page title.
+func (s *MenuService) Links(ctx context.Context, location domain.MenuLocation) ([]domain.MenuLink, error) {
+	s.mu.RLock()
+	links, ok := s.cache[location]
+	s.mu.RUnlock()
+	if ok {
+		return links, nil
+	}
+
+	items, err := s.Items(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		links = append([]domain.MenuLink{}, defaultMenus[location]...)
+	} else {
+		tree, err := s.pages.PublishedPageTree(ctx)
+		if err != nil {
+			return nil, err
+		}
+		links = resolveMenu(items, tree)
+	}
+
+	s.mu.Lock()
+	s.cache[location] = links
+	s.mu.Unlock()
+
+	return links, nil
+}
+
+// SaveMenu replaces the items of a menu. Items are stored in the given order.
+func (s *MenuService) SaveMenu(ctx context.Context, location domain.MenuLocation, items []*domain.MenuItem) error {
+	if !location.IsValid() {
+		return fmt.Errorf("unknown menu location %q", location)
+	}
+
+	tree, err := s.pages.PageTree(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		if err := validateMenuItem(item, tree); err != nil {
+			return fmt.Errorf("%w %d: %v", ErrInvalidMenuItem, i+1, err)
+		}
+		item.Location = location
+		item.SortOrder = i
+	}
+
+	if err := s.repo.ReplaceLocation(ctx, location, items); err != nil {
+		return err
+	}
+
+	s.Invalidate()
+	return nil
+}
+
+// Invalidate drops the cached menus.
+func (s *MenuService) Invalidate() {
+	s.mu.Lock()
+	s.cache = make(map[domain.MenuLocation][]domain.MenuLink)
+	s.mu.Unlock()
+}
+
+// TemplateFunc returns a template function rendering a menu by location, as
+// in {{range menu "header"}}. A menu that fails to load renders empty rather
+// than breaking the page.
+func (s *MenuService) TemplateFunc() func(args ...interface{}) (interface{}, error) {
+	return func(args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, errors.New("menu expects a location")
+		}
+
+		location := domain.MenuLocation(fmt.Sprint(args[0]))
+		links, err := s.Links(context.Background(), location)
+		if err != nil {
+			log.Printf("Error loading %s menu: %v", location, err)
+			return []domain.MenuLink{}, nil
+		}
+		return links, nil
+	}
+}
+
+func resolveMenu(items []*domain.MenuItem, tree *domain.PageTree) []domain.MenuLink {
+	links := make([]domain.MenuLink, 0, len(items))
+	for _, item := range items {
+		if item.PageID == nil {
+			links = append(links, domain.MenuLink{Label: item.Label, URL: item.URL})
+			continue
+		}
+
+		node := tree.Node(*item.PageID)
+		if node == nil {
+			continue
+		}
+		label := item.Label
+		if label == "" {
+			label = node.Title
+		}
+		links = append(links, domain.MenuLink{Label: label, URL: node.Path})
+	}
+	return links
+}
+
+func validateMenuItem(item *domain.MenuItem, tree *domain.PageTree) error {
+	item.Label = strings.TrimSpace(item.Label)
+	item.URL = strings.TrimSpace(item.URL)
+
+	if item.PageID != nil {
+		if tree.Node(*item.PageID) == nil {
+			return errors.New("page not found")
+		}
+		item.URL = ""
+		return nil
+	}
+
+	if item.Label == "" {
+		return errors.New("label is required for links")
+	}
+	if !isMenuURL(item.URL) {
+		return errors.New("link must be a site path such as /contact or an http(s) URL")
+	}
+
+	return nil
+}
+
+// isMenuURL accepts site-relative paths and absolute http(s) URLs, rejecting
+// protocol-relative and script URLs.
+func isMenuURL(raw string) bool {
+	if strings.HasPrefix(raw, "/") {
+		return !strings.HasPrefix(raw, "//") && !strings.HasPrefix(raw, `/\`)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
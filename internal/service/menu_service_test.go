@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type MockMenuRepository struct {
+	mock.Mock
+}
+
+func (m *MockMenuRepository) ListByLocation(ctx context.Context, location domain.MenuLocation) ([]*domain.MenuItem, error) {
+	args := m.Called(ctx, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.MenuItem), args.Error(1)
+}
+
+func (m *MockMenuRepository) ReplaceLocation(ctx context.Context, location domain.MenuLocation, items []*domain.MenuItem) error {
+	args := m.Called(ctx, location, items)
+	return args.Error(0)
+}
+
+func TestMenuService_Links(t *testing.T) {
+	pageRepo := new(MockPageRepository)
+	menuRepo := new(MockMenuRepository)
+	pages := NewPageService(pageRepo, nil)
+	service := NewMenuService(menuRepo, pages)
+	ctx := context.Background()
+
+	menuRepo.On("ListByLocation", ctx, domain.MenuHeader).Return([]*domain.MenuItem{
+		{PageID: int64Ptr(2)},
+		{Label: "Contact us", PageID: int64Ptr(4)},
+		{Label: "GitHub", URL: "https://github.com/toutaio"},
+	}, nil).Once()
+	pageRepo.On("ListNodes", ctx).Return(pageNodes(), nil).Once()
+
+	links, err := service.Links(ctx, domain.MenuHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.MenuLink{
+		{Label: "Team", URL: "/about/team"},
+		{Label: "GitHub", URL: "https://github.com/toutaio"},
+	}, links)
+
+	// Served from cache until pages change
+	_, err = service.Links(ctx, domain.MenuHeader)
+	assert.NoError(t, err)
+	menuRepo.AssertNumberOfCalls(t, "ListByLocation", 1)
+
+	pageRepo.On("Delete", ctx, int64(4)).Return(nil)
+	assert.NoError(t, pages.DeletePage(ctx, 4))
+
+	menuRepo.On("ListByLocation", ctx, domain.MenuHeader).Return([]*domain.MenuItem{}, nil).Once()
+	links, err = service.Links(ctx, domain.MenuHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultMenus[domain.MenuHeader], links)
+}
+
+func TestMenuService_SaveMenu(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("valid items", func(t *testing.T) {
+		pageRepo := new(MockPageRepository)
+		menuRepo := new(MockMenuRepository)
+		service := NewMenuService(menuRepo, NewPageService(pageRepo, nil))
+
+		items := []*domain.MenuItem{
+			{PageID: int64Ptr(1), URL: "ignored"},
+			{Label: " Docs ", URL: "/docs"},
+		}
+		pageRepo.On("ListNodes", ctx).Return(pageNodes(), nil)
+		menuRepo.On("ReplaceLocation", ctx, domain.MenuFooter, items).Return(nil)
+
+		assert.NoError(t, service.SaveMenu(ctx, domain.MenuFooter, items))
+		assert.Equal(t, "", items[0].URL)
+		assert.Equal(t, "Docs", items[1].Label)
+		assert.Equal(t, 1, items[1].SortOrder)
+		assert.Equal(t, domain.MenuFooter, items[1].Location)
+	})
+
+	tests := []struct {
+		name     string
+		location domain.MenuLocation
+		item     *domain.MenuItem
+	}{
+		{"unknown location", "sidebar", &domain.MenuItem{Label: "Docs", URL: "/docs"}},
+		{"missing page", domain.MenuHeader, &domain.MenuItem{PageID: int64Ptr(99)}},
+		{"missing label", domain.MenuHeader, &domain.MenuItem{URL: "/docs"}},
+		{"script URL", domain.MenuHeader, &domain.MenuItem{Label: "x", URL: "javascript:alert(1)"}},
+		{"protocol-relative URL", domain.MenuHeader, &domain.MenuItem{Label: "x", URL: "//evil.example"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pageRepo := new(MockPageRepository)
+			menuRepo := new(MockMenuRepository)
+			service := NewMenuService(menuRepo, NewPageService(pageRepo, nil))
+			pageRepo.On("ListNodes", ctx).Return(pageNodes(), nil)
+
+			err := service.SaveMenu(ctx, tt.location, []*domain.MenuItem{tt.item})
+			assert.Error(t, err)
+			if tt.location.IsValid() {
+				assert.ErrorIs(t, err, ErrInvalidMenuItem)
+			}
+			menuRepo.AssertNotCalled(t, "ReplaceLocation", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestMenuService_TemplateFunc(t *testing.T) {
+	menuRepo := new(MockMenuRepository)
+	service := NewMenuService(menuRepo, NewPageService(new(MockPageRepository), nil))
+
+	menuRepo.On("ListByLocation", mock.Anything, domain.MenuFooter).Return([]*domain.MenuItem{}, nil)
+
+	links, err := service.TemplateFunc()("footer")
+	assert.NoError(t, err)
+	assert.Empty(t, links)
+
+	_, err = service.TemplateFunc()()
+	assert.Error(t, err)
+}
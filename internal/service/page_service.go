@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
 )
 
 type PageRepository interface {
@@ -16,14 +20,53 @@ type PageRepository interface {
 	Delete(ctx context.Context, id int64) error
 	List(ctx context.Context, limit, offset int) ([]*domain.Page, error)
 	ListByStatus(ctx context.Context, status domain.PageStatus, limit, offset int) ([]*domain.Page, error)
+	ListPublished(ctx context.Context, req pagination.Request) ([]*domain.Page, error)
+	CountPublished(ctx context.Context) (int, error)
+	ListNodes(ctx context.Context) ([]*domain.PageNode, error)
+	UpdatePositions(ctx context.Context, positions []domain.PagePosition) error
+}
+
+// ErrInvalidParent is returned when a page would be placed under a missing
+// page, itself or one of its own subpages.
+var ErrInvalidParent = errors.New("invalid parent page")
+
+// ErrInvalidPosition is returned when a page tree update refers to an unknown
+// page or moves a page with a reserved slug to the top level.
+var ErrInvalidPosition = errors.New("invalid page position")
+
+// reservedPageSlugs are top-level URL segments owned by application routes.
+// Top-level pages cannot use them or they would never be reachable.
+var reservedPageSlugs = map[string]bool{
+	"admin": true, "authors": true, "categories": true, "dashboard": true,
+	"forgot-password": true, "health": true, "login": true, "logout": true,
+	"pages": true, "posts": true, "profile": true, "register": true,
+	"reset-password": true, "settings": true, "sitemaps": true, "static": true,
+	"tags": true,
 }
 
 type PageService struct {
-	repo PageRepository
+	repo    PageRepository
+	slugs   SlugHistoryRepository
+	tx      Transactor
+	changed listeners
+}
+
+// NewPageService creates a page service. slugs may be nil, in which case
+// renamed pages do not keep redirects from their previous slugs.
+func NewPageService(repo PageRepository, slugs SlugHistoryRepository) *PageService {
+	return &PageService{repo: repo, slugs: slugs, tx: noTransactor{}}
+}
+
+// SetTransactor makes the page and its slug history change together, in a
+// unit of work run by tx.
+func (s *PageService) SetTransactor(tx Transactor) {
+	s.tx = tx
 }
 
-func NewPageService(repo PageRepository) *PageService {
-	return &PageService{repo: repo}
+// OnPublishedChange registers fn to run whenever the set of published pages
+// or one of their URLs or contents changes.
+func (s *PageService) OnPublishedChange(fn func()) {
+	s.changed.add(fn)
 }
 
 func (s *PageService) CreatePage(ctx context.Context, page *domain.Page) error {
@@ -31,17 +74,36 @@ func (s *PageService) CreatePage(ctx context.Context, page *domain.Page) error {
 		return err
 	}
 
-	// Check slug uniqueness
-	existing, err := s.repo.GetBySlug(ctx, page.Slug)
-	if err == nil && existing != nil {
-		return errors.New("slug already exists")
+	if err := s.validateParent(ctx, page); err != nil {
+		return err
 	}
 
+	// De-duplicate the slug (my-page -> my-page-2)
+	slug, err := uniqueSlug(page.Slug, s.slugTaken(ctx, 0, page.ParentID))
+	if err != nil {
+		return err
+	}
+	page.Slug = slug
+
 	if page.Status == "" {
 		page.Status = domain.PageStatusDraft
 	}
+	stampPublishedPage(page)
 
-	return s.repo.Create(ctx, page)
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, page); err != nil {
+			return err
+		}
+		return releaseSlug(ctx, s.slugs, domain.ContentTypePage, page.Slug)
+	})
+	if err != nil {
+		return err
+	}
+
+	if page.Status == domain.PageStatusPublished {
+		s.changed.notify()
+	}
+	return nil
 }
 
 func (s *PageService) GetPageByID(ctx context.Context, id int64) (*domain.Page, error) {
@@ -52,22 +114,77 @@ func (s *PageService) GetPageBySlug(ctx context.Context, slug string) (*domain.P
 	return s.repo.GetBySlug(ctx, slug)
 }
 
+// GetPageByPreviousSlug returns the page that used to be published under slug.
+func (s *PageService) GetPageByPreviousSlug(ctx context.Context, slug string) (*domain.Page, error) {
+	id, err := previousSlugID(ctx, s.slugs, domain.ContentTypePage, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
 func (s *PageService) UpdatePage(ctx context.Context, page *domain.Page) error {
 	if err := s.validatePage(page); err != nil {
 		return err
 	}
 
-	// Check slug uniqueness (excluding current page)
-	existing, err := s.repo.GetBySlug(ctx, page.Slug)
-	if err == nil && existing != nil && existing.ID != page.ID {
-		return errors.New("slug already exists")
+	var current *domain.Page
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		current, err = s.repo.GetByID(ctx, page.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(page.Version, current.Version); err != nil {
+			return err
+		}
+
+		if err := s.validateParent(ctx, page); err != nil {
+			return err
+		}
+
+		// De-duplicate the slug (excluding current page)
+		slug, err := uniqueSlug(page.Slug, s.slugTaken(ctx, page.ID, page.ParentID))
+		if err != nil {
+			return err
+		}
+		page.Slug = slug
+		stampPublishedPage(page)
+
+		if err := s.repo.Update(ctx, page); err != nil {
+			return updateConflict(err)
+		}
+
+		// Keep the old URL working by redirecting it to the new slug
+		return moveSlug(ctx, s.slugs, domain.ContentTypePage, page.ID, current.Slug, page.Slug)
+	})
+	if err != nil {
+		return err
 	}
 
-	return s.repo.Update(ctx, page)
+	if current.Status == domain.PageStatusPublished || page.Status == domain.PageStatusPublished {
+		s.changed.notify()
+	}
+	return nil
 }
 
 func (s *PageService) DeletePage(ctx context.Context, id int64) error {
-	return s.repo.Delete(ctx, id)
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if s.slugs == nil {
+			return nil
+		}
+		return s.slugs.DeleteByContent(ctx, domain.ContentTypePage, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.changed.notify()
+	return nil
 }
 
 func (s *PageService) ListPages(ctx context.Context, limit, offset int) ([]*domain.Page, error) {
@@ -92,29 +209,240 @@ func (s *PageService) ListPublishedPages(ctx context.Context, limit, offset int)
 	return s.repo.ListByStatus(ctx, domain.PageStatusPublished, limit, offset)
 }
 
-func (s *PageService) PublishPage(ctx context.Context, id int64) error {
-	page, err := s.repo.GetByID(ctx, id)
+// PaginatePublishedPages returns the page req asks for of the published
+// pages, newest first, and how many there are in all.
+func (s *PageService) PaginatePublishedPages(ctx context.Context, req pagination.Request) (*pagination.Result[*domain.Page], error) {
+	pages, err := s.repo.ListPublished(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return paginate(pages, req, func(page *domain.Page) pagination.Cursor {
+		return publishedCursor(page.ID, page.PublishedAt)
+	}, func() (int, error) {
+		return s.repo.CountPublished(ctx)
+	})
+}
+
+// ListPublishedRefs returns the nested path and update time of every page
+// that is reachable on the public site.
+func (s *PageService) ListPublishedRefs(ctx context.Context) ([]*domain.ContentRef, error) {
+	tree, err := s.PublishedPageTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []*domain.ContentRef
+	for _, n := range tree.Flatten() {
+		refs = append(refs, &domain.ContentRef{ID: n.ID, Slug: n.Slug, Path: n.Path, UpdatedAt: n.UpdatedAt})
+	}
+	return refs, nil
+}
+
+// PageTree returns every page arranged by parent and sort order.
+func (s *PageService) PageTree(ctx context.Context) (*domain.PageTree, error) {
+	nodes, err := s.repo.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewPageTree(nodes), nil
+}
+
+// PublishedPageTree returns the published pages whose ancestors are all
+// published too, i.e. the pages visitors can navigate to.
+func (s *PageService) PublishedPageTree(ctx context.Context) (*domain.PageTree, error) {
+	tree, err := s.PageTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Filter(func(n *domain.PageNode) bool {
+		return n.Status == domain.PageStatusPublished
+	}), nil
+}
+
+// ResolvePath finds the page for a nested URL such as /about/team. The page is
+// looked up by the last segment, falling back to previous slugs of renamed
+// pages; callers should redirect when the returned node's Path differs from
+// the requested path.
+func (s *PageService) ResolvePath(ctx context.Context, path string) (*domain.Page, *domain.PageNode, error) {
+	tree, err := s.PageTree(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	slug := segments[len(segments)-1]
+
+	node := tree.NodeBySlug(slug)
+	if node == nil {
+		id, err := previousSlugID(ctx, s.slugs, domain.ContentTypePage, slug)
+		if err != nil {
+			return nil, nil, err
+		}
+		if node = tree.Node(id); node == nil {
+			return nil, nil, sql.ErrNoRows
+		}
+	}
+
+	page, err := s.repo.GetByID(ctx, node.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return page, node, nil
+}
+
+// MovePages applies new parents and sort orders, e.g. from the drag-and-drop
+// page tree. The whole move is rejected if it would create a cycle.
+func (s *PageService) MovePages(ctx context.Context, positions []domain.PagePosition) error {
+	// The tree is checked and changed in one unit of work, so a page moved
+	// in between can't slip a cycle past the check
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		return s.movePages(ctx, positions)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.changed.notify()
+	return nil
+}
+
+// movePages checks positions against the current tree and applies them
+func (s *PageService) movePages(ctx context.Context, positions []domain.PagePosition) error {
+	nodes, err := s.repo.ListNodes(ctx)
 	if err != nil {
 		return err
 	}
 
-	now := time.Now()
-	page.Status = domain.PageStatusPublished
-	page.PublishedAt = &now
+	byID := make(map[int64]*domain.PageNode, len(nodes))
+	for _, n := range nodes {
+		byID[n.ID] = n
+	}
+
+	for _, p := range positions {
+		n, ok := byID[p.ID]
+		if !ok {
+			return fmt.Errorf("%w: page %d not found", ErrInvalidPosition, p.ID)
+		}
+		if p.ParentID != nil {
+			if _, ok := byID[*p.ParentID]; !ok {
+				return ErrInvalidParent
+			}
+		} else if reservedPageSlugs[n.Slug] {
+			return fmt.Errorf("%w: slug %q is reserved and cannot be used by a top-level page", ErrInvalidPosition, n.Slug)
+		}
+		n.ParentID = p.ParentID
+		n.SortOrder = p.SortOrder
+	}
+
+	if hasParentCycle(byID) {
+		return ErrInvalidParent
+	}
+
+	return s.repo.UpdatePositions(ctx, positions)
+}
+
+// PublishPage publishes a page now. The page is saved at the version read,
+// so one saved by someone else in between makes it fail with ErrConflict.
+func (s *PageService) PublishPage(ctx context.Context, id int64) error {
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		page, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		page.Status = domain.PageStatusPublished
+		page.PublishedAt = &now
 
-	return s.repo.Update(ctx, page)
+		return updateConflict(s.repo.Update(ctx, page))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.changed.notify()
+	return nil
 }
 
+// UnpublishPage turns a page back into a draft, failing with ErrConflict
+// like PublishPage.
 func (s *PageService) UnpublishPage(ctx context.Context, id int64) error {
-	page, err := s.repo.GetByID(ctx, id)
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		page, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		page.Status = domain.PageStatusDraft
+		page.PublishedAt = nil
+
+		return updateConflict(s.repo.Update(ctx, page))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.changed.notify()
+	return nil
+}
+
+// slugTaken reports whether a slug is used by a page other than excludeID,
+// or is reserved for application routes when the page is at the top level.
+func (s *PageService) slugTaken(ctx context.Context, excludeID int64, parentID *int64) func(string) (bool, error) {
+	return func(slug string) (bool, error) {
+		if parentID == nil && reservedPageSlugs[slug] {
+			return true, nil
+		}
+		existing, err := s.repo.GetBySlug(ctx, slug)
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return existing != nil && existing.ID != excludeID, nil
+	}
+}
+
+// validateParent makes sure the page's parent exists and is not the page
+// itself or one of its subpages.
+func (s *PageService) validateParent(ctx context.Context, page *domain.Page) error {
+	if page.ParentID == nil {
+		return nil
+	}
+
+	tree, err := s.PageTree(ctx)
 	if err != nil {
 		return err
 	}
 
-	page.Status = domain.PageStatusDraft
-	page.PublishedAt = nil
+	parentID := *page.ParentID
+	if tree.Node(parentID) == nil || parentID == page.ID || tree.IsDescendant(parentID, page.ID) {
+		return ErrInvalidParent
+	}
+
+	return nil
+}
 
-	return s.repo.Update(ctx, page)
+// hasParentCycle reports whether following parent links from any page leads
+// back to it.
+func hasParentCycle(nodes map[int64]*domain.PageNode) bool {
+	for _, n := range nodes {
+		steps := 0
+		for p := n.ParentID; p != nil; {
+			if *p == n.ID || steps > len(nodes) {
+				return true
+			}
+			steps++
+			parent, ok := nodes[*p]
+			if !ok {
+				break
+			}
+			p = parent.ParentID
+		}
+	}
+	return false
 }
 
 func (s *PageService) validatePage(page *domain.Page) error {
@@ -130,3 +458,12 @@ func (s *PageService) validatePage(page *domain.Page) error {
 
 	return nil
 }
+
+// stampPublishedPage dates a page published without a publication time, as
+// listings are ordered by it
+func stampPublishedPage(page *domain.Page) {
+	if page.Status == domain.PageStatusPublished && page.PublishedAt == nil {
+		now := time.Now()
+		page.PublishedAt = &now
+	}
+}
@@ -3,12 +3,15 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
 )
 
 type MockPageRepository struct {
@@ -62,9 +65,35 @@ func (m *MockPageRepository) ListByStatus(ctx context.Context, status domain.Pag
 	return args.Get(0).([]*domain.Page), args.Error(1)
 }
 
+func (m *MockPageRepository) ListPublished(ctx context.Context, req pagination.Request) ([]*domain.Page, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Page), args.Error(1)
+}
+
+func (m *MockPageRepository) CountPublished(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPageRepository) ListNodes(ctx context.Context) ([]*domain.PageNode, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PageNode), args.Error(1)
+}
+
+func (m *MockPageRepository) UpdatePositions(ctx context.Context, positions []domain.PagePosition) error {
+	args := m.Called(ctx, positions)
+	return args.Error(0)
+}
+
 func TestPageService_CreatePage(t *testing.T) {
 	repo := new(MockPageRepository)
-	service := NewPageService(repo)
+	service := NewPageService(repo, nil)
 	ctx := context.Background()
 
 	page := &domain.Page{
@@ -74,16 +103,42 @@ func TestPageService_CreatePage(t *testing.T) {
 		Status:  domain.PageStatusDraft,
 	}
 
+	repo.On("GetBySlug", ctx, "test-page").Return(nil, sql.ErrNoRows)
+	repo.On("Create", ctx, page).Return(nil)
+
+	err := service.CreatePage(ctx, page)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestPageService_CreatePage_DuplicateSlug(t *testing.T) {
+	repo := new(MockPageRepository)
+	slugs := new(MockSlugHistoryRepository)
+	service := NewPageService(repo, slugs)
+	ctx := context.Background()
+
+	page := &domain.Page{
+		Title:   "Test Page",
+		Slug:    "test-page",
+		Content: "Content",
+	}
+
+	repo.On("GetBySlug", ctx, "test-page").Return(&domain.Page{ID: 1, Slug: "test-page"}, nil)
+	repo.On("GetBySlug", ctx, "test-page-2").Return(&domain.Page{ID: 2, Slug: "test-page-2"}, nil)
+	repo.On("GetBySlug", ctx, "test-page-3").Return(nil, sql.ErrNoRows)
 	repo.On("Create", ctx, page).Return(nil)
+	slugs.On("Release", ctx, domain.ContentTypePage, "test-page-3").Return(nil)
 
 	err := service.CreatePage(ctx, page)
 	assert.NoError(t, err)
+	assert.Equal(t, "test-page-3", page.Slug)
 	repo.AssertExpectations(t)
+	slugs.AssertExpectations(t)
 }
 
 func TestPageService_CreatePage_ValidationError(t *testing.T) {
 	repo := new(MockPageRepository)
-	service := NewPageService(repo)
+	service := NewPageService(repo, nil)
 	ctx := context.Background()
 
 	tests := []struct {
@@ -126,7 +181,7 @@ func TestPageService_CreatePage_ValidationError(t *testing.T) {
 
 func TestPageService_GetPageByID(t *testing.T) {
 	repo := new(MockPageRepository)
-	service := NewPageService(repo)
+	service := NewPageService(repo, nil)
 	ctx := context.Background()
 
 	expectedPage := &domain.Page{
@@ -146,7 +201,7 @@ func TestPageService_GetPageByID(t *testing.T) {
 
 func TestPageService_GetPageByID_NotFound(t *testing.T) {
 	repo := new(MockPageRepository)
-	service := NewPageService(repo)
+	service := NewPageService(repo, nil)
 	ctx := context.Background()
 
 	repo.On("GetByID", ctx, int64(999)).Return(nil, sql.ErrNoRows)
@@ -159,7 +214,7 @@ func TestPageService_GetPageByID_NotFound(t *testing.T) {
 
 func TestPageService_GetPageBySlug(t *testing.T) {
 	repo := new(MockPageRepository)
-	service := NewPageService(repo)
+	service := NewPageService(repo, nil)
 	ctx := context.Background()
 
 	expectedPage := &domain.Page{
@@ -179,7 +234,7 @@ func TestPageService_GetPageBySlug(t *testing.T) {
 
 func TestPageService_UpdatePage(t *testing.T) {
 	repo := new(MockPageRepository)
-	service := NewPageService(repo)
+	service := NewPageService(repo, nil)
 	ctx := context.Background()
 
 	page := &domain.Page{
@@ -190,16 +245,141 @@ func TestPageService_UpdatePage(t *testing.T) {
 		Status:  domain.PageStatusPublished,
 	}
 
+	repo.On("GetByID", ctx, int64(1)).Return(&domain.Page{ID: 1, Slug: "updated-page"}, nil)
+	repo.On("GetBySlug", ctx, "updated-page").Return(&domain.Page{ID: 1, Slug: "updated-page"}, nil)
 	repo.On("Update", ctx, page).Return(nil)
 
 	err := service.UpdatePage(ctx, page)
 	assert.NoError(t, err)
+	assert.Equal(t, "updated-page", page.Slug)
 	repo.AssertExpectations(t)
 }
 
+func TestPageService_UpdatePage_Conflict(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("saved since it was loaded", func(t *testing.T) {
+		repo := new(MockPageRepository)
+		service := NewPageService(repo, nil)
+		page := &domain.Page{ID: 1, Title: "Mine", Slug: "mine", Content: "Mine", Status: domain.PageStatusDraft, Version: 2}
+
+		repo.On("GetByID", ctx, int64(1)).Return(&domain.Page{ID: 1, Slug: "mine", Version: 3}, nil)
+
+		err := service.UpdatePage(ctx, page)
+		assert.ErrorIs(t, err, ErrConflict)
+		repo.AssertNotCalled(t, "Update", ctx, page)
+	})
+
+	t.Run("saved while updating", func(t *testing.T) {
+		repo := new(MockPageRepository)
+		service := NewPageService(repo, nil)
+		page := &domain.Page{ID: 1, Title: "Mine", Slug: "mine", Content: "Mine", Status: domain.PageStatusDraft, Version: 2}
+
+		repo.On("GetByID", ctx, int64(1)).Return(&domain.Page{ID: 1, Slug: "mine", Version: 2}, nil)
+		repo.On("GetBySlug", ctx, "mine").Return(&domain.Page{ID: 1, Slug: "mine"}, nil)
+		repo.On("Update", ctx, page).Return(sql.ErrNoRows)
+
+		err := service.UpdatePage(ctx, page)
+		assert.ErrorIs(t, err, ErrConflict)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestPageService_UpdatePage_SlugChangeRecordsHistory(t *testing.T) {
+	repo := new(MockPageRepository)
+	slugs := new(MockSlugHistoryRepository)
+	service := NewPageService(repo, slugs)
+	ctx := context.Background()
+
+	page := &domain.Page{
+		ID:      1,
+		Title:   "Renamed",
+		Slug:    "renamed",
+		Content: "Content",
+	}
+
+	repo.On("GetByID", ctx, int64(1)).Return(&domain.Page{ID: 1, Slug: "original"}, nil)
+	repo.On("GetBySlug", ctx, "renamed").Return(nil, sql.ErrNoRows)
+	repo.On("Update", ctx, page).Return(nil)
+	slugs.On("Release", ctx, domain.ContentTypePage, "renamed").Return(nil)
+	slugs.On("Record", ctx, domain.ContentTypePage, int64(1), "original").Return(nil)
+
+	err := service.UpdatePage(ctx, page)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	slugs.AssertExpectations(t)
+}
+
+func TestPageService_UpdatePage_SlugHistoryFailure(t *testing.T) {
+	repo := new(MockPageRepository)
+	slugs := new(MockSlugHistoryRepository)
+	service := NewPageService(repo, slugs)
+	tx := &recordingTransactor{}
+	service.SetTransactor(tx)
+	ctx := context.Background()
+
+	calls := 0
+	service.OnPublishedChange(func() { calls++ })
+
+	page := &domain.Page{ID: 1, Title: "Renamed", Slug: "renamed", Content: "Content", Status: domain.PageStatusPublished}
+	repo.On("GetByID", ctx, int64(1)).Return(&domain.Page{ID: 1, Slug: "original", Status: domain.PageStatusPublished}, nil)
+	repo.On("GetBySlug", ctx, "renamed").Return(nil, sql.ErrNoRows)
+	repo.On("Update", ctx, page).Return(nil)
+	slugs.On("Release", ctx, domain.ContentTypePage, "renamed").Return(nil)
+	slugs.On("Record", ctx, domain.ContentTypePage, int64(1), "original").Return(errors.New("disk full"))
+
+	// The update and the redirect are one unit of work, which fails as a
+	// whole, so caches of published content are left alone
+	err := service.UpdatePage(ctx, page)
+	assert.EqualError(t, err, "disk full")
+	assert.Equal(t, 1, tx.units)
+	assert.Len(t, tx.failed, 1)
+	assert.Equal(t, 0, calls)
+}
+
+func TestPageService_CreatePage_SlugHistoryFailure(t *testing.T) {
+	repo := new(MockPageRepository)
+	slugs := new(MockSlugHistoryRepository)
+	service := NewPageService(repo, slugs)
+	tx := &recordingTransactor{}
+	service.SetTransactor(tx)
+	ctx := context.Background()
+
+	calls := 0
+	service.OnPublishedChange(func() { calls++ })
+
+	page := &domain.Page{Title: "About", Slug: "about-us", Content: "Content", Status: domain.PageStatusPublished}
+	repo.On("GetBySlug", ctx, "about-us").Return(nil, sql.ErrNoRows)
+	repo.On("Create", ctx, page).Return(nil)
+	slugs.On("Release", ctx, domain.ContentTypePage, "about-us").Return(errors.New("disk full"))
+
+	err := service.CreatePage(ctx, page)
+	assert.EqualError(t, err, "disk full")
+	assert.Equal(t, 1, tx.units)
+	assert.Equal(t, 0, calls)
+}
+
+func TestPageService_GetPageByPreviousSlug(t *testing.T) {
+	repo := new(MockPageRepository)
+	slugs := new(MockSlugHistoryRepository)
+	service := NewPageService(repo, slugs)
+	ctx := context.Background()
+
+	expected := &domain.Page{ID: 7, Slug: "current"}
+	slugs.On("FindContentID", ctx, domain.ContentTypePage, "old").Return(int64(7), nil)
+	repo.On("GetByID", ctx, int64(7)).Return(expected, nil)
+
+	page, err := service.GetPageByPreviousSlug(ctx, "old")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, page)
+
+	_, err = NewPageService(repo, nil).GetPageByPreviousSlug(ctx, "old")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 func TestPageService_DeletePage(t *testing.T) {
 	repo := new(MockPageRepository)
-	service := NewPageService(repo)
+	service := NewPageService(repo, nil)
 	ctx := context.Background()
 
 	repo.On("Delete", ctx, int64(1)).Return(nil)
@@ -211,7 +391,7 @@ func TestPageService_DeletePage(t *testing.T) {
 
 func TestPageService_ListPages(t *testing.T) {
 	repo := new(MockPageRepository)
-	service := NewPageService(repo)
+	service := NewPageService(repo, nil)
 	ctx := context.Background()
 
 	expectedPages := []*domain.Page{
@@ -229,7 +409,7 @@ func TestPageService_ListPages(t *testing.T) {
 
 func TestPageService_ListPublishedPages(t *testing.T) {
 	repo := new(MockPageRepository)
-	service := NewPageService(repo)
+	service := NewPageService(repo, nil)
 	ctx := context.Background()
 
 	expectedPages := []*domain.Page{
@@ -244,9 +424,28 @@ func TestPageService_ListPublishedPages(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
+func TestPageService_PaginatePublishedPages(t *testing.T) {
+	repo := new(MockPageRepository)
+	service := NewPageService(repo, nil)
+	ctx := context.Background()
+	published := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	req := pagination.Request{Limit: 1}
+	repo.On("ListPublished", ctx, req).Return([]*domain.Page{{ID: 3, PublishedAt: &published}, {ID: 2, PublishedAt: &published}}, nil)
+	repo.On("CountPublished", ctx).Return(3, nil)
+
+	result, err := service.PaginatePublishedPages(ctx, req)
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+	assert.Equal(t, &pagination.Cursor{Time: published, ID: 3}, result.Next)
+	assert.Nil(t, result.Prev)
+	assert.Equal(t, 3, result.Total)
+	repo.AssertExpectations(t)
+}
+
 func TestPageService_PublishPage(t *testing.T) {
 	repo := new(MockPageRepository)
-	service := NewPageService(repo)
+	service := NewPageService(repo, nil)
 	ctx := context.Background()
 	now := time.Now()
 
@@ -273,7 +472,7 @@ func TestPageService_PublishPage(t *testing.T) {
 
 func TestPageService_UnpublishPage(t *testing.T) {
 	repo := new(MockPageRepository)
-	service := NewPageService(repo)
+	service := NewPageService(repo, nil)
 	ctx := context.Background()
 
 	publishedAt := time.Now()
@@ -297,3 +496,178 @@ func TestPageService_UnpublishPage(t *testing.T) {
 	assert.Nil(t, page.PublishedAt)
 	repo.AssertExpectations(t)
 }
+
+func TestPageService_OnPublishedChange(t *testing.T) {
+	repo := new(MockPageRepository)
+	service := NewPageService(repo, nil)
+	ctx := context.Background()
+
+	calls := 0
+	service.OnPublishedChange(func() { calls++ })
+
+	page := &domain.Page{ID: 1, Title: "About", Slug: "about", Content: "Content", Status: domain.PageStatusDraft}
+	repo.On("GetByID", ctx, int64(1)).Return(page, nil)
+	repo.On("Update", ctx, mock.Anything).Return(nil)
+	repo.On("Delete", ctx, int64(1)).Return(nil)
+
+	err := service.PublishPage(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	err = service.DeletePage(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func int64Ptr(v int64) *int64 { return &v }
+
+// pageNodes returns a fresh /about, /about/team, /about/team/leadership and
+// /contact hierarchy on each call, since building a tree links the nodes.
+func pageNodes() []*domain.PageNode {
+	return []*domain.PageNode{
+		{ID: 1, Title: "About", Slug: "about", Status: domain.PageStatusPublished},
+		{ID: 2, Title: "Team", Slug: "team", ParentID: int64Ptr(1), Status: domain.PageStatusPublished},
+		{ID: 3, Title: "Leadership", Slug: "leadership", ParentID: int64Ptr(2), Status: domain.PageStatusPublished},
+		{ID: 4, Title: "Contact", Slug: "contact", Status: domain.PageStatusDraft},
+	}
+}
+
+func TestPageService_ListPublishedRefs(t *testing.T) {
+	repo := new(MockPageRepository)
+	service := NewPageService(repo, nil)
+	ctx := context.Background()
+
+	repo.On("ListNodes", ctx).Return(pageNodes(), nil)
+
+	refs, err := service.ListPublishedRefs(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, refs, 3)
+	assert.Equal(t, "/about/team/leadership", refs[2].Path)
+}
+
+func TestPageService_ResolvePath(t *testing.T) {
+	ctx := context.Background()
+	leadership := &domain.Page{ID: 3, Title: "Leadership", Slug: "leadership"}
+
+	t.Run("nested path", func(t *testing.T) {
+		repo := new(MockPageRepository)
+		service := NewPageService(repo, nil)
+		repo.On("ListNodes", ctx).Return(pageNodes(), nil)
+		repo.On("GetByID", ctx, int64(3)).Return(leadership, nil)
+
+		page, node, err := service.ResolvePath(ctx, "/about/team/leadership")
+		assert.NoError(t, err)
+		assert.Equal(t, leadership, page)
+		assert.Equal(t, "/about/team/leadership", node.Path)
+		assert.Len(t, node.Breadcrumbs(), 3)
+	})
+
+	t.Run("previous slug", func(t *testing.T) {
+		repo := new(MockPageRepository)
+		slugs := new(MockSlugHistoryRepository)
+		service := NewPageService(repo, slugs)
+		repo.On("ListNodes", ctx).Return(pageNodes(), nil)
+		slugs.On("FindContentID", ctx, domain.ContentTypePage, "management").Return(int64(3), nil)
+		repo.On("GetByID", ctx, int64(3)).Return(leadership, nil)
+
+		_, node, err := service.ResolvePath(ctx, "/about/team/management")
+		assert.NoError(t, err)
+		assert.Equal(t, "/about/team/leadership", node.Path)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		repo := new(MockPageRepository)
+		service := NewPageService(repo, nil)
+		repo.On("ListNodes", ctx).Return(pageNodes(), nil)
+
+		_, _, err := service.ResolvePath(ctx, "/missing")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestPageService_PublishPage_Conflict(t *testing.T) {
+	repo := new(MockPageRepository)
+	service := NewPageService(repo, nil)
+	tx := &recordingTransactor{}
+	service.SetTransactor(tx)
+	ctx := context.Background()
+
+	calls := 0
+	service.OnPublishedChange(func() { calls++ })
+
+	// Saved by someone else after it was read, so the versioned update
+	// matches no row
+	repo.On("GetByID", ctx, int64(1)).Return(&domain.Page{ID: 1, Status: domain.PageStatusDraft, Version: 2}, nil)
+	repo.On("Update", ctx, mock.Anything).Return(sql.ErrNoRows)
+
+	err := service.PublishPage(ctx, 1)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 1, tx.units)
+	assert.Equal(t, 0, calls)
+}
+
+func TestPageService_MovePages(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("valid move", func(t *testing.T) {
+		repo := new(MockPageRepository)
+		service := NewPageService(repo, nil)
+		calls := 0
+		service.OnPublishedChange(func() { calls++ })
+
+		positions := []domain.PagePosition{{ID: 3, SortOrder: 1}, {ID: 4, ParentID: int64Ptr(1)}}
+		repo.On("ListNodes", ctx).Return(pageNodes(), nil)
+		repo.On("UpdatePositions", ctx, positions).Return(nil)
+
+		assert.NoError(t, service.MovePages(ctx, positions))
+		assert.Equal(t, 1, calls)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		repo := new(MockPageRepository)
+		service := NewPageService(repo, nil)
+		repo.On("ListNodes", ctx).Return(pageNodes(), nil)
+
+		err := service.MovePages(ctx, []domain.PagePosition{{ID: 1, ParentID: int64Ptr(3)}})
+		assert.ErrorIs(t, err, ErrInvalidParent)
+		repo.AssertNotCalled(t, "UpdatePositions", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown parent", func(t *testing.T) {
+		repo := new(MockPageRepository)
+		service := NewPageService(repo, nil)
+		repo.On("ListNodes", ctx).Return(pageNodes(), nil)
+
+		err := service.MovePages(ctx, []domain.PagePosition{{ID: 4, ParentID: int64Ptr(99)}})
+		assert.ErrorIs(t, err, ErrInvalidParent)
+	})
+}
+
+func TestPageService_UpdatePage_RejectsDescendantParent(t *testing.T) {
+	repo := new(MockPageRepository)
+	service := NewPageService(repo, nil)
+	ctx := context.Background()
+
+	page := &domain.Page{ID: 1, Title: "About", Slug: "about", Content: "Content", ParentID: int64Ptr(3)}
+	repo.On("GetByID", ctx, int64(1)).Return(&domain.Page{ID: 1, Slug: "about"}, nil)
+	repo.On("ListNodes", ctx).Return(pageNodes(), nil)
+
+	err := service.UpdatePage(ctx, page)
+	assert.ErrorIs(t, err, ErrInvalidParent)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestPageService_CreatePage_ReservedTopLevelSlug(t *testing.T) {
+	repo := new(MockPageRepository)
+	service := NewPageService(repo, nil)
+	ctx := context.Background()
+
+	page := &domain.Page{Title: "Posts", Slug: "posts", Content: "Content"}
+	repo.On("GetBySlug", ctx, "posts-2").Return(nil, sql.ErrNoRows)
+	repo.On("Create", ctx, page).Return(nil)
+
+	err := service.CreatePage(ctx, page)
+	assert.NoError(t, err)
+	assert.Equal(t, "posts-2", page.Slug)
+}
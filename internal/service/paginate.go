@@ -0,0 +1,34 @@
+package service
+
+import (
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
+)
+
+// paginate makes the page req asked for from rows fetched for it, with the
+// number of rows in the whole listing from count. A listing that fits on its
+// first page needs no counting.
+func paginate[T any](rows []T, req pagination.Request, key func(T) pagination.Cursor, count func() (int, error)) (*pagination.Result[T], error) {
+	result := pagination.Paginate(rows, req, key)
+	if req.After == nil && req.Before == nil && result.Next == nil {
+		result.Total = len(result.Items)
+		return result, nil
+	}
+
+	total, err := count()
+	if err != nil {
+		return nil, err
+	}
+	result.Total = total
+	return result, nil
+}
+
+// publishedCursor returns the position of published content in listings
+func publishedCursor(id int64, publishedAt *time.Time) pagination.Cursor {
+	c := pagination.Cursor{ID: id}
+	if publishedAt != nil {
+		c.Time = *publishedAt
+	}
+	return c
+}
@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
 	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
 )
 
 type PostRepository interface {
@@ -16,15 +18,39 @@ type PostRepository interface {
 	Delete(ctx context.Context, id int64) error
 	List(ctx context.Context, limit, offset int) ([]*domain.Post, error)
 	ListByStatus(ctx context.Context, status domain.PostStatus, limit, offset int) ([]*domain.Post, error)
+	ListRefsByStatus(ctx context.Context, status domain.PostStatus) ([]*domain.ContentRef, error)
+	ListFeatured(ctx context.Context, limit int) ([]*domain.Post, error)
 	ListByAuthor(ctx context.Context, authorID int64, limit, offset int) ([]*domain.Post, error)
+	ListByStatusAndAuthor(ctx context.Context, status domain.PostStatus, authorID int64, limit, offset int) ([]*domain.Post, error)
+	ListByStatusAndCategory(ctx context.Context, status domain.PostStatus, categoryID int64, limit, offset int) ([]*domain.Post, error)
+	ListByStatusAndTag(ctx context.Context, status domain.PostStatus, tagID int64, limit, offset int) ([]*domain.Post, error)
+	ListPublished(ctx context.Context, filter domain.PostFilter, req pagination.Request) ([]*domain.Post, error)
+	CountPublished(ctx context.Context, filter domain.PostFilter) (int, error)
 }
 
 type PostService struct {
-	repo PostRepository
+	repo    PostRepository
+	slugs   SlugHistoryRepository
+	tx      Transactor
+	changed listeners
 }
 
-func NewPostService(repo PostRepository) *PostService {
-	return &PostService{repo: repo}
+// NewPostService creates a post service. slugs may be nil, in which case
+// renamed posts do not keep redirects from their previous slugs.
+func NewPostService(repo PostRepository, slugs SlugHistoryRepository) *PostService {
+	return &PostService{repo: repo, slugs: slugs, tx: noTransactor{}}
+}
+
+// SetTransactor makes the post and its slug history change together, in a
+// unit of work run by tx.
+func (s *PostService) SetTransactor(tx Transactor) {
+	s.tx = tx
+}
+
+// OnPublishedChange registers fn to run whenever the set of published posts
+// or one of their URLs or contents changes.
+func (s *PostService) OnPublishedChange(fn func()) {
+	s.changed.add(fn)
 }
 
 func (s *PostService) CreatePost(ctx context.Context, post *domain.Post) error {
@@ -32,17 +58,32 @@ func (s *PostService) CreatePost(ctx context.Context, post *domain.Post) error {
 		return err
 	}
 
-	// Check slug uniqueness
-	existing, err := s.repo.GetBySlug(ctx, post.Slug)
-	if err == nil && existing != nil {
-		return errors.New("slug already exists")
+	// De-duplicate the slug (my-post -> my-post-2)
+	slug, err := uniqueSlug(post.Slug, s.slugTaken(ctx, 0))
+	if err != nil {
+		return err
 	}
+	post.Slug = slug
 
 	if post.Status == "" {
 		post.Status = domain.PostStatusDraft
 	}
+	stampPublished(post)
 
-	return s.repo.Create(ctx, post)
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, post); err != nil {
+			return err
+		}
+		return releaseSlug(ctx, s.slugs, domain.ContentTypePost, post.Slug)
+	})
+	if err != nil {
+		return err
+	}
+
+	if post.Status == domain.PostStatusPublished {
+		s.changed.notify()
+	}
+	return nil
 }
 
 func (s *PostService) GetPostByID(ctx context.Context, id int64) (*domain.Post, error) {
@@ -53,22 +94,73 @@ func (s *PostService) GetPostBySlug(ctx context.Context, slug string) (*domain.P
 	return s.repo.GetBySlug(ctx, slug)
 }
 
+// GetPostByPreviousSlug returns the post that used to be published under slug.
+func (s *PostService) GetPostByPreviousSlug(ctx context.Context, slug string) (*domain.Post, error) {
+	id, err := previousSlugID(ctx, s.slugs, domain.ContentTypePost, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
 func (s *PostService) UpdatePost(ctx context.Context, post *domain.Post) error {
 	if err := s.validatePost(post); err != nil {
 		return err
 	}
 
-	// Check slug uniqueness (excluding current post)
-	existing, err := s.repo.GetBySlug(ctx, post.Slug)
-	if err == nil && existing != nil && existing.ID != post.ID {
-		return errors.New("slug already exists")
+	var current *domain.Post
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		current, err = s.repo.GetByID(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(post.Version, current.Version); err != nil {
+			return err
+		}
+
+		// De-duplicate the slug (excluding current post)
+		slug, err := uniqueSlug(post.Slug, s.slugTaken(ctx, post.ID))
+		if err != nil {
+			return err
+		}
+		post.Slug = slug
+		stampPublished(post)
+
+		if err := s.repo.Update(ctx, post); err != nil {
+			return updateConflict(err)
+		}
+
+		// Keep the old URL working by redirecting it to the new slug
+		return moveSlug(ctx, s.slugs, domain.ContentTypePost, post.ID, current.Slug, post.Slug)
+	})
+	if err != nil {
+		return err
 	}
 
-	return s.repo.Update(ctx, post)
+	if current.Status == domain.PostStatusPublished || post.Status == domain.PostStatusPublished {
+		s.changed.notify()
+	}
+	return nil
 }
 
 func (s *PostService) DeletePost(ctx context.Context, id int64) error {
-	return s.repo.Delete(ctx, id)
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if s.slugs == nil {
+			return nil
+		}
+		return s.slugs.DeleteByContent(ctx, domain.ContentTypePost, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.changed.notify()
+	return nil
 }
 
 func (s *PostService) ListPosts(ctx context.Context, limit, offset int) ([]*domain.Post, error) {
@@ -104,29 +196,125 @@ func (s *PostService) ListPostsByAuthor(ctx context.Context, authorID int64, lim
 	return s.repo.ListByAuthor(ctx, authorID, limit, offset)
 }
 
+func (s *PostService) ListPublishedPostsByAuthor(ctx context.Context, authorID int64, limit, offset int) ([]*domain.Post, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.repo.ListByStatusAndAuthor(ctx, domain.PostStatusPublished, authorID, limit, offset)
+}
+
+func (s *PostService) ListPublishedPostsByCategory(ctx context.Context, categoryID int64, limit, offset int) ([]*domain.Post, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.repo.ListByStatusAndCategory(ctx, domain.PostStatusPublished, categoryID, limit, offset)
+}
+
+func (s *PostService) ListPublishedPostsByTag(ctx context.Context, tagID int64, limit, offset int) ([]*domain.Post, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.repo.ListByStatusAndTag(ctx, domain.PostStatusPublished, tagID, limit, offset)
+}
+
+// PaginatePublishedPosts returns the page req asks for of the published
+// posts matching filter, newest first, and how many there are in all.
+func (s *PostService) PaginatePublishedPosts(ctx context.Context, filter domain.PostFilter, req pagination.Request) (*pagination.Result[*domain.Post], error) {
+	posts, err := s.repo.ListPublished(ctx, filter, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return paginate(posts, req, func(post *domain.Post) pagination.Cursor {
+		return publishedCursor(post.ID, post.PublishedAt)
+	}, func() (int, error) {
+		return s.repo.CountPublished(ctx, filter)
+	})
+}
+
+// ListFeaturedPosts returns the latest published posts marked as featured.
+func (s *PostService) ListFeaturedPosts(ctx context.Context, limit int) ([]*domain.Post, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+
+	return s.repo.ListFeatured(ctx, limit)
+}
+
+// ListPublishedRefs returns the slug and update time of every published post.
+func (s *PostService) ListPublishedRefs(ctx context.Context) ([]*domain.ContentRef, error) {
+	return s.repo.ListRefsByStatus(ctx, domain.PostStatusPublished)
+}
+
+// PublishPost publishes a post now. The post is saved at the version read,
+// so one saved by someone else in between makes it fail with ErrConflict.
 func (s *PostService) PublishPost(ctx context.Context, id int64) error {
-	post, err := s.repo.GetByID(ctx, id)
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		post, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		post.Status = domain.PostStatusPublished
+		post.PublishedAt = &now
+
+		return updateConflict(s.repo.Update(ctx, post))
+	})
 	if err != nil {
 		return err
 	}
 
-	now := time.Now()
-	post.Status = domain.PostStatusPublished
-	post.PublishedAt = &now
-
-	return s.repo.Update(ctx, post)
+	s.changed.notify()
+	return nil
 }
 
+// UnpublishPost turns a post back into a draft, failing with ErrConflict
+// like PublishPost.
 func (s *PostService) UnpublishPost(ctx context.Context, id int64) error {
-	post, err := s.repo.GetByID(ctx, id)
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		post, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		post.Status = domain.PostStatusDraft
+		post.PublishedAt = nil
+
+		return updateConflict(s.repo.Update(ctx, post))
+	})
 	if err != nil {
 		return err
 	}
 
-	post.Status = domain.PostStatusDraft
-	post.PublishedAt = nil
+	s.changed.notify()
+	return nil
+}
 
-	return s.repo.Update(ctx, post)
+// slugTaken reports whether a slug is used by a post other than excludeID.
+func (s *PostService) slugTaken(ctx context.Context, excludeID int64) func(string) (bool, error) {
+	return func(slug string) (bool, error) {
+		existing, err := s.repo.GetBySlug(ctx, slug)
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return existing != nil && existing.ID != excludeID, nil
+	}
 }
 
 func (s *PostService) validatePost(post *domain.Post) error {
@@ -142,3 +330,12 @@ func (s *PostService) validatePost(post *domain.Post) error {
 
 	return nil
 }
+
+// stampPublished dates a post published without a publication time, as
+// listings are ordered by it
+func stampPublished(post *domain.Post) {
+	if post.Status == domain.PostStatusPublished && post.PublishedAt == nil {
+		now := time.Now()
+		post.PublishedAt = &now
+	}
+}
@@ -3,12 +3,15 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/pagination"
 )
 
 type MockPostRepository struct {
@@ -62,6 +65,22 @@ func (m *MockPostRepository) ListByStatus(ctx context.Context, status domain.Pos
 	return args.Get(0).([]*domain.Post), args.Error(1)
 }
 
+func (m *MockPostRepository) ListRefsByStatus(ctx context.Context, status domain.PostStatus) ([]*domain.ContentRef, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ContentRef), args.Error(1)
+}
+
+func (m *MockPostRepository) ListFeatured(ctx context.Context, limit int) ([]*domain.Post, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Post), args.Error(1)
+}
+
 func (m *MockPostRepository) ListByAuthor(ctx context.Context, authorID int64, limit, offset int) ([]*domain.Post, error) {
 	args := m.Called(ctx, authorID, limit, offset)
 	if args.Get(0) == nil {
@@ -70,9 +89,46 @@ func (m *MockPostRepository) ListByAuthor(ctx context.Context, authorID int64, l
 	return args.Get(0).([]*domain.Post), args.Error(1)
 }
 
+func (m *MockPostRepository) ListByStatusAndAuthor(ctx context.Context, status domain.PostStatus, authorID int64, limit, offset int) ([]*domain.Post, error) {
+	args := m.Called(ctx, status, authorID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) ListByStatusAndCategory(ctx context.Context, status domain.PostStatus, categoryID int64, limit, offset int) ([]*domain.Post, error) {
+	args := m.Called(ctx, status, categoryID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) ListByStatusAndTag(ctx context.Context, status domain.PostStatus, tagID int64, limit, offset int) ([]*domain.Post, error) {
+	args := m.Called(ctx, status, tagID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) ListPublished(ctx context.Context, filter domain.PostFilter, req pagination.Request) ([]*domain.Post, error) {
+	args := m.Called(ctx, filter, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) CountPublished(ctx context.Context, filter domain.PostFilter) (int, error) {
+	args := m.Called(ctx, filter)
+	return args.Int(0), args.Error(1)
+}
+
 func TestPostService_CreatePost(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 
 	post := &domain.Post{
@@ -83,16 +139,42 @@ func TestPostService_CreatePost(t *testing.T) {
 		Status:   domain.PostStatusDraft,
 	}
 
+	repo.On("GetBySlug", ctx, "test-post").Return(nil, sql.ErrNoRows)
+	repo.On("Create", ctx, post).Return(nil)
+
+	err := service.CreatePost(ctx, post)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestPostService_CreatePost_DuplicateSlug(t *testing.T) {
+	repo := new(MockPostRepository)
+	slugs := new(MockSlugHistoryRepository)
+	service := NewPostService(repo, slugs)
+	ctx := context.Background()
+
+	post := &domain.Post{
+		Title:   "Test Post",
+		Slug:    "test-post",
+		Content: "Content",
+	}
+
+	repo.On("GetBySlug", ctx, "test-post").Return(&domain.Post{ID: 1, Slug: "test-post"}, nil)
+	repo.On("GetBySlug", ctx, "test-post-2").Return(&domain.Post{ID: 2, Slug: "test-post-2"}, nil)
+	repo.On("GetBySlug", ctx, "test-post-3").Return(nil, sql.ErrNoRows)
 	repo.On("Create", ctx, post).Return(nil)
+	slugs.On("Release", ctx, domain.ContentTypePost, "test-post-3").Return(nil)
 
 	err := service.CreatePost(ctx, post)
 	assert.NoError(t, err)
+	assert.Equal(t, "test-post-3", post.Slug)
 	repo.AssertExpectations(t)
+	slugs.AssertExpectations(t)
 }
 
 func TestPostService_CreatePost_ValidationError(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 
 	tests := []struct {
@@ -138,7 +220,7 @@ func TestPostService_CreatePost_ValidationError(t *testing.T) {
 
 func TestPostService_GetPostByID(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 
 	expectedPost := &domain.Post{
@@ -158,7 +240,7 @@ func TestPostService_GetPostByID(t *testing.T) {
 
 func TestPostService_GetPostByID_NotFound(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 
 	repo.On("GetByID", ctx, int64(999)).Return(nil, sql.ErrNoRows)
@@ -171,7 +253,7 @@ func TestPostService_GetPostByID_NotFound(t *testing.T) {
 
 func TestPostService_GetPostBySlug(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 
 	expectedPost := &domain.Post{
@@ -191,7 +273,7 @@ func TestPostService_GetPostBySlug(t *testing.T) {
 
 func TestPostService_UpdatePost(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 
 	post := &domain.Post{
@@ -203,16 +285,119 @@ func TestPostService_UpdatePost(t *testing.T) {
 		Status:   domain.PostStatusPublished,
 	}
 
+	repo.On("GetByID", ctx, int64(1)).Return(&domain.Post{ID: 1, Slug: "updated-post"}, nil)
+	repo.On("GetBySlug", ctx, "updated-post").Return(&domain.Post{ID: 1, Slug: "updated-post"}, nil)
 	repo.On("Update", ctx, post).Return(nil)
 
 	err := service.UpdatePost(ctx, post)
 	assert.NoError(t, err)
+	assert.Equal(t, "updated-post", post.Slug)
 	repo.AssertExpectations(t)
 }
 
+func TestPostService_UpdatePost_Conflict(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("saved since it was loaded", func(t *testing.T) {
+		repo := new(MockPostRepository)
+		service := NewPostService(repo, nil)
+		post := &domain.Post{ID: 1, Title: "Mine", Slug: "mine", Content: "Mine", Version: 2}
+
+		repo.On("GetByID", ctx, int64(1)).Return(&domain.Post{ID: 1, Slug: "mine", Version: 3}, nil)
+
+		err := service.UpdatePost(ctx, post)
+		assert.ErrorIs(t, err, ErrConflict)
+		repo.AssertNotCalled(t, "Update", ctx, post)
+	})
+
+	t.Run("saved while updating", func(t *testing.T) {
+		repo := new(MockPostRepository)
+		service := NewPostService(repo, nil)
+		post := &domain.Post{ID: 1, Title: "Mine", Slug: "mine", Content: "Mine", Version: 2}
+
+		repo.On("GetByID", ctx, int64(1)).Return(&domain.Post{ID: 1, Slug: "mine", Version: 2}, nil)
+		repo.On("GetBySlug", ctx, "mine").Return(&domain.Post{ID: 1, Slug: "mine"}, nil)
+		repo.On("Update", ctx, post).Return(sql.ErrNoRows)
+
+		err := service.UpdatePost(ctx, post)
+		assert.ErrorIs(t, err, ErrConflict)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestPostService_UpdatePost_SlugChangeRecordsHistory(t *testing.T) {
+	repo := new(MockPostRepository)
+	slugs := new(MockSlugHistoryRepository)
+	service := NewPostService(repo, slugs)
+	ctx := context.Background()
+
+	post := &domain.Post{
+		ID:      1,
+		Title:   "Renamed",
+		Slug:    "renamed",
+		Content: "Content",
+	}
+
+	repo.On("GetByID", ctx, int64(1)).Return(&domain.Post{ID: 1, Slug: "original"}, nil)
+	repo.On("GetBySlug", ctx, "renamed").Return(nil, sql.ErrNoRows)
+	repo.On("Update", ctx, post).Return(nil)
+	slugs.On("Release", ctx, domain.ContentTypePost, "renamed").Return(nil)
+	slugs.On("Record", ctx, domain.ContentTypePost, int64(1), "original").Return(nil)
+
+	err := service.UpdatePost(ctx, post)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	slugs.AssertExpectations(t)
+}
+
+func TestPostService_UpdatePost_SlugHistoryFailure(t *testing.T) {
+	repo := new(MockPostRepository)
+	slugs := new(MockSlugHistoryRepository)
+	service := NewPostService(repo, slugs)
+	tx := &recordingTransactor{}
+	service.SetTransactor(tx)
+	ctx := context.Background()
+
+	calls := 0
+	service.OnPublishedChange(func() { calls++ })
+
+	post := &domain.Post{ID: 1, Title: "Renamed", Slug: "renamed", Content: "Content", Status: domain.PostStatusPublished}
+	repo.On("GetByID", ctx, int64(1)).Return(&domain.Post{ID: 1, Slug: "original", Status: domain.PostStatusPublished}, nil)
+	repo.On("GetBySlug", ctx, "renamed").Return(nil, sql.ErrNoRows)
+	repo.On("Update", ctx, post).Return(nil)
+	slugs.On("Release", ctx, domain.ContentTypePost, "renamed").Return(nil)
+	slugs.On("Record", ctx, domain.ContentTypePost, int64(1), "original").Return(errors.New("disk full"))
+
+	// The update and the redirect are one unit of work, which fails as a
+	// whole, so caches of published content are left alone
+	err := service.UpdatePost(ctx, post)
+	assert.EqualError(t, err, "disk full")
+	assert.Equal(t, 1, tx.units)
+	assert.Len(t, tx.failed, 1)
+	assert.Equal(t, 0, calls)
+}
+
+func TestPostService_GetPostByPreviousSlug(t *testing.T) {
+	repo := new(MockPostRepository)
+	slugs := new(MockSlugHistoryRepository)
+	service := NewPostService(repo, slugs)
+	ctx := context.Background()
+
+	expected := &domain.Post{ID: 7, Slug: "current"}
+	slugs.On("FindContentID", ctx, domain.ContentTypePost, "old").Return(int64(7), nil)
+	repo.On("GetByID", ctx, int64(7)).Return(expected, nil)
+
+	post, err := service.GetPostByPreviousSlug(ctx, "old")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, post)
+
+	_, err = NewPostService(repo, nil).GetPostByPreviousSlug(ctx, "old")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 func TestPostService_DeletePost(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 
 	repo.On("Delete", ctx, int64(1)).Return(nil)
@@ -224,7 +409,7 @@ func TestPostService_DeletePost(t *testing.T) {
 
 func TestPostService_ListPosts(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 
 	expectedPosts := []*domain.Post{
@@ -242,7 +427,7 @@ func TestPostService_ListPosts(t *testing.T) {
 
 func TestPostService_ListPublishedPosts(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 
 	expectedPosts := []*domain.Post{
@@ -257,9 +442,122 @@ func TestPostService_ListPublishedPosts(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
+func TestPostService_ListFeaturedPosts(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := NewPostService(repo, nil)
+	ctx := context.Background()
+
+	repo.On("ListFeatured", ctx, 5).Return([]*domain.Post{{ID: 1, IsFeatured: true}}, nil)
+
+	// A missing limit falls back to the default
+	posts, err := service.ListFeaturedPosts(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, posts, 1)
+	repo.AssertExpectations(t)
+}
+
+func TestPostService_PaginatePublishedPosts(t *testing.T) {
+	ctx := context.Background()
+	day := func(d int) *time.Time {
+		at := time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC)
+		return &at
+	}
+	filter := domain.PostFilter{TagID: 4}
+
+	t.Run("a listing that fits on the first page isn't counted", func(t *testing.T) {
+		repo := new(MockPostRepository)
+		service := NewPostService(repo, nil)
+		req := pagination.Request{Limit: 2}
+
+		repo.On("ListPublished", ctx, filter, req).Return([]*domain.Post{{ID: 2, PublishedAt: day(2)}, {ID: 1, PublishedAt: day(1)}}, nil)
+
+		result, err := service.PaginatePublishedPosts(ctx, filter, req)
+		require.NoError(t, err)
+		assert.Len(t, result.Items, 2)
+		assert.Nil(t, result.Next)
+		assert.Nil(t, result.Prev)
+		assert.Equal(t, 2, result.Total)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("further pages are counted", func(t *testing.T) {
+		repo := new(MockPostRepository)
+		service := NewPostService(repo, nil)
+		req := pagination.Request{After: &pagination.Cursor{Time: *day(5), ID: 5}, Limit: 2}
+
+		repo.On("ListPublished", ctx, filter, req).Return([]*domain.Post{{ID: 4, PublishedAt: day(4)}, {ID: 3, PublishedAt: day(3)}, {ID: 2, PublishedAt: day(2)}}, nil)
+		repo.On("CountPublished", ctx, filter).Return(5, nil)
+
+		result, err := service.PaginatePublishedPosts(ctx, filter, req)
+		require.NoError(t, err)
+		assert.Len(t, result.Items, 2)
+		assert.Equal(t, &pagination.Cursor{Time: *day(4), ID: 4}, result.Prev)
+		assert.Equal(t, &pagination.Cursor{Time: *day(3), ID: 3}, result.Next)
+		assert.Equal(t, 5, result.Total)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		repo := new(MockPostRepository)
+		service := NewPostService(repo, nil)
+		req := pagination.Request{Before: &pagination.Cursor{Time: *day(1), ID: 1}, Limit: 2}
+
+		repo.On("ListPublished", ctx, filter, req).Return([]*domain.Post{{ID: 2, PublishedAt: day(2)}}, nil)
+		repo.On("CountPublished", ctx, filter).Return(0, errors.New("db down"))
+
+		_, err := service.PaginatePublishedPosts(ctx, filter, req)
+		assert.Error(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestPostService_CreatePost_Published(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := NewPostService(repo, nil)
+	ctx := context.Background()
+
+	// Listings are ordered by publication time, so a post created published
+	// gets one
+	post := &domain.Post{Title: "Test Post", Slug: "test-post", Content: "Content", AuthorID: 1, Status: domain.PostStatusPublished}
+
+	repo.On("GetBySlug", ctx, "test-post").Return(nil, sql.ErrNoRows)
+	repo.On("Create", ctx, post).Return(nil)
+
+	require.NoError(t, service.CreatePost(ctx, post))
+	assert.NotNil(t, post.PublishedAt)
+	repo.AssertExpectations(t)
+}
+
+func TestPostService_ListPublishedPostsByTaxonomy(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := NewPostService(repo, nil)
+	ctx := context.Background()
+
+	expectedPosts := []*domain.Post{
+		{ID: 1, Title: "Post 1", Status: domain.PostStatusPublished},
+	}
+
+	repo.On("ListByStatusAndAuthor", ctx, domain.PostStatusPublished, int64(2), 10, 0).Return(expectedPosts, nil)
+	repo.On("ListByStatusAndCategory", ctx, domain.PostStatusPublished, int64(3), 20, 0).Return(expectedPosts, nil)
+	repo.On("ListByStatusAndTag", ctx, domain.PostStatusPublished, int64(4), 10, 0).Return([]*domain.Post{}, nil)
+
+	posts, err := service.ListPublishedPostsByAuthor(ctx, 2, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPosts, posts)
+
+	posts, err = service.ListPublishedPostsByCategory(ctx, 3, 20, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPosts, posts)
+
+	posts, err = service.ListPublishedPostsByTag(ctx, 4, 10, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, posts)
+	repo.AssertExpectations(t)
+}
+
 func TestPostService_PublishPost(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 	now := time.Now()
 
@@ -286,7 +584,7 @@ func TestPostService_PublishPost(t *testing.T) {
 
 func TestPostService_UnpublishPost(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 
 	publishedAt := time.Now()
@@ -313,7 +611,7 @@ func TestPostService_UnpublishPost(t *testing.T) {
 
 func TestPostService_PublishPost_NotFound(t *testing.T) {
 	repo := new(MockPostRepository)
-	service := NewPostService(repo)
+	service := NewPostService(repo, nil)
 	ctx := context.Background()
 
 	repo.On("GetByID", ctx, int64(999)).Return(nil, sql.ErrNoRows)
@@ -322,3 +620,69 @@ func TestPostService_PublishPost_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	repo.AssertExpectations(t)
 }
+
+func TestPostService_PublishPost_Conflict(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := NewPostService(repo, nil)
+	tx := &recordingTransactor{}
+	service.SetTransactor(tx)
+	ctx := context.Background()
+
+	calls := 0
+	service.OnPublishedChange(func() { calls++ })
+
+	// Saved by someone else after it was read, so the versioned update
+	// matches no row
+	repo.On("GetByID", ctx, int64(1)).Return(&domain.Post{ID: 1, Status: domain.PostStatusDraft, Version: 2}, nil)
+	repo.On("Update", ctx, mock.Anything).Return(sql.ErrNoRows)
+
+	err := service.PublishPost(ctx, 1)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 1, tx.units)
+	assert.Equal(t, 0, calls)
+
+	err = service.UnpublishPost(ctx, 1)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 2, tx.units)
+}
+
+func TestPostService_OnPublishedChange(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := NewPostService(repo, nil)
+	ctx := context.Background()
+
+	calls := 0
+	service.OnPublishedChange(func() { calls++ })
+
+	draft := &domain.Post{ID: 1, Title: "Draft", Slug: "draft", Content: "Content", Status: domain.PostStatusDraft}
+	repo.On("GetByID", ctx, int64(1)).Return(draft, nil)
+	repo.On("GetBySlug", ctx, "draft").Return(draft, nil)
+	repo.On("Update", ctx, mock.Anything).Return(nil)
+
+	// Editing a draft does not affect published content
+	err := service.UpdatePost(ctx, &domain.Post{ID: 1, Title: "Draft", Slug: "draft", Content: "Changed", Status: domain.PostStatusDraft})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, calls)
+
+	err = service.PublishPost(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	err = service.UnpublishPost(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestPostService_ListPublishedRefs(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := NewPostService(repo, nil)
+	ctx := context.Background()
+
+	refs := []*domain.ContentRef{{ID: 1, Slug: "hello", UpdatedAt: time.Now()}}
+	repo.On("ListRefsByStatus", ctx, domain.PostStatusPublished).Return(refs, nil)
+
+	got, err := service.ListPublishedRefs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, refs, got)
+	repo.AssertExpectations(t)
+}
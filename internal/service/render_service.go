@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/content"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type RenderRepository interface {
+	Get(ctx context.Context, contentType string, id int64) (*domain.RenderedContent, error)
+	GetMany(ctx context.Context, contentType string, ids []int64) (map[int64]*domain.RenderedContent, error)
+	Save(ctx context.Context, rc *domain.RenderedContent) error
+	Delete(ctx context.Context, contentType string, id int64) error
+	ListStale(ctx context.Context, contentType string, version, limit int) ([]*domain.ContentSource, error)
+}
+
+// ContentRenderer turns Markdown into HTML. content.Pipeline satisfies it.
+type ContentRenderer interface {
+	Render(ctx context.Context, md string) *content.Document
+	Version() int
+}
+
+// rerenderBatchSize is how many stale posts or pages are loaded at a time
+// while re-rendering.
+const rerenderBatchSize = 50
+
+// RenderService caches the rendered HTML of posts and pages, so they are
+// only converted from Markdown when saved or when the pipeline changes.
+type RenderService struct {
+	repo     RenderRepository
+	renderer ContentRenderer
+}
+
+func NewRenderService(repo RenderRepository, renderer ContentRenderer) *RenderService {
+	return &RenderService{repo: repo, renderer: renderer}
+}
+
+// Render converts a post or page body and stores the result. The render is
+// returned even when storing it fails.
+func (s *RenderService) Render(ctx context.Context, contentType string, id int64, md string) (*domain.RenderedContent, error) {
+	rc := s.Preview(ctx, md)
+	rc.ContentType, rc.ContentID = contentType, id
+
+	return rc, s.repo.Save(ctx, rc)
+}
+
+// Preview renders Markdown the way it would be shown once saved, without
+// storing anything.
+func (s *RenderService) Preview(ctx context.Context, md string) *domain.RenderedContent {
+	doc := s.renderer.Render(ctx, md)
+	return &domain.RenderedContent{
+		HTML:        doc.HTML,
+		TOC:         doc.TOCHTML(),
+		Excerpt:     doc.Excerpt(),
+		WordCount:   doc.WordCount,
+		ReadingTime: doc.ReadingTime,
+		Version:     s.renderer.Version(),
+	}
+}
+
+// Get returns the cached render of a post or page, rendering md in its place
+// when there is none yet or it is from an older pipeline version.
+func (s *RenderService) Get(ctx context.Context, contentType string, id int64, md string) *domain.RenderedContent {
+	rc, err := s.repo.Get(ctx, contentType, id)
+	if err == nil && rc.Version == s.renderer.Version() {
+		return rc
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error loading rendered %s %d: %v", contentType, id, err)
+	}
+
+	rc, err = s.Render(ctx, contentType, id, md)
+	if err != nil {
+		log.Printf("Error caching rendered %s %d: %v", contentType, id, err)
+	}
+	return rc
+}
+
+// GetAll returns the renders of several posts or pages keyed by ID, loading
+// them in one query and rendering any that are missing or stale.
+func (s *RenderService) GetAll(ctx context.Context, contentType string, sources []*domain.ContentSource) map[int64]*domain.RenderedContent {
+	ids := make([]int64, len(sources))
+	for i, src := range sources {
+		ids[i] = src.ID
+	}
+
+	renders, err := s.repo.GetMany(ctx, contentType, ids)
+	if err != nil {
+		log.Printf("Error loading rendered %ss: %v", contentType, err)
+		renders = make(map[int64]*domain.RenderedContent, len(sources))
+	}
+
+	for _, src := range sources {
+		if rc, ok := renders[src.ID]; ok && rc.Version == s.renderer.Version() {
+			continue
+		}
+		rc, err := s.Render(ctx, contentType, src.ID, src.Content)
+		if err != nil {
+			log.Printf("Error caching rendered %s %d: %v", contentType, src.ID, err)
+		}
+		renders[src.ID] = rc
+	}
+	return renders
+}
+
+// Delete removes the cached render of a deleted post or page.
+func (s *RenderService) Delete(ctx context.Context, contentType string, id int64) error {
+	return s.repo.Delete(ctx, contentType, id)
+}
+
+// RerenderStale renders every post and page that has no render from the
+// current pipeline version, returning how many it rendered. It is meant to
+// run in the background after a deploy that changed the pipeline.
+func (s *RenderService) RerenderStale(ctx context.Context) (int, error) {
+	total := 0
+	for _, contentType := range []string{domain.ContentTypePost, domain.ContentTypePage} {
+		for {
+			if err := ctx.Err(); err != nil {
+				return total, err
+			}
+
+			sources, err := s.repo.ListStale(ctx, contentType, s.renderer.Version(), rerenderBatchSize)
+			if err != nil {
+				return total, err
+			}
+			if len(sources) == 0 {
+				break
+			}
+
+			for _, src := range sources {
+				// A failed save would list the same content again, so stop
+				// rather than loop forever
+				if _, err := s.Render(ctx, contentType, src.ID, src.Content); err != nil {
+					return total, err
+				}
+				total++
+			}
+		}
+	}
+	return total, nil
+}
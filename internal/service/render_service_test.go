@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/content"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type MockRenderRepository struct {
+	mock.Mock
+}
+
+func (m *MockRenderRepository) Get(ctx context.Context, contentType string, id int64) (*domain.RenderedContent, error) {
+	args := m.Called(ctx, contentType, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RenderedContent), args.Error(1)
+}
+
+func (m *MockRenderRepository) GetMany(ctx context.Context, contentType string, ids []int64) (map[int64]*domain.RenderedContent, error) {
+	args := m.Called(ctx, contentType, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64]*domain.RenderedContent), args.Error(1)
+}
+
+func (m *MockRenderRepository) Save(ctx context.Context, rc *domain.RenderedContent) error {
+	args := m.Called(ctx, rc)
+	return args.Error(0)
+}
+
+func (m *MockRenderRepository) Delete(ctx context.Context, contentType string, id int64) error {
+	args := m.Called(ctx, contentType, id)
+	return args.Error(0)
+}
+
+func (m *MockRenderRepository) ListStale(ctx context.Context, contentType string, version, limit int) ([]*domain.ContentSource, error) {
+	args := m.Called(ctx, contentType, version, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ContentSource), args.Error(1)
+}
+
+// versionedPipeline is the real pipeline reporting a chosen version
+type versionedPipeline struct {
+	*content.Pipeline
+	version int
+}
+
+func (p versionedPipeline) Version() int { return p.version }
+
+func newRenderService(version int) (*RenderService, *MockRenderRepository) {
+	repo := new(MockRenderRepository)
+	return NewRenderService(repo, versionedPipeline{content.New(content.Options{}), version}), repo
+}
+
+func TestRenderService_Render(t *testing.T) {
+	svc, repo := newRenderService(2)
+	ctx := context.Background()
+	md := "## One\n\n## Two\n\n## Three\n\n" + strings.Repeat("word ", 450)
+
+	repo.On("Save", ctx, mock.AnythingOfType("*domain.RenderedContent")).Return(nil)
+
+	rc, err := svc.Render(ctx, domain.ContentTypePost, 7, md)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), rc.ContentID)
+	assert.Equal(t, 2, rc.Version)
+	assert.Contains(t, rc.HTML, `<h2 id="one">One</h2>`)
+	assert.Contains(t, rc.TOC, `<a href="#three">Three</a>`)
+	assert.Equal(t, 453, rc.WordCount)
+	assert.Equal(t, 3, rc.ReadingTime)
+	assert.True(t, strings.HasPrefix(rc.Excerpt, "One Two Three word word"))
+	assert.True(t, strings.HasSuffix(rc.Excerpt, "…"))
+	repo.AssertExpectations(t)
+}
+
+func TestRenderService_Preview(t *testing.T) {
+	svc, repo := newRenderService(2)
+
+	rc := svc.Preview(context.Background(), "Some **bold** text")
+	assert.Equal(t, "<p>Some <strong>bold</strong> text</p>\n", rc.HTML)
+	assert.Equal(t, 3, rc.WordCount)
+	repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestRenderService_Get(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("current render is used", func(t *testing.T) {
+		svc, repo := newRenderService(2)
+		cached := &domain.RenderedContent{ContentID: 7, HTML: "<p>cached</p>", Version: 2}
+		repo.On("Get", ctx, domain.ContentTypePost, int64(7)).Return(cached, nil)
+
+		assert.Same(t, cached, svc.Get(ctx, domain.ContentTypePost, 7, "fresh"))
+		repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("stale render is replaced", func(t *testing.T) {
+		svc, repo := newRenderService(2)
+		repo.On("Get", ctx, domain.ContentTypePost, int64(7)).Return(&domain.RenderedContent{HTML: "<p>old</p>", Version: 1}, nil)
+		repo.On("Save", ctx, mock.AnythingOfType("*domain.RenderedContent")).Return(nil)
+
+		rc := svc.Get(ctx, domain.ContentTypePost, 7, "fresh")
+		assert.Equal(t, "<p>fresh</p>\n", rc.HTML)
+		assert.Equal(t, 2, rc.Version)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("missing render is created even if saving fails", func(t *testing.T) {
+		svc, repo := newRenderService(2)
+		repo.On("Get", ctx, domain.ContentTypePage, int64(3)).Return(nil, sql.ErrNoRows)
+		repo.On("Save", ctx, mock.AnythingOfType("*domain.RenderedContent")).Return(errors.New("db down"))
+
+		rc := svc.Get(ctx, domain.ContentTypePage, 3, "fresh")
+		assert.Equal(t, "<p>fresh</p>\n", rc.HTML)
+	})
+}
+
+func TestRenderService_GetAll(t *testing.T) {
+	svc, repo := newRenderService(2)
+	ctx := context.Background()
+	current := &domain.RenderedContent{ContentID: 1, HTML: "<p>cached</p>", Version: 2}
+
+	repo.On("GetMany", ctx, domain.ContentTypePost, []int64{1, 2, 3}).Return(map[int64]*domain.RenderedContent{
+		1: current,
+		2: {ContentID: 2, HTML: "<p>old</p>", Version: 1},
+	}, nil)
+	repo.On("Save", ctx, mock.AnythingOfType("*domain.RenderedContent")).Return(nil).Times(2)
+
+	renders := svc.GetAll(ctx, domain.ContentTypePost, []*domain.ContentSource{
+		{ID: 1, Content: "one"},
+		{ID: 2, Content: "two"},
+		{ID: 3, Content: "three"},
+	})
+	require.Len(t, renders, 3)
+	assert.Same(t, current, renders[1])
+	assert.Equal(t, "<p>two</p>\n", renders[2].HTML)
+	assert.Equal(t, "<p>three</p>\n", renders[3].HTML)
+	repo.AssertExpectations(t)
+}
+
+func TestRenderService_RerenderStale(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("renders posts and pages until none are stale", func(t *testing.T) {
+		svc, repo := newRenderService(2)
+		repo.On("ListStale", ctx, domain.ContentTypePost, 2, rerenderBatchSize).
+			Return([]*domain.ContentSource{{ID: 1, Content: "a"}, {ID: 2, Content: "b"}}, nil).Once()
+		repo.On("ListStale", ctx, domain.ContentTypePost, 2, rerenderBatchSize).Return([]*domain.ContentSource{}, nil).Once()
+		repo.On("ListStale", ctx, domain.ContentTypePage, 2, rerenderBatchSize).
+			Return([]*domain.ContentSource{{ID: 5, Content: "c"}}, nil).Once()
+		repo.On("ListStale", ctx, domain.ContentTypePage, 2, rerenderBatchSize).Return([]*domain.ContentSource{}, nil).Once()
+		repo.On("Save", ctx, mock.AnythingOfType("*domain.RenderedContent")).Return(nil).Times(3)
+
+		n, err := svc.RerenderStale(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("stops when a render can't be saved", func(t *testing.T) {
+		svc, repo := newRenderService(2)
+		repo.On("ListStale", ctx, domain.ContentTypePost, 2, rerenderBatchSize).
+			Return([]*domain.ContentSource{{ID: 1, Content: "a"}}, nil)
+		repo.On("Save", ctx, mock.AnythingOfType("*domain.RenderedContent")).Return(errors.New("db down"))
+
+		n, err := svc.RerenderStale(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, 0, n)
+		repo.AssertNumberOfCalls(t, "ListStale", 1)
+	})
+}
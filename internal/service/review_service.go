@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type ReviewRepository interface {
+	CreateRevision(ctx context.Context, rev *domain.PostRevision) error
+	LatestRevision(ctx context.Context, postID int64) (*domain.PostRevision, error)
+	CreateComment(ctx context.Context, comment *domain.PostReviewComment) error
+	ListComments(ctx context.Context, postID int64) ([]*domain.PostReviewComment, error)
+}
+
+type NotificationRepository interface {
+	Create(ctx context.Context, n *domain.Notification) error
+	ListByUser(ctx context.Context, userID int64, limit int) ([]*domain.Notification, error)
+	MarkAllRead(ctx context.Context, userID int64) error
+}
+
+var (
+	// ErrNotAllowed is returned when a user's role does not permit a workflow
+	// action, such as an author approving their own post.
+	ErrNotAllowed = errors.New("not allowed")
+	// ErrCommentRequired is returned when changes are requested without
+	// saying what should change.
+	ErrCommentRequired = errors.New("a comment is required")
+	// ErrNotSubmitted is returned when commenting on a post that has never
+	// been submitted for review.
+	ErrNotSubmitted = errors.New("post has not been submitted for review")
+)
+
+// reviewQueueLimit caps how many posts each review queue section shows.
+const reviewQueueLimit = 100
+
+// ReviewQueue lists the posts waiting on editors.
+type ReviewQueue struct {
+	InReview []*domain.Post
+	Approved []*domain.Post
+}
+
+// ReviewService moves posts through the editorial workflow, keeping a
+// revision for every submission and notifying authors of decisions.
+type ReviewService struct {
+	posts         *PostService
+	reviews       ReviewRepository
+	notifications NotificationRepository
+	tx            Transactor
+}
+
+func NewReviewService(posts *PostService, reviews ReviewRepository, notifications NotificationRepository) *ReviewService {
+	return &ReviewService{posts: posts, reviews: reviews, notifications: notifications, tx: noTransactor{}}
+}
+
+// SetTransactor makes each workflow action, with its revision, review
+// comment and notifications, a unit of work run by tx.
+func (s *ReviewService) SetTransactor(tx Transactor) {
+	s.tx = tx
+}
+
+// Transition moves a post to a new status if the actor's role allows it.
+// Submitting snapshots the post as a new revision; approving or requesting
+// changes records the decision, with the comment, against that revision.
+func (s *ReviewService) Transition(ctx context.Context, postID int64, actor domain.Actor, to domain.PostStatus, comment string) (*domain.Post, error) {
+	var post *domain.Post
+	var from domain.PostStatus
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		post, err = s.posts.repo.GetByID(ctx, postID)
+		if err != nil {
+			return err
+		}
+
+		from = post.Status
+		if !from.CanTransition(to, actor.RoleFor(post)) {
+			return fmt.Errorf("%w: cannot move post from %s to %s", ErrNotAllowed, from, to)
+		}
+
+		comment = strings.TrimSpace(comment)
+		if to == domain.PostStatusChangesRequested && comment == "" {
+			return ErrCommentRequired
+		}
+
+		switch to {
+		case domain.PostStatusInReview:
+			rev := &domain.PostRevision{PostID: post.ID, Title: post.Title, Content: post.Content, AuthorID: actor.UserID}
+			if err := s.reviews.CreateRevision(ctx, rev); err != nil {
+				return err
+			}
+		case domain.PostStatusApproved, domain.PostStatusChangesRequested:
+			if err := s.addComment(ctx, post.ID, actor, domain.ReviewDecision(to), comment); err != nil {
+				return err
+			}
+		}
+
+		post.Status = to
+		if to == domain.PostStatusPublished && post.PublishedAt == nil {
+			now := time.Now()
+			post.PublishedAt = &now
+		}
+		if from == domain.PostStatusPublished {
+			post.PublishedAt = nil
+		}
+
+		if err := updateConflict(s.posts.repo.Update(ctx, post)); err != nil {
+			return err
+		}
+
+		s.notifyTransition(ctx, post, actor, to)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if from == domain.PostStatusPublished || to == domain.PostStatusPublished {
+		s.posts.changed.notify()
+	}
+	return post, nil
+}
+
+// AssignReviewer sets the editor responsible for reviewing a post, or clears
+// it when reviewerID is nil. Callers check that the reviewer is an editor.
+func (s *ReviewService) AssignReviewer(ctx context.Context, postID int64, actor domain.Actor, reviewerID *int64) error {
+	if !actor.Editor {
+		return fmt.Errorf("%w: only editors can assign reviewers", ErrNotAllowed)
+	}
+
+	return s.tx.Do(ctx, func(ctx context.Context) error {
+		post, err := s.posts.repo.GetByID(ctx, postID)
+		if err != nil {
+			return err
+		}
+
+		post.ReviewerID = reviewerID
+		if err := updateConflict(s.posts.repo.Update(ctx, post)); err != nil {
+			return err
+		}
+
+		if reviewerID != nil && *reviewerID != actor.UserID {
+			s.notify(ctx, *reviewerID, fmt.Sprintf("You were asked to review %q", post.Title), reviewLink(post))
+		}
+		return nil
+	})
+}
+
+// Comment adds feedback to the latest revision of a post without changing
+// its status. The author and editors can comment; the other side is notified.
+func (s *ReviewService) Comment(ctx context.Context, postID int64, actor domain.Actor, body string) error {
+	return s.tx.Do(ctx, func(ctx context.Context) error {
+		post, err := s.posts.repo.GetByID(ctx, postID)
+		if err != nil {
+			return err
+		}
+		if actor.RoleFor(post) == domain.WorkflowNone {
+			return fmt.Errorf("%w: only the author and editors can comment", ErrNotAllowed)
+		}
+
+		body = strings.TrimSpace(body)
+		if body == "" {
+			return ErrCommentRequired
+		}
+
+		if err := s.addComment(ctx, post.ID, actor, domain.ReviewComment, body); err != nil {
+			return err
+		}
+
+		if actor.UserID != post.AuthorID {
+			s.notify(ctx, post.AuthorID, fmt.Sprintf("New review comment on %q", post.Title), reviewLink(post))
+		} else if post.ReviewerID != nil {
+			s.notify(ctx, *post.ReviewerID, fmt.Sprintf("The author replied on %q", post.Title), reviewLink(post))
+		}
+		return nil
+	})
+}
+
+// History returns the latest revision of a post and every review comment
+// left on it. The revision is nil if the post was never submitted.
+func (s *ReviewService) History(ctx context.Context, postID int64) (*domain.PostRevision, []*domain.PostReviewComment, error) {
+	rev, err := s.reviews.LatestRevision(ctx, postID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, []*domain.PostReviewComment{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comments, err := s.reviews.ListComments(ctx, postID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rev, comments, nil
+}
+
+// Queue returns the posts waiting for review and those approved and ready to
+// publish.
+func (s *ReviewService) Queue(ctx context.Context) (*ReviewQueue, error) {
+	inReview, err := s.posts.repo.ListByStatus(ctx, domain.PostStatusInReview, reviewQueueLimit, 0)
+	if err != nil {
+		return nil, err
+	}
+	approved, err := s.posts.repo.ListByStatus(ctx, domain.PostStatusApproved, reviewQueueLimit, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &ReviewQueue{InReview: inReview, Approved: approved}, nil
+}
+
+// Notifications returns a user's recent notifications.
+func (s *ReviewService) Notifications(ctx context.Context, userID int64, limit int) ([]*domain.Notification, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	return s.notifications.ListByUser(ctx, userID, limit)
+}
+
+// MarkNotificationsRead marks all of a user's notifications as read.
+func (s *ReviewService) MarkNotificationsRead(ctx context.Context, userID int64) error {
+	return s.notifications.MarkAllRead(ctx, userID)
+}
+
+func (s *ReviewService) addComment(ctx context.Context, postID int64, actor domain.Actor, decision domain.ReviewDecision, body string) error {
+	rev, err := s.reviews.LatestRevision(ctx, postID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotSubmitted
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.reviews.CreateComment(ctx, &domain.PostReviewComment{
+		PostID:     postID,
+		RevisionID: rev.ID,
+		AuthorID:   actor.UserID,
+		Decision:   decision,
+		Body:       body,
+	})
+}
+
+// notifyTransition tells the author about editorial decisions on their post
+// and the assigned reviewer about new submissions.
+func (s *ReviewService) notifyTransition(ctx context.Context, post *domain.Post, actor domain.Actor, to domain.PostStatus) {
+	var message, link string
+	userID := post.AuthorID
+
+	switch to {
+	case domain.PostStatusInReview:
+		if post.ReviewerID == nil {
+			return
+		}
+		userID = *post.ReviewerID
+		message, link = fmt.Sprintf("%q is ready for review", post.Title), reviewLink(post)
+	case domain.PostStatusApproved:
+		message, link = fmt.Sprintf("Your post %q was approved", post.Title), reviewLink(post)
+	case domain.PostStatusChangesRequested:
+		message, link = fmt.Sprintf("Changes were requested on your post %q", post.Title), reviewLink(post)
+	case domain.PostStatusPublished:
+		message, link = fmt.Sprintf("Your post %q was published", post.Title), fmt.Sprintf("/posts/%s", post.Slug)
+	default:
+		return
+	}
+
+	if userID == actor.UserID {
+		return
+	}
+	s.notify(ctx, userID, message, link)
+}
+
+// notify stores a notification. Failing to notify must not undo the action
+// that triggered it, so errors are only logged, and the notification is a
+// unit of work of its own, a savepoint within the action's transaction.
+func (s *ReviewService) notify(ctx context.Context, userID int64, message, link string) {
+	n := &domain.Notification{UserID: userID, Message: message, Link: link}
+	err := s.tx.Do(ctx, func(ctx context.Context) error {
+		return s.notifications.Create(ctx, n)
+	})
+	if err != nil {
+		log.Printf("Error notifying user %d: %v", userID, err)
+	}
+}
+
+func reviewLink(post *domain.Post) string {
+	return fmt.Sprintf("/posts/%d/review", post.ID)
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type MockReviewRepository struct {
+	mock.Mock
+}
+
+func (m *MockReviewRepository) CreateRevision(ctx context.Context, rev *domain.PostRevision) error {
+	args := m.Called(ctx, rev)
+	return args.Error(0)
+}
+
+func (m *MockReviewRepository) LatestRevision(ctx context.Context, postID int64) (*domain.PostRevision, error) {
+	args := m.Called(ctx, postID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PostRevision), args.Error(1)
+}
+
+func (m *MockReviewRepository) CreateComment(ctx context.Context, comment *domain.PostReviewComment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+func (m *MockReviewRepository) ListComments(ctx context.Context, postID int64) ([]*domain.PostReviewComment, error) {
+	args := m.Called(ctx, postID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PostReviewComment), args.Error(1)
+}
+
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) Create(ctx context.Context, n *domain.Notification) error {
+	args := m.Called(ctx, n)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) ListByUser(ctx context.Context, userID int64, limit int) ([]*domain.Notification, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkAllRead(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+var (
+	reviewAuthor = domain.Actor{UserID: 7}
+	reviewEditor = domain.Actor{UserID: 2, Editor: true}
+)
+
+func newReviewService() (*ReviewService, *MockPostRepository, *MockReviewRepository, *MockNotificationRepository) {
+	postRepo := new(MockPostRepository)
+	reviewRepo := new(MockReviewRepository)
+	notificationRepo := new(MockNotificationRepository)
+	return NewReviewService(NewPostService(postRepo, nil), reviewRepo, notificationRepo), postRepo, reviewRepo, notificationRepo
+}
+
+func TestReviewService_Submit(t *testing.T) {
+	service, postRepo, reviewRepo, notificationRepo := newReviewService()
+	ctx := context.Background()
+
+	post := &domain.Post{ID: 3, Title: "Hello", Content: "Body", AuthorID: 7, Status: domain.PostStatusDraft, ReviewerID: int64Ptr(2)}
+	postRepo.On("GetByID", ctx, int64(3)).Return(post, nil)
+	reviewRepo.On("CreateRevision", ctx, mock.MatchedBy(func(rev *domain.PostRevision) bool {
+		return rev.PostID == 3 && rev.Content == "Body" && rev.AuthorID == 7
+	})).Return(nil)
+	postRepo.On("Update", ctx, post).Return(nil)
+	notificationRepo.On("Create", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
+		return n.UserID == 2 && n.Link == "/posts/3/review"
+	})).Return(nil)
+
+	updated, err := service.Transition(ctx, 3, reviewAuthor, domain.PostStatusInReview, "")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PostStatusInReview, updated.Status)
+	reviewRepo.AssertExpectations(t)
+	notificationRepo.AssertExpectations(t)
+}
+
+func TestReviewService_Transition_UnitOfWork(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a failed notification keeps the transition", func(t *testing.T) {
+		service, postRepo, reviewRepo, notificationRepo := newReviewService()
+		tx := &recordingTransactor{}
+		service.SetTransactor(tx)
+
+		post := &domain.Post{ID: 3, Title: "Hello", AuthorID: 7, Status: domain.PostStatusDraft, ReviewerID: int64Ptr(2)}
+		postRepo.On("GetByID", ctx, int64(3)).Return(post, nil)
+		reviewRepo.On("CreateRevision", ctx, mock.Anything).Return(nil)
+		postRepo.On("Update", ctx, post).Return(nil)
+		notificationRepo.On("Create", ctx, mock.Anything).Return(errors.New("notifications unavailable"))
+
+		// The notification is a unit of work nested in the transition's
+		_, err := service.Transition(ctx, 3, reviewAuthor, domain.PostStatusInReview, "")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, tx.units)
+		assert.Equal(t, 2, tx.maxDepth)
+		assert.Len(t, tx.failed, 1)
+	})
+
+	t.Run("a failed update fails the revision with it", func(t *testing.T) {
+		service, postRepo, reviewRepo, notificationRepo := newReviewService()
+		tx := &recordingTransactor{}
+		service.SetTransactor(tx)
+
+		post := &domain.Post{ID: 3, Title: "Hello", AuthorID: 7, Status: domain.PostStatusDraft, ReviewerID: int64Ptr(2)}
+		postRepo.On("GetByID", ctx, int64(3)).Return(post, nil)
+		reviewRepo.On("CreateRevision", ctx, mock.Anything).Return(nil)
+		postRepo.On("Update", ctx, post).Return(sql.ErrConnDone)
+
+		// The revision was written in the unit of work that failed, so the
+		// transaction rolls it back
+		_, err := service.Transition(ctx, 3, reviewAuthor, domain.PostStatusInReview, "")
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Equal(t, 1, tx.units)
+		assert.Equal(t, []error{sql.ErrConnDone}, tx.failed)
+		reviewRepo.AssertExpectations(t)
+		notificationRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestReviewService_RequestChanges(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("records the decision and notifies the author", func(t *testing.T) {
+		service, postRepo, reviewRepo, notificationRepo := newReviewService()
+
+		post := &domain.Post{ID: 3, Title: "Hello", AuthorID: 7, Status: domain.PostStatusInReview}
+		postRepo.On("GetByID", ctx, int64(3)).Return(post, nil)
+		reviewRepo.On("LatestRevision", ctx, int64(3)).Return(&domain.PostRevision{ID: 11, Version: 2}, nil)
+		reviewRepo.On("CreateComment", ctx, mock.MatchedBy(func(c *domain.PostReviewComment) bool {
+			return c.RevisionID == 11 && c.AuthorID == 2 && c.Decision == domain.ReviewChangesRequested && c.Body == "Add a conclusion"
+		})).Return(nil)
+		postRepo.On("Update", ctx, post).Return(nil)
+		notificationRepo.On("Create", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == 7
+		})).Return(nil)
+
+		_, err := service.Transition(ctx, 3, reviewEditor, domain.PostStatusChangesRequested, " Add a conclusion ")
+		assert.NoError(t, err)
+		reviewRepo.AssertExpectations(t)
+		notificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("requires a comment", func(t *testing.T) {
+		service, postRepo, _, _ := newReviewService()
+
+		postRepo.On("GetByID", ctx, int64(3)).Return(&domain.Post{ID: 3, AuthorID: 7, Status: domain.PostStatusInReview}, nil)
+
+		_, err := service.Transition(ctx, 3, reviewEditor, domain.PostStatusChangesRequested, "  ")
+		assert.ErrorIs(t, err, ErrCommentRequired)
+		postRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestReviewService_Transition_EnforcesRoles(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		status domain.PostStatus
+		actor  domain.Actor
+		to     domain.PostStatus
+	}{
+		{"author approves own post", domain.PostStatusInReview, reviewAuthor, domain.PostStatusApproved},
+		{"author publishes", domain.PostStatusApproved, reviewAuthor, domain.PostStatusPublished},
+		{"stranger submits", domain.PostStatusDraft, domain.Actor{UserID: 9}, domain.PostStatusInReview},
+		{"editor publishes unreviewed changes", domain.PostStatusChangesRequested, reviewEditor, domain.PostStatusPublished},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, postRepo, _, _ := newReviewService()
+			postRepo.On("GetByID", ctx, int64(3)).Return(&domain.Post{ID: 3, AuthorID: 7, Status: tt.status}, nil)
+
+			_, err := service.Transition(ctx, 3, tt.actor, tt.to, "")
+			assert.ErrorIs(t, err, ErrNotAllowed)
+			postRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestReviewService_Publish(t *testing.T) {
+	service, postRepo, _, notificationRepo := newReviewService()
+	ctx := context.Background()
+
+	notified := 0
+	service.posts.OnPublishedChange(func() { notified++ })
+
+	post := &domain.Post{ID: 3, Slug: "hello", AuthorID: 7, Status: domain.PostStatusApproved}
+	postRepo.On("GetByID", ctx, int64(3)).Return(post, nil)
+	postRepo.On("Update", ctx, post).Return(nil)
+	notificationRepo.On("Create", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
+		return n.UserID == 7 && n.Link == "/posts/hello"
+	})).Return(nil)
+
+	_, err := service.Transition(ctx, 3, reviewEditor, domain.PostStatusPublished, "")
+	assert.NoError(t, err)
+	assert.NotNil(t, post.PublishedAt)
+	assert.Equal(t, 1, notified)
+	notificationRepo.AssertExpectations(t)
+}
+
+func TestReviewService_AssignReviewer(t *testing.T) {
+	service, postRepo, _, notificationRepo := newReviewService()
+	ctx := context.Background()
+
+	err := service.AssignReviewer(ctx, 3, reviewAuthor, int64Ptr(2))
+	assert.ErrorIs(t, err, ErrNotAllowed)
+
+	post := &domain.Post{ID: 3, AuthorID: 7, Status: domain.PostStatusInReview}
+	postRepo.On("GetByID", ctx, int64(3)).Return(post, nil)
+	postRepo.On("Update", ctx, post).Return(nil)
+	notificationRepo.On("Create", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
+		return n.UserID == 5
+	})).Return(nil)
+
+	assert.NoError(t, service.AssignReviewer(ctx, 3, reviewEditor, int64Ptr(5)))
+	assert.Equal(t, int64(5), *post.ReviewerID)
+	notificationRepo.AssertExpectations(t)
+}
+
+func TestReviewService_Conflict(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("transition", func(t *testing.T) {
+		service, postRepo, reviewRepo, notificationRepo := newReviewService()
+		post := &domain.Post{ID: 3, AuthorID: 7, Status: domain.PostStatusDraft}
+		postRepo.On("GetByID", ctx, int64(3)).Return(post, nil)
+		reviewRepo.On("CreateRevision", ctx, mock.Anything).Return(nil)
+		postRepo.On("Update", ctx, post).Return(sql.ErrNoRows)
+
+		_, err := service.Transition(ctx, 3, reviewAuthor, domain.PostStatusInReview, "")
+		assert.ErrorIs(t, err, ErrConflict)
+		notificationRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("assign reviewer", func(t *testing.T) {
+		service, postRepo, _, notificationRepo := newReviewService()
+		post := &domain.Post{ID: 3, AuthorID: 7, Status: domain.PostStatusInReview}
+		postRepo.On("GetByID", ctx, int64(3)).Return(post, nil)
+		postRepo.On("Update", ctx, post).Return(sql.ErrNoRows)
+
+		err := service.AssignReviewer(ctx, 3, reviewEditor, int64Ptr(5))
+		assert.ErrorIs(t, err, ErrConflict)
+		notificationRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestReviewService_Comment(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("before submission", func(t *testing.T) {
+		service, postRepo, reviewRepo, _ := newReviewService()
+		postRepo.On("GetByID", ctx, int64(3)).Return(&domain.Post{ID: 3, AuthorID: 7}, nil)
+		reviewRepo.On("LatestRevision", ctx, int64(3)).Return(nil, sql.ErrNoRows)
+
+		err := service.Comment(ctx, 3, reviewEditor, "Looks good so far")
+		assert.ErrorIs(t, err, ErrNotSubmitted)
+	})
+
+	t.Run("by a stranger", func(t *testing.T) {
+		service, postRepo, _, _ := newReviewService()
+		postRepo.On("GetByID", ctx, int64(3)).Return(&domain.Post{ID: 3, AuthorID: 7}, nil)
+
+		err := service.Comment(ctx, 3, domain.Actor{UserID: 9}, "Hi")
+		assert.ErrorIs(t, err, ErrNotAllowed)
+	})
+}
+
+func TestReviewService_History_NotSubmitted(t *testing.T) {
+	service, _, reviewRepo, _ := newReviewService()
+	ctx := context.Background()
+
+	reviewRepo.On("LatestRevision", ctx, int64(3)).Return(nil, sql.ErrNoRows)
+
+	rev, comments, err := service.History(ctx, 3)
+	assert.NoError(t, err)
+	assert.Nil(t, rev)
+	assert.Empty(t, comments)
+	reviewRepo.AssertNotCalled(t, "ListComments", mock.Anything, mock.Anything)
+}
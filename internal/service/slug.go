@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// maxSlugSuffix bounds how many numbered variants are tried before giving up.
+const maxSlugSuffix = 100
+
+type SlugHistoryRepository interface {
+	Record(ctx context.Context, contentType string, contentID int64, slug string) error
+	Release(ctx context.Context, contentType, slug string) error
+	FindContentID(ctx context.Context, contentType, slug string) (int64, error)
+	DeleteByContent(ctx context.Context, contentType string, contentID int64) error
+}
+
+// uniqueSlug returns base if it is free, otherwise the first free variant
+// with a numeric suffix (my-post-2, my-post-3, ...).
+func uniqueSlug(base string, taken func(slug string) (bool, error)) (string, error) {
+	for n := 1; n <= maxSlugSuffix; n++ {
+		candidate := base
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		inUse, err := taken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !inUse {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free slug available for %q", base)
+}
+
+// previousSlugID looks up the content that used to live at slug.
+func previousSlugID(ctx context.Context, slugs SlugHistoryRepository, contentType, slug string) (int64, error) {
+	if slugs == nil {
+		return 0, sql.ErrNoRows
+	}
+	return slugs.FindContentID(ctx, contentType, slug)
+}
+
+// moveSlug records oldSlug as history for the content and makes sure newSlug
+// no longer redirects anywhere.
+func moveSlug(ctx context.Context, slugs SlugHistoryRepository, contentType string, contentID int64, oldSlug, newSlug string) error {
+	if slugs == nil || oldSlug == newSlug {
+		return nil
+	}
+	if err := releaseSlug(ctx, slugs, contentType, newSlug); err != nil {
+		return err
+	}
+	return slugs.Record(ctx, contentType, contentID, oldSlug)
+}
+
+// releaseSlug drops any redirect for slug now that live content uses it.
+func releaseSlug(ctx context.Context, slugs SlugHistoryRepository, contentType, slug string) error {
+	if slugs == nil {
+		return nil
+	}
+	return slugs.Release(ctx, contentType, slug)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSlugHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockSlugHistoryRepository) Record(ctx context.Context, contentType string, contentID int64, slug string) error {
+	args := m.Called(ctx, contentType, contentID, slug)
+	return args.Error(0)
+}
+
+func (m *MockSlugHistoryRepository) Release(ctx context.Context, contentType, slug string) error {
+	args := m.Called(ctx, contentType, slug)
+	return args.Error(0)
+}
+
+func (m *MockSlugHistoryRepository) FindContentID(ctx context.Context, contentType, slug string) (int64, error) {
+	args := m.Called(ctx, contentType, slug)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSlugHistoryRepository) DeleteByContent(ctx context.Context, contentType string, contentID int64) error {
+	args := m.Called(ctx, contentType, contentID)
+	return args.Error(0)
+}
+
+func TestUniqueSlug(t *testing.T) {
+	taken := map[string]bool{"my-post": true, "my-post-2": true}
+
+	slug, err := uniqueSlug("my-post", func(s string) (bool, error) {
+		return taken[s], nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "my-post-3", slug)
+
+	slug, err = uniqueSlug("fresh", func(s string) (bool, error) {
+		return taken[s], nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "fresh", slug)
+}
+
+func TestUniqueSlug_LastSuffix(t *testing.T) {
+	// Everything but the last numbered variant is taken
+	var checked []string
+	slug, err := uniqueSlug("my-post", func(s string) (bool, error) {
+		checked = append(checked, s)
+		return s != fmt.Sprintf("my-post-%d", maxSlugSuffix), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("my-post-%d", maxSlugSuffix), slug)
+	assert.Len(t, checked, maxSlugSuffix)
+
+	// Nothing past it is tried
+	checked = nil
+	_, err = uniqueSlug("my-post", func(s string) (bool, error) {
+		checked = append(checked, s)
+		return true, nil
+	})
+	assert.Error(t, err)
+	assert.Len(t, checked, maxSlugSuffix)
+	assert.Equal(t, fmt.Sprintf("my-post-%d", maxSlugSuffix), checked[len(checked)-1])
+}
+
+func TestUniqueSlug_Errors(t *testing.T) {
+	lookupErr := errors.New("connection refused")
+	_, err := uniqueSlug("my-post", func(string) (bool, error) {
+		return false, lookupErr
+	})
+	assert.ErrorIs(t, err, lookupErr)
+
+	_, err = uniqueSlug("my-post", func(string) (bool, error) {
+		return true, nil
+	})
+	assert.Error(t, err)
+}
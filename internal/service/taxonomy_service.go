@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/helpers"
+)
+
+type TaxonomyRepository interface {
+	CreateCategory(ctx context.Context, category *domain.Category) error
+	GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error)
+	CreateTag(ctx context.Context, tag *domain.Tag) error
+	GetTagBySlug(ctx context.Context, slug string) (*domain.Tag, error)
+	SetPostCategories(ctx context.Context, postID int64, categoryIDs []int64) error
+	SetPostTags(ctx context.Context, postID int64, tagIDs []int64) error
+	ListCategoriesByPosts(ctx context.Context, postIDs []int64) (map[int64][]*domain.Category, error)
+	ListTagsByPosts(ctx context.Context, postIDs []int64) (map[int64][]*domain.Tag, error)
+	ListPopularCategories(ctx context.Context, limit int) ([]*domain.TermCount, error)
+	ListPopularTags(ctx context.Context, limit int) ([]*domain.TermCount, error)
+}
+
+type TaxonomyService struct {
+	repo TaxonomyRepository
+}
+
+func NewTaxonomyService(repo TaxonomyRepository) *TaxonomyService {
+	return &TaxonomyService{repo: repo}
+}
+
+// ParseTerms splits a comma-separated list of category or tag names,
+// dropping blanks and names that map to the same slug.
+func ParseTerms(input string) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, name := range strings.Split(input, ",") {
+		name = strings.TrimSpace(name)
+		slug := helpers.GenerateSlug(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func (s *TaxonomyService) GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	return s.repo.GetCategoryBySlug(ctx, slug)
+}
+
+func (s *TaxonomyService) GetTagBySlug(ctx context.Context, slug string) (*domain.Tag, error) {
+	return s.repo.GetTagBySlug(ctx, slug)
+}
+
+// SetPostCategories assigns the named categories to a post, creating any
+// that do not exist yet.
+func (s *TaxonomyService) SetPostCategories(ctx context.Context, postID int64, names []string) error {
+	var ids []int64
+
+	for _, name := range ParseTerms(strings.Join(names, ",")) {
+		slug := helpers.GenerateSlug(name)
+
+		category, err := s.repo.GetCategoryBySlug(ctx, slug)
+		if errors.Is(err, sql.ErrNoRows) {
+			category = &domain.Category{Name: name, Slug: slug}
+			err = s.repo.CreateCategory(ctx, category)
+		}
+		if err != nil {
+			return err
+		}
+
+		ids = append(ids, category.ID)
+	}
+
+	return s.repo.SetPostCategories(ctx, postID, ids)
+}
+
+// SetPostTags assigns the named tags to a post, creating any that do not
+// exist yet.
+func (s *TaxonomyService) SetPostTags(ctx context.Context, postID int64, names []string) error {
+	var ids []int64
+
+	for _, name := range ParseTerms(strings.Join(names, ",")) {
+		slug := helpers.GenerateSlug(name)
+
+		tag, err := s.repo.GetTagBySlug(ctx, slug)
+		if errors.Is(err, sql.ErrNoRows) {
+			tag = &domain.Tag{Name: name, Slug: slug}
+			err = s.repo.CreateTag(ctx, tag)
+		}
+		if err != nil {
+			return err
+		}
+
+		ids = append(ids, tag.ID)
+	}
+
+	return s.repo.SetPostTags(ctx, postID, ids)
+}
+
+// CategoriesForPosts returns the categories of each post, keyed by post ID.
+func (s *TaxonomyService) CategoriesForPosts(ctx context.Context, posts []*domain.Post) (map[int64][]*domain.Category, error) {
+	return s.repo.ListCategoriesByPosts(ctx, postIDs(posts))
+}
+
+// TagsForPosts returns the tags of each post, keyed by post ID.
+func (s *TaxonomyService) TagsForPosts(ctx context.Context, posts []*domain.Post) (map[int64][]*domain.Tag, error) {
+	return s.repo.ListTagsByPosts(ctx, postIDs(posts))
+}
+
+// PopularCategories returns up to limit categories, most used by published posts first.
+func (s *TaxonomyService) PopularCategories(ctx context.Context, limit int) ([]*domain.TermCount, error) {
+	return s.repo.ListPopularCategories(ctx, limit)
+}
+
+// PopularTags returns up to limit tags, most used by published posts first.
+func (s *TaxonomyService) PopularTags(ctx context.Context, limit int) ([]*domain.TermCount, error) {
+	return s.repo.ListPopularTags(ctx, limit)
+}
+
+func postIDs(posts []*domain.Post) []int64 {
+	ids := make([]int64, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	return ids
+}
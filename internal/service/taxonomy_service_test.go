@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/toutaio/toutago-starter-kit-basic/internal/domain"
+)
+
+type MockTaxonomyRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaxonomyRepository) CreateCategory(ctx context.Context, category *domain.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockTaxonomyRepository) GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	args := m.Called(ctx, slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Category), args.Error(1)
+}
+
+func (m *MockTaxonomyRepository) CreateTag(ctx context.Context, tag *domain.Tag) error {
+	args := m.Called(ctx, tag)
+	return args.Error(0)
+}
+
+func (m *MockTaxonomyRepository) GetTagBySlug(ctx context.Context, slug string) (*domain.Tag, error) {
+	args := m.Called(ctx, slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Tag), args.Error(1)
+}
+
+func (m *MockTaxonomyRepository) SetPostCategories(ctx context.Context, postID int64, categoryIDs []int64) error {
+	args := m.Called(ctx, postID, categoryIDs)
+	return args.Error(0)
+}
+
+func (m *MockTaxonomyRepository) SetPostTags(ctx context.Context, postID int64, tagIDs []int64) error {
+	args := m.Called(ctx, postID, tagIDs)
+	return args.Error(0)
+}
+
+func (m *MockTaxonomyRepository) ListCategoriesByPosts(ctx context.Context, postIDs []int64) (map[int64][]*domain.Category, error) {
+	args := m.Called(ctx, postIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64][]*domain.Category), args.Error(1)
+}
+
+func (m *MockTaxonomyRepository) ListTagsByPosts(ctx context.Context, postIDs []int64) (map[int64][]*domain.Tag, error) {
+	args := m.Called(ctx, postIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64][]*domain.Tag), args.Error(1)
+}
+
+func (m *MockTaxonomyRepository) ListPopularCategories(ctx context.Context, limit int) ([]*domain.TermCount, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.TermCount), args.Error(1)
+}
+
+func (m *MockTaxonomyRepository) ListPopularTags(ctx context.Context, limit int) ([]*domain.TermCount, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.TermCount), args.Error(1)
+}
+
+func TestParseTerms(t *testing.T) {
+	assert.Equal(t, []string{"Go", "Web Dev"}, ParseTerms(" Go, ,Web Dev, go ,"))
+	assert.Empty(t, ParseTerms(""))
+}
+
+func TestTaxonomyService_SetPostCategories(t *testing.T) {
+	repo := new(MockTaxonomyRepository)
+	service := NewTaxonomyService(repo)
+	ctx := context.Background()
+
+	repo.On("GetCategoryBySlug", ctx, "go").Return(&domain.Category{ID: 1, Name: "Go", Slug: "go"}, nil)
+	repo.On("GetCategoryBySlug", ctx, "web-dev").Return(nil, sql.ErrNoRows)
+	repo.On("CreateCategory", ctx, mock.MatchedBy(func(c *domain.Category) bool {
+		return c.Name == "Web Dev" && c.Slug == "web-dev"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Category).ID = 2
+	}).Return(nil)
+	repo.On("SetPostCategories", ctx, int64(5), []int64{1, 2}).Return(nil)
+
+	err := service.SetPostCategories(ctx, 5, []string{"Go", "Web Dev"})
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestTaxonomyService_SetPostTags_LookupError(t *testing.T) {
+	repo := new(MockTaxonomyRepository)
+	service := NewTaxonomyService(repo)
+	ctx := context.Background()
+
+	repo.On("GetTagBySlug", ctx, "go").Return(nil, assert.AnError)
+
+	err := service.SetPostTags(ctx, 5, []string{"Go"})
+	assert.ErrorIs(t, err, assert.AnError)
+	repo.AssertNotCalled(t, "SetPostTags", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTaxonomyService_TagsForPosts(t *testing.T) {
+	repo := new(MockTaxonomyRepository)
+	service := NewTaxonomyService(repo)
+	ctx := context.Background()
+
+	expected := map[int64][]*domain.Tag{1: {{ID: 3, Name: "Go", Slug: "go"}}}
+	repo.On("ListTagsByPosts", ctx, []int64{1, 2}).Return(expected, nil)
+
+	tags, err := service.TagsForPosts(ctx, []*domain.Post{{ID: 1}, {ID: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, tags)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,18 @@
+package service
+
+import "context"
+
+// Transactor runs fn as a unit of work: what the repositories do with the
+// context fn is given is committed only if fn returns nil. *txn.TxManager
+// is the one backed by the database.
+type Transactor interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// noTransactor runs units of work step by step, for services whose
+// repositories share no transaction, such as in tests
+type noTransactor struct{}
+
+func (noTransactor) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
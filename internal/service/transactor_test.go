@@ -0,0 +1,25 @@
+package service
+
+import "context"
+
+// recordingTransactor runs units of work like noTransactor and records how
+// they nested and which failed, standing in for the database's transactions
+type recordingTransactor struct {
+	depth    int
+	maxDepth int
+	units    int
+	failed   []error
+}
+
+func (r *recordingTransactor) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	r.units++
+	r.depth++
+	r.maxDepth = max(r.maxDepth, r.depth)
+	defer func() { r.depth-- }()
+
+	err := fn(ctx)
+	if err != nil {
+		r.failed = append(r.failed, err)
+	}
+	return err
+}
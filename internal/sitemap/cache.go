@@ -0,0 +1,35 @@
+package sitemap
+
+import "sync"
+
+// Cache holds the most recently built sitemap until it is invalidated.
+type Cache struct {
+	mu  sync.Mutex
+	set *Set
+}
+
+// Get returns the cached sitemap, calling build to generate it if the cache
+// is empty. Failed builds are not cached.
+func (c *Cache) Get(build func() (*Set, error)) (*Set, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.set != nil {
+		return c.set, nil
+	}
+
+	set, err := build()
+	if err != nil {
+		return nil, err
+	}
+	c.set = set
+
+	return set, nil
+}
+
+// Invalidate drops the cached sitemap so the next Get rebuilds it.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	c.set = nil
+	c.mu.Unlock()
+}
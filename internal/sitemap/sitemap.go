@@ -0,0 +1,159 @@
+// Package sitemap builds XML sitemaps, sitemap indexes and robots.txt files.
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxURLs is the largest number of URLs a single sitemap may list
+// (sitemaps.org protocol limit).
+const MaxURLs = 50000
+
+// ContentType is the content type of sitemap documents.
+const ContentType = "application/xml; charset=utf-8"
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL is a single sitemap entry.
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// Set is a generated sitemap. Root is served at /sitemap.xml; when the URLs
+// do not fit in one sitemap Root is an index and Parts holds the sitemaps it
+// references, served at PartPath(n).
+type Set struct {
+	Root         []byte
+	Parts        [][]byte
+	LastModified time.Time
+}
+
+// PartPath returns the path of the nth (1-based) sitemap of an index.
+func PartPath(n int) string {
+	return fmt.Sprintf("/sitemaps/%d.xml", n)
+}
+
+type urlSet struct {
+	XMLName xml.Name   `xml:"urlset"`
+	XMLNS   string     `xml:"xmlns,attr"`
+	URLs    []urlEntry `xml:"url"`
+}
+
+type urlEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name   `xml:"sitemapindex"`
+	XMLNS    string     `xml:"xmlns,attr"`
+	Sitemaps []urlEntry `xml:"sitemap"`
+}
+
+// Build generates the sitemap for urls, splitting it into an index of
+// sitemaps of at most perSitemap URLs once there are more than that.
+// baseURL is used to make the index entries absolute.
+func Build(baseURL string, urls []URL, perSitemap int) (*Set, error) {
+	if perSitemap <= 0 || perSitemap > MaxURLs {
+		perSitemap = MaxURLs
+	}
+
+	set := &Set{LastModified: latest(urls)}
+
+	if len(urls) <= perSitemap {
+		root, err := encodeURLSet(urls)
+		if err != nil {
+			return nil, err
+		}
+		set.Root = root
+		return set, nil
+	}
+
+	index := sitemapIndex{XMLNS: namespace}
+	for start := 0; start < len(urls); start += perSitemap {
+		end := start + perSitemap
+		if end > len(urls) {
+			end = len(urls)
+		}
+		chunk := urls[start:end]
+
+		part, err := encodeURLSet(chunk)
+		if err != nil {
+			return nil, err
+		}
+		set.Parts = append(set.Parts, part)
+
+		index.Sitemaps = append(index.Sitemaps, urlEntry{
+			Loc:     strings.TrimRight(baseURL, "/") + PartPath(len(set.Parts)),
+			LastMod: w3cDate(latest(chunk)),
+		})
+	}
+
+	root, err := encodeXML(index)
+	if err != nil {
+		return nil, err
+	}
+	set.Root = root
+
+	return set, nil
+}
+
+// Robots renders a robots.txt that allows or blocks all crawlers, disallows
+// the given paths and points to the sitemap.
+func Robots(allowIndexing bool, disallow []string, sitemapURL string) []byte {
+	var b strings.Builder
+
+	b.WriteString("User-agent: *\n")
+	if !allowIndexing {
+		b.WriteString("Disallow: /\n")
+	} else if len(disallow) == 0 {
+		b.WriteString("Disallow:\n")
+	} else {
+		for _, path := range disallow {
+			b.WriteString("Disallow: " + path + "\n")
+		}
+	}
+
+	if sitemapURL != "" {
+		b.WriteString("\nSitemap: " + sitemapURL + "\n")
+	}
+
+	return []byte(b.String())
+}
+
+func encodeURLSet(urls []URL) ([]byte, error) {
+	doc := urlSet{XMLNS: namespace, URLs: make([]urlEntry, 0, len(urls))}
+	for _, u := range urls {
+		doc.URLs = append(doc.URLs, urlEntry{Loc: u.Loc, LastMod: w3cDate(u.LastMod)})
+	}
+	return encodeXML(doc)
+}
+
+func encodeXML(v interface{}) ([]byte, error) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func w3cDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func latest(urls []URL) time.Time {
+	var t time.Time
+	for _, u := range urls {
+		if u.LastMod.After(t) {
+			t = u.LastMod
+		}
+	}
+	return t
+}
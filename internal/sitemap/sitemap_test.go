@@ -0,0 +1,127 @@
+package sitemap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testURLs(n int) []URL {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	urls := make([]URL, n)
+	for i := range urls {
+		urls[i] = URL{
+			Loc:     fmt.Sprintf("https://example.com/posts/post-%d", i),
+			LastMod: base.Add(time.Duration(i) * time.Hour),
+		}
+	}
+	return urls
+}
+
+func TestBuild_SingleSitemap(t *testing.T) {
+	set, err := Build("https://example.com", testURLs(3), 0)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	root := string(set.Root)
+	if !strings.Contains(root, "<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">") {
+		t.Errorf("expected urlset root, got %s", root)
+	}
+	if strings.Count(root, "<url>") != 3 {
+		t.Errorf("expected 3 urls, got %s", root)
+	}
+	if !strings.Contains(root, "<lastmod>2026-01-01T02:00:00Z</lastmod>") {
+		t.Errorf("expected lastmod of last url, got %s", root)
+	}
+	if len(set.Parts) != 0 {
+		t.Errorf("expected no parts, got %d", len(set.Parts))
+	}
+	if want := time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC); !set.LastModified.Equal(want) {
+		t.Errorf("LastModified = %v, want %v", set.LastModified, want)
+	}
+}
+
+func TestBuild_Index(t *testing.T) {
+	set, err := Build("https://example.com/", testURLs(5), 2)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if len(set.Parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(set.Parts))
+	}
+
+	root := string(set.Root)
+	if !strings.Contains(root, "<sitemapindex") {
+		t.Errorf("expected sitemap index, got %s", root)
+	}
+	for n := 1; n <= 3; n++ {
+		if !strings.Contains(root, "<loc>https://example.com"+PartPath(n)+"</loc>") {
+			t.Errorf("expected index entry for part %d, got %s", n, root)
+		}
+	}
+	if strings.Count(string(set.Parts[2]), "<url>") != 1 {
+		t.Errorf("expected last part to hold 1 url, got %s", set.Parts[2])
+	}
+}
+
+func TestBuild_EmptyHasURLSet(t *testing.T) {
+	set, err := Build("https://example.com", nil, 0)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if !strings.Contains(string(set.Root), "<urlset") {
+		t.Errorf("expected empty urlset, got %s", set.Root)
+	}
+}
+
+func TestRobots(t *testing.T) {
+	got := string(Robots(true, []string{"/dashboard", "/admin"}, "https://example.com/sitemap.xml"))
+	want := "User-agent: *\nDisallow: /dashboard\nDisallow: /admin\n\nSitemap: https://example.com/sitemap.xml\n"
+	if got != want {
+		t.Errorf("Robots() = %q, want %q", got, want)
+	}
+
+	got = string(Robots(false, []string{"/dashboard"}, "https://example.com/sitemap.xml"))
+	if !strings.Contains(got, "Disallow: /\n") || strings.Contains(got, "/dashboard") {
+		t.Errorf("expected everything disallowed, got %q", got)
+	}
+
+	got = string(Robots(true, nil, ""))
+	if got != "User-agent: *\nDisallow:\n" {
+		t.Errorf("Robots() = %q", got)
+	}
+}
+
+func TestCache(t *testing.T) {
+	var c Cache
+	builds := 0
+	build := func() (*Set, error) {
+		builds++
+		return &Set{Root: []byte("x")}, nil
+	}
+
+	c.Get(build)
+	c.Get(build)
+	if builds != 1 {
+		t.Errorf("expected 1 build, got %d", builds)
+	}
+
+	c.Invalidate()
+	c.Get(build)
+	if builds != 2 {
+		t.Errorf("expected rebuild after invalidation, got %d builds", builds)
+	}
+
+	c.Invalidate()
+	if _, err := c.Get(func() (*Set, error) { return nil, errors.New("boom") }); err == nil {
+		t.Error("expected build error")
+	}
+	c.Get(build)
+	if builds != 3 {
+		t.Errorf("expected failed builds not to be cached, got %d builds", builds)
+	}
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocal_PutGetStatDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocal(t.TempDir(), "/static")
+
+	if err := s.Put(ctx, "/uploads/2026/01/a.png", strings.NewReader("png"), 3, "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	obj, err := s.Stat(ctx, "uploads/2026/01/a.png")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if obj.Key != "uploads/2026/01/a.png" || obj.Size != 3 || obj.ContentType != "image/png" {
+		t.Errorf("unexpected object %+v", obj)
+	}
+
+	r, err := s.Get(ctx, "/uploads/2026/01/a.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "png" {
+		t.Errorf("expected stored content, got %q", data)
+	}
+
+	if got := s.URL("/uploads/2026/01/a.png"); got != "/static/uploads/2026/01/a.png" {
+		t.Errorf("URL = %q", got)
+	}
+
+	if err := s.Delete(ctx, "/uploads/2026/01/a.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(ctx, "/uploads/2026/01/a.png"); err != nil {
+		t.Errorf("expected deleting a missing file to succeed, got %v", err)
+	}
+	if _, err := s.Stat(ctx, "/uploads/2026/01/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := s.Get(ctx, "/uploads/2026/01/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestLocal_RejectsEscapingKeys(t *testing.T) {
+	s := NewLocal(t.TempDir(), "/static")
+
+	for _, key := range []string{"", "/", "../secret", "uploads/../../secret"} {
+		err := s.Put(context.Background(), key, strings.NewReader("x"), 1, "")
+		if !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	src := NewLocal(t.TempDir(), "/static")
+	dst := NewLocal(t.TempDir(), "/static")
+
+	src.Put(ctx, "uploads/a.png", strings.NewReader("aaa"), 3, "image/png")
+	src.Put(ctx, "uploads/2026/b.jpg", strings.NewReader("bb"), 2, "image/jpeg")
+	src.Put(ctx, "css/site.css", strings.NewReader("body{}"), 6, "text/css")
+	dst.Put(ctx, "uploads/a.png", strings.NewReader("aaa"), 3, "image/png")
+
+	var keys []string
+	copied, err := Copy(ctx, src, dst, "uploads", func(key string) { keys = append(keys, key) })
+	if err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	if copied != 1 || len(keys) != 1 || keys[0] != "uploads/2026/b.jpg" {
+		t.Errorf("expected only the missing upload to be copied, got %d %v", copied, keys)
+	}
+	if _, err := dst.Stat(ctx, "css/site.css"); !errors.Is(err, ErrNotFound) {
+		t.Error("expected files outside the prefix to be left alone")
+	}
+}
+
+func TestSignedURL_FallsBackToPublicURL(t *testing.T) {
+	got, err := SignedURL(context.Background(), NewLocal(t.TempDir(), "/static"), "uploads/a.png", 0)
+	if err != nil || got != "/static/uploads/a.png" {
+		t.Errorf("SignedURL = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+)
+
+// fakeS3 is just enough of the S3 API, with path-style addressing, to run
+// the driver against.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Paths are /bucket/key
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	key := ""
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+
+	switch {
+	case r.Method == http.MethodGet && key == "" && r.URL.Query().Get("list-type") == "2":
+		f.list(w, r.URL.Query().Get("prefix"))
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			data = decodeChunked(data)
+		}
+		f.objects[key] = data
+		f.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"etag"`)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix string) {
+	type content struct {
+		Key          string
+		Size         int64
+		LastModified string
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		IsTruncated bool
+		Contents    []content
+	}{Name: "media"}
+
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		result.Contents = append(result.Contents, content{Key: k, Size: int64(len(f.objects[k])), LastModified: time.Now().UTC().Format(time.RFC3339)})
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// decodeChunked strips aws-chunked framing: "<hex size>;chunk-signature=...\r\n<data>\r\n".
+func decodeChunked(data []byte) []byte {
+	var out bytes.Buffer
+	r := bufio.NewReader(bytes.NewReader(data))
+	for {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return out.Bytes()
+		}
+		size, _ := strconv.ParseInt(strings.SplitN(strings.TrimSpace(header), ";", 2)[0], 16, 64)
+		if size == 0 {
+			return out.Bytes()
+		}
+		io.CopyN(&out, r, size)
+		r.ReadString('\n')
+	}
+}
+
+func newTestS3(t *testing.T, publicURL string) (*S3, *fakeS3) {
+	t.Helper()
+
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	s, err := NewS3(config.S3Config{
+		Endpoint:  u.Host,
+		Region:    "us-east-1",
+		Bucket:    "media",
+		AccessKey: "minio",
+		SecretKey: "minio123",
+		PathStyle: true,
+		PublicURL: publicURL,
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	return s, fake
+}
+
+func TestS3_PutGetStatDelete(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newTestS3(t, "")
+
+	if err := s.Put(ctx, "/uploads/a.png", strings.NewReader("png"), 3, "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if string(fake.objects["uploads/a.png"]) != "png" || fake.types["uploads/a.png"] != "image/png" {
+		t.Fatalf("expected the object to be stored under its key, got %v", fake.objects)
+	}
+
+	obj, err := s.Stat(ctx, "/uploads/a.png")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if obj.Size != 3 || obj.ContentType != "image/png" {
+		t.Errorf("unexpected object %+v", obj)
+	}
+
+	r, err := s.Get(ctx, "uploads/a.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "png" {
+		t.Errorf("expected stored content, got %q", data)
+	}
+
+	var walked []string
+	if err := s.Walk(ctx, "uploads", func(o Object) error { walked = append(walked, o.Key); return nil }); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if len(walked) != 1 || walked[0] != "uploads/a.png" {
+		t.Errorf("Walk found %v", walked)
+	}
+
+	if err := s.Delete(ctx, "/uploads/a.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Stat(ctx, "/uploads/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := s.Get(ctx, "/uploads/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestS3_URLs(t *testing.T) {
+	s, _ := newTestS3(t, "https://cdn.example.com/")
+	if got := s.URL("/uploads/a.png"); got != "https://cdn.example.com/uploads/a.png" {
+		t.Errorf("URL = %q", got)
+	}
+
+	signed, err := SignedURL(context.Background(), s, "/uploads/a.png", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	u, _ := url.Parse(signed)
+	if u.Path != "/media/uploads/a.png" || u.Query().Get("X-Amz-Expires") != "600" || u.Query().Get("X-Amz-Signature") == "" {
+		t.Errorf("expected a presigned URL for the object, got %s", signed)
+	}
+}
@@ -0,0 +1,132 @@
+// Package storage keeps uploaded files behind a common interface so they can
+// live on local disk or in an S3-compatible bucket such as AWS S3 or MinIO.
+//
+// Keys are slash-separated paths without a leading slash, for example
+// uploads/2026/01/<uuid>.jpg.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/toutaio/toutago-starter-kit-basic/internal/config"
+)
+
+// Driver names accepted by New.
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	// ErrNotFound is returned when no object exists under a key.
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrInvalidKey is returned for empty keys and keys escaping the root.
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+// Object describes a stored file.
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage saves and serves uploaded files.
+type Storage interface {
+	// Put stores size bytes from r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object under key. The caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object under key. Deleting a missing key is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL browsers load the object from.
+	URL(key string) string
+	// Stat describes the object under key.
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Walk calls fn for every object whose key starts with prefix.
+	Walk(ctx context.Context, prefix string, fn func(Object) error) error
+}
+
+// Presigner is implemented by backends that can hand out temporary URLs to
+// objects that are not public.
+type Presigner interface {
+	PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// New creates the storage backend named by driver from cfg.
+func New(cfg config.StorageConfig, driver string) (Storage, error) {
+	switch driver {
+	case DriverLocal:
+		return NewLocal(cfg.LocalRoot, cfg.LocalURL), nil
+	case DriverS3:
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", driver)
+	}
+}
+
+// SignedURL returns a presigned URL for key when the backend supports them
+// and its public URL otherwise.
+func SignedURL(ctx context.Context, s Storage, key string, expiry time.Duration) (string, error) {
+	if p, ok := s.(Presigner); ok {
+		return p.PresignedURL(ctx, key, expiry)
+	}
+	return s.URL(key), nil
+}
+
+// Copy copies every object under prefix from src to dst, returning the
+// number copied. Objects dst already has with the same size are skipped, so
+// an interrupted copy can be resumed.
+func Copy(ctx context.Context, src, dst Storage, prefix string, progress func(key string)) (int, error) {
+	copied := 0
+	err := src.Walk(ctx, prefix, func(obj Object) error {
+		if existing, err := dst.Stat(ctx, obj.Key); err == nil && existing.Size == obj.Size {
+			return nil
+		} else if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		r, err := src.Get(ctx, obj.Key)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", obj.Key, err)
+		}
+		defer r.Close()
+
+		if err := dst.Put(ctx, obj.Key, r, obj.Size, obj.ContentType); err != nil {
+			return fmt.Errorf("writing %s: %w", obj.Key, err)
+		}
+
+		copied++
+		if progress != nil {
+			progress(obj.Key)
+		}
+		return nil
+	})
+	return copied, err
+}
+
+// cleanKey normalises a key, rejecting ones that would escape the root.
+func cleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(strings.ReplaceAll(key, `\`, "/"), "/")
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %s", ErrInvalidKey, key)
+	}
+	return cleaned, nil
+}
+
+// joinURL appends a key to a base URL.
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimPrefix(key, "/")
+}
@@ -29,8 +29,7 @@ func (m *Migration_20260113000001_CreateUsersTable) Description() string {
 func (m *Migration_20260113000001_CreateUsersTable) Up(adapter sil.DatabaseAdapter) error {
 	ctx := context.Background()
 
-	// Try PostgreSQL syntax first
-	err := adapter.Exec(ctx, `
+	err := execDialect(ctx, adapter, `
 		CREATE TABLE IF NOT EXISTS users (
 			id SERIAL PRIMARY KEY,
 			email VARCHAR(255) UNIQUE NOT NULL,
@@ -41,24 +40,19 @@ func (m *Migration_20260113000001_CreateUsersTable) Up(adapter sil.DatabaseAdapt
 			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 		)
+	`, `
+		CREATE TABLE IF NOT EXISTS users (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			email VARCHAR(255) UNIQUE NOT NULL,
+			password_hash VARCHAR(255) NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			role VARCHAR(50) NOT NULL DEFAULT 'user',
+			email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
 	`)
-	
-	if err != nil {
-		// Try MySQL syntax
-		err = adapter.Exec(ctx, `
-			CREATE TABLE IF NOT EXISTS users (
-				id INT AUTO_INCREMENT PRIMARY KEY,
-				email VARCHAR(255) UNIQUE NOT NULL,
-				password_hash VARCHAR(255) NOT NULL,
-				name VARCHAR(255) NOT NULL,
-				role VARCHAR(50) NOT NULL DEFAULT 'user',
-				email_verified BOOLEAN NOT NULL DEFAULT FALSE,
-				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-				updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
-			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
-		`)
-	}
-	
+
 	if err != nil {
 		return err
 	}
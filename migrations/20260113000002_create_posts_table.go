@@ -29,8 +29,7 @@ func (m *Migration_20260113000002_CreatePostsTable) Description() string {
 func (m *Migration_20260113000002_CreatePostsTable) Up(adapter sil.DatabaseAdapter) error {
 	ctx := context.Background()
 
-	// Try PostgreSQL syntax first
-	err := adapter.Exec(ctx, `
+	err := execDialect(ctx, adapter, `
 		CREATE TABLE IF NOT EXISTS posts (
 			id SERIAL PRIMARY KEY,
 			title VARCHAR(255) NOT NULL,
@@ -44,27 +43,22 @@ func (m *Migration_20260113000002_CreatePostsTable) Up(adapter sil.DatabaseAdapt
 			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
 		)
+	`, `
+		CREATE TABLE IF NOT EXISTS posts (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			title VARCHAR(255) NOT NULL,
+			slug VARCHAR(255) UNIQUE NOT NULL,
+			content TEXT NOT NULL,
+			excerpt TEXT,
+			status VARCHAR(50) NOT NULL DEFAULT 'draft',
+			author_id INT NOT NULL,
+			published_at TIMESTAMP NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
 	`)
-	
-	if err != nil {
-		// Try MySQL syntax
-		err = adapter.Exec(ctx, `
-			CREATE TABLE IF NOT EXISTS posts (
-				id INT AUTO_INCREMENT PRIMARY KEY,
-				title VARCHAR(255) NOT NULL,
-				slug VARCHAR(255) UNIQUE NOT NULL,
-				content TEXT NOT NULL,
-				excerpt TEXT,
-				status VARCHAR(50) NOT NULL DEFAULT 'draft',
-				author_id INT NOT NULL,
-				published_at TIMESTAMP NULL,
-				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-				updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-				FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
-			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
-		`)
-	}
-	
+
 	if err != nil {
 		return err
 	}
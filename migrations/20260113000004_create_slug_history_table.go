@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000004_CreateSlugHistoryTable{})
+}
+
+// Migration_20260113000004_CreateSlugHistoryTable creates the table of previous post and page slugs
+type Migration_20260113000004_CreateSlugHistoryTable struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000004_CreateSlugHistoryTable) Version() string {
+	return "20260113000004"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000004_CreateSlugHistoryTable) Description() string {
+	return "create slug history table"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000004_CreateSlugHistoryTable) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	err := execDialect(ctx, adapter, `
+		CREATE TABLE IF NOT EXISTS slug_history (
+			id SERIAL PRIMARY KEY,
+			content_type VARCHAR(20) NOT NULL,
+			content_id INT NOT NULL,
+			slug VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (content_type, slug)
+		)
+	`, `
+		CREATE TABLE IF NOT EXISTS slug_history (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			content_type VARCHAR(20) NOT NULL,
+			content_id INT NOT NULL,
+			slug VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uq_slug_history_slug (content_type, slug)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`)
+
+	if err != nil {
+		return err
+	}
+
+	// Create indexes
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_slug_history_content ON slug_history(content_type, content_id)`)
+
+	return nil
+}
+
+// Down reverts the migration
+func (m *Migration_20260113000004_CreateSlugHistoryTable) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+	return adapter.Exec(ctx, `DROP TABLE IF EXISTS slug_history`)
+}
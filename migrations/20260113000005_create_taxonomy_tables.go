@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000005_CreateTaxonomyTables{})
+}
+
+// Migration_20260113000005_CreateTaxonomyTables creates the categories and tags tables
+type Migration_20260113000005_CreateTaxonomyTables struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000005_CreateTaxonomyTables) Version() string {
+	return "20260113000005"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000005_CreateTaxonomyTables) Description() string {
+	return "create categories, tags and post taxonomy tables"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000005_CreateTaxonomyTables) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	for _, table := range []string{"categories", "tags"} {
+		err := execDialect(ctx, adapter, `
+			CREATE TABLE IF NOT EXISTS `+table+` (
+				id SERIAL PRIMARY KEY,
+				name VARCHAR(100) NOT NULL,
+				slug VARCHAR(100) UNIQUE NOT NULL,
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)
+		`, `
+			CREATE TABLE IF NOT EXISTS `+table+` (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				name VARCHAR(100) NOT NULL,
+				slug VARCHAR(100) UNIQUE NOT NULL,
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+		`)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	links := []struct{ table, column, parent string }{
+		{"post_categories", "category_id", "categories"},
+		{"post_tags", "tag_id", "tags"},
+	}
+
+	for _, link := range links {
+		err := adapter.Exec(ctx, `
+			CREATE TABLE IF NOT EXISTS `+link.table+` (
+				post_id INT NOT NULL,
+				`+link.column+` INT NOT NULL,
+				PRIMARY KEY (post_id, `+link.column+`),
+				FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+				FOREIGN KEY (`+link.column+`) REFERENCES `+link.parent+`(id) ON DELETE CASCADE
+			)
+		`)
+
+		if err != nil {
+			return err
+		}
+
+		// Create indexes
+		adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_`+link.table+`_`+link.column+` ON `+link.table+`(`+link.column+`)`)
+	}
+
+	return nil
+}
+
+// Down reverts the migration
+func (m *Migration_20260113000005_CreateTaxonomyTables) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	for _, table := range []string{"post_tags", "post_categories", "tags", "categories"} {
+		if err := adapter.Exec(ctx, `DROP TABLE IF EXISTS `+table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
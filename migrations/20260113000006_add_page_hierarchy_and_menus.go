@@ -0,0 +1,86 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000006_AddPageHierarchyAndMenus{})
+}
+
+// Migration_20260113000006_AddPageHierarchyAndMenus adds page parents and ordering and the menu_items table
+type Migration_20260113000006_AddPageHierarchyAndMenus struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000006_AddPageHierarchyAndMenus) Version() string {
+	return "20260113000006"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000006_AddPageHierarchyAndMenus) Description() string {
+	return "add page hierarchy and navigation menus"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000006_AddPageHierarchyAndMenus) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	if err := addReference(ctx, adapter, "pages", "parent_id", "fk_pages_parent", "pages(id) ON DELETE SET NULL"); err != nil {
+		return err
+	}
+	if err := adapter.Exec(ctx, `ALTER TABLE pages ADD COLUMN sort_order INT NOT NULL DEFAULT 0`); err != nil {
+		return err
+	}
+
+	err := execDialect(ctx, adapter, `
+		CREATE TABLE IF NOT EXISTS menu_items (
+			id SERIAL PRIMARY KEY,
+			location VARCHAR(20) NOT NULL,
+			label VARCHAR(100) NOT NULL DEFAULT '',
+			page_id INT NULL REFERENCES pages(id) ON DELETE CASCADE,
+			url VARCHAR(500) NOT NULL DEFAULT '',
+			sort_order INT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`, `
+		CREATE TABLE IF NOT EXISTS menu_items (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			location VARCHAR(20) NOT NULL,
+			label VARCHAR(100) NOT NULL DEFAULT '',
+			page_id INT NULL,
+			url VARCHAR(500) NOT NULL DEFAULT '',
+			sort_order INT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (page_id) REFERENCES pages(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`)
+
+	if err != nil {
+		return err
+	}
+
+	// Create indexes
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_pages_parent_id ON pages(parent_id, sort_order)`)
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_menu_items_location ON menu_items(location, sort_order)`)
+
+	return nil
+}
+
+// Down reverts the migration
+func (m *Migration_20260113000006_AddPageHierarchyAndMenus) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	// Dropping the reference goes first as SQLite refuses to
+	if err := dropReference(ctx, adapter, "pages", "parent_id", "fk_pages_parent"); err != nil {
+		return err
+	}
+	if err := adapter.Exec(ctx, `ALTER TABLE pages DROP COLUMN sort_order`); err != nil {
+		return err
+	}
+
+	return adapter.Exec(ctx, `DROP TABLE IF EXISTS menu_items`)
+}
@@ -0,0 +1,144 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000007_AddEditorialReview{})
+}
+
+// Migration_20260113000007_AddEditorialReview adds post reviewers, revisions, review comments and notifications
+type Migration_20260113000007_AddEditorialReview struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000007_AddEditorialReview) Version() string {
+	return "20260113000007"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000007_AddEditorialReview) Description() string {
+	return "add editorial review workflow"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000007_AddEditorialReview) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	if err := addReference(ctx, adapter, "posts", "reviewer_id", "fk_posts_reviewer", "users(id) ON DELETE SET NULL"); err != nil {
+		return err
+	}
+
+	err := execDialect(ctx, adapter, `
+		CREATE TABLE IF NOT EXISTS post_revisions (
+			id SERIAL PRIMARY KEY,
+			post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+			version INT NOT NULL,
+			title VARCHAR(255) NOT NULL,
+			content TEXT NOT NULL,
+			author_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (post_id, version)
+		)
+	`, `
+		CREATE TABLE IF NOT EXISTS post_revisions (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			post_id INT NOT NULL,
+			version INT NOT NULL,
+			title VARCHAR(255) NOT NULL,
+			content TEXT NOT NULL,
+			author_id INT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (post_id, version),
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+			FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`)
+
+	if err != nil {
+		return err
+	}
+
+	err = execDialect(ctx, adapter, `
+		CREATE TABLE IF NOT EXISTS review_comments (
+			id SERIAL PRIMARY KEY,
+			post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+			revision_id INT NOT NULL REFERENCES post_revisions(id) ON DELETE CASCADE,
+			author_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			decision VARCHAR(20) NOT NULL DEFAULT 'comment',
+			body TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`, `
+		CREATE TABLE IF NOT EXISTS review_comments (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			post_id INT NOT NULL,
+			revision_id INT NOT NULL,
+			author_id INT NOT NULL,
+			decision VARCHAR(20) NOT NULL DEFAULT 'comment',
+			body TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+			FOREIGN KEY (revision_id) REFERENCES post_revisions(id) ON DELETE CASCADE,
+			FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`)
+
+	if err != nil {
+		return err
+	}
+
+	err = execDialect(ctx, adapter, `
+		CREATE TABLE IF NOT EXISTS notifications (
+			id SERIAL PRIMARY KEY,
+			user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			message VARCHAR(255) NOT NULL,
+			link VARCHAR(500) NOT NULL DEFAULT '',
+			read_at TIMESTAMP NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`, `
+		CREATE TABLE IF NOT EXISTS notifications (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			user_id INT NOT NULL,
+			message VARCHAR(255) NOT NULL,
+			link VARCHAR(500) NOT NULL DEFAULT '',
+			read_at TIMESTAMP NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`)
+
+	if err != nil {
+		return err
+	}
+
+	// Create indexes
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_posts_reviewer_id ON posts(reviewer_id)`)
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_review_comments_post_id ON review_comments(post_id, created_at)`)
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at)`)
+
+	return nil
+}
+
+// Down reverts the migration
+func (m *Migration_20260113000007_AddEditorialReview) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	// Dropping the reference goes first as SQLite refuses to
+	if err := dropReference(ctx, adapter, "posts", "reviewer_id", "fk_posts_reviewer"); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"notifications", "review_comments", "post_revisions"} {
+		if err := adapter.Exec(ctx, `DROP TABLE IF EXISTS `+table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
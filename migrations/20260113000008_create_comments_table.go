@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000008_CreateCommentsTable{})
+}
+
+// Migration_20260113000008_CreateCommentsTable creates the comments table for reader comments on posts
+type Migration_20260113000008_CreateCommentsTable struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000008_CreateCommentsTable) Version() string {
+	return "20260113000008"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000008_CreateCommentsTable) Description() string {
+	return "create comments table"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000008_CreateCommentsTable) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	err := execDialect(ctx, adapter, `
+		CREATE TABLE IF NOT EXISTS comments (
+			id SERIAL PRIMARY KEY,
+			post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+			parent_id INT NULL REFERENCES comments(id) ON DELETE CASCADE,
+			user_id INT NULL REFERENCES users(id) ON DELETE SET NULL,
+			author_name VARCHAR(100) NOT NULL,
+			author_email VARCHAR(255) NOT NULL DEFAULT '',
+			body TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			depth INT NOT NULL DEFAULT 0,
+			ip_address VARCHAR(45) NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`, `
+		CREATE TABLE IF NOT EXISTS comments (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			post_id INT NOT NULL,
+			parent_id INT NULL,
+			user_id INT NULL,
+			author_name VARCHAR(100) NOT NULL,
+			author_email VARCHAR(255) NOT NULL DEFAULT '',
+			body TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			depth INT NOT NULL DEFAULT 0,
+			ip_address VARCHAR(45) NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+			FOREIGN KEY (parent_id) REFERENCES comments(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`)
+
+	if err != nil {
+		return err
+	}
+
+	// Create indexes
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_comments_post_status ON comments(post_id, status, created_at)`)
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_comments_status ON comments(status, created_at)`)
+
+	return nil
+}
+
+// Down reverts the migration
+func (m *Migration_20260113000008_CreateCommentsTable) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+	return adapter.Exec(ctx, `DROP TABLE IF EXISTS comments`)
+}
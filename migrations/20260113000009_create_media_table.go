@@ -0,0 +1,78 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000009_CreateMediaTable{})
+}
+
+// Migration_20260113000009_CreateMediaTable creates the media library table
+type Migration_20260113000009_CreateMediaTable struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000009_CreateMediaTable) Version() string {
+	return "20260113000009"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000009_CreateMediaTable) Description() string {
+	return "create media table"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000009_CreateMediaTable) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	err := execDialect(ctx, adapter, `
+		CREATE TABLE IF NOT EXISTS media (
+			id SERIAL PRIMARY KEY,
+			owner_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			file_name VARCHAR(255) NOT NULL,
+			path VARCHAR(500) NOT NULL UNIQUE,
+			mime_type VARCHAR(100) NOT NULL,
+			size BIGINT NOT NULL,
+			width INT NOT NULL DEFAULT 0,
+			height INT NOT NULL DEFAULT 0,
+			alt_text VARCHAR(255) NOT NULL DEFAULT '',
+			checksum CHAR(64) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`, `
+		CREATE TABLE IF NOT EXISTS media (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			owner_id INT NOT NULL,
+			file_name VARCHAR(255) NOT NULL,
+			path VARCHAR(500) NOT NULL UNIQUE,
+			mime_type VARCHAR(100) NOT NULL,
+			size BIGINT NOT NULL,
+			width INT NOT NULL DEFAULT 0,
+			height INT NOT NULL DEFAULT 0,
+			alt_text VARCHAR(255) NOT NULL DEFAULT '',
+			checksum CHAR(64) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`)
+
+	if err != nil {
+		return err
+	}
+
+	// Create indexes
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_media_owner_id ON media(owner_id, created_at)`)
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_media_checksum ON media(owner_id, checksum)`)
+
+	return nil
+}
+
+// Down reverts the migration
+func (m *Migration_20260113000009_CreateMediaTable) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+	return adapter.Exec(ctx, `DROP TABLE IF EXISTS media`)
+}
@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000010_AddFeaturedImages{})
+}
+
+// Migration_20260113000010_AddFeaturedImages links posts and pages to a featured image in the media library
+type Migration_20260113000010_AddFeaturedImages struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000010_AddFeaturedImages) Version() string {
+	return "20260113000010"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000010_AddFeaturedImages) Description() string {
+	return "add featured images to posts and pages"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000010_AddFeaturedImages) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	for _, table := range []string{"posts", "pages"} {
+		if err := addReference(ctx, adapter, table, "featured_image_id", "fk_"+table+"_featured_image", "media(id) ON DELETE SET NULL"); err != nil {
+			return err
+		}
+	}
+
+	// Create indexes
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_posts_featured ON posts(is_featured, status, published_at)`)
+
+	return nil
+}
+
+// Down reverts the migration
+func (m *Migration_20260113000010_AddFeaturedImages) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	adapter.Exec(ctx, `DROP INDEX IF EXISTS idx_posts_featured`)
+
+	for _, table := range []string{"posts", "pages"} {
+		if err := dropReference(ctx, adapter, table, "featured_image_id", "fk_"+table+"_featured_image"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
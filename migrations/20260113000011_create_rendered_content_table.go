@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000011_CreateRenderedContentTable{})
+}
+
+// Migration_20260113000011_CreateRenderedContentTable creates the cache of rendered post and page bodies
+type Migration_20260113000011_CreateRenderedContentTable struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000011_CreateRenderedContentTable) Version() string {
+	return "20260113000011"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000011_CreateRenderedContentTable) Description() string {
+	return "create rendered content table"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000011_CreateRenderedContentTable) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	// The same statement works on PostgreSQL and MySQL
+	if err := adapter.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS rendered_content (
+			content_type VARCHAR(20) NOT NULL,
+			content_id INT NOT NULL,
+			html TEXT NOT NULL,
+			toc TEXT NOT NULL,
+			excerpt TEXT NOT NULL,
+			word_count INT NOT NULL DEFAULT 0,
+			reading_time INT NOT NULL DEFAULT 0,
+			version INT NOT NULL,
+			rendered_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (content_type, content_id)
+		)
+	`); err != nil {
+		return err
+	}
+
+	// Create indexes
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_rendered_content_version ON rendered_content(content_type, version)`)
+
+	return nil
+}
+
+// Down reverts the migration
+func (m *Migration_20260113000011_CreateRenderedContentTable) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+	return adapter.Exec(ctx, `DROP TABLE IF EXISTS rendered_content`)
+}
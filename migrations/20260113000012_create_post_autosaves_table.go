@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000012_CreatePostAutosavesTable{})
+}
+
+// Migration_20260113000012_CreatePostAutosavesTable creates the per-user slots for unsaved post edits
+type Migration_20260113000012_CreatePostAutosavesTable struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000012_CreatePostAutosavesTable) Version() string {
+	return "20260113000012"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000012_CreatePostAutosavesTable) Description() string {
+	return "create post autosaves table"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000012_CreatePostAutosavesTable) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	// The same statement works on PostgreSQL and MySQL
+	if err := adapter.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS post_autosaves (
+			user_id INT NOT NULL,
+			post_id INT NOT NULL DEFAULT 0,
+			title VARCHAR(255) NOT NULL DEFAULT '',
+			content TEXT NOT NULL,
+			base_updated_at TIMESTAMP NULL,
+			saved_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, post_id)
+		)
+	`); err != nil {
+		return err
+	}
+
+	// Create indexes
+	adapter.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_post_autosaves_post ON post_autosaves(post_id)`)
+
+	return nil
+}
+
+// Down reverts the migration
+func (m *Migration_20260113000012_CreatePostAutosavesTable) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+	return adapter.Exec(ctx, `DROP TABLE IF EXISTS post_autosaves`)
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000013_AddContentVersions{})
+}
+
+// Migration_20260113000013_AddContentVersions adds the version posts and pages are checked against when saved, so concurrent edits don't overwrite each other
+type Migration_20260113000013_AddContentVersions struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000013_AddContentVersions) Version() string {
+	return "20260113000013"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000013_AddContentVersions) Description() string {
+	return "add versions to posts and pages"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000013_AddContentVersions) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	// The same statement works on PostgreSQL and MySQL
+	for _, table := range []string{"posts", "pages"} {
+		if err := adapter.Exec(ctx, `ALTER TABLE `+table+` ADD COLUMN version INT NOT NULL DEFAULT 1`); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Down reverts the migration
+func (m *Migration_20260113000013_AddContentVersions) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	for _, table := range []string{"posts", "pages"} {
+		if err := adapter.Exec(ctx, `ALTER TABLE `+table+` DROP COLUMN version`); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000014_AddContentSEOFields{})
+}
+
+// Migration_20260113000014_AddContentSEOFields adds the SEO fields of posts and pages and the featured flag of posts, which the repositories read and write
+type Migration_20260113000014_AddContentSEOFields struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000014_AddContentSEOFields) Version() string {
+	return "20260113000014"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000014_AddContentSEOFields) Description() string {
+	return "add SEO fields to posts and pages and featured flag to posts"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000014_AddContentSEOFields) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	// The same statements work on PostgreSQL, MySQL and SQLite. The columns
+	// aren't nullable as the repositories scan them into strings.
+	for _, table := range []string{"posts", "pages"} {
+		if err := adapter.Exec(ctx, `ALTER TABLE `+table+` ADD COLUMN meta_title VARCHAR(255) NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+		if err := adapter.Exec(ctx, `ALTER TABLE `+table+` ADD COLUMN meta_desc VARCHAR(500) NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+	}
+
+	return adapter.Exec(ctx, `ALTER TABLE posts ADD COLUMN is_featured BOOLEAN NOT NULL DEFAULT FALSE`)
+}
+
+// Down reverts the migration
+func (m *Migration_20260113000014_AddContentSEOFields) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	if err := adapter.Exec(ctx, `ALTER TABLE posts DROP COLUMN is_featured`); err != nil {
+		return err
+	}
+	for _, table := range []string{"posts", "pages"} {
+		if err := adapter.Exec(ctx, `ALTER TABLE `+table+` DROP COLUMN meta_desc`); err != nil {
+			return err
+		}
+		if err := adapter.Exec(ctx, `ALTER TABLE `+table+` DROP COLUMN meta_title`); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+func init() {
+	sil.RegisterMigration(&Migration_20260113000015_AddListingIndexes{})
+}
+
+// Migration_20260113000015_AddListingIndexes indexes published posts and pages in the order listings page through them, newest first by publication time
+type Migration_20260113000015_AddListingIndexes struct {
+	sil.BaseMigration
+}
+
+// Version returns the migration version
+func (m *Migration_20260113000015_AddListingIndexes) Version() string {
+	return "20260113000015"
+}
+
+// Description returns the migration description
+func (m *Migration_20260113000015_AddListingIndexes) Description() string {
+	return "add listing indexes to posts and pages"
+}
+
+// Up applies the migration
+func (m *Migration_20260113000015_AddListingIndexes) Up(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	for _, table := range []string{"posts", "pages"} {
+		// Content published without a publication time would drop out of
+		// listings that page by it, so it's dated when it was created
+		if err := adapter.Exec(ctx, `UPDATE `+table+` SET published_at = created_at WHERE status = 'published' AND published_at IS NULL`); err != nil {
+			return err
+		}
+
+		err := execDialect(ctx, adapter,
+			`CREATE INDEX IF NOT EXISTS idx_`+table+`_listing ON `+table+`(status, published_at, id)`,
+			`CREATE INDEX idx_`+table+`_listing ON `+table+`(status, published_at, id)`)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Down reverts the migration. The publication times filled in are kept.
+func (m *Migration_20260113000015_AddListingIndexes) Down(adapter sil.DatabaseAdapter) error {
+	ctx := context.Background()
+
+	for _, table := range []string{"posts", "pages"} {
+		err := execDialect(ctx, adapter,
+			`DROP INDEX IF EXISTS idx_`+table+`_listing`,
+			`DROP INDEX idx_`+table+`_listing ON `+table)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil/adapters"
+)
+
+// base returns the adapter of the database itself, looking through
+// adapters that wrap another, like DryRunAdapter
+func base(adapter sil.DatabaseAdapter) sil.DatabaseAdapter {
+	for {
+		wrapper, ok := adapter.(interface{ Unwrap() sil.DatabaseAdapter })
+		if !ok {
+			return adapter
+		}
+		adapter = wrapper.Unwrap()
+	}
+}
+
+// isSQLite reports whether migrations run against SQLite
+func isSQLite(adapter sil.DatabaseAdapter) bool {
+	_, ok := base(adapter).(*SQLiteAdapter)
+	return ok
+}
+
+// isMySQL reports whether migrations run against MySQL or MariaDB
+func isMySQL(adapter sil.DatabaseAdapter) bool {
+	_, ok := base(adapter).(*adapters.MySQLAdapter)
+	return ok
+}
+
+// execDialect runs the MySQL statement against MySQL and the PostgreSQL one
+// against the others, adapted by portable for SQLite. The statement is
+// picked up front rather than tried in turn, as MySQL accepts some
+// PostgreSQL syntax with another meaning, such as SERIAL for an unsigned
+// BIGINT that INT foreign keys can't reference.
+func execDialect(ctx context.Context, adapter sil.DatabaseAdapter, postgres, mysql string) error {
+	if isMySQL(adapter) {
+		return adapter.Exec(ctx, mysql)
+	}
+	return adapter.Exec(ctx, portable(adapter, postgres))
+}
@@ -0,0 +1,130 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+)
+
+// DryRunAdapter writes the statements migrations would run to out instead
+// of running them. It reads which migrations are applied from the database
+// it wraps and keeps what would be recorded in memory, so the migrator picks
+// the same migrations a real run would, even over several commands.
+type DryRunAdapter struct {
+	sil.DatabaseAdapter
+	out io.Writer
+	// applied is the migrations table as the dry run left it, once read
+	applied []sil.MigrationRecord
+	read    bool
+}
+
+// NewDryRunAdapter wraps adapter to write statements to out.
+func NewDryRunAdapter(adapter sil.DatabaseAdapter, out io.Writer) *DryRunAdapter {
+	return &DryRunAdapter{DatabaseAdapter: adapter, out: out}
+}
+
+// Unwrap returns the adapter of the database.
+func (a *DryRunAdapter) Unwrap() sil.DatabaseAdapter {
+	return a.DatabaseAdapter
+}
+
+// Exec writes the statement, with its arguments if it has any.
+func (a *DryRunAdapter) Exec(ctx context.Context, query string, args ...interface{}) error {
+	statement := dedent(query) + ";"
+	if len(args) > 0 {
+		statement += fmt.Sprintf(" -- %v", args)
+	}
+	_, err := fmt.Fprintln(a.out, statement)
+	return err
+}
+
+// BeginTx returns a transaction with nothing to commit.
+func (a *DryRunAdapter) BeginTx(ctx context.Context) (sil.Transaction, error) {
+	return dryRunTransaction{a}, nil
+}
+
+// CreateMigrationsTable leaves a database without migrations untouched.
+func (a *DryRunAdapter) CreateMigrationsTable(ctx context.Context) error {
+	return nil
+}
+
+// GetAppliedMigrations treats a database it can't read the migrations table
+// of as one without any, since the table isn't created on a dry run.
+func (a *DryRunAdapter) GetAppliedMigrations(ctx context.Context) ([]sil.MigrationRecord, error) {
+	if !a.read {
+		// An error is a missing table
+		a.applied, _ = a.DatabaseAdapter.GetAppliedMigrations(ctx)
+		a.read = true
+	}
+	return append([]sil.MigrationRecord(nil), a.applied...), nil
+}
+
+// GetLastBatch returns the highest batch of the applied migrations.
+func (a *DryRunAdapter) GetLastBatch(ctx context.Context) (int, error) {
+	applied, _ := a.GetAppliedMigrations(ctx)
+	batch := 0
+	for _, record := range applied {
+		batch = max(batch, record.Batch)
+	}
+	return batch, nil
+}
+
+// RecordMigration records a migration in memory only.
+func (a *DryRunAdapter) RecordMigration(ctx context.Context, version, description string, batch int) error {
+	a.GetAppliedMigrations(ctx)
+	a.applied = append(a.applied, sil.MigrationRecord{Version: version, Description: description, Batch: batch})
+	return nil
+}
+
+// RemoveMigration removes the record of a migration in memory only.
+func (a *DryRunAdapter) RemoveMigration(ctx context.Context, version string) error {
+	a.GetAppliedMigrations(ctx)
+	a.applied = slices.DeleteFunc(a.applied, func(record sil.MigrationRecord) bool {
+		return record.Version == version
+	})
+	return nil
+}
+
+// dryRunTransaction writes its statements like the adapter
+type dryRunTransaction struct {
+	adapter *DryRunAdapter
+}
+
+func (t dryRunTransaction) Commit() error   { return nil }
+func (t dryRunTransaction) Rollback() error { return nil }
+
+func (t dryRunTransaction) Exec(ctx context.Context, query string, args ...interface{}) error {
+	return t.adapter.Exec(ctx, query, args...)
+}
+
+func (t dryRunTransaction) Query(ctx context.Context, query string, args ...interface{}) (sil.Rows, error) {
+	return t.adapter.Query(ctx, query, args...)
+}
+
+// dedent trims a statement written as an indented raw string, removing the
+// indentation its lines share
+func dedent(query string) string {
+	lines := strings.Split(strings.Trim(query, "\n"), "\n")
+
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -1,27 +0,0 @@
--- Create users table
-CREATE TABLE IF NOT EXISTS users (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    email VARCHAR(255) UNIQUE NOT NULL,
-    username VARCHAR(100) UNIQUE NOT NULL,
-    password_hash VARCHAR(255) NOT NULL,
-    first_name VARCHAR(100),
-    last_name VARCHAR(100),
-    role VARCHAR(50) NOT NULL DEFAULT 'user',
-    email_verified BOOLEAN DEFAULT FALSE,
-    verification_token VARCHAR(255),
-    verification_token_expires_at DATETIME,
-    reset_token VARCHAR(255),
-    reset_token_expires_at DATETIME,
-    last_login_at DATETIME,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
-
--- Create index on email for faster lookups
-CREATE INDEX idx_users_email ON users(email);
-
--- Create index on username for faster lookups
-CREATE INDEX idx_users_username ON users(username);
-
--- Create index on role for filtering
-CREATE INDEX idx_users_role ON users(role);
@@ -1,27 +0,0 @@
--- Create posts table
-CREATE TABLE posts (
-    id VARCHAR(36) PRIMARY KEY,
-    title VARCHAR(255) NOT NULL,
-    slug VARCHAR(255) NOT NULL UNIQUE,
-    content TEXT NOT NULL,
-    excerpt TEXT,
-    author_id VARCHAR(36) NOT NULL,
-    category_id VARCHAR(36),
-    status VARCHAR(20) NOT NULL DEFAULT 'draft',
-    published_at TIMESTAMP NULL,
-    deleted_at TIMESTAMP NULL,
-    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    
-    CONSTRAINT fk_posts_author FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE,
-    CONSTRAINT fk_posts_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL,
-    CONSTRAINT chk_posts_status CHECK (status IN ('draft', 'published', 'archived'))
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
-
--- Create indexes for common queries
-CREATE INDEX idx_posts_slug ON posts(slug);
-CREATE INDEX idx_posts_author_id ON posts(author_id);
-CREATE INDEX idx_posts_category_id ON posts(category_id);
-CREATE INDEX idx_posts_status ON posts(status);
-CREATE INDEX idx_posts_published_at ON posts(published_at);
-CREATE INDEX idx_posts_deleted_at ON posts(deleted_at);
@@ -1,13 +0,0 @@
--- Create post_tags join table
-CREATE TABLE post_tags (
-    post_id VARCHAR(36) NOT NULL,
-    tag_id VARCHAR(36) NOT NULL,
-    
-    PRIMARY KEY (post_id, tag_id),
-    CONSTRAINT fk_post_tags_post FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
-    CONSTRAINT fk_post_tags_tag FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
-
--- Create indexes for efficient lookups
-CREATE INDEX idx_post_tags_post_id ON post_tags(post_id);
-CREATE INDEX idx_post_tags_tag_id ON post_tags(tag_id);
@@ -1,19 +0,0 @@
--- Create post_versions table for version history
-CREATE TABLE post_versions (
-    id VARCHAR(36) PRIMARY KEY,
-    post_id VARCHAR(36) NOT NULL,
-    version INT NOT NULL,
-    title VARCHAR(255) NOT NULL,
-    content TEXT NOT NULL,
-    excerpt TEXT,
-    author_id VARCHAR(36) NOT NULL,
-    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    
-    CONSTRAINT fk_post_versions_post FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
-    CONSTRAINT fk_post_versions_author FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE,
-    UNIQUE KEY unique_post_version (post_id, version)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
-
--- Create indexes
-CREATE INDEX idx_post_versions_post_id ON post_versions(post_id);
-CREATE INDEX idx_post_versions_version ON post_versions(post_id, version DESC);
@@ -1,2 +0,0 @@
--- Drop page_versions table
-DROP TABLE IF EXISTS page_versions;
@@ -1,18 +0,0 @@
--- Create page_versions table for version history
-CREATE TABLE page_versions (
-    id VARCHAR(36) PRIMARY KEY,
-    page_id VARCHAR(36) NOT NULL,
-    version INT NOT NULL,
-    title VARCHAR(255) NOT NULL,
-    content TEXT NOT NULL,
-    author_id VARCHAR(36) NOT NULL,
-    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    
-    CONSTRAINT fk_page_versions_page FOREIGN KEY (page_id) REFERENCES pages(id) ON DELETE CASCADE,
-    CONSTRAINT fk_page_versions_author FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE,
-    UNIQUE KEY unique_page_version (page_id, version)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
-
--- Create indexes
-CREATE INDEX idx_page_versions_page_id ON page_versions(page_id);
-CREATE INDEX idx_page_versions_version ON page_versions(page_id, version DESC);
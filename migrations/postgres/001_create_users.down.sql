@@ -1,5 +0,0 @@
--- Drop users table
-DROP INDEX IF EXISTS idx_users_role;
-DROP INDEX IF EXISTS idx_users_username;
-DROP INDEX IF EXISTS idx_users_email;
-DROP TABLE IF EXISTS users;
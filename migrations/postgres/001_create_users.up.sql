@@ -1,27 +0,0 @@
--- Create users table
-CREATE TABLE IF NOT EXISTS users (
-    id SERIAL PRIMARY KEY,
-    email VARCHAR(255) UNIQUE NOT NULL,
-    username VARCHAR(100) UNIQUE NOT NULL,
-    password_hash VARCHAR(255) NOT NULL,
-    first_name VARCHAR(100),
-    last_name VARCHAR(100),
-    role VARCHAR(50) NOT NULL DEFAULT 'user',
-    email_verified BOOLEAN DEFAULT FALSE,
-    verification_token VARCHAR(255),
-    verification_token_expires_at TIMESTAMP,
-    reset_token VARCHAR(255),
-    reset_token_expires_at TIMESTAMP,
-    last_login_at TIMESTAMP,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Create index on email for faster lookups
-CREATE INDEX idx_users_email ON users(email);
-
--- Create index on username for faster lookups
-CREATE INDEX idx_users_username ON users(username);
-
--- Create index on role for filtering
-CREATE INDEX idx_users_role ON users(role);
@@ -1,4 +0,0 @@
--- Drop sessions table
-DROP INDEX IF EXISTS idx_sessions_expires_at;
-DROP INDEX IF EXISTS idx_sessions_user_id;
-DROP TABLE IF EXISTS sessions;
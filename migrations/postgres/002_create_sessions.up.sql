@@ -1,17 +0,0 @@
--- Create sessions table for session-based authentication
-CREATE TABLE IF NOT EXISTS sessions (
-    id VARCHAR(255) PRIMARY KEY,
-    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    ip_address VARCHAR(45),
-    user_agent TEXT,
-    data TEXT,
-    expires_at TIMESTAMP NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Create index on user_id for faster lookups
-CREATE INDEX idx_sessions_user_id ON sessions(user_id);
-
--- Create index on expires_at for cleanup queries
-CREATE INDEX idx_sessions_expires_at ON sessions(expires_at);
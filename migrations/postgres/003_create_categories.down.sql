@@ -1,2 +0,0 @@
--- Drop categories table
-DROP TABLE IF EXISTS categories;
@@ -1,2 +0,0 @@
--- Drop tags table
-DROP TABLE IF EXISTS tags;
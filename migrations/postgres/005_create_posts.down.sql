@@ -1,2 +0,0 @@
--- Drop posts table
-DROP TABLE IF EXISTS posts;
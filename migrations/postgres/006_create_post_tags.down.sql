@@ -1,2 +0,0 @@
--- Drop post_tags table
-DROP TABLE IF EXISTS post_tags;
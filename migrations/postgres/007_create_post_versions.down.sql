@@ -1,2 +0,0 @@
--- Drop post_versions table
-DROP TABLE IF EXISTS post_versions;
@@ -1,2 +0,0 @@
--- Drop pages table
-DROP TABLE IF EXISTS pages;
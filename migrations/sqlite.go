@@ -0,0 +1,126 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil"
+	"github.com/toutaio/toutago-sil-migrator/pkg/sil/adapters"
+)
+
+// SQLiteAdapter runs migrations against SQLite. sil's adapter keeps a
+// single connection, which the migrator's transaction holds while a
+// migration runs, so statements a migration sends through the adapter would
+// wait for it forever. This adapter sends them through the transaction.
+type SQLiteAdapter struct {
+	*adapters.SQLiteAdapter
+	table string
+	tx    sil.Transaction
+}
+
+// NewSQLiteAdapter creates a SQLite adapter for the database in config.
+func NewSQLiteAdapter(config *sil.Config) (*SQLiteAdapter, error) {
+	adapter, err := adapters.NewSQLiteAdapter(config)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteAdapter{SQLiteAdapter: adapter, table: config.TableName}, nil
+}
+
+// BeginTx begins the transaction later statements run in until it ends.
+func (a *SQLiteAdapter) BeginTx(ctx context.Context) (sil.Transaction, error) {
+	tx, err := a.SQLiteAdapter.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.tx = tx
+	return &sqliteTransaction{Transaction: tx, adapter: a}, nil
+}
+
+// Exec executes a statement in the open transaction, if any.
+func (a *SQLiteAdapter) Exec(ctx context.Context, query string, args ...interface{}) error {
+	if a.tx != nil {
+		return a.tx.Exec(ctx, query, args...)
+	}
+	return a.SQLiteAdapter.Exec(ctx, query, args...)
+}
+
+// Query runs a query in the open transaction, if any.
+func (a *SQLiteAdapter) Query(ctx context.Context, query string, args ...interface{}) (sil.Rows, error) {
+	if a.tx != nil {
+		return a.tx.Query(ctx, query, args...)
+	}
+	return a.SQLiteAdapter.Query(ctx, query, args...)
+}
+
+// RecordMigration records a migration as applied.
+func (a *SQLiteAdapter) RecordMigration(ctx context.Context, version, description string, batch int) error {
+	return a.Exec(ctx, `INSERT INTO `+a.table+` (version, description, batch) VALUES (?, ?, ?)`, version, description, batch)
+}
+
+// RemoveMigration removes the record of an applied migration.
+func (a *SQLiteAdapter) RemoveMigration(ctx context.Context, version string) error {
+	return a.Exec(ctx, `DELETE FROM `+a.table+` WHERE version = ?`, version)
+}
+
+// sqliteTransaction hands statements back to the adapter's connection once
+// it ends
+type sqliteTransaction struct {
+	sil.Transaction
+	adapter *SQLiteAdapter
+}
+
+func (t *sqliteTransaction) Commit() error {
+	t.adapter.tx = nil
+	return t.Transaction.Commit()
+}
+
+func (t *sqliteTransaction) Rollback() error {
+	t.adapter.tx = nil
+	return t.Transaction.Rollback()
+}
+
+// portable adapts a PostgreSQL CREATE TABLE statement for SQLite, which
+// accepts SERIAL but only auto-increments an INTEGER PRIMARY KEY
+func portable(adapter sil.DatabaseAdapter, query string) string {
+	if !isSQLite(adapter) {
+		return query
+	}
+	return strings.ReplaceAll(query, "SERIAL PRIMARY KEY", "INTEGER PRIMARY KEY AUTOINCREMENT")
+}
+
+// addReference adds a nullable column to table that references another
+// table. SQLite can't add constraints to existing tables, so there the
+// reference is declared with the column instead of as a named constraint.
+func addReference(ctx context.Context, adapter sil.DatabaseAdapter, table, column, constraint, references string) error {
+	if isSQLite(adapter) {
+		return adapter.Exec(ctx, `ALTER TABLE `+table+` ADD COLUMN `+column+` INT NULL REFERENCES `+references)
+	}
+
+	if err := adapter.Exec(ctx, `ALTER TABLE `+table+` ADD COLUMN `+column+` INT NULL`); err != nil {
+		return err
+	}
+	return adapter.Exec(ctx, `
+		ALTER TABLE `+table+` ADD CONSTRAINT `+constraint+`
+		FOREIGN KEY (`+column+`) REFERENCES `+references)
+}
+
+// dropReference drops a column added by addReference along with its
+// constraint. SQLite can't drop a column that references another table
+// without rebuilding the table, so rolling back there is refused; delete
+// the database file and migrate again instead.
+func dropReference(ctx context.Context, adapter sil.DatabaseAdapter, table, column, constraint string) error {
+	if isSQLite(adapter) {
+		return fmt.Errorf("SQLite can't drop %s.%s, which references another table: delete the database file and migrate again", table, column)
+	}
+
+	// PostgreSQL drops constraints, MySQL foreign keys
+	err := execDialect(ctx, adapter,
+		`ALTER TABLE `+table+` DROP CONSTRAINT `+constraint,
+		`ALTER TABLE `+table+` DROP FOREIGN KEY `+constraint)
+	if err != nil {
+		return err
+	}
+	return adapter.Exec(ctx, `ALTER TABLE `+table+` DROP COLUMN `+column)
+}
@@ -0,0 +1,62 @@
+// Threaded replies for the comment form on posts: Reply buttons move the form
+// under the comment being answered and set its parent.
+(function () {
+    var form = document.getElementById('comment-form');
+    var buttons = document.querySelectorAll('.comment-reply');
+    var i;
+
+    if (!form) {
+        for (i = 0; i < buttons.length; i++) {
+            buttons[i].hidden = true;
+        }
+        return;
+    }
+
+    var home = form.parentNode;
+    var parentField = form.querySelector('input[name="parent_id"]');
+    var replying = form.querySelector('.comment-replying');
+
+    function replyTo(id) {
+        var comment = document.getElementById('comment-' + id);
+        if (!comment) {
+            return;
+        }
+        parentField.value = id;
+        replying.hidden = false;
+        comment.appendChild(form);
+        form.querySelector('textarea').focus();
+    }
+
+    function cancel() {
+        parentField.value = '';
+        replying.hidden = true;
+        home.appendChild(form);
+    }
+
+    for (i = 0; i < buttons.length; i++) {
+        buttons[i].addEventListener('click', function () {
+            replyTo(this.getAttribute('data-comment-id'));
+        });
+    }
+
+    form.querySelector('.comment-cancel').addEventListener('click', function (event) {
+        event.preventDefault();
+        cancel();
+    });
+
+    // Show validation and rate limit messages from the server, which HTMX
+    // would otherwise drop because of their error status.
+    document.body.addEventListener('htmx:beforeSwap', function (event) {
+        var status = event.detail.xhr.status;
+        if (event.detail.elt === form && (status === 401 || status === 422 || status === 429)) {
+            event.detail.shouldSwap = true;
+            event.detail.isError = false;
+        }
+    });
+
+    form.addEventListener('htmx:afterRequest', function (event) {
+        if (event.detail.successful && parentField.value !== '') {
+            cancel();
+        }
+    });
+})();
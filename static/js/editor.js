@@ -0,0 +1,46 @@
+// Autosave recovery and edit conflicts on the post editor. Previews and
+// autosaves themselves are HTMX requests triggered from the form fields.
+(function () {
+    // Replace the editor's title and content, letting the preview and
+    // autosave catch up as if they had been typed
+    function setFields(title, content) {
+        var titleField = document.getElementById('title');
+        var contentField = document.getElementById('content');
+        if (titleField) {
+            titleField.value = title;
+            titleField.dispatchEvent(new Event('input', { bubbles: true }));
+        }
+        if (contentField) {
+            contentField.value = content;
+            contentField.dispatchEvent(new Event('input', { bubbles: true }));
+        }
+    }
+
+    document.addEventListener('click', function (event) {
+        var restore = event.target.closest('.autosave-restore');
+        if (restore) {
+            var recovery = restore.closest('.autosave-recovery');
+            setFields(recovery.querySelector('.draft-title').value, recovery.querySelector('.draft-content').value);
+            recovery.remove();
+            return;
+        }
+
+        var theirs = event.target.closest('.conflict-theirs');
+        var mine = event.target.closest('.conflict-mine');
+        if (!theirs && !mine) {
+            return;
+        }
+
+        // Either way the editor now builds on the other version, so later
+        // autosaves don't report the same conflict again
+        var conflict = event.target.closest('.autosave-conflict');
+        var base = document.getElementById('autosave_base');
+        if (base) {
+            base.value = conflict.getAttribute('data-updated-at');
+        }
+        if (theirs) {
+            setFields(conflict.querySelector('.conflict-title').value, conflict.querySelector('.conflict-content').value);
+        }
+        conflict.remove();
+    });
+})();
@@ -0,0 +1,74 @@
+// Media library and the image picker on post and page editors.
+(function () {
+    // Errors the server points at a message area with HX-Retarget, e.g. a
+    // rejected upload or a file still used by content, are shown there
+    // instead of being dropped by HTMX.
+    document.body.addEventListener('htmx:beforeSwap', function (event) {
+        var xhr = event.detail.xhr;
+        if (xhr.status >= 400 && xhr.status < 500 && xhr.getResponseHeader('HX-Retarget')) {
+            event.detail.shouldSwap = true;
+            event.detail.isError = false;
+        }
+    });
+
+    // Clear earlier upload errors once a file uploads
+    document.body.addEventListener('htmx:afterRequest', function (event) {
+        var error = document.getElementById('media-upload-error');
+        if (error && event.detail.elt.id === 'media-upload' && event.detail.successful) {
+            error.textContent = '';
+        }
+    });
+
+    function insertAtCursor(field, text) {
+        var start = field.selectionStart || 0;
+        var end = field.selectionEnd || 0;
+        var before = field.value.slice(0, start);
+        var after = field.value.slice(end);
+
+        // Put images on a line of their own
+        if (before !== '' && before.slice(-1) !== '\n') {
+            text = '\n' + text;
+        }
+        if (after.charAt(0) !== '\n') {
+            text = text + '\n';
+        }
+
+        field.value = before + text + after;
+        field.selectionStart = field.selectionEnd = start + text.length;
+        field.focus();
+        field.dispatchEvent(new Event('input', { bubbles: true }));
+    }
+
+    // The featured image is kept in a hidden field; an empty ID removes it
+    function setFeaturedImage(id, thumb) {
+        var input = document.getElementById('featured_image_id');
+        var preview = document.getElementById('featured-image-preview');
+        if (input) {
+            input.value = id;
+        }
+        if (preview) {
+            preview.setAttribute('src', thumb);
+        }
+    }
+
+    document.addEventListener('click', function (event) {
+        var pick = event.target.closest('.media-pick');
+        var close = event.target.closest('.media-picker-close');
+        var picker = event.target.closest('.media-picker');
+
+        if (pick && picker && picker.getAttribute('data-mode') === 'featured') {
+            setFeaturedImage(pick.getAttribute('data-id'), pick.getAttribute('data-thumb'));
+        } else if (pick) {
+            var field = document.getElementById('content');
+            if (field) {
+                insertAtCursor(field, pick.getAttribute('data-markdown'));
+            }
+        }
+        if (event.target.closest('.featured-image-clear')) {
+            setFeaturedImage('', '');
+        }
+        if ((pick || close) && picker) {
+            picker.parentNode.innerHTML = '';
+        }
+    });
+})();
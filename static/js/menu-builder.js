@@ -0,0 +1,35 @@
+// Menu builder: add, remove and reorder the rows of each menu form.
+(function () {
+    document.querySelectorAll('form.menu-builder').forEach(function (form) {
+        var items = form.querySelector('[data-menu-items]');
+        var blank = form.querySelector('template[data-menu-blank]');
+
+        form.addEventListener('click', function (event) {
+            var button = event.target.closest('button[data-action]');
+            if (!button) {
+                return;
+            }
+            var row = button.closest('tr');
+
+            switch (button.dataset.action) {
+            case 'add':
+                items.appendChild(blank.content.cloneNode(true));
+                items.lastElementChild.querySelector('input').focus();
+                break;
+            case 'remove':
+                row.remove();
+                break;
+            case 'up':
+                if (row.previousElementSibling) {
+                    items.insertBefore(row, row.previousElementSibling);
+                }
+                break;
+            case 'down':
+                if (row.nextElementSibling) {
+                    items.insertBefore(row.nextElementSibling, row);
+                }
+                break;
+            }
+        });
+    });
+})();
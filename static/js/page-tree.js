@@ -0,0 +1,149 @@
+// Drag-and-drop page tree. Pages are listed depth-first with their depth; the
+// nesting is rebuilt from the order and depths when saving.
+(function () {
+    var INDENT_PX = 32;
+
+    var tree = document.getElementById('page-tree');
+    if (!tree) {
+        return;
+    }
+    var saveButton = document.getElementById('page-tree-save');
+    var status = document.getElementById('page-tree-status');
+    var dragged = null;
+    var startX = 0;
+
+    function rows() {
+        return Array.prototype.slice.call(tree.children);
+    }
+
+    function depth(row) {
+        return parseInt(row.dataset.depth, 10) || 0;
+    }
+
+    function setDepth(row, d) {
+        row.dataset.depth = d;
+        row.style.setProperty('--depth', d);
+    }
+
+    // subtree returns the row followed by all of its descendants.
+    function subtree(row) {
+        var result = [row];
+        var d = depth(row);
+        var next = row.nextElementSibling;
+        while (next && depth(next) > d) {
+            result.push(next);
+            next = next.nextElementSibling;
+        }
+        return result;
+    }
+
+    // normalize keeps every row at most one level below the row above it.
+    function normalize() {
+        var previous = -1;
+        rows().forEach(function (row) {
+            setDepth(row, Math.max(0, Math.min(depth(row), previous + 1)));
+            previous = depth(row);
+        });
+    }
+
+    function changed() {
+        normalize();
+        saveButton.disabled = false;
+        status.textContent = 'Unsaved changes';
+    }
+
+    function shift(row, delta) {
+        subtree(row).forEach(function (r) {
+            setDepth(r, Math.max(0, depth(r) + delta));
+        });
+        changed();
+    }
+
+    function positions() {
+        var parents = [];
+        var counts = {};
+        return rows().map(function (row) {
+            var d = depth(row);
+            var id = parseInt(row.dataset.id, 10);
+            var parentID = d > 0 ? parents[d - 1] : null;
+            var key = String(parentID);
+            counts[key] = (counts[key] || 0) + 1;
+            parents.length = d;
+            parents[d] = id;
+            return { id: id, parent_id: parentID, sort_order: counts[key] - 1 };
+        });
+    }
+
+    tree.addEventListener('click', function (event) {
+        var button = event.target.closest('button[data-action]');
+        if (!button) {
+            return;
+        }
+        shift(button.closest('li'), button.dataset.action === 'indent' ? 1 : -1);
+    });
+
+    tree.addEventListener('dragstart', function (event) {
+        var row = event.target.closest('li');
+        dragged = subtree(row);
+        startX = event.clientX;
+        row.classList.add('dragging');
+        event.dataTransfer.effectAllowed = 'move';
+        event.dataTransfer.setData('text/plain', row.dataset.id);
+    });
+
+    tree.addEventListener('dragover', function (event) {
+        if (!dragged) {
+            return;
+        }
+        event.preventDefault();
+
+        var row = event.target.closest('li');
+        if (!row || dragged.indexOf(row) !== -1) {
+            return;
+        }
+        var rect = row.getBoundingClientRect();
+        var below = event.clientY > rect.top + rect.height / 2;
+        var anchor = below ? subtree(row).pop().nextElementSibling : row;
+        dragged.forEach(function (r) {
+            tree.insertBefore(r, anchor);
+        });
+    });
+
+    tree.addEventListener('drop', function (event) {
+        event.preventDefault();
+    });
+
+    tree.addEventListener('dragend', function (event) {
+        if (!dragged) {
+            return;
+        }
+        var row = dragged[0];
+        row.classList.remove('dragging');
+        dragged = null;
+        // Dragging sideways changes the level, like indenting in an outline
+        shift(row, Math.round((event.clientX - startX) / INDENT_PX));
+    });
+
+    saveButton.addEventListener('click', function () {
+        saveButton.disabled = true;
+        status.textContent = 'Saving…';
+
+        fetch(tree.dataset.saveUrl, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            credentials: 'same-origin',
+            body: JSON.stringify(positions())
+        }).then(function (response) {
+            return response.json().then(function (body) {
+                if (!response.ok) {
+                    throw new Error(body.error || 'Could not save the page tree');
+                }
+                // Reload to show the new nested URLs
+                window.location.reload();
+            });
+        }).catch(function (err) {
+            saveButton.disabled = false;
+            status.textContent = err.message;
+        });
+    });
+})();
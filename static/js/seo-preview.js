@@ -0,0 +1,54 @@
+// Live search-result snippet preview for the SEO fields on post and page forms.
+(function () {
+    var DESCRIPTION_LENGTH = 160;
+
+    function field(id) {
+        return document.getElementById(id);
+    }
+
+    function truncate(text, max) {
+        text = text.replace(/\s+/g, ' ').trim();
+        if (text.length <= max) {
+            return text;
+        }
+        var cut = text.slice(0, max - 1);
+        var space = cut.lastIndexOf(' ');
+        return (space > 0 ? cut.slice(0, space) : cut) + '…';
+    }
+
+    function plainText(markdown) {
+        return markdown
+            .replace(/!\[[^\]]*\]\([^)]*\)/g, '')
+            .replace(/\[([^\]]*)\]\([^)]*\)/g, '$1')
+            .replace(/[#>*_`~-]/g, ' ');
+    }
+
+    function slugify(text) {
+        return text.toLowerCase().replace(/[^a-z0-9]+/g, '-').replace(/^-+|-+$/g, '');
+    }
+
+    function update() {
+        var title = field('title'), slug = field('slug'), content = field('content');
+        var metaTitle = field('meta_title'), metaDesc = field('meta_desc');
+
+        var snippetTitle = metaTitle.value.trim() || title.value.trim();
+        var snippetDesc = metaDesc.value.trim() || plainText(content.value);
+
+        document.querySelector('[data-snippet="title"]').textContent = truncate(snippetTitle, 60);
+        document.querySelector('[data-snippet="desc"]').textContent = truncate(snippetDesc, DESCRIPTION_LENGTH);
+        document.querySelector('[data-snippet="slug"]').textContent = slug.value.trim() || slugify(title.value);
+        field('meta_desc_count').textContent = metaDesc.value.length;
+    }
+
+    document.addEventListener('DOMContentLoaded', function () {
+        if (!field('meta_title')) {
+            return;
+        }
+        ['title', 'slug', 'content', 'meta_title', 'meta_desc'].forEach(function (id) {
+            if (field(id)) {
+                field(id).addEventListener('input', update);
+            }
+        });
+        update();
+    });
+})();